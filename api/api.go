@@ -1,102 +1,618 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/tls"
 	"errors"
-	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"govulnapi/api/database"
+	"govulnapi/coingecko"
 	m "govulnapi/models"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/jwtauth/v5"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
 )
 
 type Api struct {
-	db               *database.DB
-	router           *chi.Mux
-	coins            []m.Coin
-	currentDate      time.Time
-	dayDuration      time.Duration
-	coingeckoBaseUrl string
-	listenAddress    string
-	jwtAuth          *jwtauth.JWTAuth
-}
-
-func New(listenAddress string, coingeckoBaseUrl string) *Api {
-	db := database.Init("api.db")
-	virtualTime := time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)
-	priceRefreshInterval := time.Minute
+	db          database.Repository
+	router      *chi.Mux
+	server      *http.Server
+	ctx         context.Context // Cancelled on Shutdown
+	cancelFn    context.CancelFunc
+	daysMu      sync.Mutex   // Serializes advancing of virtual days
+	replaceMu   sync.RWMutex // Held by writes, exclusively while replacing data
+	replacing   atomic.Bool  // Set while replacing data, see rejectWhileReplacing
+	backupMu    sync.Mutex   // Held while writing a backup to BackupDir
+	coinsMu     sync.RWMutex // Guards coins, currentDate and leaderboard
+	coins       []m.Coin
+	currentDate time.Time
+	leaderboard leaderboard
+	similar     similarCache
+	history     historyCache
+	scoreboard  scoreboardCache
+	sandboxes   sandboxes
+	logins      loginThrottle
+	flags       map[string]string // By id, set in CTF mode
+	prices      PriceProvider
+	clock       Clock
+	jwtAuth     *jwtauth.JWTAuth
+	optionsMu   sync.RWMutex
+	options     Options
+	metrics     metrics
+	background  sync.WaitGroup // Jobs Shutdown waits for, see goBackground
+	grpcServer  *grpc.Server   // Set when GRPCListenAddress is
+	priceFeed   priceFeed
+}
+
+func New(listenAddress string, coingeckoBaseUrl string, opts ...Option) *Api {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	db := options.Repository
+	if options.SandboxMode && (db != nil || options.DBDriver != database.DriverSQLite) {
+		log.Fatalln("Sandbox mode needs the default SQLite storage")
+	}
+	if db == nil {
+		sqlDB := database.InitDriver(options.DBDriver, options.DBDsn)
+		sqlDB.SetMaxRetries(options.DBMaxRetries)
+		if len(options.SQLitePragmas) > 0 {
+			if err := sqlDB.ApplyPragmas(options.SQLitePragmas); err != nil {
+				log.Fatalln(err)
+			}
+		}
+		db = sqlDB
+	}
+	settings, err := db.GetVulnerabilitySettings()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if len(settings) > 0 {
+		options.Vulnerabilities = mergeVulnerabilities(options.Vulnerabilities, settings)
+	}
+	db.SetSQLInjection(options.vulnerable(VulnSQLInjection))
+	db.SetOrderRace(options.vulnerable(VulnOrderRaceCondition))
+	prices := options.Prices
+	if prices == nil && coingeckoBaseUrl == "" {
+		// Without a virtual Coingecko server to reach, its data is read
+		// in-process
+		prices = coingecko.New("")
+	} else if prices == nil {
+		prices = coingeckoPrices{
+			baseUrl: coingeckoBaseUrl,
+			workers: options.WorkerCount,
+			coins:   db.GetCoins,
+		}
+	}
+	if options.PriceSimulation {
+		simulated := &SimulatedPrices{Seed: options.PriceSimulationSeed, StartDate: options.StartDate, Coins: db.GetCoins}
+		if options.PriceSimulationAnchored {
+			simulated.Anchor = prices
+		}
+		prices = simulated
+	}
+	clock := options.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
 	coins, err := db.GetCoins()
 	if err != nil {
 		log.Fatalln(err)
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	router := chi.NewRouter()
 	api := Api{
-		db:               db,
-		router:           chi.NewRouter(),
-		currentDate:      virtualTime,
-		dayDuration:      priceRefreshInterval,
-		coins:            coins,
-		coingeckoBaseUrl: coingeckoBaseUrl,
-		listenAddress:    listenAddress,
-		// CWE-547: Use of Hard-coded, Security-relevant Constants
-		jwtAuth: jwtauth.New("HS256", []byte("safe-secret"), nil),
+		db:          db,
+		router:      router,
+		server:      &http.Server{Addr: listenAddress, Handler: router},
+		ctx:         ctx,
+		cancelFn:    cancel,
+		currentDate: resumeVirtualDate(db, options),
+		coins:       coins,
+		prices:      prices,
+		clock:       clock,
+		jwtAuth:     jwtauth.New("HS256", []byte(options.JwtSecret), nil),
+		options:     options,
+	}
+
+	if options.GRPCListenAddress != "" {
+		api.grpcServer = newGrpcServer(&api)
+	}
+
+	// The first virtual day starts now
+	api.metrics.lastAdvance.Store(clock.Now().UnixNano())
+
+	if options.CTFMode {
+		api.flags = generateFlags()
+		if err = api.placeFlags(); err != nil {
+			log.Fatalln("Unable to place flags:", err)
+		}
 	}
 
 	return &api
 }
 
 func (a *Api) Run() {
-	go a.managePrices()
+	listener, err := net.Listen("tcp", a.server.Addr)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	var tlsListener net.Listener
+	if address := a.getOptions().TLSListenAddress; address != "" {
+		if tlsListener, err = net.Listen("tcp", address); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	var grpcListener net.Listener
+	if address := a.getOptions().GRPCListenAddress; address != "" {
+		if grpcListener, err = net.Listen("tcp", address); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if err = a.serve(listener, tlsListener, grpcListener); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// Like Run, but serves the API on the listener, e.g. one on a random port,
+// and returns the error it stopped with unless it was shut down
+func (a *Api) Serve(listener net.Listener) error {
+	return a.serve(listener, nil, nil)
+}
+
+// Serves plain HTTP on the listener and HTTPS on tlsListener when it's set,
+// or only HTTPS on the listener when TLS is enabled without it. gRPC is
+// served on grpcListener when it's set.
+func (a *Api) serve(listener net.Listener, tlsListener net.Listener, grpcListener net.Listener) error {
+	a.warmupPrices()
+	a.goBackground(a.managePrices)
+	a.goBackground(a.dispatchWebhooks)
+	a.goBackground(a.vacuumPeriodically)
 	a.setupRoutes()
 	log.Println("Starting API ...")
-	// CWE-319: Cleartext Transmission of Sensitive Information
-	log.Fatalln(http.ListenAndServe(a.listenAddress, a.router))
+
+	if grpcListener != nil {
+		log.Println("Starting gRPC API ...")
+		go func() {
+			if err := a.grpcServer.Serve(grpcListener); err != nil {
+				log.Fatalln(err)
+			}
+		}()
+	}
+
+	options := a.getOptions()
+	if !options.tlsEnabled() {
+		// CWE-319: Cleartext Transmission of Sensitive Information
+		return serveErr(a.server.Serve(listener))
+	}
+
+	tlsConfig, err := newTLSConfig(options)
+	if err != nil {
+		return err
+	}
+	a.server.TLSConfig = tlsConfig
+	if err = configureHTTP2(a.server, options.HTTP2Enabled); err != nil {
+		return err
+	}
+	if tlsListener == nil {
+		return serveErr(a.server.ServeTLS(listener, "", ""))
+	}
+
+	if options.TLSRedirectHTTP {
+		a.server.Handler = redirectToHTTPS(options.TLSListenAddress, a.server.Handler)
+	}
+	// Both stop with http.ErrServerClosed on Shutdown, otherwise the first
+	// error stops the API
+	errs := make(chan error, 2)
+	go func() {
+		// CWE-319: Cleartext Transmission of Sensitive Information
+		errs <- a.server.Serve(listener)
+	}()
+	go func() {
+		errs <- a.server.ServeTLS(tlsListener, "", "")
+	}()
+	return serveErr(<-errs)
+}
+
+// Drops the error servers return once they're shut down
+func serveErr(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
+// Advertises h2 in the TLS handshake of the server when enabled, and keeps
+// it to HTTP/1.1 otherwise
+func configureHTTP2(server *http.Server, enabled bool) error {
+	if !enabled {
+		// A non-nil map stops net/http from configuring HTTP/2 on its own
+		server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		return nil
+	}
+	return http2.ConfigureServer(server, nil)
+}
+
+// Stops accepting connections and fetching prices, waits up to
+// ShutdownTimeout for in-flight requests and background jobs, e.g. webhook
+// deliveries, and closes the database
 func (a *Api) Shutdown() {
+	a.cancelFn()
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.getOptions().ShutdownTimeout)
+	defer cancel()
+	if err := a.server.Shutdown(ctx); err != nil {
+		log.Println(err)
+	}
+	if a.grpcServer != nil {
+		a.stopGrpc(ctx)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		a.background.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Println("Background jobs didn't stop in time, closing the database anyway")
+	}
+
+	a.closeSandboxes()
 	a.db.Close()
 }
 
+// Runs the job in the background until it returns, which it needs to do once
+// ctx is cancelled
+func (a *Api) goBackground(job func()) {
+	a.background.Add(1)
+	go func() {
+		defer a.background.Done()
+		job()
+	}()
+}
+
 func (a *Api) managePrices() {
 	log.Println("Starting price management daemon ...")
-	for {
+	if a.metrics.stalePrices.Load() {
+		a.daysMu.Lock()
 		a.refreshCoins()
+		a.daysMu.Unlock()
+	}
+	if seed := a.getOptions().Seed; seed != nil {
+		reset := defaultLabReset()
+		reset.Seed = *seed
+		if err := a.reseedLab(reset); err != nil {
+			log.Fatalln("Unable to seed lab:", err)
+		}
+	}
+	a.paceDays(a.clock.After(a.getOptions().DayDuration))
+}
+
+// Advances virtual time by a day every DayDuration of the clock, starting
+// once next fires. The following day is scheduled before the current one is
+// processed, so a fake clock never misses a tick.
+func (a *Api) paceDays(next <-chan time.Time) {
+	for {
+		select {
+		case <-next:
+		case <-a.ctx.Done():
+			return
+		}
+		next = a.clock.After(a.getOptions().DayDuration)
+		a.advanceDays(1)
+	}
+}
+
+// Moves virtual time forward day by day, refreshing prices for each of them
+func (a *Api) advanceDays(days int) time.Time {
+	a.daysMu.Lock()
+	defer a.daysMu.Unlock()
+
+	for i := 0; i < days; i++ {
+		a.coinsMu.Lock()
+		previous, coins := a.currentDate, a.coins
 		a.currentDate = a.currentDate.Add(time.Hour * 24)
-		time.Sleep(a.dayDuration)
+		a.coinsMu.Unlock()
+		a.metrics.lastAdvance.Store(a.clock.Now().UnixNano())
+
+		if a.currentDate.Month() != previous.Month() {
+			a.generateMonthlyReports(previous, coins)
+		}
+
+		a.cleanupNotifications()
+		a.purgeDeletedUsers()
+		a.refreshCoins()
+		a.saveVirtualClock()
 	}
+
+	return a.virtualDate()
 }
 
+// Fetches prices of the current virtual date for at most PriceWarmupTimeout
+// before serving. The coins loaded from the database are served as stale
+// when it fails, until a later refresh succeeds.
+func (a *Api) warmupPrices() {
+	ctx, cancel := context.WithTimeout(a.ctx, a.getOptions().PriceWarmupTimeout)
+	defer cancel()
+	a.daysMu.Lock()
+	defer a.daysMu.Unlock()
+
+	if err := a.fetchCoins(ctx); err != nil {
+		log.Println("Unable to fetch prices before serving, serving the ones saved in the database as stale:", err)
+		a.metrics.stalePrices.Store(true)
+	}
+}
+
+// Fetches prices of the current virtual date, giving up once the api is
+// shut down. Callers hold daysMu, so the date can't move or be reset while
+// its prices are saved.
 func (a *Api) refreshCoins() {
-	var (
-		coins []m.Coin
-		r     *http.Response
-		err   error
-	)
+	err := a.fetchCoins(a.ctx)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Println(err)
+	}
+}
 
-	url := fmt.Sprintf("%s/coins/%v", a.coingeckoBaseUrl, a.currentDate.UnixMilli())
+// Prices are only served once they're saved, so the ones loaded from the
+// database after a restart are the ones served last
+func (a *Api) fetchCoins(ctx context.Context) error {
+	date := a.virtualDate()
+	coins, err := a.prices.Prices(ctx, date)
+	if err != nil {
+		return err
+	}
+	if err = a.db.AddPriceHistory(coins, date, false); err != nil {
+		return err
+	}
+	a.history.invalidate()
 
-	for {
-		r, err = http.Get(url)
-		if err == nil {
-			break
+	a.coinsMu.Lock()
+	a.coins = coins
+	a.coinsMu.Unlock()
+	a.metrics.lastRefresh.Store(a.clock.Now().UnixNano())
+	a.metrics.stalePrices.Store(false)
+	a.priceFeed.publish(coins)
+
+	if err := a.fillLimitOrders(coins, date); err != nil {
+		log.Println(err)
+	}
+	if err := a.triggerPriceAlerts(coins, date); err != nil {
+		log.Println(err)
+	}
+
+	a.refreshLeaderboard(coins)
+	return nil
+}
+
+// Swaps the database for the snapshot read from r
+func (a *Api) restoreDatabase(r io.Reader) error {
+	return a.replaceData(func() error {
+		if err := a.db.Restore(r); err != nil {
+			return err
+		}
+		a.removeUserFiles()
+		return nil
+	})
+}
+
+// Wipes all lab data and seeds it again, trades are made at current prices
+func (a *Api) reseedLab(reset m.LabReset) error {
+	return a.replaceData(func() error {
+		if err := a.db.Reset(a.seedConfig(reset)); err != nil {
+			return err
+		}
+		a.removeUserFiles()
+		return nil
+	})
+}
+
+// Deletes the lab data of the scope and seeds it again. Resetting all data
+// or prices also moves virtual time back to StartDate, and the seeded trades
+// are made at its prices, so the same seed always recreates the same lab.
+func (a *Api) resetLabScope(scope string, reset m.LabReset) error {
+	rewind := scope == database.ResetAll || scope == database.ResetPrices
+
+	var startPrices []m.Coin
+	if scope == database.ResetAll {
+		var err error
+		if startPrices, err = a.prices.Prices(a.ctx, a.getOptions().StartDate); err != nil {
+			return err
 		}
-		time.Sleep(time.Second)
 	}
 
-	json.NewDecoder(r.Body).Decode(&coins)
+	return a.replaceData(func() error {
+		if rewind {
+			a.coinsMu.Lock()
+			a.currentDate = a.getOptions().StartDate
+			a.coinsMu.Unlock()
+			a.metrics.lastAdvance.Store(a.clock.Now().UnixNano())
+			a.saveVirtualClock()
+		}
+
+		config := a.seedConfig(reset)
+		if startPrices != nil {
+			config.Prices = startPrices
+		}
+		if err := a.db.ResetScope(scope, config); err != nil {
+			return err
+		}
+
+		// Reports summarize trades of the users
+		if scope != database.ResetPrices {
+			a.removeReports()
+		}
+		if scope == database.ResetAll || scope == database.ResetUsers {
+			a.removeAvatars()
+		}
+		return nil
+	})
+}
+
+// Seeding of reset at the current prices, trades are made on the days
+// before the current virtual date
+func (a *Api) seedConfig(reset m.LabReset) database.SeedConfig {
+	a.coinsMu.RLock()
+	defer a.coinsMu.RUnlock()
+
+	return database.SeedConfig{
+		Seed:            reset.Seed,
+		Users:           reset.Users,
+		StartingBalance: reset.StartingBalance,
+		TradesPerUser:   reset.TradesPerUser,
+		Prices:          append([]m.Coin{}, a.coins...),
+		VirtualDate:     a.currentDate,
+	}
+}
+
+// Deletes the files of all users, when the user ids they belong to start
+// over
+func (a *Api) removeUserFiles() {
+	a.removeReports()
+	a.removeAvatars()
+}
+
+// Seeding used by POST /admin/reset and the -seed flag, unless overridden
+func defaultLabReset() m.LabReset {
+	return m.LabReset{
+		Users:           10,
+		StartingBalance: m.UsdFromFloat(10000),
+		TradesPerUser:   3,
+	}
+}
+
+// Runs replace while other requests are rejected and the price daemon
+// waits, then reloads the coin list from the replaced data and clears
+// everything cached from the old one
+func (a *Api) replaceData(replace func() error) error {
+	a.replaceMu.Lock()
+	defer a.replaceMu.Unlock()
+	a.replacing.Store(true)
+	defer a.replacing.Store(false)
+	a.daysMu.Lock()
+	defer a.daysMu.Unlock()
+
+	if err := replace(); err != nil {
+		return err
+	}
+	a.removeSandboxes()
+
+	coins, err := a.db.GetCoins()
+	if err != nil {
+		return err
+	}
+	a.coinsMu.Lock()
 	a.coins = coins
+	a.coinsMu.Unlock()
+
+	a.loadVulnerabilitySettings()
+	if err = a.placeFlags(); err != nil {
+		return err
+	}
+
+	a.similar.mu.Lock()
+	a.similar.date = time.Time{}
+	a.similar.mu.Unlock()
+	a.history.invalidate()
+	a.scoreboard.mu.Lock()
+	a.scoreboard.computed = time.Time{}
+	a.scoreboard.mu.Unlock()
+
+	a.refreshCoins()
+
+	return nil
+}
+
+// Gets a tracked coin by its id, or else by its symbol regardless of case,
+// e.g. bitcoin or BTC. Coins left out of the last refresh are served with
+// the price they were stored with before.
+func (a *Api) getCoin(identifier string) (m.Coin, error) {
+	if coin, ok := a.trackedCoin(identifier); ok {
+		return coin, nil
+	}
+
+	names, err := a.db.GetCoinNames()
+	if err != nil {
+		return m.Coin{}, err
+	}
+	coinId := identifier
+	for _, name := range names {
+		if strings.EqualFold(name.Symbol, identifier) {
+			if coin, ok := a.trackedCoin(name.Id); ok {
+				return coin, nil
+			}
+			if coinId == identifier {
+				coinId = name.Id
+			}
+		}
+	}
+
+	coin, err := a.db.GetCoinByID(coinId)
+	if errors.Is(err, database.ErrCoinNotFound) {
+		return m.Coin{}, errors.New("Requested coin doesn't exist!")
+	}
+	return coin, err
+}
+
+// Adds the names and symbols to the coins
+func (a *Api) listCoins(coins []m.Coin) ([]m.ListedCoin, error) {
+	names, err := a.db.GetCoinNames()
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]m.NewCoin, len(names))
+	for _, name := range names {
+		byId[name.Id] = name
+	}
+
+	listed := make([]m.ListedCoin, 0, len(coins))
+	for _, coin := range coins {
+		listed = append(listed, m.ListedCoin{Coin: coin, Name: byId[coin.Id].Name, Symbol: byId[coin.Id].Symbol})
+	}
+	return listed, nil
 }
 
-func (a *Api) getCoin(coin_id string) (m.Coin, error) {
+func (a *Api) trackedCoin(coinId string) (m.Coin, bool) {
+	a.coinsMu.RLock()
+	defer a.coinsMu.RUnlock()
+
 	for _, coin := range a.coins {
-		if coin.Id == coin_id {
-			return coin, nil
+		if coin.Id == coinId {
+			return coin, true
+		}
+	}
+	return m.Coin{}, false
+}
+
+func (a *Api) setCoinPrice(coin_id string, price m.Usd) (m.Coin, error) {
+	a.coinsMu.Lock()
+	defer a.coinsMu.Unlock()
+
+	for i := range a.coins {
+		if a.coins[i].Id == coin_id {
+			a.coins[i].Price = price
+			return a.coins[i], nil
 		}
 	}
 	return m.Coin{}, errors.New("Requested coin doesn't exist!")
 }
+
+func (a *Api) virtualDate() time.Time {
+	a.coinsMu.RLock()
+	defer a.coinsMu.RUnlock()
+
+	return a.currentDate
+}
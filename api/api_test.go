@@ -0,0 +1,332 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"govulnapi/api/database"
+	"govulnapi/mockgecko"
+	m "govulnapi/models"
+
+	"go.uber.org/mock/gomock"
+)
+
+// Keeps the migrations and background jobs from logging in between the
+// test results
+func TestMain(main *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(main.Run())
+}
+
+// Serves the request through the routes of a, sent with token unless it's
+// empty
+func serve(a *Api, r *http.Request, token string) *httptest.ResponseRecorder {
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	a.Handler().ServeHTTP(w, r)
+	return w
+}
+
+// Registers the user unless the email is taken, and logs them in
+func login(t testing.TB, a *Api, email, password string) string {
+	t.Helper()
+
+	query := url.Values{"email": {email}, "password": {password}}.Encode()
+	serve(a, httptest.NewRequest(http.MethodGet, "/api/register?"+query, nil), "")
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/login?"+query, nil), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("logging in as %s: %d %s", email, w.Code, w.Body)
+	}
+	return w.Body.String()
+}
+
+// Fails when a handler panicked so far, or answered with JSON that doesn't
+// parse
+func checkFuzzedResponse(t *testing.T, a *Api, w *httptest.ResponseRecorder) {
+	t.Helper()
+
+	if panics := a.metrics.panics.Load(); panics != 0 {
+		t.Fatalf("%d handlers panicked, answered %d %s", panics, w.Code, w.Body)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type")); mediaType == "application/json" && !json.Valid(w.Body.Bytes()) {
+		t.Fatalf("answered %d with invalid JSON %q", w.Code, w.Body)
+	}
+}
+
+// Looks up the last of n tracked coins, the slowest case for getCoin. The
+// baseline in testdata/bench-baseline.txt was recorded with
+//
+//	go test -run '^$' -bench GetCoin -benchmem -count 6 ./api
+//
+// CI runs the same command on pull requests (.github/workflows/bench.yml)
+// and compares both with
+//
+//	benchstat api/testdata/bench-baseline.txt new.txt
+//
+// failing the build when sec/op of any size grew by more than 20%.
+func BenchmarkGetCoin(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("coins=%d", n), func(b *testing.B) {
+			a, _ := NewForTesting()
+			b.Cleanup(a.Shutdown)
+
+			coins := make([]m.Coin, n)
+			for i := range coins {
+				coins[i] = m.Coin{Id: fmt.Sprintf("coin-%d", i), Price: m.UsdFromFloat(float64(i + 1))}
+			}
+			a.coinsMu.Lock()
+			a.coins = coins
+			a.coinsMu.Unlock()
+			last := coins[n-1].Id
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := a.getCoin(last); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// Creates an api on a mock repository, tracking TestPrices on the first
+// virtual day. Only the calls made while creating and shutting it down are
+// expected so far. Options override the test setup.
+func newMockedForTesting(t *testing.T, opts ...Option) (*Api, *database.MockRepository) {
+	repo := database.NewMockRepository(gomock.NewController(t))
+	repo.EXPECT().GetVulnerabilitySettings().Return(nil, nil)
+	repo.EXPECT().SetSQLInjection(gomock.Any())
+	repo.EXPECT().SetOrderRace(gomock.Any())
+	repo.EXPECT().GetCoins().Return([]m.Coin(TestPrices), nil)
+	repo.EXPECT().GetVirtualClock().Return(m.VirtualClock{}, database.ErrClockNotSaved)
+	repo.EXPECT().Close()
+
+	clock := NewFakeClock(time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC))
+	opts = append([]Option{WithRepository(repo), WithPriceProvider(TestPrices), WithClock(clock)}, opts...)
+	a := New("", "", opts...)
+	a.setupRoutes()
+	t.Cleanup(a.Shutdown)
+
+	return a, repo
+}
+
+func TestGetCoinLooksUpUntrackedCoinsOnce(t *testing.T) {
+	names := []m.NewCoin{
+		{Id: "bitcoin", Name: "Bitcoin", Symbol: "btc"},
+		{Id: "gridcoin", Name: "Gridcoin", Symbol: "grc"},
+	}
+	gridcoin := m.Coin{Id: "gridcoin", Price: m.UsdFromFloat(0.01)}
+
+	for _, test := range []struct {
+		identifier string
+		// Id GetCoinByID is called with, none for tracked coins
+		lookedUp string
+		stored   error
+		want     m.Coin
+	}{
+		{identifier: "bitcoin", want: TestPrices[0]},
+		{identifier: "BTC", want: TestPrices[0]},
+		{identifier: "gridcoin", lookedUp: "gridcoin", want: gridcoin},
+		{identifier: "GRC", lookedUp: "gridcoin", want: gridcoin},
+		{identifier: "nocoin", lookedUp: "nocoin", stored: database.ErrCoinNotFound},
+	} {
+		t.Run(test.identifier, func(t *testing.T) {
+			a, repo := newMockedForTesting(t)
+			if test.identifier != "bitcoin" {
+				repo.EXPECT().GetCoinNames().Return(names, nil)
+			}
+			if test.lookedUp != "" {
+				repo.EXPECT().GetCoinByID(test.lookedUp).Return(test.want, test.stored).Times(1)
+			}
+
+			coin, err := a.getCoin(test.identifier)
+			if test.stored != nil {
+				if err == nil {
+					t.Errorf("got %+v, want an error", coin)
+				}
+				return
+			}
+			if err != nil || coin != test.want {
+				t.Errorf("got %+v (%v), want %+v", coin, err, test.want)
+			}
+		})
+	}
+}
+
+func TestGetCoinDetailLooksUpCoinOnce(t *testing.T) {
+	a, repo := newMockedForTesting(t)
+	gridcoin := m.Coin{Id: "gridcoin", Price: m.UsdFromFloat(0.01)}
+	repo.EXPECT().GetCoinNames().Return(nil, nil)
+	repo.EXPECT().GetCoinByID("gridcoin").Return(gridcoin, nil).Times(1)
+	repo.EXPECT().GetCoinListing("gridcoin").Return(m.CoinListing{Id: "gridcoin", Name: "Gridcoin", Symbol: "grc"}, nil)
+	repo.EXPECT().GetDailyPrices("2013-12-31", "2014-01-01", "gridcoin").Return(nil, nil)
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/gridcoin", nil), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("getting gridcoin answered %d %s", w.Code, w.Body)
+	}
+	var detail m.CoinDetail
+	if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+		t.Fatal(err)
+	}
+	if detail.Price != gridcoin.Price || detail.Symbol != "grc" {
+		t.Errorf("detail = %+v, want the stored gridcoin", detail)
+	}
+}
+
+func TestGetCoinDetailRejects(t *testing.T) {
+	for _, test := range []struct {
+		id       string
+		lookedUp bool
+		status   int
+		code     string
+	}{
+		{id: "nocoin", lookedUp: true, status: http.StatusNotFound, code: codeNotFound},
+		{id: "-bitcoin", status: http.StatusBadRequest, code: codeBadRequest},
+		{id: "bit_coin", status: http.StatusBadRequest, code: codeBadRequest},
+		{id: strings.Repeat("a", 65), status: http.StatusBadRequest, code: codeBadRequest},
+	} {
+		a, repo := newMockedForTesting(t)
+		// Malformed ids are rejected before looking up the coin
+		if test.lookedUp {
+			repo.EXPECT().GetCoinNames().Return(nil, nil)
+			repo.EXPECT().GetCoinByID(test.id).Return(m.Coin{}, database.ErrCoinNotFound)
+		}
+
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/"+test.id, nil), "")
+		if w.Code != test.status {
+			t.Errorf("getting %s answered %d %s, want %d", test.id, w.Code, w.Body, test.status)
+			continue
+		}
+		checkAPIError(t, "getting "+test.id, w, test.code)
+	}
+}
+
+// Counts the prices fetched from TestPrices
+type countedPrices struct {
+	fetched atomic.Int32
+}
+
+func (c *countedPrices) Prices(ctx context.Context, date time.Time) ([]m.Coin, error) {
+	c.fetched.Add(1)
+	return TestPrices.Prices(ctx, date)
+}
+
+func TestStaleRefreshWaitsForLabReset(t *testing.T) {
+	prices := &countedPrices{}
+	a, _ := NewForTesting(WithPriceProvider(prices))
+	t.Cleanup(a.Shutdown)
+	a.advanceDays(3)
+
+	// The price daemon refreshes stale prices right away, while the lab is
+	// reset back to the start date
+	a.metrics.stalePrices.Store(true)
+	a.goBackground(a.managePrices)
+	if err := a.resetLabScope(database.ResetAll, defaultLabReset()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once for NewForTesting, 3 days, the start prices of the reset, its
+	// refresh and the one of the daemon
+	for deadline := time.Now().Add(5 * time.Second); prices.fetched.Load() < 7; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("fetched prices %d times, the daemon didn't refresh", prices.fetched.Load())
+		}
+	}
+	a.daysMu.Lock()
+	a.daysMu.Unlock()
+
+	start := a.getOptions().StartDate
+	if date := a.virtualDate(); !date.Equal(start) {
+		t.Errorf("virtual date is %v after the reset, want %v", date, start)
+	}
+	later, err := a.db.GetDailyPrices(start.AddDate(0, 0, 1).Format(time.DateOnly), "2014-12-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(later) != 0 {
+		t.Errorf("prices after the start date survived the reset: %+v", later)
+	}
+}
+
+func TestShutdownCancelsRefresh(t *testing.T) {
+	// Nothing listens on the port anymore, so fetching prices retries
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.Close()
+
+	a, _ := NewForTesting()
+	a.prices = coingeckoPrices{baseUrl: "http://" + listener.Addr().String(), workers: 1}
+	a.goBackground(func() {
+		a.daysMu.Lock()
+		defer a.daysMu.Unlock()
+		a.refreshCoins()
+	})
+	// Lets the first attempt fail, so the refresh sleeps until the next one
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	a.Shutdown()
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("shutting down took %v during a refresh, want at most 500ms", took)
+	}
+}
+
+// Fails like an unreachable price feed
+type unreachablePrices struct{}
+
+func (unreachablePrices) Prices(context.Context, time.Time) ([]m.Coin, error) {
+	return nil, errors.New("Price feed is unreachable!")
+}
+
+func TestRestartServesLastPrices(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "api.db")
+	prices := mockgecko.New()
+
+	a, _ := NewForTesting(WithDatabase(database.DriverSQLite, dsn), WithPriceProvider(prices))
+	a.advanceDays(3)
+	day3, err := prices.Prices(context.Background(), a.virtualDate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Shutdown()
+
+	// The feed is gone after day 3, so the restarted api can only serve the
+	// prices it saved
+	a, _ = NewForTesting(WithDatabase(database.DriverSQLite, dsn), WithPriceProvider(unreachablePrices{}))
+	t.Cleanup(a.Shutdown)
+	for _, want := range day3 {
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/"+want.Id, nil), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("getting %s answered %d %s", want.Id, w.Code, w.Body)
+		}
+		var coin m.Coin
+		if err := json.NewDecoder(w.Body).Decode(&coin); err != nil {
+			t.Fatal(err)
+		}
+		if coin.Price != want.Price {
+			t.Errorf("%s costs %v after the restart, want %v of day 3", want.Id, coin.Price, want.Price)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+)
+
+const (
+	// Upload requests can't be larger than this in any case
+	avatarRequestMaxBytes = 8 << 20
+	avatarMaxBytes        = 1 << 20
+	avatarMaxSide         = 1024
+)
+
+var errAvatarNotImage = errors.New("Avatar needs to be a PNG or JPEG image!")
+
+// Directory holding the avatar uploaded by a user
+func (a *Api) avatarsDir(userId int) string {
+	return filepath.Join(a.getOptions().AvatarsDir, strconv.Itoa(userId))
+}
+
+// Reads an uploaded avatar and decides the name and content type it's stored
+// with. While unrestricted uploads are enabled, the file is taken as it is.
+func (a *Api) checkAvatar(header *multipart.FileHeader) (m.Avatar, []byte, error) {
+	file, err := header.Open()
+	if err != nil {
+		return m.Avatar{}, nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return m.Avatar{}, nil, err
+	}
+	if len(data) == 0 {
+		return m.Avatar{}, nil, errors.New("Avatar is empty!")
+	}
+
+	if a.vulnerable(VulnAvatarUpload) {
+		// CWE-434: Unrestricted Upload of File with Dangerous Type
+		// Name and content type are the client's, so an .html or .svg file is
+		// served as a page running its scripts
+		avatar := m.Avatar{
+			FileName:    filepath.Base(header.Filename),
+			ContentType: header.Header.Get("Content-Type"),
+		}
+		if avatar.FileName == "." || avatar.FileName == ".." || avatar.FileName == string(filepath.Separator) {
+			avatar.FileName = "avatar"
+		}
+		if avatar.ContentType == "" {
+			avatar.ContentType = "application/octet-stream"
+		}
+		return avatar, data, nil
+	}
+
+	if len(data) > avatarMaxBytes {
+		return m.Avatar{}, nil, fmt.Errorf("Avatar can't be larger than %d KiB!", avatarMaxBytes>>10)
+	}
+	return reencodeAvatar(data)
+}
+
+// Decodes a PNG or JPEG avatar, whatever the client claimed it is, and
+// encodes it once more so only the pixels are kept
+func reencodeAvatar(data []byte) (m.Avatar, []byte, error) {
+	contentType := http.DetectContentType(data)
+	if contentType != "image/png" && contentType != "image/jpeg" {
+		return m.Avatar{}, nil, errAvatarNotImage
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return m.Avatar{}, nil, errAvatarNotImage
+	}
+	if config.Width > avatarMaxSide || config.Height > avatarMaxSide {
+		return m.Avatar{}, nil, fmt.Errorf("Avatar can't be larger than %dx%d pixels!", avatarMaxSide, avatarMaxSide)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return m.Avatar{}, nil, errAvatarNotImage
+	}
+
+	var buf bytes.Buffer
+	avatar := m.Avatar{}
+	switch format {
+	case "png":
+		avatar.FileName, avatar.ContentType = "avatar.png", "image/png"
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		avatar.FileName, avatar.ContentType = "avatar.jpg", "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+	default:
+		return m.Avatar{}, nil, errAvatarNotImage
+	}
+	if err != nil {
+		return m.Avatar{}, nil, err
+	}
+
+	return avatar, buf.Bytes(), nil
+}
+
+// Writes the avatar to the user's directory and stores it, removing the file
+// of the avatar it replaces
+func (a *Api) saveAvatar(userId int, avatar m.Avatar, data []byte) (m.Avatar, error) {
+	avatar.UserId = userId
+	avatar.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	dir := a.avatarsDir(userId)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return m.Avatar{}, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, avatar.FileName), data, 0o644); err != nil {
+		return m.Avatar{}, err
+	}
+
+	previous, err := a.db.GetAvatar(userId)
+	if err != nil && !errors.Is(err, database.ErrAvatarNotFound) {
+		return m.Avatar{}, err
+	}
+	if err = a.db.SetAvatar(avatar); err != nil {
+		return m.Avatar{}, err
+	}
+	if previous.FileName != "" && previous.FileName != avatar.FileName {
+		if err = os.Remove(filepath.Join(dir, previous.FileName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Println(err)
+		}
+	}
+
+	return avatar, nil
+}
+
+// Opens the avatar of a user
+func (a *Api) openAvatar(userId int) (m.Avatar, *os.File, error) {
+	avatar, err := a.db.GetAvatar(userId)
+	if err != nil {
+		return m.Avatar{}, nil, err
+	}
+
+	file, err := os.Open(filepath.Join(a.avatarsDir(userId), avatar.FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return m.Avatar{}, nil, database.ErrAvatarNotFound
+	}
+	return avatar, file, err
+}
+
+// Deletes the avatars of all users, e.g. when user ids start over
+func (a *Api) removeAvatars() {
+	if err := os.RemoveAll(a.getOptions().AvatarsDir); err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	m "govulnapi/models"
+)
+
+var errBackupRunning = errors.New("Another backup is still running!")
+
+// Writes a snapshot of the database to a new file in BackupDir, named after
+// the real time it was taken. Only one backup runs at a time.
+func (a *Api) backupToDir() (m.BackupResult, error) {
+	if !a.backupMu.TryLock() {
+		return m.BackupResult{}, errBackupRunning
+	}
+	defer a.backupMu.Unlock()
+
+	dir := a.getOptions().BackupDir
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return m.BackupResult{}, err
+	}
+	path, err := filepath.Abs(filepath.Join(dir, fmt.Sprintf("api_%s.db", a.clock.Now().Format("2006-01-02_150405"))))
+	if err != nil {
+		return m.BackupResult{}, err
+	}
+
+	// Snapshots are written next to the final file and renamed once
+	// complete, so a failed backup never leaves a partial one behind
+	file, err := os.CreateTemp(dir, "api_*.db.tmp")
+	if err != nil {
+		return m.BackupResult{}, err
+	}
+	defer os.Remove(file.Name())
+
+	if err = a.db.Backup(file); err != nil {
+		file.Close()
+		return m.BackupResult{}, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return m.BackupResult{}, err
+	}
+	if err = file.Close(); err != nil {
+		return m.BackupResult{}, err
+	}
+	if err = os.Rename(file.Name(), path); err != nil {
+		return m.BackupResult{}, err
+	}
+
+	return m.BackupResult{BackupPath: path, SizeBytes: info.Size()}, nil
+}
+
+// VACUUMs the database every VacuumInterval of the clock until shutdown,
+// giving back the pages purged notifications and users leave unused
+func (a *Api) vacuumPeriodically() {
+	for {
+		interval := a.getOptions().VacuumInterval
+		if interval <= 0 {
+			return
+		}
+
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-a.clock.After(interval):
+		}
+
+		if err := a.db.Vacuum(); err != nil {
+			log.Println("Unable to vacuum database:", err)
+		}
+	}
+}
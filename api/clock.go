@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+)
+
+// Source of real time, which paces virtual days
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Clock that only moves when advanced, so tests decide when a virtual day
+// passes
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+
+	return ch
+}
+
+// Moves the clock forward, firing every After whose duration has passed
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	waiting := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			waiting = append(waiting, w)
+		} else {
+			w.ch <- c.now
+		}
+	}
+	c.waiters = waiting
+}
+
+// Virtual date saved before the last shutdown, or StartDate when nothing was
+// saved yet or the lab was started from another StartDate since
+func resumeVirtualDate(db database.Repository, options Options) time.Time {
+	saved, err := db.GetVirtualClock()
+	if errors.Is(err, database.ErrClockNotSaved) {
+		return options.StartDate
+	} else if err != nil {
+		log.Fatalln("Unable to load virtual clock:", err)
+	}
+
+	startDate := options.StartDate.Format(time.DateOnly)
+	if saved.StartDate != startDate {
+		log.Printf("Starting over on %s, the saved virtual date %s started on %s\n", startDate, saved.VirtualDate, saved.StartDate)
+		return options.StartDate
+	}
+	date, err := time.Parse(time.DateOnly, saved.VirtualDate)
+	if err != nil {
+		log.Fatalln("Unable to load virtual clock:", err)
+	}
+
+	log.Printf(
+		"Resuming virtual time on %s, days lasted %v until %s\n",
+		saved.VirtualDate, time.Duration(saved.DayDurationMs)*time.Millisecond, saved.UpdatedAt,
+	)
+	return date
+}
+
+// Saves the current virtual date, so it's resumed after a restart
+func (a *Api) saveVirtualClock() {
+	options := a.getOptions()
+	err := a.db.SaveVirtualClock(m.VirtualClock{
+		VirtualDate:   a.virtualDate().Format(time.DateOnly),
+		StartDate:     options.StartDate.Format(time.DateOnly),
+		DayDurationMs: options.DayDuration.Milliseconds(),
+		UpdatedAt:     a.clock.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Println("Unable to save virtual clock:", err)
+	}
+}
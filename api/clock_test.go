@@ -0,0 +1,41 @@
+package api
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"govulnapi/api/database"
+)
+
+func TestVirtualClockResumes(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "api.db")
+
+	a, _ := NewForTesting(WithDatabase(database.DriverSQLite, dsn))
+	a.advanceDays(5)
+	a.Shutdown()
+
+	// The restarted api continues on the day it stopped on
+	a, _ = NewForTesting(WithDatabase(database.DriverSQLite, dsn))
+	if date := a.virtualDate().Format(time.DateOnly); date != "2014-01-06" {
+		t.Errorf("resumed on %s, want 2014-01-06", date)
+	}
+	a.advanceDays(1)
+	a.Shutdown()
+
+	a, _ = NewForTesting(WithDatabase(database.DriverSQLite, dsn))
+	if date := a.virtualDate().Format(time.DateOnly); date != "2014-01-07" {
+		t.Errorf("resumed on %s, want 2014-01-07", date)
+	}
+	a.Shutdown()
+
+	// Unless the lab is started from another date
+	a, _ = NewForTesting(
+		WithDatabase(database.DriverSQLite, dsn),
+		WithStartDate(time.Date(2014, time.March, 1, 0, 0, 0, 0, time.UTC)),
+	)
+	t.Cleanup(a.Shutdown)
+	if date := a.virtualDate().Format(time.DateOnly); date != "2014-03-01" {
+		t.Errorf("started on %s, want the new start date 2014-03-01", date)
+	}
+}
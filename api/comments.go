@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+	"unicode"
+	"unicode/utf8"
+
+	m "govulnapi/models"
+)
+
+const (
+	commentMaxBytes = 16 << 10
+	commentMaxRunes = 500
+	commentPageSize = 50
+)
+
+// Checks a comment body before it's stored. While stored XSS is enabled,
+// any non-empty body is accepted, markup included.
+func (a *Api) checkComment(body string) (string, error) {
+	if strings.TrimSpace(body) == "" {
+		return "", errors.New("Body is required!")
+	}
+	if len(body) > commentMaxBytes {
+		return "", fmt.Errorf("Body can't be longer than %d bytes!", commentMaxBytes)
+	}
+	if a.vulnerable(VulnCommentXSS) {
+		return body, nil
+	}
+
+	body = strings.TrimSpace(body)
+	if !utf8.ValidString(body) {
+		return "", errors.New("Body needs to be valid UTF-8!")
+	}
+	if utf8.RuneCountInString(body) > commentMaxRunes {
+		return "", fmt.Errorf("Body can't be longer than %d characters!", commentMaxRunes)
+	}
+	for _, r := range body {
+		if unicode.IsControl(r) && r != '\n' {
+			return "", errors.New("Body can't contain control characters!")
+		}
+	}
+
+	return body, nil
+}
+
+type coinPage struct {
+	Coin     m.Coin
+	Comments []m.Comment
+}
+
+const coinPageSource = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Coin.Id}} - govulnapi</title>
+</head>
+<body>
+<h1>{{.Coin.Id}}</h1>
+<p>Price: {{printf "%.2f" .Coin.Price.Float64}} usd</p>
+<h2>Comments</h2>
+{{range .Comments}}<article>
+<header>{{if .DisplayName}}{{.DisplayName}}{{else}}User {{.UserId}}{{end}} on {{.VirtualDate}}{{if .EditedAt}} (edited){{end}}</header>
+<p>{{.Body}}</p>
+</article>
+{{else}}<p>No comments yet.</p>
+{{end}}</body>
+</html>
+`
+
+var (
+	// CWE-79: Improper Neutralization of Input During Web Page Generation ('Cross-site Scripting')
+	// text/template leaves markup in display names and comment bodies as it is
+	rawCoinPage  = texttemplate.Must(texttemplate.New("coin").Parse(coinPageSource))
+	safeCoinPage = htmltemplate.Must(htmltemplate.New("coin").Parse(coinPageSource))
+)
+
+// Renders the coin page, escaping comments unless stored XSS is enabled
+func (a *Api) renderCoinPage(w io.Writer, page coinPage) error {
+	if a.vulnerable(VulnCommentXSS) {
+		return rawCoinPage.Execute(w, page)
+	}
+	return safeCoinPage.Execute(w, page)
+}
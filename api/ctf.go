@@ -0,0 +1,143 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+)
+
+const (
+	// Served by an admin only route, reachable with a forged token
+	flagAdminRoute = "admin_route"
+
+	// Points a team gets for every flag it finds
+	flagPoints = 100
+
+	// Real time the scoreboard is cached for, so requesting it repeatedly
+	// doesn't query the database every time
+	scoreboardTTL = 5 * time.Second
+)
+
+type scoreboardCache struct {
+	mu       sync.Mutex
+	computed time.Time
+	entries  []m.ScoreboardEntry
+}
+
+// Creates flags that differ on every start of the API
+func generateFlags() map[string]string {
+	flags := map[string]string{}
+	for _, id := range []string{database.FlagAdminProfile, database.FlagHiddenTable, flagAdminRoute} {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			panic(err)
+		}
+		flags[id] = fmt.Sprintf("govulnapi{%s}", hex.EncodeToString(b))
+	}
+	return flags
+}
+
+// Places the flags into lab data again, e.g. after it was replaced
+func (a *Api) placeFlags() error {
+	if !a.getOptions().CTFMode {
+		return nil
+	}
+	return a.db.PlaceFlags(a.flags)
+}
+
+// Gets the id of the flag, comparing its hash against all of them in
+// constant time
+func (a *Api) checkFlag(flag string) (string, bool, error) {
+	hashes, err := a.db.GetFlagHashes()
+	if err != nil {
+		return "", false, err
+	}
+
+	var (
+		hash  = []byte(database.HashFlag(flag))
+		match string
+	)
+	for id, expected := range hashes {
+		if subtle.ConstantTimeCompare(hash, []byte(expected)) == 1 {
+			match = id
+		}
+	}
+
+	return match, match != "", nil
+}
+
+// Gets teams by their score, ties going to the one that found its last flag
+// first. Recomputed at most once per scoreboardTTL.
+func (a *Api) getScoreboardEntries() ([]m.ScoreboardEntry, error) {
+	a.scoreboard.mu.Lock()
+	defer a.scoreboard.mu.Unlock()
+
+	now := a.clock.Now()
+	if a.scoreboard.entries != nil && now.Sub(a.scoreboard.computed) < scoreboardTTL {
+		return a.scoreboard.entries, nil
+	}
+
+	solves, err := a.db.GetSolves()
+	if err != nil {
+		return nil, err
+	}
+	hints, err := a.db.GetHintsTaken()
+	if err != nil {
+		return nil, err
+	}
+	hintsTaken := map[int]int{}
+	for _, hint := range hints {
+		hintsTaken[hint.UserId]++
+	}
+
+	var (
+		entries = []m.ScoreboardEntry{}
+		index   = map[int]int{}
+		penalty = a.getOptions().CTFHintPenalty
+	)
+	for _, solve := range solves {
+		i, ok := index[solve.UserId]
+		if !ok {
+			team := solve.DisplayName
+			if team == "" {
+				team = fmt.Sprintf("User #%d", solve.UserId)
+			}
+			i = len(entries)
+			index[solve.UserId] = i
+			entries = append(entries, m.ScoreboardEntry{
+				Team:       team,
+				HintsTaken: hintsTaken[solve.UserId],
+				Score:      -hintsTaken[solve.UserId] * penalty,
+			})
+		}
+		entries[i].Score += flagPoints
+		entries[i].Solves = append(entries[i].Solves, solve)
+	}
+
+	// Solves are oldest first, so teams are in the order of their first solve
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return lastSolve(entries[i]) < lastSolve(entries[j])
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+
+	a.scoreboard.computed = now
+	a.scoreboard.entries = entries
+
+	return entries, nil
+}
+
+func lastSolve(entry m.ScoreboardEntry) string {
+	return entry.Solves[len(entry.Solves)-1].SolvedAt
+}
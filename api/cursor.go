@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Opaque keyset cursor pointing at the last item of a page
+type cursor struct {
+	VirtualDate string `json:"d"`
+	Id          int    `json:"i"`
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (cursor, error) {
+	var c cursor
+	invalid := errors.New("Invalid cursor!")
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor{}, invalid
+	}
+	if err = json.Unmarshal(data, &c); err != nil || c.Id <= 0 {
+		return cursor{}, invalid
+	}
+	if _, err = time.Parse(time.DateOnly, c.VirtualDate); err != nil {
+		return cursor{}, invalid
+	}
+
+	return c, nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"errors"
+	m "govulnapi/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (d *DB) AddPriceAlert(userId int, coinId string, thresholdUsd m.Usd, direction string) (m.PriceAlert, error) {
+	if direction != "above" && direction != "below" {
+		return m.PriceAlert{}, errors.New("Direction needs to be 'above' or 'below'!")
+	}
+
+	if thresholdUsd <= 0 {
+		return m.PriceAlert{}, errors.New("Threshold needs to be > 0!")
+	}
+
+	var id int64
+	query := `INSERT INTO "price_alert" (user_id, coin_id, threshold_usd, direction) VALUES (?, ?, ?, ?) RETURNING id`
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&id, db.Rebind(query), userId, coinId, thresholdUsd, direction)
+	})
+	if err != nil {
+		return m.PriceAlert{}, err
+	}
+
+	return m.PriceAlert{
+		Id:           int(id),
+		UserId:       userId,
+		CoinId:       coinId,
+		ThresholdUsd: thresholdUsd,
+		Direction:    direction,
+	}, nil
+}
+
+func (d *DB) GetActivePriceAlerts(userId int) ([]m.PriceAlert, error) {
+	alerts := []m.PriceAlert{}
+	query := `SELECT * FROM "price_alert" WHERE user_id = ? AND triggered_at IS NULL`
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&alerts, db.Rebind(query), userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// Marks untriggered alerts whose threshold was crossed by the given prices
+// and returns them
+func (d *DB) TriggerPriceAlerts(coins []m.Coin, date time.Time) ([]m.PriceAlert, error) {
+	var (
+		triggered []m.PriceAlert
+		query     = `UPDATE "price_alert" SET triggered_at = ?
+			WHERE triggered_at IS NULL AND coin_id = ? AND (
+				(direction = 'above' AND threshold_usd <= ?) OR
+				(direction = 'below' AND threshold_usd >= ?)
+			) RETURNING *`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		triggered = []m.PriceAlert{}
+
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for _, coin := range coins {
+			var alerts []m.PriceAlert
+			if err = tx.Select(&alerts, tx.Rebind(query), date.Format(time.DateOnly), coin.Id, coin.Price, coin.Price); err != nil {
+				return err
+			}
+			triggered = append(triggered, alerts...)
+		}
+
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return triggered, nil
+}
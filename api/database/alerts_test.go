@@ -0,0 +1,48 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	m "govulnapi/models"
+)
+
+func TestTriggerPriceAlerts(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, d *DB) {
+		user := addTestUser(t, d, "alice@example.com")
+		date := time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		above, err := d.AddPriceAlert(user.Id, "bitcoin", m.UsdFromFloat(900), "above")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = d.AddPriceAlert(user.Id, "bitcoin", m.UsdFromFloat(700), "below"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = d.AddPriceAlert(user.Id, "bitcoin", 0, "above"); err == nil {
+			t.Error("added an alert without a threshold")
+		}
+
+		coins := []m.Coin{{Id: "bitcoin", Price: m.UsdFromFloat(900)}}
+		triggered, err := d.TriggerPriceAlerts(coins, date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(triggered) != 1 || triggered[0].Id != above.Id || triggered[0].ThresholdUsd != above.ThresholdUsd {
+			t.Errorf("triggered %+v, want the alert above %v", triggered, above.ThresholdUsd)
+		}
+
+		// Alerts only trigger once
+		if triggered, err = d.TriggerPriceAlerts(coins, date); err != nil || len(triggered) != 0 {
+			t.Errorf("triggered %+v again (%v)", triggered, err)
+		}
+
+		active, err := d.GetActivePriceAlerts(user.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(active) != 1 || active[0].Direction != "below" {
+			t.Errorf("active alerts are %+v, want the one below", active)
+		}
+	})
+}
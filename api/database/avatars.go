@@ -0,0 +1,41 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	m "govulnapi/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrAvatarNotFound = errors.New("Avatar not found!")
+
+// Stores the avatar of a user, replacing the one they had
+func (d *DB) SetAvatar(avatar m.Avatar) error {
+	query := `INSERT INTO "avatar" (user_id, file_name, content_type, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET file_name = excluded.file_name, content_type = excluded.content_type, updated_at = excluded.updated_at`
+
+	return d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(db.Rebind(query), avatar.UserId, avatar.FileName, avatar.ContentType, avatar.UpdatedAt)
+		return err
+	})
+}
+
+// Gets the avatar of a user whose account wasn't deleted
+func (d *DB) GetAvatar(userId int) (m.Avatar, error) {
+	var avatar m.Avatar
+	query := `SELECT a.* FROM "avatar" a
+		JOIN "user" u ON u.id = a.user_id
+		WHERE u.deleted_at IS NULL AND a.user_id = ?`
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&avatar, db.Rebind(query), userId)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return m.Avatar{}, ErrAvatarNotFound
+	} else if err != nil {
+		return m.Avatar{}, err
+	}
+
+	return avatar, nil
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	"modernc.org/sqlite"
+)
+
+var (
+	ErrBackupUnsupported = errors.New("Backups are only supported with sqlite!")
+	ErrInvalidSnapshot   = errors.New("Snapshot is not a SQLite database!")
+)
+
+// Header every SQLite database file starts with
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// Online backup API of the modernc driver connection
+type sqliteBackuper interface {
+	NewBackup(dstUri string) (*sqlite.Backup, error)
+	NewRestore(srcUri string) (*sqlite.Backup, error)
+}
+
+// Writes a consistent snapshot of the database to w, taken with the SQLite
+// online backup API while the database stays in use
+func (d *DB) Backup(w io.Writer) error {
+	if d.driver != DriverSQLite {
+		return ErrBackupUnsupported
+	}
+
+	file, err := os.CreateTemp("", "govulnapi-backup-*.db")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	err = d.withRetry(func(db *sqlx.DB) error {
+		return runBackup(db, func(b sqliteBackuper) (*sqlite.Backup, error) {
+			return b.NewBackup(file.Name())
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// Replaces the contents of the database with the snapshot read from r and
+// migrates it, so snapshots of older versions can be restored too
+func (d *DB) Restore(r io.Reader) error {
+	if d.driver != DriverSQLite {
+		return ErrBackupUnsupported
+	}
+
+	file, err := os.CreateTemp("", "govulnapi-restore-*.db")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if _, err = io.Copy(file, r); err != nil {
+		return err
+	}
+
+	header := make([]byte, len(sqliteHeader))
+	if _, err = file.ReadAt(header, 0); err != nil || !bytes.Equal(header, sqliteHeader) {
+		return ErrInvalidSnapshot
+	}
+
+	err = d.withRetry(func(db *sqlx.DB) error {
+		return runBackup(db, func(b sqliteBackuper) (*sqlite.Backup, error) {
+			return b.NewRestore(file.Name())
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return d.Migrate()
+}
+
+// Writes a snapshot of the database to the file at path
+func (d *DB) BackupFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err = d.Backup(file); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+
+	return file.Close()
+}
+
+// Restores the database from the snapshot file at path
+func (d *DB) RestoreFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return d.Restore(file)
+}
+
+// Copies all pages of the backup started by start on a raw connection
+func runBackup(db *sqlx.DB, start func(sqliteBackuper) (*sqlite.Backup, error)) error {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		backuper, ok := driverConn.(sqliteBackuper)
+		if !ok {
+			return ErrBackupUnsupported
+		}
+
+		backup, err := start(backuper)
+		if err != nil {
+			return err
+		}
+
+		_, err = backup.Step(-1)
+		if finishErr := backup.Finish(); err == nil {
+			err = finishErr
+		}
+		return err
+	})
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	m "govulnapi/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Moves usd between users and records the pair of ledger entries
+func (d *DB) TransferCash(senderId int, receiverEmail string, amount m.Usd, virtualDate time.Time, allowNegative bool) error {
+	// CWE-839: Numeric Range Comparison Without Minimum Check
+	// With negative amounts allowed, the sender takes money from the receiver
+	if amount == 0 || (amount < 0 && !allowNegative) {
+		return errors.New("Amount needs to be > 0!")
+	}
+
+	return d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		var receiverId int
+		err = tx.Get(&receiverId, tx.Rebind(`SELECT id FROM "user" WHERE email = ? AND deleted_at IS NULL`), receiverEmail)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("Receiver doesn't exist!")
+		} else if err != nil {
+			return err
+		}
+
+		if receiverId == senderId {
+			return errors.New("Can't send usd to your own account!")
+		}
+
+		// Balance is checked and updated in a single statement, so concurrent
+		// transfers can't spend the same usd twice
+		r, err := tx.Exec(
+			tx.Rebind(`UPDATE "user" SET usd_balance = usd_balance - ? WHERE id = ? AND usd_balance >= ?`),
+			amount, senderId, amount,
+		)
+		if err != nil {
+			return err
+		}
+		if rows, _ := r.RowsAffected(); rows == 0 {
+			return errors.New("Not enough usd!")
+		}
+
+		if _, err = tx.Exec(tx.Rebind(`UPDATE "user" SET usd_balance = usd_balance + ? WHERE id = ?`), amount, receiverId); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO "cash_transaction" (user_id, counterparty_id, type, amount, virtual_date, date) VALUES (?, ?, 'transfer', ?, ?, ?)`
+		now := time.Now().UTC().Format(time.RFC3339)
+		if _, err = tx.Exec(tx.Rebind(query), senderId, receiverId, -amount, virtualDate.Format(time.DateOnly), now); err != nil {
+			return err
+		}
+		if _, err = tx.Exec(tx.Rebind(query), receiverId, senderId, amount, virtualDate.Format(time.DateOnly), now); err != nil {
+			return err
+		}
+
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (d *DB) GetCashTransactions(userId int, transactionType string) ([]m.CashTransaction, error) {
+	var (
+		transactions = []m.CashTransaction{}
+		query        = `SELECT c.*, u.email AS counterparty_email
+			FROM "cash_transaction" c LEFT JOIN "user" u ON u.id = c.counterparty_id
+			WHERE c.user_id = ? AND c.type = ?
+			ORDER BY c.id DESC`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&transactions, db.Rebind(query), userId, transactionType)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// Credits (positive amount) or debits (negative amount) usd and records it in
+// the ledger. Amounts of the same type on one virtual date are capped by
+// dailyLimit, which doesn't apply if it's 0.
+func (d *DB) AdjustCash(userId int, amount m.Usd, transactionType string, virtualDate time.Time, dailyLimit m.Usd) error {
+	date := virtualDate.Format(time.DateOnly)
+
+	return d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if dailyLimit > 0 {
+			var used m.Usd
+			query := `SELECT COALESCE(SUM(ABS(amount)), 0) FROM "cash_transaction" WHERE user_id = ? AND type = ? AND virtual_date = ?`
+			if err = tx.Get(&used, tx.Rebind(query), userId, transactionType, date); err != nil {
+				return err
+			}
+			if used+max(amount, -amount) > dailyLimit {
+				return fmt.Errorf("Daily %s limit of %v usd exceeded!", transactionType, dailyLimit)
+			}
+		}
+
+		r, err := tx.Exec(
+			tx.Rebind(`UPDATE "user" SET usd_balance = usd_balance + ? WHERE id = ? AND usd_balance + ? >= 0`),
+			amount, userId, amount,
+		)
+		if err != nil {
+			return err
+		}
+		if rows, _ := r.RowsAffected(); rows == 0 {
+			return errors.New("Not enough usd!")
+		}
+
+		query := `INSERT INTO "cash_transaction" (user_id, type, amount, virtual_date, date) VALUES (?, ?, ?, ?, ?)`
+		if _, err = tx.Exec(tx.Rebind(query), userId, transactionType, amount, date, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			return err
+		}
+
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	m "govulnapi/models"
+)
+
+func TestTransferCash(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, d *DB) {
+		alice := addTestUser(t, d, "alice@example.com")
+		bob := addTestUser(t, d, "bob@example.com")
+		date := time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)
+		amount := m.UsdFromFloat(12.345678)
+
+		if err := d.TransferCash(alice.Id, "bob@example.com", amount, date, false); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, test := range []struct {
+			user m.User
+			want m.Usd
+		}{
+			{alice, alice.UsdBalance - amount},
+			{bob, bob.UsdBalance + amount},
+		} {
+			user, err := d.GetUserById(test.user.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.UsdBalance != test.want {
+				t.Errorf("balance of %s is %v, want %v", user.Email, user.UsdBalance, test.want)
+			}
+		}
+
+		transfers, err := d.GetCashTransactions(bob.Id, "transfer")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(transfers) != 1 || transfers[0].Amount != amount || *transfers[0].CounterpartyEmail != "alice@example.com" {
+			t.Errorf("transfers of bob are %+v, want the one from alice", transfers)
+		}
+
+		for _, failing := range []struct {
+			name     string
+			receiver string
+			amount   m.Usd
+		}{
+			{"more than the balance", "bob@example.com", alice.UsdBalance},
+			{"to oneself", "alice@example.com", amount},
+			{"to nobody", "nobody@example.com", amount},
+			{"a negative amount", "bob@example.com", -amount},
+		} {
+			if err = d.TransferCash(alice.Id, failing.receiver, failing.amount, date, false); err == nil {
+				t.Errorf("transferring %s succeeded", failing.name)
+			}
+		}
+	})
+}
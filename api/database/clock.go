@@ -0,0 +1,39 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	m "govulnapi/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrClockNotSaved = errors.New("Virtual clock wasn't saved yet!")
+
+// Gets the virtual time saved last, there's only ever one
+func (d *DB) GetVirtualClock() (m.VirtualClock, error) {
+	var clock m.VirtualClock
+	query := `SELECT virtual_date, start_date, day_duration_ms, updated_at FROM "virtual_clock" WHERE id = 1`
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&clock, query)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return m.VirtualClock{}, ErrClockNotSaved
+	} else if err != nil {
+		return m.VirtualClock{}, err
+	}
+
+	return clock, nil
+}
+
+func (d *DB) SaveVirtualClock(clock m.VirtualClock) error {
+	query := `INSERT INTO "virtual_clock" (id, virtual_date, start_date, day_duration_ms, updated_at) VALUES (1, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET virtual_date = excluded.virtual_date, start_date = excluded.start_date,
+			day_duration_ms = excluded.day_duration_ms, updated_at = excluded.updated_at`
+
+	return d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(db.Rebind(query), clock.VirtualDate, clock.StartDate, clock.DayDurationMs, clock.UpdatedAt)
+		return err
+	})
+}
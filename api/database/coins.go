@@ -1,19 +1,291 @@
 package database
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
 	m "govulnapi/models"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
 )
 
+// Gets every coin with the prices it was last refreshed with
 func (d *DB) GetCoins() ([]m.Coin, error) {
 	var (
 		coins []m.Coin
-		query = "SELECT id FROM 'coin'"
+		query = `SELECT id, price, market_cap AS marketcap, volume_24h AS volume24h FROM "coin"`
 	)
 
-	if err := d.db.Select(&coins, query); err != nil {
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&coins, query)
+	})
+	if err != nil {
 		return nil, errors.New("Unable to load coins from the database!")
 	}
 
 	return coins, nil
 }
+
+var (
+	ErrCoinNotFound    = errors.New("Coin not found!")
+	ErrVersionMismatch = errors.New("Coin was changed in the meantime, get its current version!")
+)
+
+// Gets the coin with the price it was last refreshed with, ErrCoinNotFound
+// when it wasn't priced yet
+func (d *DB) GetCoinByID(coinId string) (m.Coin, error) {
+	var (
+		coin  m.Coin
+		query = `SELECT id, price, market_cap AS marketcap, volume_24h AS volume24h FROM "coin" WHERE id = ? AND price > 0`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&coin, db.Rebind(query), coinId)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return m.Coin{}, ErrCoinNotFound
+	} else if err != nil {
+		return m.Coin{}, err
+	}
+
+	return coin, nil
+}
+
+func (d *DB) GetCoinListing(coinId string) (m.CoinListing, error) {
+	var (
+		listing m.CoinListing
+		query   = `SELECT id, name, symbol, version FROM "coin" WHERE id = ?`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&listing, db.Rebind(query), coinId)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return m.CoinListing{}, ErrCoinNotFound
+	} else if err != nil {
+		return m.CoinListing{}, err
+	}
+
+	return listing, nil
+}
+
+// Replaces the name and symbol of the coin as long as it's still at version,
+// incrementing it
+func (d *DB) UpdateCoinListing(coinId string, name string, symbol string, version int) (m.CoinListing, error) {
+	query := `UPDATE "coin" SET name = ?, symbol = ?, version = version + 1 WHERE id = ? AND version = ?`
+
+	var updated int64
+	err := d.withRetry(func(db *sqlx.DB) error {
+		r, err := db.Exec(db.Rebind(query), name, symbol, coinId, version)
+		if err != nil {
+			return err
+		}
+		updated, err = r.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return m.CoinListing{}, err
+	}
+
+	listing, err := d.GetCoinListing(coinId)
+	if err == nil && updated == 0 {
+		err = ErrVersionMismatch
+	}
+	return listing, err
+}
+
+// Gets the name and symbol of every coin
+func (d *DB) GetCoinNames() ([]m.NewCoin, error) {
+	var (
+		coins []m.NewCoin
+		query = `SELECT id, name, symbol FROM "coin" ORDER BY id`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&coins, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return coins, nil
+}
+
+// Lists the coins in a single transaction, leaving out the ones listed
+// already, and returns how many were added. Users get empty balances of the
+// new coins, like they get of every coin when they register.
+func (d *DB) AddCoins(coins []m.NewCoin) (int, error) {
+	var (
+		query        = `INSERT INTO "coin" (id, name, symbol) VALUES (?, ?, ?) ON CONFLICT (id) DO NOTHING`
+		usersQuery   = `SELECT id, email FROM "user"`
+		balanceQuery = `INSERT INTO "coin_balance" (user_id, coin_id, address, qty) VALUES (?, ?, ?, 0)
+			ON CONFLICT (user_id, coin_id) DO NOTHING`
+	)
+
+	var inserted int
+	err := d.withRetry(func(db *sqlx.DB) error {
+		inserted = 0
+
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		var users []m.User
+		if err = tx.Select(&users, usersQuery); err != nil {
+			return err
+		}
+
+		for _, coin := range coins {
+			r, err := tx.Exec(tx.Rebind(query), coin.Id, coin.Name, coin.Symbol)
+			if err != nil {
+				return err
+			}
+			rows, _ := r.RowsAffected()
+			if rows == 0 {
+				continue
+			}
+			inserted++
+
+			for _, user := range users {
+				address := coinAddress(coin.Id, user.Email, user.Id)
+				if _, err = tx.Exec(tx.Rebind(balanceQuery), user.Id, coin.Id, address); err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return inserted, nil
+}
+
+// Records the prices of the coins on date and stores them as their latest
+// prices in the same transaction, so coins are loaded with them on startup
+func (d *DB) AddPriceHistory(coins []m.Coin, date time.Time, manual bool) error {
+	var (
+		query       = `INSERT INTO "price_history" (coin_id, price, market_cap, volume_24h, date, manual) VALUES (?, ?, ?, ?, ?, ?)`
+		latestQuery = `INSERT INTO "coin" (id, price, market_cap, volume_24h) VALUES (?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET price = excluded.price, market_cap = excluded.market_cap, volume_24h = excluded.volume_24h`
+	)
+
+	return d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for _, coin := range coins {
+			if _, err = tx.Exec(tx.Rebind(query), coin.Id, coin.Price, coin.MarketCap, coin.Volume24h, date.Format(time.DateOnly), manual); err != nil {
+				return err
+			}
+			if _, err = tx.Exec(tx.Rebind(latestQuery), coin.Id, coin.Price, coin.MarketCap, coin.Volume24h); err != nil {
+				return err
+			}
+		}
+
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Gets the last recorded price of the given coins, or of every coin when
+// none are given, for each virtual date between from and until inclusive,
+// oldest first. Empty from starts at the beginning.
+func (d *DB) GetDailyPrices(from string, until string, coinIds ...string) ([]m.PriceHistory, error) {
+	var prices []m.PriceHistory
+
+	query, args := dailyPricesQuery(from, until, coinIds)
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&prices, db.Rebind(query), args...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return prices, nil
+}
+
+func dailyPricesQuery(from string, until string, coinIds []string) (string, []interface{}) {
+	var (
+		conditions = []string{"date >= ?", "date <= ?"}
+		args       = []interface{}{from, until}
+	)
+
+	if len(coinIds) > 0 {
+		conditions = append(conditions, fmt.Sprintf("coin_id IN (?%s)", strings.Repeat(", ?", len(coinIds)-1)))
+		for _, coinId := range coinIds {
+			args = append(args, coinId)
+		}
+	}
+
+	// Grouping follows the price_history_coin_date index, which also covers
+	// the max id of each group
+	query := fmt.Sprintf(
+		`SELECT id, coin_id, price, market_cap, volume_24h, date, manual FROM "price_history" WHERE id IN (
+			SELECT MAX(id) FROM "price_history" WHERE %s GROUP BY coin_id, date
+		) ORDER BY date, id`,
+		strings.Join(conditions, " AND "),
+	)
+
+	return query, args
+}
+
+// Gets the last recorded price of the coin on each of the virtual dates with
+// a single query, nil for dates without one
+func (d *DB) GetPricesOn(coinId string, dates ...string) ([]*m.Usd, error) {
+	if len(dates) == 0 {
+		return nil, nil
+	}
+
+	// Dates without a price are left nil
+	prices := make([]*m.Usd, len(dates))
+	query, args := pricesOnQuery(coinId, dates)
+	err := d.withRetry(func(db *sqlx.DB) error {
+		dest := make([]interface{}, len(prices))
+		for i := range prices {
+			dest[i] = &prices[i]
+		}
+		return db.QueryRow(db.Rebind(query), args...).Scan(dest...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return prices, nil
+}
+
+func pricesOnQuery(coinId string, dates []string) (string, []interface{}) {
+	var (
+		columns = make([]string, len(dates))
+		args    = make([]interface{}, 0, 2*len(dates)+1)
+	)
+	for i, date := range dates {
+		columns[i] = "MAX(CASE WHEN date = ? THEN price END)"
+		args = append(args, date)
+	}
+	args = append(args, coinId)
+	for _, date := range dates {
+		args = append(args, date)
+	}
+
+	query := fmt.Sprintf(
+		`SELECT %s FROM "price_history" WHERE id IN (
+			SELECT MAX(id) FROM "price_history" WHERE coin_id = ? AND date IN (?%s) GROUP BY date
+		)`,
+		strings.Join(columns, ", "), strings.Repeat(", ?", len(dates)-1),
+	)
+
+	return query, args
+}
@@ -0,0 +1,250 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	m "govulnapi/models"
+)
+
+func TestGetCoinByID(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, d *DB) {
+		date := time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)
+		if _, err := d.AddCoins([]m.NewCoin{{Id: "gridcoin", Name: "Gridcoin", Symbol: "GRC"}}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Listed coins are only found once they were priced
+		if _, err := d.GetCoinByID("gridcoin"); !errors.Is(err, ErrCoinNotFound) {
+			t.Errorf("getting the unpriced coin gave %v, want ErrCoinNotFound", err)
+		}
+
+		priced := m.Coin{Id: "gridcoin", Price: m.UsdFromFloat(0.012345), MarketCap: 5e6, Volume24h: 1e4}
+		if err := d.AddPriceHistory([]m.Coin{priced}, date, false); err != nil {
+			t.Fatal(err)
+		}
+		if coin, err := d.GetCoinByID("gridcoin"); err != nil || coin != priced {
+			t.Errorf("got %+v (%v), want %+v", coin, err, priced)
+		}
+
+		if _, err := d.GetCoinByID("nocoin"); !errors.Is(err, ErrCoinNotFound) {
+			t.Errorf("getting a missing coin gave %v, want ErrCoinNotFound", err)
+		}
+	})
+}
+
+func TestAddCoins(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, d *DB) {
+		user := addTestUser(t, d, "importer@example.com")
+		date := time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		// Listed coins are left out
+		inserted, err := d.AddCoins([]m.NewCoin{
+			{Id: "bitcoin", Name: "Bitcoin", Symbol: "BTC"},
+			{Id: "gridcoin", Name: "Gridcoin", Symbol: "GRC"},
+			{Id: "peercoin", Name: "Peercoin", Symbol: "PPC"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inserted != 2 {
+			t.Errorf("added %d coins, want 2", inserted)
+		}
+
+		// Users registered before get empty balances of the new coins, so
+		// they can buy them
+		if err = d.AddOrder(user.Id, "gridcoin", m.UsdFromFloat(0.01), true, 100, date); err != nil {
+			t.Fatal(err)
+		}
+		after, err := d.GetUserById(user.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		balances := map[string]float64{}
+		for _, balance := range after.CoinBalances {
+			balances[balance.CoinId] = balance.Qty
+		}
+		if qty, ok := balances["peercoin"]; !ok || qty != 0 {
+			t.Errorf("peercoin balance is %v (%v), want an empty one", qty, ok)
+		}
+		if balances["gridcoin"] != 100 {
+			t.Errorf("gridcoin balance is %v, want 100", balances["gridcoin"])
+		}
+
+		// Orders fail without a balance to credit, leaving the usd unspent
+		if _, err = d.db.Exec(d.db.Rebind(`DELETE FROM "coin_balance" WHERE user_id = ? AND coin_id = 'peercoin'`), user.Id); err != nil {
+			t.Fatal(err)
+		}
+		if err = d.AddOrder(user.Id, "peercoin", m.UsdFromFloat(1), true, 1, date); !errors.Is(err, ErrNoBalance) {
+			t.Errorf("buying without a balance gave %v, want ErrNoBalance", err)
+		}
+		if unchanged, err := d.GetUserById(user.Id); err != nil || unchanged.UsdBalance != after.UsdBalance {
+			t.Errorf("balance is %v (%v), want %v", unchanged.UsdBalance, err, after.UsdBalance)
+		}
+		var orders int
+		if err = d.db.Get(&orders, d.db.Rebind(`SELECT COUNT(*) FROM "order" WHERE coin_id = 'peercoin'`)); err != nil {
+			t.Fatal(err)
+		}
+		if orders != 0 {
+			t.Errorf("%d peercoin orders recorded, want none", orders)
+		}
+	})
+}
+
+func TestAddCoinsRollsBack(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, d *DB) {
+		user := addTestUser(t, d, "rollback@example.com")
+
+		// The address of the peercoin balance is taken, so listing it fails
+		// after gridcoin was listed
+		_, err := d.db.Exec(
+			d.db.Rebind(`UPDATE "coin_balance" SET address = ? WHERE user_id = ? AND coin_id = 'bitcoin'`),
+			coinAddress("peercoin", user.Email, user.Id), user.Id,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = d.AddCoins([]m.NewCoin{
+			{Id: "gridcoin", Name: "Gridcoin", Symbol: "GRC"},
+			{Id: "peercoin", Name: "Peercoin", Symbol: "PPC"},
+		})
+		if err == nil {
+			t.Fatal("listing coins with a taken address succeeded")
+		}
+
+		var coins, balances int
+		if err = d.db.Get(&coins, `SELECT COUNT(*) FROM "coin" WHERE id IN ('gridcoin', 'peercoin')`); err != nil {
+			t.Fatal(err)
+		}
+		if err = d.db.Get(&balances, `SELECT COUNT(*) FROM "coin_balance" WHERE coin_id IN ('gridcoin', 'peercoin')`); err != nil {
+			t.Fatal(err)
+		}
+		if coins != 0 || balances != 0 {
+			t.Errorf("%d coins and %d balances left, want none", coins, balances)
+		}
+	})
+}
+
+// Every query on price_history needs to look it up by id or through the
+// price_history_coin_date index, instead of scanning the table
+func TestPriceHistoryQueryPlans(t *testing.T) {
+	d := Init(MemoryDSN)
+	t.Cleanup(d.Close)
+
+	for name, build := range map[string]func() (string, []interface{}){
+		"daily prices": func() (string, []interface{}) {
+			return dailyPricesQuery("2014-01-01", "2014-01-31", nil)
+		},
+		"daily prices of coins": func() (string, []interface{}) {
+			return dailyPricesQuery("2014-01-01", "2014-01-31", []string{"bitcoin", "litecoin"})
+		},
+		"prices on dates": func() (string, []interface{}) {
+			return pricesOnQuery("bitcoin", []string{"2014-01-01", "2014-01-08", "2014-01-31"})
+		},
+	} {
+		query, args := build()
+		plan := queryPlan(t, d, query, args...)
+
+		for _, step := range plan {
+			if strings.Contains(step, "price_history") &&
+				!strings.Contains(step, "USING INTEGER PRIMARY KEY") &&
+				!strings.Contains(step, "USING COVERING INDEX price_history_coin_date") {
+				t.Errorf("%s: %q doesn't use the index, plan %q", name, step, plan)
+			}
+		}
+	}
+
+	// Prices of given coins search the index instead of scanning it
+	query, args := dailyPricesQuery("2014-01-01", "2014-01-31", []string{"bitcoin"})
+	plan := strings.Join(queryPlan(t, d, query, args...), "\n")
+	if !strings.Contains(plan, "SEARCH price_history USING COVERING INDEX price_history_coin_date (coin_id=?") {
+		t.Errorf("got plan %q, want a search by coin_id", plan)
+	}
+}
+
+// Details of the steps of SQLite's plan for the query
+func queryPlan(t *testing.T, d *DB, query string, args ...interface{}) []string {
+	t.Helper()
+
+	rows, err := d.conn().Query(`EXPLAIN QUERY PLAN `+query, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var (
+			id, parent, notUsed int
+			detail              string
+		)
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			t.Fatal(err)
+		}
+		plan = append(plan, detail)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return plan
+}
+
+// Compares the price history queries on 100k rows, 100 coins over 1000
+// days, with and without the price_history_coin_date index:
+//
+//	go test -run '^$' -bench PriceHistory ./api/database
+func BenchmarkPriceHistory(b *testing.B) {
+	const coins, days = 100, 1000
+	start := time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)
+	date := func(day int) string { return start.AddDate(0, 0, day).Format(time.DateOnly) }
+
+	for _, indexed := range []bool{true, false} {
+		d := Init(MemoryDSN)
+		b.Cleanup(d.Close)
+
+		listed := make([]m.NewCoin, coins)
+		for coin := range listed {
+			id := fmt.Sprintf("coin-%d", coin)
+			listed[coin] = m.NewCoin{Id: id, Name: id, Symbol: id}
+		}
+		if _, err := d.AddCoins(listed); err != nil {
+			b.Fatal(err)
+		}
+
+		tx := d.conn().MustBegin()
+		for day := 0; day < days; day++ {
+			for coin := 0; coin < coins; coin++ {
+				tx.MustExec(
+					`INSERT INTO "price_history" (coin_id, price, market_cap, volume_24h, date, manual) VALUES (?, ?, 0, 0, ?, false)`,
+					fmt.Sprintf("coin-%d", coin), m.UsdFromFloat(float64(day+1)), date(day),
+				)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			b.Fatal(err)
+		}
+		if !indexed {
+			d.conn().MustExec(`DROP INDEX "price_history_coin_date"`)
+		}
+
+		b.Run(fmt.Sprintf("GetDailyPrices/indexed=%t", indexed), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := d.GetDailyPrices(date(days-30), date(days-1), "coin-42"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("GetPricesOn/indexed=%t", indexed), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := d.GetPricesOn("coin-42", date(days-1), date(days-7), date(days-30), date(days-90)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
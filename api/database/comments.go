@@ -0,0 +1,127 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	m "govulnapi/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrCommentNotFound = errors.New("Comment not found!")
+
+type CommentFilter struct {
+	// Keyset of the last comment on the previous page
+	AfterVirtualDate string
+	AfterId          int
+	Limit            int
+}
+
+// Comments along with the display name of their author, whose account wasn't
+// deleted
+const commentsQuery = `SELECT c.*, u.display_name FROM "comment" c
+	JOIN "user" u ON u.id = c.user_id
+	WHERE u.deleted_at IS NULL`
+
+func (d *DB) AddComment(userId int, coinId string, body string, virtualDate time.Time, createdAt time.Time) (m.Comment, error) {
+	var (
+		id    int
+		query = `INSERT INTO "comment" (coin_id, user_id, body, virtual_date, created_at) VALUES (?, ?, ?, ?, ?) RETURNING id`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&id, db.Rebind(query), coinId, userId, body, virtualDate.Format(time.DateOnly), createdAt.Format(time.RFC3339))
+	})
+	if err != nil {
+		return m.Comment{}, err
+	}
+
+	return d.GetComment(id)
+}
+
+// Gets comments on the coin newest first, ordered by virtual date and id
+func (d *DB) GetComments(coinId string, filter CommentFilter) ([]m.Comment, error) {
+	var (
+		comments = []m.Comment{}
+		query    = commentsQuery + ` AND c.coin_id = ?`
+		args     = []interface{}{coinId}
+	)
+
+	if filter.AfterId != 0 {
+		query += " AND (c.virtual_date < ? OR (c.virtual_date = ? AND c.id < ?))"
+		args = append(args, filter.AfterVirtualDate, filter.AfterVirtualDate, filter.AfterId)
+	}
+
+	query += " ORDER BY c.virtual_date DESC, c.id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&comments, db.Rebind(query), args...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (d *DB) GetComment(commentId int) (m.Comment, error) {
+	var comment m.Comment
+	query := commentsQuery + ` AND c.id = ?`
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&comment, db.Rebind(query), commentId)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return m.Comment{}, ErrCommentNotFound
+	} else if err != nil {
+		return m.Comment{}, err
+	}
+
+	return comment, nil
+}
+
+func (d *DB) UpdateComment(commentId int, body string, editedAt time.Time) (m.Comment, error) {
+	query := `UPDATE "comment" SET body = ?, edited_at = ? WHERE id = ?`
+
+	var rows int64
+	err := d.withRetry(func(db *sqlx.DB) error {
+		r, err := db.Exec(db.Rebind(query), body, editedAt.Format(time.RFC3339), commentId)
+		if err != nil {
+			return err
+		}
+		rows, _ = r.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return m.Comment{}, err
+	}
+	if rows == 0 {
+		return m.Comment{}, ErrCommentNotFound
+	}
+
+	return d.GetComment(commentId)
+}
+
+func (d *DB) DeleteComment(commentId int) error {
+	query := `DELETE FROM "comment" WHERE id = ?`
+
+	var rows int64
+	err := d.withRetry(func(db *sqlx.DB) error {
+		r, err := db.Exec(db.Rebind(query), commentId)
+		if err != nil {
+			return err
+		}
+		rows, _ = r.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrCommentNotFound
+	}
+
+	return nil
+}
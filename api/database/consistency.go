@@ -0,0 +1,64 @@
+package database
+
+import (
+	m "govulnapi/models"
+	"math"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Rounding errors of summing many float coin amounts are not discrepancies
+const balanceTolerance = 1e-6
+
+// Recomputes the usd and coin balances of every user from their starting
+// balance, cash transactions, orders and coin transfers, and returns the
+// ones that differ from the stored balance. Open orders only count with
+// what was set aside for them, cancelled ones not at all.
+func (d *DB) GetBalanceDiscrepancies() (m.ConsistencyReport, error) {
+	var (
+		report = m.ConsistencyReport{Discrepancies: []m.BalanceDiscrepancy{}}
+		usd    []m.BalanceDiscrepancy
+		coins  []m.BalanceDiscrepancy
+	)
+
+	// Usd amounts are summed exactly in millionths of a dollar, then
+	// converted to dollars
+	usdQuery := `SELECT u.id AS user_id, u.email, 'usd' AS asset, u.usd_balance / 1000000.0 AS actual,
+		(u.usd_starting_balance
+			+ COALESCE((SELECT SUM(c.amount) FROM "cash_transaction" c WHERE c.user_id = u.id), 0)
+			- COALESCE((SELECT SUM(CASE WHEN o.is_buy THEN ` + orderTotal + ` ELSE -` + orderTotal + ` END) FROM "order" o
+				WHERE o.user_id = u.id AND (o.status = 'filled' OR (o.status = 'open' AND o.is_buy))), 0)
+		) / 1000000.0 AS expected
+		FROM "user" u WHERE u.deleted_at IS NULL ORDER BY u.id`
+	coinQuery := `SELECT u.id AS user_id, u.email, b.coin_id AS asset, b.qty AS actual,
+		COALESCE((SELECT SUM(CASE WHEN o.is_buy THEN o.qty ELSE -o.qty END) FROM "order" o
+			WHERE o.user_id = u.id AND o.coin_id = b.coin_id AND (o.status = 'filled' OR (o.status = 'open' AND NOT o.is_buy))), 0)
+			+ COALESCE((SELECT SUM(t.qty) FROM "transaction" t WHERE t.receiver_id = u.id AND t.coin_id = b.coin_id), 0)
+			- COALESCE((SELECT SUM(t.qty) FROM "transaction" t WHERE t.sender_id = u.id AND t.coin_id = b.coin_id), 0)
+			AS expected
+		FROM "coin_balance" b JOIN "user" u ON u.id = b.user_id
+		WHERE u.deleted_at IS NULL ORDER BY u.id, b.coin_id`
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		if err := db.Select(&usd, usdQuery); err != nil {
+			return err
+		}
+		return db.Select(&coins, coinQuery)
+	})
+	if err != nil {
+		return m.ConsistencyReport{}, err
+	}
+
+	checked := map[int]bool{}
+	for _, balance := range append(usd, coins...) {
+		checked[balance.UserId] = true
+
+		balance.Difference = balance.Actual - balance.Expected
+		if math.Abs(balance.Difference) > balanceTolerance*math.Max(1, math.Abs(balance.Expected)) {
+			report.Discrepancies = append(report.Discrepancies, balance)
+		}
+	}
+	report.UsersChecked = len(checked)
+
+	return report, nil
+}
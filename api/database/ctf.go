@@ -0,0 +1,189 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	m "govulnapi/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Flags placed in lab data, others are only known by their hash
+const (
+	// Display name of the default admin account
+	FlagAdminProfile = "admin_profile"
+	// Row of the "secret" table, which no query of the API reads
+	FlagHiddenTable = "hidden_table"
+)
+
+// Flags are stored hashed, so reading the ctf_flag table doesn't reveal them
+func HashFlag(flag string) string {
+	sum := sha256.Sum256([]byte(flag))
+	return hex.EncodeToString(sum[:])
+}
+
+// Stores the hashes of the flags by id and places the ones living in lab
+// data, replacing flags placed before
+func (d *DB) PlaceFlags(flags map[string]string) error {
+	return d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		query := `INSERT INTO "ctf_flag" (id, hash) VALUES (?, ?) ON CONFLICT (id) DO UPDATE SET hash = excluded.hash`
+		for id, flag := range flags {
+			if _, err = tx.Exec(tx.Rebind(query), id, HashFlag(flag)); err != nil {
+				return err
+			}
+		}
+
+		if flag, ok := flags[FlagAdminProfile]; ok {
+			query = `UPDATE "user" SET display_name = ? WHERE email = 'admin@govulnapi.com'`
+			if _, err = tx.Exec(tx.Rebind(query), flag); err != nil {
+				return err
+			}
+		}
+		if flag, ok := flags[FlagHiddenTable]; ok {
+			if _, err = tx.Exec(`DELETE FROM "secret"`); err != nil {
+				return err
+			}
+			query = `INSERT INTO "secret" (name, value) VALUES ('flag', ?)`
+			if _, err = tx.Exec(tx.Rebind(query), flag); err != nil {
+				return err
+			}
+		}
+
+		return tx.Commit()
+	})
+}
+
+// Gets the hashes of placed flags by id
+func (d *DB) GetFlagHashes() (map[string]string, error) {
+	var (
+		flags []struct {
+			Id   string `db:"id"`
+			Hash string `db:"hash"`
+		}
+		query = `SELECT id, hash FROM "ctf_flag"`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&flags, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := map[string]string{}
+	for _, flag := range flags {
+		hashes[flag.Id] = flag.Hash
+	}
+
+	return hashes, nil
+}
+
+// Records that the user found the flag, reporting false when they already had
+func (d *DB) AddSolve(userId int, flagId string, solvedAt time.Time) (bool, error) {
+	var (
+		query = `INSERT INTO "ctf_solve" (user_id, flag_id, solved_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`
+		added bool
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		r, err := db.Exec(db.Rebind(query), userId, flagId, solvedAt.UTC().Format(time.RFC3339))
+		if err != nil {
+			return err
+		}
+		rows, err := r.RowsAffected()
+		added = rows == 1
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return added, nil
+}
+
+// Gets the solves of users that aren't deleted, oldest first
+func (d *DB) GetSolves() ([]m.CTFSolve, error) {
+	var (
+		solves = []m.CTFSolve{}
+		query  = `SELECT s.user_id, u.display_name, s.flag_id, s.solved_at
+			FROM "ctf_solve" s JOIN "user" u ON u.id = s.user_id
+			WHERE u.deleted_at IS NULL
+			ORDER BY s.solved_at, s.user_id`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&solves, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return solves, nil
+}
+
+var ErrHintLocked = errors.New("Previous hints need to be taken first!")
+
+// Records that the user took hint n (from 1) of a challenge, which needs all
+// hints before it to be taken. Taking a hint again keeps when it was first
+// taken.
+func (d *DB) TakeHint(userId int, challengeId string, n int, takenAt time.Time) error {
+	return d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		var previous int
+		err = tx.Get(
+			&previous,
+			tx.Rebind(`SELECT COUNT(*) FROM "ctf_hint" WHERE user_id = ? AND challenge_id = ? AND number < ?`),
+			userId, challengeId, n,
+		)
+		if err != nil {
+			return err
+		}
+		if previous < n-1 {
+			return ErrHintLocked
+		}
+
+		_, err = tx.Exec(
+			tx.Rebind(`INSERT INTO "ctf_hint" (user_id, challenge_id, number, taken_at) VALUES (?, ?, ?, ?) ON CONFLICT DO NOTHING`),
+			userId, challengeId, n, takenAt.UTC().Format(time.RFC3339),
+		)
+		if err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
+}
+
+// Gets the hints users that aren't deleted took, oldest first
+func (d *DB) GetHintsTaken() ([]m.HintTaken, error) {
+	var (
+		hints = []m.HintTaken{}
+		query = `SELECT h.user_id, u.email, h.challenge_id, h.number, h.taken_at
+			FROM "ctf_hint" h JOIN "user" u ON u.id = h.user_id
+			WHERE u.deleted_at IS NULL
+			ORDER BY h.taken_at, h.user_id, h.challenge_id, h.number`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&hints, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hints, nil
+}
@@ -1,34 +1,428 @@
 package database
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
 	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "modernc.org/sqlite"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const (
+	DefaultFileName = "api.db"
+	// SQLite database that only lives as long as the DB, e.g. for tests
+	MemoryDSN = ":memory:"
+
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+
+	defaultMaxRetries = 3
+	retryBackoff      = 100 * time.Millisecond
+	// How long SQLite waits for a lock held by another connection, e.g. the
+	// -backup command, before failing with SQLITE_BUSY
+	busyTimeout = 5 * time.Second
+	// Connections to a SQLite file, one writer and readers
+	sqliteMaxOpenConns = 8
 )
 
 type DB struct {
-	db *sqlx.DB
+	mu         sync.RWMutex // Guards db and closed
+	db         *sqlx.DB
+	closed     bool
+	driver     string
+	dsn        string
+	memory     bool
+	maxRetries int
+	// Build queries by formatting values into them instead of binding them
+	sqlInjection atomic.Bool
+	// Write balances of orders back without a transaction, see AddOrder
+	orderRace atomic.Bool
 }
 
+// Opens the SQLite database file
 func Init(dbFileName string) *DB {
-	db, err := sqlx.Connect("sqlite", dbFileName)
+	return InitDriver(DriverSQLite, dbFileName)
+}
+
+// Opens the database of the given backend, dsn being the file name (or
+// MemoryDSN) for SQLite and the connection string for Postgres
+func InitDriver(driver string, dsn string) *DB {
+	if driver != DriverSQLite && driver != DriverPostgres {
+		log.Fatalf("Unsupported database driver %s\n", driver)
+	}
+	if driver == DriverSQLite && dsn == "" {
+		dsn = DefaultFileName
+	}
+	memory := driver == DriverSQLite && dsn == MemoryDSN
+	if driver == DriverSQLite {
+		dsn = sqliteDSN(dsn)
+	}
+
+	db, err := connect(driver, dsn, memory)
 
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	// Create tables
-	db.Exec(sqlSchema)
+	d := &DB{
+		db:         db,
+		driver:     driver,
+		dsn:        dsn,
+		memory:     memory,
+		maxRetries: defaultMaxRetries,
+	}
+	d.sqlInjection.Store(true)
+
+	if err = d.Migrate(); err != nil {
+		log.Fatalln("Unable to migrate database:", err)
+	}
+
+	log.Printf("Database opened (%s)\n", driver)
+
+	return d
+}
 
-	log.Println("Database opened")
+func connect(driver string, dsn string, memory bool) (*sqlx.DB, error) {
+	db, err := sqlx.Connect(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
 
-	return &DB{
-		db: db,
+	// SQLite allows a single writer at a time, which transactions wait for
+	// with BEGIN IMMEDIATE (see sqliteDSN), while readers use the other
+	// connections of the pool. Every connection to :memory: opens its own
+	// empty database, so there's a single one that's never closed either.
+	if driver == DriverSQLite {
+		db.SetMaxOpenConns(sqliteMaxOpenConns)
+	}
+	if memory {
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		db.SetConnMaxLifetime(0)
+		db.SetConnMaxIdleTime(0)
 	}
+
+	return db, nil
 }
 
 func (d *DB) Close() {
 	log.Println("Closing database ...")
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.closed = true
+	d.db.Close()
+}
+
+func (d *DB) Stats() sql.DBStats {
+	return d.conn().Stats()
+}
+
+// Rebuilds the database to give pages left unused by deleted rows back to
+// the file system
+func (d *DB) Vacuum() error {
+	before, err := d.pageCount()
+	if err != nil {
+		return err
+	}
+
+	err = d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(`VACUUM`)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	after, err := d.pageCount()
+	if err != nil {
+		return err
+	}
+	log.Printf("Database vacuumed: pages_before=%d pages_after=%d\n", before, after)
+
+	return nil
+}
+
+// Pages the database takes up, of the page or block size of its backend
+func (d *DB) pageCount() (int64, error) {
+	query := `PRAGMA page_count`
+	if d.driver == DriverPostgres {
+		query = `SELECT pg_database_size(current_database()) / current_setting('block_size')::int`
+	}
+
+	var pages int64
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&pages, query)
+	})
+	return pages, err
+}
+
+// Sets how many times a failed call is retried when the database connection
+// is temporarily unavailable
+func (d *DB) SetMaxRetries(maxRetries int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.maxRetries = maxRetries
+}
+
+// Switches between the SQL injectable and the parameterized variants of
+// queries, taking effect on the next call
+func (d *DB) SetSQLInjection(enabled bool) {
+	d.sqlInjection.Store(enabled)
+}
+
+// Switches between placing orders with and without a transaction, taking
+// effect on the next order
+func (d *DB) SetOrderRace(enabled bool) {
+	d.orderRace.Store(enabled)
+}
+
+// Picks the query built by formatting values into it while SQL injection is
+// enabled, and the parameterized one with its args otherwise
+func (d *DB) injectable(vulnerable string, parameterized string, args ...interface{}) (string, []interface{}) {
+	if d.sqlInjection.Load() {
+		return vulnerable, nil
+	}
+	return sqlx.Rebind(sqlx.BindType(d.driver), parameterized), args
+}
+
+func (d *DB) conn() *sqlx.DB {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.db
+}
+
+// Runs fn, retrying it with exponential backoff while it fails because the
+// database connection is unavailable or another connection holds a lock.
+// Calls run in a transaction are retried as a whole, so fn needs to begin
+// and commit it itself.
+func (d *DB) withRetry(fn func(db *sqlx.DB) error) error {
+	d.mu.RLock()
+	maxRetries := d.maxRetries
+	d.mu.RUnlock()
+
+	err := fn(d.conn())
+	for attempt := 0; attempt < maxRetries && (isConnectionError(err) || isBusyError(err)); attempt++ {
+		time.Sleep(retryBackoff << attempt)
+		log.Printf("Retrying database call after error: %v\n", err)
+
+		if isConnectionError(err) {
+			if reconnectErr := d.reconnect(); reconnectErr != nil {
+				err = reconnectErr
+				continue
+			}
+		}
+		err = fn(d.conn())
+	}
+
+	return err
+}
+
+// Reopens the database when its connection pool can't be used anymore,
+// unless it was closed on purpose
+func (d *DB) reconnect() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return errors.New("Database was closed!")
+	}
+	if err := d.db.Ping(); err == nil {
+		return nil
+	}
+	if d.memory {
+		return errors.New("In-memory database can't be reopened!")
+	}
+
+	db, err := connect(d.driver, d.dsn, d.memory)
+	if err != nil {
+		return err
+	}
+	d.db.Close()
+	d.db = db
+
+	return nil
+}
+
+var (
+	pragmaName  = regexp.MustCompile(`^[a-z_]+$`)
+	pragmaValue = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// Sets SQLite pragmas, e.g. {"cache_size": "-20000"}, on the connection and
+// on every connection opened later. Pragmas set on open, like journal_mode,
+// can be overridden this way too.
+func (d *DB) ApplyPragmas(pragmas map[string]string) error {
+	if d.driver != DriverSQLite {
+		return errors.New("Pragmas are only supported with sqlite!")
+	}
+	for name, value := range pragmas {
+		if !pragmaName.MatchString(name) || !pragmaValue.MatchString(value) {
+			return fmt.Errorf("Invalid pragma %s = %s!", name, value)
+		}
+	}
+
+	// The in-memory database can't be reopened, but its connection lives as
+	// long as the DB
+	if d.memory {
+		return d.withRetry(func(db *sqlx.DB) error {
+			for name, value := range pragmas {
+				if _, err := db.Exec(fmt.Sprintf("PRAGMA %s = %s", name, value)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return errors.New("Database was closed!")
+	}
+
+	// Leaving WAL mode needs the database to itself, so the old connections
+	// are closed first. When the new ones fail, calls reconnect with the
+	// previous pragmas.
 	d.db.Close()
+	dsn := withPragmas(d.dsn, pragmas)
+	db, err := connect(d.driver, dsn, d.memory)
+	if err != nil {
+		return err
+	}
+
+	d.db, d.dsn = db, dsn
+
+	return nil
+}
+
+// Aggregates expr of grouped rows into a comma separated list
+func (d *DB) groupConcat(expr string) string {
+	if d.driver == DriverPostgres {
+		return fmt.Sprintf("STRING_AGG(%s, ',')", expr)
+	}
+	return fmt.Sprintf("GROUP_CONCAT(%s)", expr)
+}
+
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, sql.ErrConnDone) || errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	// database/sql doesn't export the error returned after Close
+	if err.Error() == "sql: database is closed" {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Connection exceptions, serialization failures and deadlocks, and
+		// server shutdowns
+		switch pqErr.Code.Class() {
+		case "08", "40":
+			return true
+		}
+		if strings.HasPrefix(string(pqErr.Code), "57P") {
+			return true
+		}
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() & 0xff {
+		case sqlite3.SQLITE_IOERR, sqlite3.SQLITE_CANTOPEN:
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reports whether err was caused by a lock that is still held after the busy
+// timeout, or that couldn't be waited for without a deadlock
+func isBusyError(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() & 0xff {
+		case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+			return true
+		}
+	}
+
+	return false
+}
+
+// Adds the pragmas every SQLite connection is opened with: write-ahead
+// logging so readers don't block the writer, syncing to disk only at WAL
+// checkpoints, waiting for locks instead of failing right away, and
+// enforcing foreign keys. Transactions begin with BEGIN IMMEDIATE, taking
+// the write lock up front: a transaction that read first and then wanted to
+// write would fail with SQLITE_BUSY without waiting.
+func sqliteDSN(dsn string) string {
+	dsn = withPragmas(dsn, map[string]string{
+		"journal_mode": "WAL",
+		"synchronous":  "NORMAL",
+		"busy_timeout": strconv.FormatInt(busyTimeout.Milliseconds(), 10),
+		"foreign_keys": "1",
+	})
+	return dsn + "&_txlock=immediate"
+}
+
+// Adds pragmas to the query parameters of a SQLite dsn, in name order
+func withPragmas(dsn string, pragmas map[string]string) string {
+	names := make([]string, 0, len(pragmas))
+	for name := range pragmas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		dsn += fmt.Sprintf("%s_pragma=%s(%s)", separator, name, pragmas[name])
+	}
+
+	return dsn
+}
+
+// Error of a failed SQL statement, carrying the statement for debugging
+type QueryError struct {
+	Query string
+	Err   error
+}
+
+func (e *QueryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// Wraps the error of running the query, nil stays nil
+func queryError(query string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &QueryError{Query: query, Err: err}
 }
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	m "govulnapi/models"
+	"net/http"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const idempotencyKeyTTL = time.Hour * 24
+
+// Claims the key for a request that is about to be processed. Returns false
+// when the key was already claimed and hasn't expired yet.
+func (d *DB) ReserveIdempotencyKey(userId int, key string) (bool, error) {
+	var (
+		now      = time.Now()
+		reserved bool
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		if _, err := db.Exec(db.Rebind(`DELETE FROM "idempotency_key" WHERE created_at < ?`), now.Add(-idempotencyKeyTTL).Unix()); err != nil {
+			return err
+		}
+
+		r, err := db.Exec(
+			db.Rebind(`INSERT INTO "idempotency_key" (user_id, key, created_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`),
+			userId, key, now.Unix(),
+		)
+		if err != nil {
+			return err
+		}
+		rows, _ := r.RowsAffected()
+		reserved = rows == 1
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return reserved, nil
+}
+
+// Gets the stored response for the key. Returns false when the request
+// holding the key hasn't finished yet.
+func (d *DB) GetIdempotentResponse(userId int, key string) (m.IdempotentResponse, bool, error) {
+	var (
+		stored struct {
+			Body       sql.NullString `db:"response"`
+			StatusCode sql.NullInt64  `db:"status_code"`
+			Headers    sql.NullString `db:"headers"`
+		}
+		query = `SELECT response, status_code, headers FROM "idempotency_key" WHERE user_id = ? AND key = ?`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&stored, db.Rebind(query), userId, key)
+	})
+	if err != nil || !stored.Body.Valid {
+		return m.IdempotentResponse{}, false, err
+	}
+
+	// Responses stored before their status and headers were always 200
+	response := m.IdempotentResponse{StatusCode: http.StatusOK, Body: stored.Body.String}
+	if stored.StatusCode.Valid {
+		response.StatusCode = int(stored.StatusCode.Int64)
+	}
+	if stored.Headers.Valid {
+		if err = json.Unmarshal([]byte(stored.Headers.String), &response.Header); err != nil {
+			return m.IdempotentResponse{}, false, err
+		}
+	}
+
+	return response, true, nil
+}
+
+func (d *DB) SaveIdempotentResponse(userId int, key string, response m.IdempotentResponse) error {
+	headers, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE "idempotency_key" SET response = ?, status_code = ?, headers = ? WHERE user_id = ? AND key = ?`
+	return d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(db.Rebind(query), response.Body, response.StatusCode, string(headers), userId, key)
+		return err
+	})
+}
+
+func (d *DB) ReleaseIdempotencyKey(userId int, key string) error {
+	query := `DELETE FROM "idempotency_key" WHERE user_id = ? AND key = ?`
+	return d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(db.Rebind(query), userId, key)
+		return err
+	})
+}
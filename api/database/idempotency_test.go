@@ -0,0 +1,56 @@
+package database
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	m "govulnapi/models"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, d *DB) {
+		user := addTestUser(t, d, "alice@example.com")
+		const key = "5f0c6f8e-8a5e-4b8e-9d4c-2c7f9a1e3b6d"
+
+		if reserved, err := d.ReserveIdempotencyKey(user.Id, key); err != nil || !reserved {
+			t.Fatalf("reserving a new key gave %v (%v)", reserved, err)
+		}
+		if reserved, err := d.ReserveIdempotencyKey(user.Id, key); err != nil || reserved {
+			t.Fatalf("reserving the key again gave %v (%v)", reserved, err)
+		}
+		if _, done, err := d.GetIdempotentResponse(user.Id, key); err != nil || done {
+			t.Errorf("request in progress is done %v (%v)", done, err)
+		}
+
+		saved := m.IdempotentResponse{
+			StatusCode: http.StatusPreconditionFailed,
+			Header:     http.Header{"Content-Type": {"application/json"}, "X-Error-Code": {"precondition_failed"}},
+			Body:       `{"Code":"precondition_failed","Message":"Not enough usd!"}`,
+		}
+		if err := d.SaveIdempotentResponse(user.Id, key, saved); err != nil {
+			t.Fatal(err)
+		}
+		response, done, err := d.GetIdempotentResponse(user.Id, key)
+		if err != nil || !done || !reflect.DeepEqual(response, saved) {
+			t.Errorf("stored response is %+v, done %v (%v), want %+v", response, done, err, saved)
+		}
+
+		// Responses stored before the status and headers were 200s
+		if _, err = d.db.Exec(d.db.Rebind(`UPDATE "idempotency_key" SET status_code = NULL, headers = NULL WHERE key = ?`), key); err != nil {
+			t.Fatal(err)
+		}
+		response, done, err = d.GetIdempotentResponse(user.Id, key)
+		if want := (m.IdempotentResponse{StatusCode: http.StatusOK, Body: saved.Body}); err != nil || !done || !reflect.DeepEqual(response, want) {
+			t.Errorf("legacy response is %+v, done %v (%v), want %+v", response, done, err, want)
+		}
+
+		// Released keys can be used again
+		if err = d.ReleaseIdempotencyKey(user.Id, key); err != nil {
+			t.Fatal(err)
+		}
+		if reserved, err := d.ReserveIdempotencyKey(user.Id, key); err != nil || !reserved {
+			t.Errorf("reserving a released key gave %v (%v)", reserved, err)
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Numbered schema changes applied in order, e.g. 0002_add_audit_log.sql,
+// kept in a directory per driver. Every change needs a migration with the
+// same version for each driver. Applied migrations must never be edited,
+// add a new one instead.
+//
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+var migrationName = regexp.MustCompile(`^(\d+)_\w+\.sql$`)
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+func loadMigrations(driver string) ([]migration, error) {
+	var migrations []migration
+
+	entries, err := fs.ReadDir(migrationFiles, "migrations/"+driver)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("Invalid migration file name %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+
+		data, err := migrationFiles.ReadFile("migrations/" + driver + "/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, migration{version: version, name: entry.Name(), sql: string(data)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version == migrations[i-1].version {
+			return nil, fmt.Errorf("Duplicate migration version %d", migrations[i].version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Applies migrations that haven't been applied yet, each in its own
+// transaction so a failing one leaves the schema at the previous version
+func (d *DB) Migrate() error {
+	return d.migrateTo(math.MaxInt)
+}
+
+// Applies migrations up to the given version, leaving later ones for the
+// next call
+func (d *DB) migrateTo(version int) error {
+	query := `CREATE TABLE IF NOT EXISTS "schema_migrations" (
+		"version"	INTEGER NOT NULL,
+		"name"	TEXT NOT NULL,
+		"applied_at"	TEXT NOT NULL,
+		PRIMARY KEY("version")
+	)`
+	err := d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(query)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	migrations, err := loadMigrations(d.driver)
+	if err != nil {
+		return err
+	}
+
+	if err = d.adoptUpgrades(migrations); err != nil {
+		return err
+	}
+
+	applied := map[int]bool{}
+	var versions []int
+	err = d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&versions, `SELECT version FROM "schema_migrations"`)
+	})
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		applied[version] = true
+	}
+
+	for _, migration := range migrations {
+		if migration.version > version {
+			break
+		}
+		if applied[migration.version] {
+			continue
+		}
+		if err = d.applyMigration(migration); err != nil {
+			return fmt.Errorf("Migration %s failed: %w", migration.name, err)
+		}
+		log.Printf("Applied migration %s\n", migration.name)
+	}
+
+	return nil
+}
+
+// SQLite migrations run with foreign keys off, so they can rebuild tables
+// other tables reference, and are only committed when they left no foreign
+// key dangling
+func (d *DB) applyMigration(migration migration) error {
+	return d.withRetry(func(db *sqlx.DB) error {
+		ctx := context.Background()
+		conn, err := db.Connx(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		// Foreign keys can't be switched inside a transaction
+		if d.driver == DriverSQLite {
+			if _, err = conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+				return err
+			}
+			defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+		}
+
+		tx, err := conn.BeginTxx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		// Databases created before foreign keys were enforced may already
+		// have dangling ones
+		var dangling int
+		if d.driver == DriverSQLite {
+			if err = tx.Get(&dangling, `SELECT COUNT(*) FROM pragma_foreign_key_check`); err != nil {
+				return err
+			}
+		}
+
+		if _, err = tx.Exec(migration.sql); err != nil {
+			return err
+		}
+
+		if d.driver == DriverSQLite {
+			var after int
+			if err = tx.Get(&after, `SELECT COUNT(*) FROM pragma_foreign_key_check`); err != nil {
+				return err
+			}
+			if after > dangling {
+				return fmt.Errorf("%d rows reference missing rows", after-dangling)
+			}
+		}
+
+		query := `INSERT INTO "schema_migrations" (version, name, applied_at) VALUES (?, ?, ?)`
+		if _, err = tx.Exec(tx.Rebind(query), migration.version, migration.name, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			return err
+		}
+
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Migrations standing for the upgrades applied to SQLite databases before
+// versioned migrations, in the order user_version counted them
+var upgradeMigrations = []int{2, 4, 6, 9}
+
+// Records the migrations a database created before versioned migrations
+// already got through the upgrades counted in its user_version
+func (d *DB) adoptUpgrades(migrations []migration) error {
+	if d.driver != DriverSQLite {
+		return nil
+	}
+
+	return d.withRetry(func(db *sqlx.DB) error {
+		var upgrades int
+		if err := db.Get(&upgrades, "PRAGMA user_version"); err != nil {
+			return err
+		}
+		if upgrades == 0 {
+			return nil
+		}
+
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		now := time.Now().UTC().Format(time.RFC3339)
+		for i, version := range upgradeMigrations {
+			if i == upgrades {
+				break
+			}
+			for _, migration := range migrations {
+				if migration.version != version {
+					continue
+				}
+				query := `INSERT OR IGNORE INTO "schema_migrations" (version, name, applied_at) VALUES (?, ?, ?)`
+				if _, err = tx.Exec(query, migration.version, migration.name, now); err != nil {
+					return err
+				}
+			}
+		}
+		if _, err = tx.Exec("PRAGMA user_version = 0"); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
+}
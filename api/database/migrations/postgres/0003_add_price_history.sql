@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS "price_history" (
+	"id"	INTEGER GENERATED BY DEFAULT AS IDENTITY,
+	"coin_id"	TEXT NOT NULL,
+	"price"	DOUBLE PRECISION NOT NULL,
+	"date"	TEXT NOT NULL,
+	"manual"	BOOLEAN NOT NULL DEFAULT FALSE,
+	PRIMARY KEY("id"),
+	FOREIGN KEY("coin_id") REFERENCES "coin"("id")
+);
@@ -0,0 +1 @@
+ALTER TABLE "order" ADD COLUMN "status" TEXT NOT NULL DEFAULT 'filled';
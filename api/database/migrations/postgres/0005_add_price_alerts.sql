@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "price_alert" (
+	"id"	INTEGER GENERATED BY DEFAULT AS IDENTITY,
+	"user_id"	INTEGER NOT NULL,
+	"coin_id"	TEXT NOT NULL,
+	"threshold_usd"	DOUBLE PRECISION NOT NULL,
+	"direction"	TEXT NOT NULL CHECK("direction" IN ('above', 'below')),
+	"triggered_at"	TEXT,
+	PRIMARY KEY("id"),
+	FOREIGN KEY("user_id") REFERENCES "user"("id"),
+	FOREIGN KEY("coin_id") REFERENCES "coin"("id")
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "cash_transaction" (
+	"id"	INTEGER GENERATED BY DEFAULT AS IDENTITY,
+	"user_id"	INTEGER NOT NULL,
+	"counterparty_id"	INTEGER,
+	"type"	TEXT NOT NULL,
+	"amount"	DOUBLE PRECISION NOT NULL,
+	"virtual_date"	TEXT NOT NULL,
+	"date"	TEXT NOT NULL,
+	PRIMARY KEY("id"),
+	FOREIGN KEY("user_id") REFERENCES "user"("id"),
+	FOREIGN KEY("counterparty_id") REFERENCES "user"("id")
+);
+CREATE INDEX IF NOT EXISTS "cash_transaction_user_type" ON "cash_transaction" ("user_id", "type");
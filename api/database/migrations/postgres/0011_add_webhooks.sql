@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS "webhook" (
+	"id"	INTEGER GENERATED BY DEFAULT AS IDENTITY,
+	"user_id"	INTEGER NOT NULL,
+	"url"	TEXT NOT NULL,
+	"events"	TEXT NOT NULL,
+	"secret"	TEXT NOT NULL,
+	"failing"	BOOLEAN NOT NULL DEFAULT FALSE,
+	PRIMARY KEY("id"),
+	FOREIGN KEY("user_id") REFERENCES "user"("id")
+);
+CREATE TABLE IF NOT EXISTS "webhook_delivery" (
+	"id"	INTEGER GENERATED BY DEFAULT AS IDENTITY,
+	"webhook_id"	INTEGER NOT NULL,
+	"event"	TEXT NOT NULL,
+	"payload"	TEXT NOT NULL,
+	"status"	TEXT NOT NULL DEFAULT 'pending',
+	"attempts"	INTEGER NOT NULL DEFAULT 0,
+	"next_attempt_at"	TEXT NOT NULL,
+	"last_error"	TEXT,
+	PRIMARY KEY("id"),
+	FOREIGN KEY("webhook_id") REFERENCES "webhook"("id")
+);
+CREATE INDEX IF NOT EXISTS "webhook_delivery_status_next_attempt" ON "webhook_delivery" ("status", "next_attempt_at");
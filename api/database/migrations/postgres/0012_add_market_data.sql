@@ -0,0 +1,2 @@
+ALTER TABLE "price_history" ADD COLUMN "market_cap" DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE "price_history" ADD COLUMN "volume_24h" DOUBLE PRECISION NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "ctf_hint" (
+	"user_id"	INTEGER NOT NULL,
+	"challenge_id"	TEXT NOT NULL,
+	"number"	INTEGER NOT NULL,
+	"taken_at"	TEXT NOT NULL,
+	PRIMARY KEY("user_id","challenge_id","number"),
+	FOREIGN KEY("user_id") REFERENCES "user"("id")
+);
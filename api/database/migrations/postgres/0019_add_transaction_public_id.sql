@@ -0,0 +1,3 @@
+ALTER TABLE "transaction" ADD COLUMN "public_id" TEXT;
+UPDATE "transaction" SET "public_id" = gen_random_uuid()::text;
+CREATE UNIQUE INDEX IF NOT EXISTS "transaction_public_id" ON "transaction" ("public_id");
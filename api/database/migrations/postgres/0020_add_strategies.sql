@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "strategy" (
+	"id"	INTEGER GENERATED BY DEFAULT AS IDENTITY,
+	"user_id"	INTEGER NOT NULL,
+	"name"	TEXT NOT NULL,
+	"format"	TEXT NOT NULL,
+	"data"	BYTEA NOT NULL,
+	"created_at"	TEXT NOT NULL,
+	PRIMARY KEY("id"),
+	FOREIGN KEY("user_id") REFERENCES "user"("id")
+);
+CREATE INDEX IF NOT EXISTS "strategy_user" ON "strategy" ("user_id");
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "comment" (
+	"id"	INTEGER GENERATED BY DEFAULT AS IDENTITY,
+	"coin_id"	TEXT NOT NULL,
+	"user_id"	INTEGER NOT NULL,
+	"body"	TEXT NOT NULL,
+	"virtual_date"	TEXT NOT NULL,
+	"created_at"	TEXT NOT NULL,
+	"edited_at"	TEXT,
+	PRIMARY KEY("id"),
+	FOREIGN KEY("user_id") REFERENCES "user"("id")
+);
+CREATE INDEX IF NOT EXISTS "comment_coin" ON "comment" ("coin_id", "virtual_date", "id");
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS "watchlist" (
+	"user_id"	INTEGER NOT NULL,
+	"coin_id"	TEXT NOT NULL,
+	"added_at"	TEXT NOT NULL,
+	PRIMARY KEY("user_id", "coin_id"),
+	FOREIGN KEY("user_id") REFERENCES "user"("id")
+);
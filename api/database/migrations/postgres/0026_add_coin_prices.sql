@@ -0,0 +1,3 @@
+ALTER TABLE "coin" ADD COLUMN "price" DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE "coin" ADD COLUMN "market_cap" DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE "coin" ADD COLUMN "volume_24h" DOUBLE PRECISION NOT NULL DEFAULT 0;
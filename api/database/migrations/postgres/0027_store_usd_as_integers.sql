@@ -0,0 +1,7 @@
+-- Usd amounts are stored in millionths of a dollar
+ALTER TABLE "user"
+	ALTER COLUMN "usd_balance" TYPE BIGINT USING ROUND("usd_balance" * 1000000),
+	ALTER COLUMN "usd_balance" SET DEFAULT 10000000000,
+	ALTER COLUMN "usd_starting_balance" TYPE BIGINT USING ROUND("usd_starting_balance" * 1000000),
+	ALTER COLUMN "usd_starting_balance" SET DEFAULT 10000000000;
+ALTER TABLE "cash_transaction" ALTER COLUMN "amount" TYPE BIGINT USING ROUND("amount" * 1000000);
@@ -0,0 +1,5 @@
+-- Prices are stored in millionths of a dollar like usd amounts
+ALTER TABLE "coin" ALTER COLUMN "price" TYPE BIGINT USING ROUND("price" * 1000000);
+ALTER TABLE "price_history" ALTER COLUMN "price" TYPE BIGINT USING ROUND("price" * 1000000);
+ALTER TABLE "order" ALTER COLUMN "price" TYPE BIGINT USING ROUND("price" * 1000000);
+ALTER TABLE "price_alert" ALTER COLUMN "threshold_usd" TYPE BIGINT USING ROUND("threshold_usd" * 1000000);
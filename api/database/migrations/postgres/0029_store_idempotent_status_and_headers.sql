@@ -0,0 +1,2 @@
+ALTER TABLE "idempotency_key" ADD COLUMN "status_code" INTEGER;
+ALTER TABLE "idempotency_key" ADD COLUMN "headers" TEXT;
@@ -1,8 +1,5 @@
-package database
-
-// CWE-340: Generation of Predictable Numbers or Identifiers
-// Autoincrement is used for user id
-const sqlSchema = `
+-- CWE-340: Generation of Predictable Numbers or Identifiers
+-- Autoincrement is used for user id
 CREATE TABLE IF NOT EXISTS "coin" (
 	"id"	TEXT NOT NULL,
 	PRIMARY KEY("id")
@@ -51,9 +48,8 @@ CREATE TABLE IF NOT EXISTS "transaction" (
 	FOREIGN KEY("address") REFERENCES "coin_balance"("address"),
 	FOREIGN KEY("coin_id") REFERENCES "coin"("id")
 );
-INSERT INTO "coin" ("id") VALUES ('bitcoin'),
+INSERT OR IGNORE INTO "coin" ("id") VALUES ('bitcoin'),
  ('litecoin'),
  ('namecoin'),
  ('ripple'),
  ('dogecoin');
-`
@@ -0,0 +1,4 @@
+ALTER TABLE "user" ADD COLUMN "role" TEXT NOT NULL DEFAULT 'user';
+-- CWE-798: Use of Hard-coded Credentials
+-- Default admin account (admin@govulnapi.com:admin123)
+INSERT OR IGNORE INTO "user" ("email", "password", "role") VALUES ('admin@govulnapi.com', '0192023a7bbd73250516f069df18b500', 'admin');
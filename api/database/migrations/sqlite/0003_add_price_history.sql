@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS "price_history" (
+	"id"	INTEGER,
+	"coin_id"	TEXT NOT NULL,
+	"price"	REAL NOT NULL,
+	"date"	TEXT NOT NULL,
+	"manual"	INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY("id" AUTOINCREMENT),
+	FOREIGN KEY("coin_id") REFERENCES "coin"("id")
+);
@@ -0,0 +1,6 @@
+ALTER TABLE "order" ADD COLUMN "virtual_date" TEXT NOT NULL DEFAULT '';
+-- Orders placed before the virtual clock get the real day they were placed
+UPDATE "order" SET "virtual_date" = substr("date", 1, 10);
+-- Sides were stored as the text true or false
+UPDATE "order" SET "is_buy" = "is_buy" IN ('true', 1);
+CREATE INDEX IF NOT EXISTS "order_user_virtual_date" ON "order" ("user_id", "virtual_date", "id");
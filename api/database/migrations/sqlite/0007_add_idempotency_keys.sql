@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "idempotency_key" (
+	"user_id"	INTEGER NOT NULL,
+	"key"	TEXT NOT NULL,
+	"response"	TEXT,
+	"created_at"	INTEGER NOT NULL,
+	PRIMARY KEY("user_id","key"),
+	FOREIGN KEY("user_id") REFERENCES "user"("id")
+);
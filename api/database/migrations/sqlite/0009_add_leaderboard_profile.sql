@@ -0,0 +1,2 @@
+ALTER TABLE "user" ADD COLUMN "display_name" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "user" ADD COLUMN "hide_from_leaderboard" INTEGER NOT NULL DEFAULT 0;
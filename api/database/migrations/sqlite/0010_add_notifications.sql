@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "notification" (
+	"id"	INTEGER,
+	"user_id"	INTEGER NOT NULL,
+	"type"	TEXT NOT NULL,
+	"payload"	TEXT NOT NULL,
+	"virtual_date"	TEXT NOT NULL,
+	"read_at"	TEXT,
+	PRIMARY KEY("id" AUTOINCREMENT),
+	FOREIGN KEY("user_id") REFERENCES "user"("id")
+);
+CREATE INDEX IF NOT EXISTS "notification_user_virtual_date" ON "notification" ("user_id", "virtual_date", "id");
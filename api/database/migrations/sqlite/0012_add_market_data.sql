@@ -0,0 +1,2 @@
+ALTER TABLE "price_history" ADD COLUMN "market_cap" REAL NOT NULL DEFAULT 0;
+ALTER TABLE "price_history" ADD COLUMN "volume_24h" REAL NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+ALTER TABLE "coin" ADD COLUMN "name" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "coin" ADD COLUMN "symbol" TEXT NOT NULL DEFAULT '';
+UPDATE "coin" SET "name" = 'Bitcoin', "symbol" = 'BTC' WHERE "id" = 'bitcoin';
+UPDATE "coin" SET "name" = 'Litecoin', "symbol" = 'LTC' WHERE "id" = 'litecoin';
+UPDATE "coin" SET "name" = 'Namecoin', "symbol" = 'NMC' WHERE "id" = 'namecoin';
+UPDATE "coin" SET "name" = 'XRP', "symbol" = 'XRP' WHERE "id" = 'ripple';
+UPDATE "coin" SET "name" = 'Dogecoin', "symbol" = 'DOGE' WHERE "id" = 'dogecoin';
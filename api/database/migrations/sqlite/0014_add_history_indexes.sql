@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS "transaction_sender_date" ON "transaction" ("sender_id", "date");
+CREATE INDEX IF NOT EXISTS "transaction_receiver_date" ON "transaction" ("receiver_id", "date");
+CREATE INDEX IF NOT EXISTS "price_history_coin_date" ON "price_history" ("coin_id", "date", "id");
+CREATE INDEX IF NOT EXISTS "order_status_coin" ON "order" ("status", "coin_id");
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS "vulnerability_setting" (
+	"id"	TEXT NOT NULL,
+	"enabled"	INTEGER NOT NULL,
+	PRIMARY KEY("id")
+);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS "ctf_flag" (
+	"id"	TEXT NOT NULL,
+	"hash"	TEXT NOT NULL,
+	PRIMARY KEY("id")
+);
+CREATE TABLE IF NOT EXISTS "ctf_solve" (
+	"user_id"	INTEGER NOT NULL,
+	"flag_id"	TEXT NOT NULL,
+	"solved_at"	TEXT NOT NULL,
+	PRIMARY KEY("user_id","flag_id"),
+	FOREIGN KEY("user_id") REFERENCES "user"("id"),
+	FOREIGN KEY("flag_id") REFERENCES "ctf_flag"("id")
+);
+CREATE TABLE IF NOT EXISTS "secret" (
+	"id"	INTEGER,
+	"name"	TEXT NOT NULL,
+	"value"	TEXT NOT NULL,
+	PRIMARY KEY("id" AUTOINCREMENT)
+);
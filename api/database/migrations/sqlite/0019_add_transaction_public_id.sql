@@ -0,0 +1,6 @@
+ALTER TABLE "transaction" ADD COLUMN "public_id" TEXT;
+UPDATE "transaction" SET "public_id" = lower(
+	hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+	substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "transaction_public_id" ON "transaction" ("public_id");
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "strategy" (
+	"id"	INTEGER,
+	"user_id"	INTEGER NOT NULL,
+	"name"	TEXT NOT NULL,
+	"format"	TEXT NOT NULL,
+	"data"	BLOB NOT NULL,
+	"created_at"	TEXT NOT NULL,
+	PRIMARY KEY("id" AUTOINCREMENT),
+	FOREIGN KEY("user_id") REFERENCES "user"("id")
+);
+CREATE INDEX IF NOT EXISTS "strategy_user" ON "strategy" ("user_id");
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "avatar" (
+	"user_id"	INTEGER NOT NULL,
+	"file_name"	TEXT NOT NULL,
+	"content_type"	TEXT NOT NULL,
+	"updated_at"	TEXT NOT NULL,
+	PRIMARY KEY("user_id"),
+	FOREIGN KEY("user_id") REFERENCES "user"("id")
+);
@@ -0,0 +1 @@
+ALTER TABLE "coin" ADD COLUMN "version" INTEGER NOT NULL DEFAULT 1;
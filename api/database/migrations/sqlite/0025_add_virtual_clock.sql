@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "virtual_clock" (
+	"id"	INTEGER NOT NULL,
+	"virtual_date"	TEXT NOT NULL,
+	"start_date"	TEXT NOT NULL,
+	"day_duration_ms"	INTEGER NOT NULL,
+	"updated_at"	TEXT NOT NULL,
+	PRIMARY KEY("id")
+);
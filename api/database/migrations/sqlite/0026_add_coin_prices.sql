@@ -0,0 +1,3 @@
+ALTER TABLE "coin" ADD COLUMN "price" REAL NOT NULL DEFAULT 0;
+ALTER TABLE "coin" ADD COLUMN "market_cap" REAL NOT NULL DEFAULT 0;
+ALTER TABLE "coin" ADD COLUMN "volume_24h" REAL NOT NULL DEFAULT 0;
@@ -0,0 +1,45 @@
+-- Usd amounts are stored in millionths of a dollar. SQLite can't change the
+-- type of a column, so the tables are rebuilt with foreign keys off, see
+-- https://www.sqlite.org/lang_altertable.html#otheralter
+CREATE TABLE "user_new" (
+	"id"	INTEGER NOT NULL,
+	"email"	TEXT NOT NULL UNIQUE,
+	"password"	TEXT NOT NULL,
+	"usd_balance"	INTEGER NOT NULL DEFAULT 10000000000,
+	"usd_starting_balance"	INTEGER NOT NULL DEFAULT 10000000000,
+	"role"	TEXT NOT NULL DEFAULT 'user',
+	"display_name"	TEXT NOT NULL DEFAULT '',
+	"hide_from_leaderboard"	INTEGER NOT NULL DEFAULT 0,
+	"deleted_at"	TEXT,
+	PRIMARY KEY("id" AUTOINCREMENT)
+);
+INSERT INTO "user_new" ("id", "email", "password", "usd_balance", "usd_starting_balance", "role", "display_name", "hide_from_leaderboard", "deleted_at")
+	SELECT "id", "email", "password", CAST(ROUND("usd_balance" * 1000000) AS INTEGER), CAST(ROUND("usd_starting_balance" * 1000000) AS INTEGER),
+		"role", "display_name", "hide_from_leaderboard", "deleted_at"
+	FROM "user";
+-- Ids of deleted users aren't handed out again
+DELETE FROM "sqlite_sequence" WHERE "name" = 'user_new';
+INSERT INTO "sqlite_sequence" ("name", "seq") SELECT 'user_new', "seq" FROM "sqlite_sequence" WHERE "name" = 'user';
+DROP TABLE "user";
+ALTER TABLE "user_new" RENAME TO "user";
+
+CREATE TABLE "cash_transaction_new" (
+	"id"	INTEGER,
+	"user_id"	INTEGER NOT NULL,
+	"counterparty_id"	INTEGER,
+	"type"	TEXT NOT NULL,
+	"amount"	INTEGER NOT NULL,
+	"virtual_date"	TEXT NOT NULL,
+	"date"	TEXT NOT NULL,
+	PRIMARY KEY("id" AUTOINCREMENT),
+	FOREIGN KEY("user_id") REFERENCES "user"("id"),
+	FOREIGN KEY("counterparty_id") REFERENCES "user"("id")
+);
+INSERT INTO "cash_transaction_new" ("id", "user_id", "counterparty_id", "type", "amount", "virtual_date", "date")
+	SELECT "id", "user_id", "counterparty_id", "type", CAST(ROUND("amount" * 1000000) AS INTEGER), "virtual_date", "date"
+	FROM "cash_transaction";
+DELETE FROM "sqlite_sequence" WHERE "name" = 'cash_transaction_new';
+INSERT INTO "sqlite_sequence" ("name", "seq") SELECT 'cash_transaction_new', "seq" FROM "sqlite_sequence" WHERE "name" = 'cash_transaction';
+DROP TABLE "cash_transaction";
+ALTER TABLE "cash_transaction_new" RENAME TO "cash_transaction";
+CREATE INDEX IF NOT EXISTS "cash_transaction_user_type" ON "cash_transaction" ("user_id", "type");
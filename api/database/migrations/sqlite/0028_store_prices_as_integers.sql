@@ -0,0 +1,80 @@
+-- Prices are stored in millionths of a dollar like usd amounts, the tables
+-- are rebuilt like in 0027_store_usd_as_integers
+CREATE TABLE "coin_new" (
+	"id"	TEXT NOT NULL,
+	"name"	TEXT NOT NULL DEFAULT '',
+	"symbol"	TEXT NOT NULL DEFAULT '',
+	"version"	INTEGER NOT NULL DEFAULT 1,
+	"price"	INTEGER NOT NULL DEFAULT 0,
+	"market_cap"	REAL NOT NULL DEFAULT 0,
+	"volume_24h"	REAL NOT NULL DEFAULT 0,
+	PRIMARY KEY("id")
+);
+INSERT INTO "coin_new" ("id", "name", "symbol", "version", "price", "market_cap", "volume_24h")
+	SELECT "id", "name", "symbol", "version", CAST(ROUND("price" * 1000000) AS INTEGER), "market_cap", "volume_24h"
+	FROM "coin";
+DROP TABLE "coin";
+ALTER TABLE "coin_new" RENAME TO "coin";
+
+CREATE TABLE "price_history_new" (
+	"id"	INTEGER,
+	"coin_id"	TEXT NOT NULL,
+	"price"	INTEGER NOT NULL,
+	"date"	TEXT NOT NULL,
+	"manual"	INTEGER NOT NULL DEFAULT 0,
+	"market_cap"	REAL NOT NULL DEFAULT 0,
+	"volume_24h"	REAL NOT NULL DEFAULT 0,
+	PRIMARY KEY("id" AUTOINCREMENT),
+	FOREIGN KEY("coin_id") REFERENCES "coin"("id")
+);
+INSERT INTO "price_history_new" ("id", "coin_id", "price", "date", "manual", "market_cap", "volume_24h")
+	SELECT "id", "coin_id", CAST(ROUND("price" * 1000000) AS INTEGER), "date", "manual", "market_cap", "volume_24h"
+	FROM "price_history";
+DELETE FROM "sqlite_sequence" WHERE "name" = 'price_history_new';
+INSERT INTO "sqlite_sequence" ("name", "seq") SELECT 'price_history_new', "seq" FROM "sqlite_sequence" WHERE "name" = 'price_history';
+DROP TABLE "price_history";
+ALTER TABLE "price_history_new" RENAME TO "price_history";
+CREATE INDEX IF NOT EXISTS "price_history_coin_date" ON "price_history" ("coin_id", "date", "id");
+
+CREATE TABLE "order_new" (
+	"id"	INTEGER,
+	"user_id"	INTEGER NOT NULL,
+	"coin_id"	TEXT NOT NULL,
+	"price"	INTEGER NOT NULL,
+	"is_buy"	INTEGER NOT NULL,
+	"qty"	REAL NOT NULL,
+	"date"	TEXT NOT NULL,
+	"status"	TEXT NOT NULL DEFAULT 'filled',
+	"virtual_date"	TEXT NOT NULL DEFAULT '',
+	FOREIGN KEY("user_id") REFERENCES "user"("id"),
+	PRIMARY KEY("id" AUTOINCREMENT),
+	FOREIGN KEY("coin_id") REFERENCES "coin"("id")
+);
+INSERT INTO "order_new" ("id", "user_id", "coin_id", "price", "is_buy", "qty", "date", "status", "virtual_date")
+	SELECT "id", "user_id", "coin_id", CAST(ROUND("price" * 1000000) AS INTEGER), "is_buy", "qty", "date", "status", "virtual_date"
+	FROM "order";
+DELETE FROM "sqlite_sequence" WHERE "name" = 'order_new';
+INSERT INTO "sqlite_sequence" ("name", "seq") SELECT 'order_new', "seq" FROM "sqlite_sequence" WHERE "name" = 'order';
+DROP TABLE "order";
+ALTER TABLE "order_new" RENAME TO "order";
+CREATE INDEX IF NOT EXISTS "order_user_virtual_date" ON "order" ("user_id", "virtual_date", "id");
+CREATE INDEX IF NOT EXISTS "order_status_coin" ON "order" ("status", "coin_id");
+
+CREATE TABLE "price_alert_new" (
+	"id"	INTEGER,
+	"user_id"	INTEGER NOT NULL,
+	"coin_id"	TEXT NOT NULL,
+	"threshold_usd"	INTEGER NOT NULL,
+	"direction"	TEXT NOT NULL CHECK("direction" IN ('above', 'below')),
+	"triggered_at"	TEXT,
+	PRIMARY KEY("id" AUTOINCREMENT),
+	FOREIGN KEY("user_id") REFERENCES "user"("id"),
+	FOREIGN KEY("coin_id") REFERENCES "coin"("id")
+);
+INSERT INTO "price_alert_new" ("id", "user_id", "coin_id", "threshold_usd", "direction", "triggered_at")
+	SELECT "id", "user_id", "coin_id", CAST(ROUND("threshold_usd" * 1000000) AS INTEGER), "direction", "triggered_at"
+	FROM "price_alert";
+DELETE FROM "sqlite_sequence" WHERE "name" = 'price_alert_new';
+INSERT INTO "sqlite_sequence" ("name", "seq") SELECT 'price_alert_new', "seq" FROM "sqlite_sequence" WHERE "name" = 'price_alert';
+DROP TABLE "price_alert";
+ALTER TABLE "price_alert_new" RENAME TO "price_alert";
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	m "govulnapi/models"
+)
+
+// Opens the SQLite database file without migrating it
+func openUnmigrated(t *testing.T, fileName string) *DB {
+	t.Helper()
+
+	dsn := sqliteDSN(fileName)
+	db, err := connect(DriverSQLite, dsn, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &DB{db: db, driver: DriverSQLite, dsn: dsn, maxRetries: defaultMaxRetries}
+	t.Cleanup(d.Close)
+
+	return d
+}
+
+// Opens a database created by the schema of the API before migrations, see
+// testdata/baseline.sql
+func openBaseline(t *testing.T) *DB {
+	t.Helper()
+
+	snapshot, err := os.ReadFile("testdata/baseline.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := openUnmigrated(t, filepath.Join(t.TempDir(), "baseline.db"))
+	if _, err = d.db.Exec(string(snapshot)); err != nil {
+		t.Fatal(err)
+	}
+
+	return d
+}
+
+type schemaObject struct {
+	Type string
+	Name string
+	Sql  *string
+}
+
+func schema(t *testing.T, d *DB) []schemaObject {
+	t.Helper()
+
+	var objects []schemaObject
+	err := d.db.Select(&objects, `SELECT type, name, sql FROM sqlite_schema WHERE name != 'schema_migrations' ORDER BY name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return objects
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	baseline := openBaseline(t)
+	if err := baseline.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := openUnmigrated(t, filepath.Join(t.TempDir(), "fresh.db"))
+	if err := fresh.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, created := schema(t, baseline), schema(t, fresh)
+	if !reflect.DeepEqual(migrated, created) {
+		t.Errorf("migrated schema differs from a fresh one:\n%v\nwant\n%v", migrated, created)
+	}
+
+	// Migrating again leaves the database alone
+	if err := baseline.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var user struct {
+		UsdBalance m.Usd
+		Role       string
+	}
+	err := baseline.db.Get(&user, `SELECT usd_balance AS usdbalance, role FROM "user" WHERE email = 'alice@example.com'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UsdBalance != m.UsdFromFloat(9905.67397) || user.Role != "user" {
+		t.Errorf("user = %+v, want a balance of 9905.67397 and the user role", user)
+	}
+
+	var types []string
+	err = baseline.db.Select(&types, `SELECT DISTINCT typeof(usd_balance) FROM "user"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(types, []string{"integer"}) {
+		t.Errorf("balances are stored as %v, want integers", types)
+	}
+
+	var admins int
+	if err = baseline.db.Get(&admins, `SELECT COUNT(*) FROM "user" WHERE role = 'admin'`); err != nil {
+		t.Fatal(err)
+	}
+	if admins != 1 {
+		t.Errorf("%d admins, want the default one", admins)
+	}
+}
+
+func TestOrderStatusMigration(t *testing.T) {
+	d := openBaseline(t)
+	if err := d.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var order struct {
+		Price       m.Usd
+		Status      string
+		VirtualDate string `db:"virtual_date"`
+	}
+	if err := d.db.Get(&order, `SELECT price, status, virtual_date FROM "order"`); err != nil {
+		t.Fatal(err)
+	}
+	want := m.UsdFromFloat(31442.01)
+	if order.Price != want || order.Status != "filled" || order.VirtualDate != "2023-06-01" {
+		t.Errorf("order = %+v, want filled on 2023-06-01 at %d", order, want)
+	}
+}
+
+func TestOrderSideMigration(t *testing.T) {
+	d := openBaseline(t)
+	if err := d.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var side struct {
+		IsBuy bool   `db:"is_buy"`
+		Type  string `db:"type"`
+	}
+	if err := d.db.Get(&side, `SELECT is_buy, typeof(is_buy) AS type FROM "order"`); err != nil {
+		t.Fatal(err)
+	}
+	if !side.IsBuy || side.Type != "integer" {
+		t.Errorf("side = %+v, want a buy stored as an integer", side)
+	}
+}
+
+// Databases upgraded by the schema before versioned migrations got the
+// migrations of upgradeMigrations counted in their user_version
+func TestAdoptUpgrades(t *testing.T) {
+	migrations, err := loadMigrations(DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := openBaseline(t)
+	for _, migration := range migrations {
+		if migration.version == upgradeMigrations[0] || migration.version == upgradeMigrations[1] {
+			if _, err = d.db.Exec(migration.sql); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if _, err = d.db.Exec("PRAGMA user_version = 2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := openUnmigrated(t, filepath.Join(t.TempDir(), "fresh.db"))
+	if err = fresh.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if upgraded, created := schema(t, d), schema(t, fresh); !reflect.DeepEqual(upgraded, created) {
+		t.Errorf("upgraded schema differs from a fresh one:\n%v\nwant\n%v", upgraded, created)
+	}
+
+	var version int
+	if err = d.db.Get(&version, "PRAGMA user_version"); err != nil {
+		t.Fatal(err)
+	}
+	if version != 0 {
+		t.Errorf("user_version = %d, want 0 once the upgrades are recorded", version)
+	}
+
+	var applied int
+	if err = d.db.Get(&applied, `SELECT COUNT(*) FROM "schema_migrations"`); err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(migrations) {
+		t.Errorf("%d migrations recorded, want %d", applied, len(migrations))
+	}
+}
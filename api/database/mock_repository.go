@@ -0,0 +1,1402 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=repository.go -destination=mock_repository.go -package=database -self_package=govulnapi/api/database
+//
+
+// Package database is a generated GoMock package.
+package database
+
+import (
+	sql "database/sql"
+	models "govulnapi/models"
+	io "io"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddCoins mocks base method.
+func (m *MockRepository) AddCoins(coins []models.NewCoin) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCoins", coins)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddCoins indicates an expected call of AddCoins.
+func (mr *MockRepositoryMockRecorder) AddCoins(coins any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCoins", reflect.TypeOf((*MockRepository)(nil).AddCoins), coins)
+}
+
+// AddComment mocks base method.
+func (m *MockRepository) AddComment(userId int, coinId, body string, virtualDate, createdAt time.Time) (models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddComment", userId, coinId, body, virtualDate, createdAt)
+	ret0, _ := ret[0].(models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddComment indicates an expected call of AddComment.
+func (mr *MockRepositoryMockRecorder) AddComment(userId, coinId, body, virtualDate, createdAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddComment", reflect.TypeOf((*MockRepository)(nil).AddComment), userId, coinId, body, virtualDate, createdAt)
+}
+
+// AddLimitOrder mocks base method.
+func (m *MockRepository) AddLimitOrder(userId int, coinId string, limitPrice models.Usd, isBuy bool, qty float64, virtualDate time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLimitOrder", userId, coinId, limitPrice, isBuy, qty, virtualDate)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLimitOrder indicates an expected call of AddLimitOrder.
+func (mr *MockRepositoryMockRecorder) AddLimitOrder(userId, coinId, limitPrice, isBuy, qty, virtualDate any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLimitOrder", reflect.TypeOf((*MockRepository)(nil).AddLimitOrder), userId, coinId, limitPrice, isBuy, qty, virtualDate)
+}
+
+// AddNotification mocks base method.
+func (m *MockRepository) AddNotification(userId int, notificationType, payload string, virtualDate time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddNotification", userId, notificationType, payload, virtualDate)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddNotification indicates an expected call of AddNotification.
+func (mr *MockRepositoryMockRecorder) AddNotification(userId, notificationType, payload, virtualDate any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddNotification", reflect.TypeOf((*MockRepository)(nil).AddNotification), userId, notificationType, payload, virtualDate)
+}
+
+// AddOrder mocks base method.
+func (m *MockRepository) AddOrder(userId int, coinId string, price models.Usd, isBuy bool, qty float64, virtualDate time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOrder", userId, coinId, price, isBuy, qty, virtualDate)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOrder indicates an expected call of AddOrder.
+func (mr *MockRepositoryMockRecorder) AddOrder(userId, coinId, price, isBuy, qty, virtualDate any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrder", reflect.TypeOf((*MockRepository)(nil).AddOrder), userId, coinId, price, isBuy, qty, virtualDate)
+}
+
+// AddPriceAlert mocks base method.
+func (m *MockRepository) AddPriceAlert(userId int, coinId string, thresholdUsd models.Usd, direction string) (models.PriceAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPriceAlert", userId, coinId, thresholdUsd, direction)
+	ret0, _ := ret[0].(models.PriceAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddPriceAlert indicates an expected call of AddPriceAlert.
+func (mr *MockRepositoryMockRecorder) AddPriceAlert(userId, coinId, thresholdUsd, direction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPriceAlert", reflect.TypeOf((*MockRepository)(nil).AddPriceAlert), userId, coinId, thresholdUsd, direction)
+}
+
+// AddPriceHistory mocks base method.
+func (m *MockRepository) AddPriceHistory(coins []models.Coin, date time.Time, manual bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPriceHistory", coins, date, manual)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPriceHistory indicates an expected call of AddPriceHistory.
+func (mr *MockRepositoryMockRecorder) AddPriceHistory(coins, date, manual any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPriceHistory", reflect.TypeOf((*MockRepository)(nil).AddPriceHistory), coins, date, manual)
+}
+
+// AddSolve mocks base method.
+func (m *MockRepository) AddSolve(userId int, flagId string, solvedAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSolve", userId, flagId, solvedAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddSolve indicates an expected call of AddSolve.
+func (mr *MockRepositoryMockRecorder) AddSolve(userId, flagId, solvedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSolve", reflect.TypeOf((*MockRepository)(nil).AddSolve), userId, flagId, solvedAt)
+}
+
+// AddStrategy mocks base method.
+func (m *MockRepository) AddStrategy(userId int, name, format string, data []byte, createdAt time.Time) (models.SavedStrategy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddStrategy", userId, name, format, data, createdAt)
+	ret0, _ := ret[0].(models.SavedStrategy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddStrategy indicates an expected call of AddStrategy.
+func (mr *MockRepositoryMockRecorder) AddStrategy(userId, name, format, data, createdAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddStrategy", reflect.TypeOf((*MockRepository)(nil).AddStrategy), userId, name, format, data, createdAt)
+}
+
+// AddToWatchlist mocks base method.
+func (m *MockRepository) AddToWatchlist(userId int, coinId string, addedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddToWatchlist", userId, coinId, addedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddToWatchlist indicates an expected call of AddToWatchlist.
+func (mr *MockRepositoryMockRecorder) AddToWatchlist(userId, coinId, addedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToWatchlist", reflect.TypeOf((*MockRepository)(nil).AddToWatchlist), userId, coinId, addedAt)
+}
+
+// AddTransaction mocks base method.
+func (m *MockRepository) AddTransaction(senderId int, coinId, address string, qty float64, note string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTransaction", senderId, coinId, address, qty, note)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddTransaction indicates an expected call of AddTransaction.
+func (mr *MockRepositoryMockRecorder) AddTransaction(senderId, coinId, address, qty, note any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTransaction", reflect.TypeOf((*MockRepository)(nil).AddTransaction), senderId, coinId, address, qty, note)
+}
+
+// AddUser mocks base method.
+func (m *MockRepository) AddUser(email, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUser", email, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUser indicates an expected call of AddUser.
+func (mr *MockRepositoryMockRecorder) AddUser(email, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockRepository)(nil).AddUser), email, password)
+}
+
+// AddWebhook mocks base method.
+func (m *MockRepository) AddWebhook(userId int, url string, events []string, secret string) (models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWebhook", userId, url, events, secret)
+	ret0, _ := ret[0].(models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddWebhook indicates an expected call of AddWebhook.
+func (mr *MockRepositoryMockRecorder) AddWebhook(userId, url, events, secret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWebhook", reflect.TypeOf((*MockRepository)(nil).AddWebhook), userId, url, events, secret)
+}
+
+// AdjustCash mocks base method.
+func (m *MockRepository) AdjustCash(userId int, amount models.Usd, transactionType string, virtualDate time.Time, dailyLimit models.Usd) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustCash", userId, amount, transactionType, virtualDate, dailyLimit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AdjustCash indicates an expected call of AdjustCash.
+func (mr *MockRepositoryMockRecorder) AdjustCash(userId, amount, transactionType, virtualDate, dailyLimit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustCash", reflect.TypeOf((*MockRepository)(nil).AdjustCash), userId, amount, transactionType, virtualDate, dailyLimit)
+}
+
+// Backup mocks base method.
+func (m *MockRepository) Backup(w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Backup", w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Backup indicates an expected call of Backup.
+func (mr *MockRepositoryMockRecorder) Backup(w any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backup", reflect.TypeOf((*MockRepository)(nil).Backup), w)
+}
+
+// BroadcastNotification mocks base method.
+func (m *MockRepository) BroadcastNotification(notificationType, payload string, virtualDate time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BroadcastNotification", notificationType, payload, virtualDate)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BroadcastNotification indicates an expected call of BroadcastNotification.
+func (mr *MockRepositoryMockRecorder) BroadcastNotification(notificationType, payload, virtualDate any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BroadcastNotification", reflect.TypeOf((*MockRepository)(nil).BroadcastNotification), notificationType, payload, virtualDate)
+}
+
+// CancelOrder mocks base method.
+func (m *MockRepository) CancelOrder(userId, orderId int) (models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelOrder", userId, orderId)
+	ret0, _ := ret[0].(models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelOrder indicates an expected call of CancelOrder.
+func (mr *MockRepositoryMockRecorder) CancelOrder(userId, orderId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrder", reflect.TypeOf((*MockRepository)(nil).CancelOrder), userId, orderId)
+}
+
+// ClearVulnerabilitySettings mocks base method.
+func (m *MockRepository) ClearVulnerabilitySettings() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearVulnerabilitySettings")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearVulnerabilitySettings indicates an expected call of ClearVulnerabilitySettings.
+func (mr *MockRepositoryMockRecorder) ClearVulnerabilitySettings() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearVulnerabilitySettings", reflect.TypeOf((*MockRepository)(nil).ClearVulnerabilitySettings))
+}
+
+// Close mocks base method.
+func (m *MockRepository) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockRepositoryMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockRepository)(nil).Close))
+}
+
+// CompleteWebhookDelivery mocks base method.
+func (m *MockRepository) CompleteWebhookDelivery(delivery models.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteWebhookDelivery", delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteWebhookDelivery indicates an expected call of CompleteWebhookDelivery.
+func (mr *MockRepositoryMockRecorder) CompleteWebhookDelivery(delivery any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteWebhookDelivery", reflect.TypeOf((*MockRepository)(nil).CompleteWebhookDelivery), delivery)
+}
+
+// CountUnreadNotifications mocks base method.
+func (m *MockRepository) CountUnreadNotifications(userId int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnreadNotifications", userId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnreadNotifications indicates an expected call of CountUnreadNotifications.
+func (mr *MockRepositoryMockRecorder) CountUnreadNotifications(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnreadNotifications", reflect.TypeOf((*MockRepository)(nil).CountUnreadNotifications), userId)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockRepository) DeleteAccount(userId int, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", userId, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockRepositoryMockRecorder) DeleteAccount(userId, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockRepository)(nil).DeleteAccount), userId, password)
+}
+
+// DeleteComment mocks base method.
+func (m *MockRepository) DeleteComment(commentId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteComment", commentId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteComment indicates an expected call of DeleteComment.
+func (mr *MockRepositoryMockRecorder) DeleteComment(commentId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComment", reflect.TypeOf((*MockRepository)(nil).DeleteComment), commentId)
+}
+
+// DeleteReadNotifications mocks base method.
+func (m *MockRepository) DeleteReadNotifications(before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteReadNotifications", before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteReadNotifications indicates an expected call of DeleteReadNotifications.
+func (mr *MockRepositoryMockRecorder) DeleteReadNotifications(before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteReadNotifications", reflect.TypeOf((*MockRepository)(nil).DeleteReadNotifications), before)
+}
+
+// DeleteUser mocks base method.
+func (m *MockRepository) DeleteUser(userId int, virtualDate time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", userId, virtualDate)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockRepositoryMockRecorder) DeleteUser(userId, virtualDate any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockRepository)(nil).DeleteUser), userId, virtualDate)
+}
+
+// EnqueueWebhookDeliveries mocks base method.
+func (m *MockRepository) EnqueueWebhookDeliveries(userId int, event, payload string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueWebhookDeliveries", userId, event, payload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnqueueWebhookDeliveries indicates an expected call of EnqueueWebhookDeliveries.
+func (mr *MockRepositoryMockRecorder) EnqueueWebhookDeliveries(userId, event, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueWebhookDeliveries", reflect.TypeOf((*MockRepository)(nil).EnqueueWebhookDeliveries), userId, event, payload)
+}
+
+// ExportOrders mocks base method.
+func (m *MockRepository) ExportOrders(userId int, filter OrderFilter, fn func(models.OrderExportRow) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportOrders", userId, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportOrders indicates an expected call of ExportOrders.
+func (mr *MockRepositoryMockRecorder) ExportOrders(userId, filter, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportOrders", reflect.TypeOf((*MockRepository)(nil).ExportOrders), userId, filter, fn)
+}
+
+// FailWebhookDelivery mocks base method.
+func (m *MockRepository) FailWebhookDelivery(delivery models.WebhookDelivery, reason string, nextAttempt *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailWebhookDelivery", delivery, reason, nextAttempt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailWebhookDelivery indicates an expected call of FailWebhookDelivery.
+func (mr *MockRepositoryMockRecorder) FailWebhookDelivery(delivery, reason, nextAttempt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailWebhookDelivery", reflect.TypeOf((*MockRepository)(nil).FailWebhookDelivery), delivery, reason, nextAttempt)
+}
+
+// FillLimitOrders mocks base method.
+func (m *MockRepository) FillLimitOrders(coins []models.Coin, date time.Time) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FillLimitOrders", coins, date)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FillLimitOrders indicates an expected call of FillLimitOrders.
+func (mr *MockRepositoryMockRecorder) FillLimitOrders(coins, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FillLimitOrders", reflect.TypeOf((*MockRepository)(nil).FillLimitOrders), coins, date)
+}
+
+// GetActivePriceAlerts mocks base method.
+func (m *MockRepository) GetActivePriceAlerts(userId int) ([]models.PriceAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActivePriceAlerts", userId)
+	ret0, _ := ret[0].([]models.PriceAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActivePriceAlerts indicates an expected call of GetActivePriceAlerts.
+func (mr *MockRepositoryMockRecorder) GetActivePriceAlerts(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActivePriceAlerts", reflect.TypeOf((*MockRepository)(nil).GetActivePriceAlerts), userId)
+}
+
+// GetAvatar mocks base method.
+func (m *MockRepository) GetAvatar(userId int) (models.Avatar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAvatar", userId)
+	ret0, _ := ret[0].(models.Avatar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAvatar indicates an expected call of GetAvatar.
+func (mr *MockRepositoryMockRecorder) GetAvatar(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAvatar", reflect.TypeOf((*MockRepository)(nil).GetAvatar), userId)
+}
+
+// GetBalanceDiscrepancies mocks base method.
+func (m *MockRepository) GetBalanceDiscrepancies() (models.ConsistencyReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceDiscrepancies")
+	ret0, _ := ret[0].(models.ConsistencyReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceDiscrepancies indicates an expected call of GetBalanceDiscrepancies.
+func (mr *MockRepositoryMockRecorder) GetBalanceDiscrepancies() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceDiscrepancies", reflect.TypeOf((*MockRepository)(nil).GetBalanceDiscrepancies))
+}
+
+// GetCashTransactions mocks base method.
+func (m *MockRepository) GetCashTransactions(userId int, transactionType string) ([]models.CashTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCashTransactions", userId, transactionType)
+	ret0, _ := ret[0].([]models.CashTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCashTransactions indicates an expected call of GetCashTransactions.
+func (mr *MockRepositoryMockRecorder) GetCashTransactions(userId, transactionType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCashTransactions", reflect.TypeOf((*MockRepository)(nil).GetCashTransactions), userId, transactionType)
+}
+
+// GetCoinByID mocks base method.
+func (m *MockRepository) GetCoinByID(coinId string) (models.Coin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCoinByID", coinId)
+	ret0, _ := ret[0].(models.Coin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCoinByID indicates an expected call of GetCoinByID.
+func (mr *MockRepositoryMockRecorder) GetCoinByID(coinId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCoinByID", reflect.TypeOf((*MockRepository)(nil).GetCoinByID), coinId)
+}
+
+// GetCoinListing mocks base method.
+func (m *MockRepository) GetCoinListing(coinId string) (models.CoinListing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCoinListing", coinId)
+	ret0, _ := ret[0].(models.CoinListing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCoinListing indicates an expected call of GetCoinListing.
+func (mr *MockRepositoryMockRecorder) GetCoinListing(coinId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCoinListing", reflect.TypeOf((*MockRepository)(nil).GetCoinListing), coinId)
+}
+
+// GetCoinNames mocks base method.
+func (m *MockRepository) GetCoinNames() ([]models.NewCoin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCoinNames")
+	ret0, _ := ret[0].([]models.NewCoin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCoinNames indicates an expected call of GetCoinNames.
+func (mr *MockRepositoryMockRecorder) GetCoinNames() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCoinNames", reflect.TypeOf((*MockRepository)(nil).GetCoinNames))
+}
+
+// GetCoins mocks base method.
+func (m *MockRepository) GetCoins() ([]models.Coin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCoins")
+	ret0, _ := ret[0].([]models.Coin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCoins indicates an expected call of GetCoins.
+func (mr *MockRepositoryMockRecorder) GetCoins() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCoins", reflect.TypeOf((*MockRepository)(nil).GetCoins))
+}
+
+// GetComment mocks base method.
+func (m *MockRepository) GetComment(commentId int) (models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetComment", commentId)
+	ret0, _ := ret[0].(models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetComment indicates an expected call of GetComment.
+func (mr *MockRepositoryMockRecorder) GetComment(commentId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComment", reflect.TypeOf((*MockRepository)(nil).GetComment), commentId)
+}
+
+// GetComments mocks base method.
+func (m *MockRepository) GetComments(coinId string, filter CommentFilter) ([]models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetComments", coinId, filter)
+	ret0, _ := ret[0].([]models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetComments indicates an expected call of GetComments.
+func (mr *MockRepositoryMockRecorder) GetComments(coinId, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComments", reflect.TypeOf((*MockRepository)(nil).GetComments), coinId, filter)
+}
+
+// GetDailyPrices mocks base method.
+func (m *MockRepository) GetDailyPrices(from, until string, coinIds ...string) ([]models.PriceHistory, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{from, until}
+	for _, a := range coinIds {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetDailyPrices", varargs...)
+	ret0, _ := ret[0].([]models.PriceHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDailyPrices indicates an expected call of GetDailyPrices.
+func (mr *MockRepositoryMockRecorder) GetDailyPrices(from, until any, coinIds ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{from, until}, coinIds...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDailyPrices", reflect.TypeOf((*MockRepository)(nil).GetDailyPrices), varargs...)
+}
+
+// GetDueWebhookDeliveries mocks base method.
+func (m *MockRepository) GetDueWebhookDeliveries(limit int) ([]models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueWebhookDeliveries", limit)
+	ret0, _ := ret[0].([]models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueWebhookDeliveries indicates an expected call of GetDueWebhookDeliveries.
+func (mr *MockRepositoryMockRecorder) GetDueWebhookDeliveries(limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueWebhookDeliveries", reflect.TypeOf((*MockRepository)(nil).GetDueWebhookDeliveries), limit)
+}
+
+// GetFilledOrders mocks base method.
+func (m *MockRepository) GetFilledOrders(userId int, until string) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFilledOrders", userId, until)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFilledOrders indicates an expected call of GetFilledOrders.
+func (mr *MockRepositoryMockRecorder) GetFilledOrders(userId, until any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFilledOrders", reflect.TypeOf((*MockRepository)(nil).GetFilledOrders), userId, until)
+}
+
+// GetFlagHashes mocks base method.
+func (m *MockRepository) GetFlagHashes() (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFlagHashes")
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFlagHashes indicates an expected call of GetFlagHashes.
+func (mr *MockRepositoryMockRecorder) GetFlagHashes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFlagHashes", reflect.TypeOf((*MockRepository)(nil).GetFlagHashes))
+}
+
+// GetHintsTaken mocks base method.
+func (m *MockRepository) GetHintsTaken() ([]models.HintTaken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHintsTaken")
+	ret0, _ := ret[0].([]models.HintTaken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHintsTaken indicates an expected call of GetHintsTaken.
+func (mr *MockRepositoryMockRecorder) GetHintsTaken() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHintsTaken", reflect.TypeOf((*MockRepository)(nil).GetHintsTaken))
+}
+
+// GetIdempotentResponse mocks base method.
+func (m *MockRepository) GetIdempotentResponse(userId int, key string) (models.IdempotentResponse, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotentResponse", userId, key)
+	ret0, _ := ret[0].(models.IdempotentResponse)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetIdempotentResponse indicates an expected call of GetIdempotentResponse.
+func (mr *MockRepositoryMockRecorder) GetIdempotentResponse(userId, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotentResponse", reflect.TypeOf((*MockRepository)(nil).GetIdempotentResponse), userId, key)
+}
+
+// GetNotifications mocks base method.
+func (m *MockRepository) GetNotifications(userId int, filter NotificationFilter) ([]models.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotifications", userId, filter)
+	ret0, _ := ret[0].([]models.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotifications indicates an expected call of GetNotifications.
+func (mr *MockRepositoryMockRecorder) GetNotifications(userId, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotifications", reflect.TypeOf((*MockRepository)(nil).GetNotifications), userId, filter)
+}
+
+// GetOrderBook mocks base method.
+func (m *MockRepository) GetOrderBook(coinId string, includeUsers bool) ([]models.OrderBookLevel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderBook", coinId, includeUsers)
+	ret0, _ := ret[0].([]models.OrderBookLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderBook indicates an expected call of GetOrderBook.
+func (mr *MockRepositoryMockRecorder) GetOrderBook(coinId, includeUsers any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderBook", reflect.TypeOf((*MockRepository)(nil).GetOrderBook), coinId, includeUsers)
+}
+
+// GetOrders mocks base method.
+func (m *MockRepository) GetOrders(userId int, filter OrderFilter) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrders", userId, filter)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrders indicates an expected call of GetOrders.
+func (mr *MockRepositoryMockRecorder) GetOrders(userId, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockRepository)(nil).GetOrders), userId, filter)
+}
+
+// GetPortfolio mocks base method.
+func (m *MockRepository) GetPortfolio(userId int) (models.Portfolio, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPortfolio", userId)
+	ret0, _ := ret[0].(models.Portfolio)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPortfolio indicates an expected call of GetPortfolio.
+func (mr *MockRepositoryMockRecorder) GetPortfolio(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPortfolio", reflect.TypeOf((*MockRepository)(nil).GetPortfolio), userId)
+}
+
+// GetPortfolios mocks base method.
+func (m *MockRepository) GetPortfolios() ([]models.Portfolio, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPortfolios")
+	ret0, _ := ret[0].([]models.Portfolio)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPortfolios indicates an expected call of GetPortfolios.
+func (mr *MockRepositoryMockRecorder) GetPortfolios() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPortfolios", reflect.TypeOf((*MockRepository)(nil).GetPortfolios))
+}
+
+// GetPricesOn mocks base method.
+func (m *MockRepository) GetPricesOn(coinId string, dates ...string) ([]*models.Usd, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{coinId}
+	for _, a := range dates {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetPricesOn", varargs...)
+	ret0, _ := ret[0].([]*models.Usd)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPricesOn indicates an expected call of GetPricesOn.
+func (mr *MockRepositoryMockRecorder) GetPricesOn(coinId any, dates ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{coinId}, dates...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPricesOn", reflect.TypeOf((*MockRepository)(nil).GetPricesOn), varargs...)
+}
+
+// GetSolves mocks base method.
+func (m *MockRepository) GetSolves() ([]models.CTFSolve, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSolves")
+	ret0, _ := ret[0].([]models.CTFSolve)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSolves indicates an expected call of GetSolves.
+func (mr *MockRepositoryMockRecorder) GetSolves() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSolves", reflect.TypeOf((*MockRepository)(nil).GetSolves))
+}
+
+// GetStrategies mocks base method.
+func (m *MockRepository) GetStrategies(userId int) ([]models.SavedStrategy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStrategies", userId)
+	ret0, _ := ret[0].([]models.SavedStrategy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStrategies indicates an expected call of GetStrategies.
+func (mr *MockRepositoryMockRecorder) GetStrategies(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStrategies", reflect.TypeOf((*MockRepository)(nil).GetStrategies), userId)
+}
+
+// GetStrategy mocks base method.
+func (m *MockRepository) GetStrategy(userId, strategyId int) (models.SavedStrategy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStrategy", userId, strategyId)
+	ret0, _ := ret[0].(models.SavedStrategy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStrategy indicates an expected call of GetStrategy.
+func (mr *MockRepositoryMockRecorder) GetStrategy(userId, strategyId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStrategy", reflect.TypeOf((*MockRepository)(nil).GetStrategy), userId, strategyId)
+}
+
+// GetTrades mocks base method.
+func (m *MockRepository) GetTrades(userId int, filter OrderFilter) ([]models.Trade, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrades", userId, filter)
+	ret0, _ := ret[0].([]models.Trade)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTrades indicates an expected call of GetTrades.
+func (mr *MockRepositoryMockRecorder) GetTrades(userId, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrades", reflect.TypeOf((*MockRepository)(nil).GetTrades), userId, filter)
+}
+
+// GetTransaction mocks base method.
+func (m *MockRepository) GetTransaction(transactionId int) (models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransaction", transactionId)
+	ret0, _ := ret[0].(models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransaction indicates an expected call of GetTransaction.
+func (mr *MockRepositoryMockRecorder) GetTransaction(transactionId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransaction", reflect.TypeOf((*MockRepository)(nil).GetTransaction), transactionId)
+}
+
+// GetTransactionByPublicId mocks base method.
+func (m *MockRepository) GetTransactionByPublicId(publicId string) (models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionByPublicId", publicId)
+	ret0, _ := ret[0].(models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionByPublicId indicates an expected call of GetTransactionByPublicId.
+func (mr *MockRepositoryMockRecorder) GetTransactionByPublicId(publicId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionByPublicId", reflect.TypeOf((*MockRepository)(nil).GetTransactionByPublicId), publicId)
+}
+
+// GetUserByCredentials mocks base method.
+func (m *MockRepository) GetUserByCredentials(email, password string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByCredentials", email, password)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByCredentials indicates an expected call of GetUserByCredentials.
+func (mr *MockRepositoryMockRecorder) GetUserByCredentials(email, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByCredentials", reflect.TypeOf((*MockRepository)(nil).GetUserByCredentials), email, password)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockRepository) GetUserByEmail(email string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", email)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockRepositoryMockRecorder) GetUserByEmail(email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockRepository)(nil).GetUserByEmail), email)
+}
+
+// GetUserById mocks base method.
+func (m *MockRepository) GetUserById(userId int) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserById", userId)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserById indicates an expected call of GetUserById.
+func (mr *MockRepositoryMockRecorder) GetUserById(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockRepository)(nil).GetUserById), userId)
+}
+
+// GetVirtualClock mocks base method.
+func (m *MockRepository) GetVirtualClock() (models.VirtualClock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVirtualClock")
+	ret0, _ := ret[0].(models.VirtualClock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVirtualClock indicates an expected call of GetVirtualClock.
+func (mr *MockRepositoryMockRecorder) GetVirtualClock() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualClock", reflect.TypeOf((*MockRepository)(nil).GetVirtualClock))
+}
+
+// GetVulnerabilitySettings mocks base method.
+func (m *MockRepository) GetVulnerabilitySettings() (map[string]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVulnerabilitySettings")
+	ret0, _ := ret[0].(map[string]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVulnerabilitySettings indicates an expected call of GetVulnerabilitySettings.
+func (mr *MockRepositoryMockRecorder) GetVulnerabilitySettings() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVulnerabilitySettings", reflect.TypeOf((*MockRepository)(nil).GetVulnerabilitySettings))
+}
+
+// GetWatchlist mocks base method.
+func (m *MockRepository) GetWatchlist(userId int) ([]models.WatchlistEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWatchlist", userId)
+	ret0, _ := ret[0].([]models.WatchlistEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWatchlist indicates an expected call of GetWatchlist.
+func (mr *MockRepositoryMockRecorder) GetWatchlist(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWatchlist", reflect.TypeOf((*MockRepository)(nil).GetWatchlist), userId)
+}
+
+// GetWebhooks mocks base method.
+func (m *MockRepository) GetWebhooks(userId int) ([]models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhooks", userId)
+	ret0, _ := ret[0].([]models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhooks indicates an expected call of GetWebhooks.
+func (mr *MockRepositoryMockRecorder) GetWebhooks(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhooks", reflect.TypeOf((*MockRepository)(nil).GetWebhooks), userId)
+}
+
+// MarkNotificationRead mocks base method.
+func (m *MockRepository) MarkNotificationRead(userId, notificationId int, virtualDate time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationRead", userId, notificationId, virtualDate)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkNotificationRead indicates an expected call of MarkNotificationRead.
+func (mr *MockRepositoryMockRecorder) MarkNotificationRead(userId, notificationId, virtualDate any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationRead", reflect.TypeOf((*MockRepository)(nil).MarkNotificationRead), userId, notificationId, virtualDate)
+}
+
+// PlaceFlags mocks base method.
+func (m *MockRepository) PlaceFlags(flags map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlaceFlags", flags)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PlaceFlags indicates an expected call of PlaceFlags.
+func (mr *MockRepositoryMockRecorder) PlaceFlags(flags any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlaceFlags", reflect.TypeOf((*MockRepository)(nil).PlaceFlags), flags)
+}
+
+// PurgeDeletedUsers mocks base method.
+func (m *MockRepository) PurgeDeletedUsers(before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedUsers", before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedUsers indicates an expected call of PurgeDeletedUsers.
+func (mr *MockRepositoryMockRecorder) PurgeDeletedUsers(before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedUsers", reflect.TypeOf((*MockRepository)(nil).PurgeDeletedUsers), before)
+}
+
+// ReleaseIdempotencyKey mocks base method.
+func (m *MockRepository) ReleaseIdempotencyKey(userId int, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseIdempotencyKey", userId, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseIdempotencyKey indicates an expected call of ReleaseIdempotencyKey.
+func (mr *MockRepositoryMockRecorder) ReleaseIdempotencyKey(userId, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdempotencyKey", reflect.TypeOf((*MockRepository)(nil).ReleaseIdempotencyKey), userId, key)
+}
+
+// RemoveFromWatchlist mocks base method.
+func (m *MockRepository) RemoveFromWatchlist(userId int, coinId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveFromWatchlist", userId, coinId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveFromWatchlist indicates an expected call of RemoveFromWatchlist.
+func (mr *MockRepositoryMockRecorder) RemoveFromWatchlist(userId, coinId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFromWatchlist", reflect.TypeOf((*MockRepository)(nil).RemoveFromWatchlist), userId, coinId)
+}
+
+// ReserveIdempotencyKey mocks base method.
+func (m *MockRepository) ReserveIdempotencyKey(userId int, key string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveIdempotencyKey", userId, key)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveIdempotencyKey indicates an expected call of ReserveIdempotencyKey.
+func (mr *MockRepositoryMockRecorder) ReserveIdempotencyKey(userId, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveIdempotencyKey", reflect.TypeOf((*MockRepository)(nil).ReserveIdempotencyKey), userId, key)
+}
+
+// Reset mocks base method.
+func (m *MockRepository) Reset(config SeedConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", config)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockRepositoryMockRecorder) Reset(config any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockRepository)(nil).Reset), config)
+}
+
+// ResetScope mocks base method.
+func (m *MockRepository) ResetScope(scope string, config SeedConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetScope", scope, config)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetScope indicates an expected call of ResetScope.
+func (mr *MockRepositoryMockRecorder) ResetScope(scope, config any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetScope", reflect.TypeOf((*MockRepository)(nil).ResetScope), scope, config)
+}
+
+// Restore mocks base method.
+func (m *MockRepository) Restore(r io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockRepositoryMockRecorder) Restore(r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockRepository)(nil).Restore), r)
+}
+
+// RestoreUser mocks base method.
+func (m *MockRepository) RestoreUser(userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockRepositoryMockRecorder) RestoreUser(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockRepository)(nil).RestoreUser), userId)
+}
+
+// SaveIdempotentResponse mocks base method.
+func (m *MockRepository) SaveIdempotentResponse(userId int, key string, response models.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveIdempotentResponse", userId, key, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveIdempotentResponse indicates an expected call of SaveIdempotentResponse.
+func (mr *MockRepositoryMockRecorder) SaveIdempotentResponse(userId, key, response any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIdempotentResponse", reflect.TypeOf((*MockRepository)(nil).SaveIdempotentResponse), userId, key, response)
+}
+
+// SaveVirtualClock mocks base method.
+func (m *MockRepository) SaveVirtualClock(clock models.VirtualClock) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveVirtualClock", clock)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveVirtualClock indicates an expected call of SaveVirtualClock.
+func (mr *MockRepositoryMockRecorder) SaveVirtualClock(clock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVirtualClock", reflect.TypeOf((*MockRepository)(nil).SaveVirtualClock), clock)
+}
+
+// SetAvatar mocks base method.
+func (m *MockRepository) SetAvatar(avatar models.Avatar) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAvatar", avatar)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAvatar indicates an expected call of SetAvatar.
+func (mr *MockRepositoryMockRecorder) SetAvatar(avatar any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAvatar", reflect.TypeOf((*MockRepository)(nil).SetAvatar), avatar)
+}
+
+// SetOrderRace mocks base method.
+func (m *MockRepository) SetOrderRace(enabled bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetOrderRace", enabled)
+}
+
+// SetOrderRace indicates an expected call of SetOrderRace.
+func (mr *MockRepositoryMockRecorder) SetOrderRace(enabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOrderRace", reflect.TypeOf((*MockRepository)(nil).SetOrderRace), enabled)
+}
+
+// SetSQLInjection mocks base method.
+func (m *MockRepository) SetSQLInjection(enabled bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetSQLInjection", enabled)
+}
+
+// SetSQLInjection indicates an expected call of SetSQLInjection.
+func (mr *MockRepositoryMockRecorder) SetSQLInjection(enabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSQLInjection", reflect.TypeOf((*MockRepository)(nil).SetSQLInjection), enabled)
+}
+
+// SetVulnerabilitySetting mocks base method.
+func (m *MockRepository) SetVulnerabilitySetting(id string, enabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVulnerabilitySetting", id, enabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetVulnerabilitySetting indicates an expected call of SetVulnerabilitySetting.
+func (mr *MockRepositoryMockRecorder) SetVulnerabilitySetting(id, enabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVulnerabilitySetting", reflect.TypeOf((*MockRepository)(nil).SetVulnerabilitySetting), id, enabled)
+}
+
+// Stats mocks base method.
+func (m *MockRepository) Stats() sql.DBStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(sql.DBStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockRepositoryMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockRepository)(nil).Stats))
+}
+
+// TakeHint mocks base method.
+func (m *MockRepository) TakeHint(userId int, challengeId string, n int, takenAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeHint", userId, challengeId, n, takenAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TakeHint indicates an expected call of TakeHint.
+func (mr *MockRepositoryMockRecorder) TakeHint(userId, challengeId, n, takenAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeHint", reflect.TypeOf((*MockRepository)(nil).TakeHint), userId, challengeId, n, takenAt)
+}
+
+// TransferCash mocks base method.
+func (m *MockRepository) TransferCash(senderId int, receiverEmail string, amount models.Usd, virtualDate time.Time, allowNegative bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferCash", senderId, receiverEmail, amount, virtualDate, allowNegative)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TransferCash indicates an expected call of TransferCash.
+func (mr *MockRepositoryMockRecorder) TransferCash(senderId, receiverEmail, amount, virtualDate, allowNegative any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferCash", reflect.TypeOf((*MockRepository)(nil).TransferCash), senderId, receiverEmail, amount, virtualDate, allowNegative)
+}
+
+// TriggerPriceAlerts mocks base method.
+func (m *MockRepository) TriggerPriceAlerts(coins []models.Coin, date time.Time) ([]models.PriceAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TriggerPriceAlerts", coins, date)
+	ret0, _ := ret[0].([]models.PriceAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TriggerPriceAlerts indicates an expected call of TriggerPriceAlerts.
+func (mr *MockRepositoryMockRecorder) TriggerPriceAlerts(coins, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TriggerPriceAlerts", reflect.TypeOf((*MockRepository)(nil).TriggerPriceAlerts), coins, date)
+}
+
+// UpdateCoinListing mocks base method.
+func (m *MockRepository) UpdateCoinListing(coinId, name, symbol string, version int) (models.CoinListing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCoinListing", coinId, name, symbol, version)
+	ret0, _ := ret[0].(models.CoinListing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateCoinListing indicates an expected call of UpdateCoinListing.
+func (mr *MockRepositoryMockRecorder) UpdateCoinListing(coinId, name, symbol, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCoinListing", reflect.TypeOf((*MockRepository)(nil).UpdateCoinListing), coinId, name, symbol, version)
+}
+
+// UpdateComment mocks base method.
+func (m *MockRepository) UpdateComment(commentId int, body string, editedAt time.Time) (models.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateComment", commentId, body, editedAt)
+	ret0, _ := ret[0].(models.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateComment indicates an expected call of UpdateComment.
+func (mr *MockRepositoryMockRecorder) UpdateComment(commentId, body, editedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateComment", reflect.TypeOf((*MockRepository)(nil).UpdateComment), commentId, body, editedAt)
+}
+
+// UpdateCredentials mocks base method.
+func (m *MockRepository) UpdateCredentials(userId int, currentPassword, newEmail, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCredentials", userId, currentPassword, newEmail, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCredentials indicates an expected call of UpdateCredentials.
+func (mr *MockRepositoryMockRecorder) UpdateCredentials(userId, currentPassword, newEmail, newPassword any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCredentials", reflect.TypeOf((*MockRepository)(nil).UpdateCredentials), userId, currentPassword, newEmail, newPassword)
+}
+
+// UpdateDisplayName mocks base method.
+func (m *MockRepository) UpdateDisplayName(userId int, displayName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDisplayName", userId, displayName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDisplayName indicates an expected call of UpdateDisplayName.
+func (mr *MockRepositoryMockRecorder) UpdateDisplayName(userId, displayName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDisplayName", reflect.TypeOf((*MockRepository)(nil).UpdateDisplayName), userId, displayName)
+}
+
+// UpdateEmail mocks base method.
+func (m *MockRepository) UpdateEmail(userId int, newEmail string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmail", userId, newEmail)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEmail indicates an expected call of UpdateEmail.
+func (mr *MockRepositoryMockRecorder) UpdateEmail(userId, newEmail any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmail", reflect.TypeOf((*MockRepository)(nil).UpdateEmail), userId, newEmail)
+}
+
+// UpdateLeaderboardVisibility mocks base method.
+func (m *MockRepository) UpdateLeaderboardVisibility(userId int, hidden bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLeaderboardVisibility", userId, hidden)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLeaderboardVisibility indicates an expected call of UpdateLeaderboardVisibility.
+func (mr *MockRepositoryMockRecorder) UpdateLeaderboardVisibility(userId, hidden any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLeaderboardVisibility", reflect.TypeOf((*MockRepository)(nil).UpdateLeaderboardVisibility), userId, hidden)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockRepository) UpdatePassword(userId int, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", userId, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockRepositoryMockRecorder) UpdatePassword(userId, newPassword any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockRepository)(nil).UpdatePassword), userId, newPassword)
+}
+
+// UpdateUser mocks base method.
+func (m *MockRepository) UpdateUser(userId int, user models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", userId, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockRepositoryMockRecorder) UpdateUser(userId, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockRepository)(nil).UpdateUser), userId, user)
+}
+
+// Vacuum mocks base method.
+func (m *MockRepository) Vacuum() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Vacuum")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Vacuum indicates an expected call of Vacuum.
+func (mr *MockRepositoryMockRecorder) Vacuum() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vacuum", reflect.TypeOf((*MockRepository)(nil).Vacuum))
+}
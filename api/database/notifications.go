@@ -0,0 +1,143 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	m "govulnapi/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type NotificationFilter struct {
+	UnreadOnly bool
+	// Keyset of the last notification on the previous page
+	AfterVirtualDate string
+	AfterId          int
+	Limit            int
+}
+
+func (d *DB) AddNotification(userId int, notificationType string, payload string, virtualDate time.Time) error {
+	query := `INSERT INTO "notification" (user_id, type, payload, virtual_date) VALUES (?, ?, ?, ?)`
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(db.Rebind(query), userId, notificationType, payload, virtualDate.Format(time.DateOnly))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Adds the same notification for every user
+func (d *DB) BroadcastNotification(notificationType string, payload string, virtualDate time.Time) error {
+	query := `INSERT INTO "notification" (user_id, type, payload, virtual_date) SELECT id, CAST(? AS TEXT), CAST(? AS TEXT), CAST(? AS TEXT) FROM "user" WHERE deleted_at IS NULL`
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(db.Rebind(query), notificationType, payload, virtualDate.Format(time.DateOnly))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Gets user notifications newest first, ordered by virtual date and id
+func (d *DB) GetNotifications(userId int, filter NotificationFilter) ([]m.Notification, error) {
+	var (
+		notifications = []m.Notification{}
+		query         = `SELECT * FROM "notification" WHERE user_id = ?`
+		args          = []interface{}{userId}
+	)
+
+	if filter.UnreadOnly {
+		query += " AND read_at IS NULL"
+	}
+
+	if filter.AfterId != 0 {
+		query += " AND (virtual_date < ? OR (virtual_date = ? AND id < ?))"
+		args = append(args, filter.AfterVirtualDate, filter.AfterVirtualDate, filter.AfterId)
+	}
+
+	query += " ORDER BY virtual_date DESC, id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	// Payload is stored as text, which can't be scanned into json.RawMessage
+	var rows []struct {
+		m.Notification
+		Payload string `db:"payload"`
+	}
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&rows, db.Rebind(query), args...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		row.Notification.Payload = json.RawMessage(row.Payload)
+		notifications = append(notifications, row.Notification)
+	}
+
+	return notifications, nil
+}
+
+func (d *DB) CountUnreadNotifications(userId int) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM "notification" WHERE user_id = ? AND read_at IS NULL`
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&count, db.Rebind(query), userId)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (d *DB) MarkNotificationRead(userId int, notificationId int, virtualDate time.Time) error {
+	query := `UPDATE "notification" SET read_at = COALESCE(read_at, ?) WHERE id = ? AND user_id = ?`
+
+	var rows int64
+	err := d.withRetry(func(db *sqlx.DB) error {
+		r, err := db.Exec(db.Rebind(query), virtualDate.Format(time.DateOnly), notificationId, userId)
+		if err != nil {
+			return err
+		}
+		rows, _ = r.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("Notification not found!")
+	}
+
+	return nil
+}
+
+// Removes notifications read before the given virtual date
+func (d *DB) DeleteReadNotifications(before time.Time) (int64, error) {
+	query := `DELETE FROM "notification" WHERE read_at IS NOT NULL AND read_at < ?`
+
+	var rows int64
+	err := d.withRetry(func(db *sqlx.DB) error {
+		r, err := db.Exec(db.Rebind(query), before.Format(time.DateOnly))
+		if err != nil {
+			return err
+		}
+		rows, err = r.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,124 @@
+package database
+
+//go:generate go run go.uber.org/mock/mockgen -source=repository.go -destination=mock_repository.go -package=database -self_package=govulnapi/api/database
+
+import (
+	"database/sql"
+	m "govulnapi/models"
+	"io"
+	"time"
+)
+
+// Storage used by the api, implemented by DB on top of SQLite
+type Repository interface {
+	Close()
+	Stats() sql.DBStats
+	Backup(w io.Writer) error
+	Restore(r io.Reader) error
+	Vacuum() error
+	GetBalanceDiscrepancies() (m.ConsistencyReport, error)
+	Reset(config SeedConfig) error
+	ResetScope(scope string, config SeedConfig) error
+	SetSQLInjection(enabled bool)
+	SetOrderRace(enabled bool)
+
+	GetCoins() ([]m.Coin, error)
+	GetCoinByID(coinId string) (m.Coin, error)
+	GetCoinNames() ([]m.NewCoin, error)
+	GetCoinListing(coinId string) (m.CoinListing, error)
+	UpdateCoinListing(coinId string, name string, symbol string, version int) (m.CoinListing, error)
+	AddCoins(coins []m.NewCoin) (int, error)
+	AddPriceHistory(coins []m.Coin, date time.Time, manual bool) error
+	GetDailyPrices(from string, until string, coinIds ...string) ([]m.PriceHistory, error)
+	GetPricesOn(coinId string, dates ...string) ([]*m.Usd, error)
+	GetVirtualClock() (m.VirtualClock, error)
+	SaveVirtualClock(clock m.VirtualClock) error
+
+	GetUserByCredentials(email string, password string) (m.User, error)
+	GetUserByEmail(email string) (m.User, error)
+	GetUserById(userId int) (m.User, error)
+	AddUser(email string, password string) error
+	UpdateEmail(userId int, newEmail string) error
+	UpdatePassword(userId int, newPassword string) error
+	UpdateCredentials(userId int, currentPassword string, newEmail string, newPassword string) error
+	UpdateDisplayName(userId int, displayName string) error
+	UpdateUser(userId int, user m.User) error
+	GetPortfolios() ([]m.Portfolio, error)
+	GetPortfolio(userId int) (m.Portfolio, error)
+	UpdateLeaderboardVisibility(userId int, hidden bool) error
+	DeleteUser(userId int, virtualDate time.Time) error
+	RestoreUser(userId int) error
+	PurgeDeletedUsers(before time.Time) (int64, error)
+	DeleteAccount(userId int, password string) error
+
+	AddOrder(userId int, coinId string, price m.Usd, isBuy bool, qty float64, virtualDate time.Time) error
+	AddLimitOrder(userId int, coinId string, limitPrice m.Usd, isBuy bool, qty float64, virtualDate time.Time) (int, error)
+	CancelOrder(userId int, orderId int) (m.Order, error)
+	FillLimitOrders(coins []m.Coin, date time.Time) ([]m.Order, error)
+	GetOrderBook(coinId string, includeUsers bool) ([]m.OrderBookLevel, error)
+	GetOrders(userId int, filter OrderFilter) ([]m.Order, error)
+	ExportOrders(userId int, filter OrderFilter, fn func(m.OrderExportRow) error) error
+	GetTrades(userId int, filter OrderFilter) ([]m.Trade, int, error)
+	GetFilledOrders(userId int, until string) ([]m.Order, error)
+
+	AddTransaction(senderId int, coinId string, address string, qty float64, note string) error
+	GetTransaction(transactionId int) (m.Transaction, error)
+	GetTransactionByPublicId(publicId string) (m.Transaction, error)
+
+	TransferCash(senderId int, receiverEmail string, amount m.Usd, virtualDate time.Time, allowNegative bool) error
+	GetCashTransactions(userId int, transactionType string) ([]m.CashTransaction, error)
+	AdjustCash(userId int, amount m.Usd, transactionType string, virtualDate time.Time, dailyLimit m.Usd) error
+
+	AddPriceAlert(userId int, coinId string, thresholdUsd m.Usd, direction string) (m.PriceAlert, error)
+	GetActivePriceAlerts(userId int) ([]m.PriceAlert, error)
+	TriggerPriceAlerts(coins []m.Coin, date time.Time) ([]m.PriceAlert, error)
+
+	AddToWatchlist(userId int, coinId string, addedAt time.Time) error
+	RemoveFromWatchlist(userId int, coinId string) error
+	GetWatchlist(userId int) ([]m.WatchlistEntry, error)
+
+	AddNotification(userId int, notificationType string, payload string, virtualDate time.Time) error
+	BroadcastNotification(notificationType string, payload string, virtualDate time.Time) error
+	GetNotifications(userId int, filter NotificationFilter) ([]m.Notification, error)
+	CountUnreadNotifications(userId int) (int, error)
+	MarkNotificationRead(userId int, notificationId int, virtualDate time.Time) error
+	DeleteReadNotifications(before time.Time) (int64, error)
+
+	AddWebhook(userId int, url string, events []string, secret string) (m.Webhook, error)
+	GetWebhooks(userId int) ([]m.Webhook, error)
+	EnqueueWebhookDeliveries(userId int, event string, payload string) error
+	GetDueWebhookDeliveries(limit int) ([]m.WebhookDelivery, error)
+	CompleteWebhookDelivery(delivery m.WebhookDelivery) error
+	FailWebhookDelivery(delivery m.WebhookDelivery, reason string, nextAttempt *time.Time) error
+
+	AddStrategy(userId int, name string, format string, data []byte, createdAt time.Time) (m.SavedStrategy, error)
+	GetStrategies(userId int) ([]m.SavedStrategy, error)
+	GetStrategy(userId int, strategyId int) (m.SavedStrategy, error)
+
+	AddComment(userId int, coinId string, body string, virtualDate time.Time, createdAt time.Time) (m.Comment, error)
+	GetComments(coinId string, filter CommentFilter) ([]m.Comment, error)
+	GetComment(commentId int) (m.Comment, error)
+	UpdateComment(commentId int, body string, editedAt time.Time) (m.Comment, error)
+	DeleteComment(commentId int) error
+
+	SetAvatar(avatar m.Avatar) error
+	GetAvatar(userId int) (m.Avatar, error)
+
+	ReserveIdempotencyKey(userId int, key string) (bool, error)
+	GetIdempotentResponse(userId int, key string) (m.IdempotentResponse, bool, error)
+	SaveIdempotentResponse(userId int, key string, response m.IdempotentResponse) error
+	ReleaseIdempotencyKey(userId int, key string) error
+
+	PlaceFlags(flags map[string]string) error
+	GetFlagHashes() (map[string]string, error)
+	AddSolve(userId int, flagId string, solvedAt time.Time) (bool, error)
+	GetSolves() ([]m.CTFSolve, error)
+	TakeHint(userId int, challengeId string, n int, takenAt time.Time) error
+	GetHintsTaken() ([]m.HintTaken, error)
+
+	GetVulnerabilitySettings() (map[string]bool, error)
+	SetVulnerabilitySetting(id string, enabled bool) error
+	ClearVulnerabilitySettings() error
+}
+
+var _ Repository = (*DB)(nil)
@@ -0,0 +1,221 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	m "govulnapi/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Tables holding lab data, children before the tables they reference.
+// Tables added by new migrations need to be listed here too.
+var dataTables = []string{
+	"ctf_solve",
+	"ctf_hint",
+	"comment",
+	"avatar",
+	"watchlist",
+	"strategy",
+	"secret",
+	"webhook_delivery",
+	"webhook",
+	"notification",
+	"cash_transaction",
+	"idempotency_key",
+	"price_alert",
+	"price_history",
+	"transaction",
+	"order",
+	"coin_balance",
+	"user",
+}
+
+// Lab state created by Seed, the same config always creates the same users,
+// balances and trades
+type SeedConfig struct {
+	Seed            int64
+	Users           int
+	StartingBalance m.Usd
+	TradesPerUser   int
+	// Prices historical trades are made at
+	Prices []m.Coin
+	// Trades are made on the days before it
+	VirtualDate time.Time
+}
+
+// Parts of the lab data ResetScope deletes and seeds again
+const (
+	// Everything, like Reset
+	ResetAll = "all"
+	// Users and all data referencing them, price history is kept
+	ResetUsers = "users"
+	// Orders, transfers and cash transactions, balances start over
+	ResetTrades = "trades"
+	// Price history
+	ResetPrices = "prices"
+)
+
+var ErrUnknownResetScope = errors.New("Scope needs to be all, users, trades or prices!")
+
+// Tables deleted by each scope, in the order of dataTables
+func resetTables(scope string) ([]string, error) {
+	switch scope {
+	case ResetAll:
+		return dataTables, nil
+	case ResetUsers:
+		var tables []string
+		for _, table := range dataTables {
+			if table != "price_history" {
+				tables = append(tables, table)
+			}
+		}
+		return tables, nil
+	case ResetTrades:
+		return []string{"idempotency_key", "cash_transaction", "transaction", "order"}, nil
+	case ResetPrices:
+		return []string{"price_history"}, nil
+	}
+	return nil, ErrUnknownResetScope
+}
+
+// Creates users student1@govulnapi.com, student2@govulnapi.com, ... with
+// passwords student1, student2, ... and gives each of them a few buy orders
+func (d *DB) Seed(config SeedConfig) error {
+	return d.seed(config, true)
+}
+
+// Seeds users, or only the trades of the users Seed created before when
+// createUsers is false. Users that don't exist anymore are skipped.
+func (d *DB) seed(config SeedConfig, createUsers bool) error {
+	var (
+		rng    = rand.New(rand.NewSource(config.Seed))
+		prices []m.Coin
+	)
+	for _, coin := range config.Prices {
+		if coin.Price > 0 {
+			prices = append(prices, coin)
+		}
+	}
+
+	for i := 1; i <= config.Users; i++ {
+		email := fmt.Sprintf("student%d@govulnapi.com", i)
+		if createUsers {
+			if err := d.AddUser(email, fmt.Sprintf("student%d", i)); err != nil {
+				return err
+			}
+		}
+
+		user, err := d.GetUserByEmail(email)
+		if err != nil && !createUsers {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if createUsers {
+			query := `UPDATE "user" SET usd_balance = ?, usd_starting_balance = ? WHERE id = ?`
+			err = d.withRetry(func(db *sqlx.DB) error {
+				_, err := db.Exec(db.Rebind(query), config.StartingBalance, config.StartingBalance, user.Id)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(prices) == 0 {
+			continue
+		}
+		balance := config.StartingBalance
+		for t := 0; t < config.TradesPerUser; t++ {
+			coin := prices[rng.Intn(len(prices))]
+			// Spend between 5% and 20% of what's left
+			qty := balance.Float64() * (0.05 + rng.Float64()*0.15) / coin.Price.Float64()
+			virtualDate := config.VirtualDate.AddDate(0, 0, t-config.TradesPerUser)
+
+			if err = d.AddOrder(user.Id, coin.Id, coin.Price, true, qty, virtualDate); err != nil {
+				return err
+			}
+			balance -= m.UsdValue(coin.Price, qty)
+		}
+	}
+
+	return nil
+}
+
+// Deletes all lab data, restarting ids from 1, and seeds it again
+func (d *DB) Reset(config SeedConfig) error {
+	return d.ResetScope(ResetAll, config)
+}
+
+// Deletes the data of the scope, restarting its ids from 1, and seeds it
+// again. Resetting trades sets balances back to the starting ones before the
+// seeded users trade once more.
+func (d *DB) ResetScope(scope string, config SeedConfig) error {
+	tables, err := resetTables(scope)
+	if err != nil {
+		return err
+	}
+
+	err = d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		quoted := make([]string, len(tables))
+		for i, table := range tables {
+			quoted[i] = fmt.Sprintf(`"%s"`, table)
+		}
+		if d.driver == DriverPostgres {
+			query := fmt.Sprintf(`TRUNCATE %s RESTART IDENTITY`, strings.Join(quoted, ", "))
+			if _, err = tx.Exec(query); err != nil {
+				return err
+			}
+		} else {
+			for _, table := range quoted {
+				if _, err = tx.Exec(`DELETE FROM ` + table); err != nil {
+					return err
+				}
+			}
+			names := `'` + strings.Join(tables, `', '`) + `'`
+			if _, err = tx.Exec(`DELETE FROM sqlite_sequence WHERE name IN (` + names + `)`); err != nil {
+				return err
+			}
+		}
+
+		switch scope {
+		case ResetAll, ResetUsers:
+			// CWE-798: Use of Hard-coded Credentials
+			// Default admin account (admin@govulnapi.com:admin123) is recreated
+			_, err = tx.Exec(`INSERT INTO "user" ("email", "password", "role") VALUES ('admin@govulnapi.com', '0192023a7bbd73250516f069df18b500', 'admin')`)
+		case ResetTrades:
+			if _, err = tx.Exec(`UPDATE "user" SET usd_balance = usd_starting_balance`); err != nil {
+				return err
+			}
+			_, err = tx.Exec(`UPDATE "coin_balance" SET qty = 0`)
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		return err
+	}
+
+	switch scope {
+	case ResetAll, ResetUsers:
+		return d.seed(config, true)
+	case ResetTrades:
+		return d.seed(config, false)
+	}
+	return nil
+}
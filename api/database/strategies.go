@@ -0,0 +1,66 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	m "govulnapi/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrStrategyNotFound = errors.New("Strategy not found!")
+
+func (d *DB) AddStrategy(userId int, name string, format string, data []byte, createdAt time.Time) (m.SavedStrategy, error) {
+	var (
+		strategy = m.SavedStrategy{
+			UserId:    userId,
+			Name:      name,
+			Format:    format,
+			Data:      data,
+			CreatedAt: createdAt.Format(time.RFC3339),
+		}
+		query = `INSERT INTO "strategy" (user_id, name, format, data, created_at) VALUES (?, ?, ?, ?, ?) RETURNING id`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&strategy.Id, db.Rebind(query), userId, name, format, data, strategy.CreatedAt)
+	})
+	if err != nil {
+		return m.SavedStrategy{}, err
+	}
+
+	return strategy, nil
+}
+
+// Gets user strategies oldest first, without their data
+func (d *DB) GetStrategies(userId int) ([]m.SavedStrategy, error) {
+	strategies := []m.SavedStrategy{}
+	query := `SELECT id, user_id, name, format, created_at FROM "strategy" WHERE user_id = ? ORDER BY id`
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&strategies, db.Rebind(query), userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return strategies, nil
+}
+
+// Gets a strategy of the user along with its data
+func (d *DB) GetStrategy(userId int, strategyId int) (m.SavedStrategy, error) {
+	var strategy m.SavedStrategy
+	query := `SELECT * FROM "strategy" WHERE id = ? AND user_id = ?`
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&strategy, db.Rebind(query), strategyId, userId)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return m.SavedStrategy{}, ErrStrategyNotFound
+	} else if err != nil {
+		return m.SavedStrategy{}, err
+	}
+
+	return strategy, nil
+}
@@ -0,0 +1,61 @@
+-- Database created by the schema the API had before versioned migrations,
+-- with a user who traded and sent coins
+CREATE TABLE IF NOT EXISTS "coin" (
+	"id"	TEXT NOT NULL,
+	PRIMARY KEY("id")
+);
+CREATE TABLE IF NOT EXISTS "user" (
+	"id"	INTEGER NOT NULL,
+	"email"	TEXT NOT NULL UNIQUE,
+	"password"	TEXT NOT NULL,
+	"usd_balance"	REAL NOT NULL DEFAULT 10000,
+	"usd_starting_balance"	REAL NOT NULL DEFAULT 10000,
+	PRIMARY KEY("id" AUTOINCREMENT)
+);
+CREATE TABLE IF NOT EXISTS "coin_balance" (
+	"user_id"	INTEGER,
+	"coin_id"	TEXT,
+	"address"	TEXT NOT NULL UNIQUE,
+	"qty"	REAL NOT NULL,
+	PRIMARY KEY("user_id","coin_id"),
+	FOREIGN KEY("coin_id") REFERENCES "coin"("id"),
+	FOREIGN KEY("user_id") REFERENCES "user"("id")
+);
+CREATE TABLE IF NOT EXISTS "order" (
+	"id"	INTEGER,
+	"user_id"	INTEGER NOT NULL,
+	"coin_id"	TEXT NOT NULL,
+	"price"	REAL NOT NULL,
+	"is_buy"	INTEGER NOT NULL,
+	"qty"	REAL NOT NULL,
+	"date"	TEXT NOT NULL,
+	FOREIGN KEY("user_id") REFERENCES "user"("id"),
+	PRIMARY KEY("id" AUTOINCREMENT),
+	FOREIGN KEY("coin_id") REFERENCES "coin"("id")
+);
+CREATE TABLE IF NOT EXISTS "transaction" (
+	"id"	INTEGER,
+	"sender_id"	INTEGER NOT NULL,
+	"receiver_id"	INTEGER NOT NULL,
+	"coin_id"	TEXT NOT NULL,
+	"address"	TEXT NOT NULL,
+	"qty"	REAL NOT NULL,
+	"date"	TEXT NOT NULL,
+	"note"	TEXT,
+	PRIMARY KEY("id" AUTOINCREMENT),
+	FOREIGN KEY("sender_id") REFERENCES "user"("id"),
+	FOREIGN KEY("receiver_id") REFERENCES "user"("id"),
+	FOREIGN KEY("address") REFERENCES "coin_balance"("address"),
+	FOREIGN KEY("coin_id") REFERENCES "coin"("id")
+);
+INSERT INTO "coin" ("id") VALUES ('bitcoin'),
+ ('litecoin'),
+ ('namecoin'),
+ ('ripple'),
+ ('dogecoin');
+INSERT INTO "user" ("email", "password", "usd_balance") VALUES ('alice@example.com', '5f4dcc3b5aa765d61d8327deb882cf99', 9905.67397);
+INSERT INTO "user" ("email", "password") VALUES ('bob@example.com', '5f4dcc3b5aa765d61d8327deb882cf99');
+INSERT INTO "coin_balance" ("user_id", "coin_id", "address", "qty") VALUES (1, 'bitcoin', '1BoatSLRHtKNngkdXEeobR76b53LETtpyT', 0.002),
+ (2, 'bitcoin', '1dice8EMZmqKvrGE4Qc9bUFf9PX3xaYDp', 0.001);
+INSERT INTO "order" ("user_id", "coin_id", "price", "is_buy", "qty", "date") VALUES (1, 'bitcoin', 31442.01, 'true', 0.003, '2023-06-01 10:00:00.000000000 +0000 UTC');
+INSERT INTO "transaction" ("sender_id", "receiver_id", "coin_id", "address", "qty", "date", "note") VALUES (1, 2, 'bitcoin', '1dice8EMZmqKvrGE4Qc9bUFf9PX3xaYDp', 0.001, '2023-06-02 10:00:00.000000000 +0000 UTC', 'Lunch');
@@ -1,23 +1,41 @@
 package database
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	m "govulnapi/models"
+	"math"
+	"strings"
 	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrNotEnoughUsd  = errors.New("Not enough usd!")
+	ErrNotEnoughCoin = errors.New("Not enough coin!")
+	// The user has no balance of the coin to credit, see AddCoins
+	ErrNoBalance     = errors.New("No balance to credit!")
+	ErrOrderNotFound = errors.New("Open order not found!")
 )
 
-func (d *DB) AddOrder(userId int, coinId string, price float64, isBuy bool, qty float64) error {
+// Value of an order in millionths of a dollar, rounded like m.UsdValue
+const orderTotal = `ROUND(o.price * o.qty)`
+
+// Time orders wait between checking and writing the balances while the
+// order race condition is enabled, so concurrent orders overlap reliably
+const orderRaceWindow = 50 * time.Millisecond
+
+func (d *DB) AddOrder(userId int, coinId string, price m.Usd, isBuy bool, qty float64, virtualDate time.Time) error {
 	user, err := d.GetUserById(userId)
 	if err != nil {
 		return err
 	}
 
 	var (
-		orderValue         = qty * price
-		newUsdBalance      float64
+		orderValue         = m.UsdValue(price, qty)
 		currentCoinBalance m.CoinBalance
-		newCoinBalance     float64
 	)
 
 	for _, c := range user.CoinBalances {
@@ -33,53 +51,483 @@ func (d *DB) AddOrder(userId int, coinId string, price float64, isBuy bool, qty
 	// 	return errors.New("Quantity needs to be > 0!")
 	// }
 
+	if isBuy && user.UsdBalance < orderValue {
+		return ErrNotEnoughUsd
+	}
+	if !isBuy && currentCoinBalance.Qty < qty {
+		return ErrNotEnoughCoin
+	}
+
+	isBuyInt := 0
 	if isBuy {
-		if user.UsdBalance < orderValue {
-			return errors.New("Not enough usd!")
-		}
-		newUsdBalance = user.UsdBalance - orderValue
-		newCoinBalance = currentCoinBalance.Qty + qty
-	} else {
-		if currentCoinBalance.Qty < qty {
-			return errors.New("Not enough coin!")
-		}
-		newUsdBalance = user.UsdBalance + orderValue
-		newCoinBalance = currentCoinBalance.Qty - qty
+		isBuyInt = 1
 	}
 
 	// CWE-89:  SQL Injection
-	qAddOrder := fmt.Sprintf(
-		"INSERT INTO 'order' (user_id, coin_id, price, is_buy, qty, date) VALUES ('%v','%v','%v','%v','%v','%v')",
-		user.Id, coinId, price, isBuy, qty, time.Now(),
+	now := time.Now().UTC()
+	qAddOrder, addOrderArgs := d.injectable(
+		fmt.Sprintf(
+			`INSERT INTO "order" (user_id, coin_id, price, is_buy, qty, date, virtual_date) VALUES ('%v','%v','%d','%v','%v','%v','%v')`,
+			user.Id, coinId, int64(price), isBuyInt, qty, now, virtualDate.Format(time.DateOnly),
+		),
+		`INSERT INTO "order" (user_id, coin_id, price, is_buy, qty, date, virtual_date) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		user.Id, coinId, price, isBuy, qty, now.String(), virtualDate.Format(time.DateOnly),
 	)
-	qUpdateFiat := fmt.Sprintf(
-		"UPDATE 'user' SET usd_balance = %v WHERE id = %d",
-		newUsdBalance, user.Id,
+
+	// Balances are updated relative to their current values, and the spent
+	// one only if it still covers the order, so concurrent orders can't
+	// spend the same usd or coin twice
+	var (
+		qSpend, qReceive       string
+		spendArgs, receiveArgs []interface{}
+		spendErr               error
 	)
-	qUpdateCoinBalance := fmt.Sprintf(
-		"UPDATE 'coin_balance' SET qty = %v WHERE user_id = %d AND coin_id = '%s'",
-		newCoinBalance, user.Id, coinId,
+	if isBuy {
+		qSpend, spendArgs = d.injectable(
+			fmt.Sprintf(
+				`UPDATE "user" SET usd_balance = usd_balance - %d WHERE id = %d AND usd_balance >= %d`,
+				int64(orderValue), user.Id, int64(orderValue),
+			),
+			`UPDATE "user" SET usd_balance = usd_balance - ? WHERE id = ? AND usd_balance >= ?`, orderValue, user.Id, orderValue,
+		)
+		qReceive, receiveArgs = d.injectable(
+			fmt.Sprintf(
+				`UPDATE "coin_balance" SET qty = qty + %v WHERE user_id = %d AND coin_id = '%s'`,
+				qty, user.Id, coinId,
+			),
+			`UPDATE "coin_balance" SET qty = qty + ? WHERE user_id = ? AND coin_id = ?`, qty, user.Id, coinId,
+		)
+		spendErr = ErrNotEnoughUsd
+	} else {
+		qSpend, spendArgs = d.injectable(
+			fmt.Sprintf(
+				`UPDATE "coin_balance" SET qty = qty - %v WHERE user_id = %d AND coin_id = '%s' AND qty >= %v`,
+				qty, user.Id, coinId, qty,
+			),
+			`UPDATE "coin_balance" SET qty = qty - ? WHERE user_id = ? AND coin_id = ? AND qty >= ?`, qty, user.Id, coinId, qty,
+		)
+		qReceive, receiveArgs = d.injectable(
+			fmt.Sprintf(
+				`UPDATE "user" SET usd_balance = usd_balance + %d WHERE id = %d`,
+				int64(orderValue), user.Id,
+			),
+			`UPDATE "user" SET usd_balance = usd_balance + ? WHERE id = ?`, orderValue, user.Id,
+		)
+		spendErr = ErrNotEnoughCoin
+	}
+
+	if d.orderRace.Load() {
+		// CWE-362: Concurrent Execution using Shared Resource with Improper Synchronization ('Race Condition')
+		// The balances checked above are written back without a transaction
+		// once the order is stored, so orders placed meanwhile are overwritten
+		// and the same usd or coin is spent again
+		time.Sleep(orderRaceWindow)
+
+		spendArgs = []interface{}{user.UsdBalance - orderValue, user.Id}
+		qSpend = `UPDATE "user" SET usd_balance = ? WHERE id = ?`
+		if !isBuy {
+			spendArgs = []interface{}{currentCoinBalance.Qty - qty, user.Id, coinId}
+			qSpend = `UPDATE "coin_balance" SET qty = ? WHERE user_id = ? AND coin_id = ?`
+		}
+
+		return d.withRetry(func(db *sqlx.DB) error {
+			if _, err := db.Exec(qAddOrder, addOrderArgs...); err != nil {
+				return queryError(qAddOrder, err)
+			}
+			if _, err := db.Exec(db.Rebind(qSpend), spendArgs...); err != nil {
+				return queryError(qSpend, err)
+			}
+			r, err := db.Exec(qReceive, receiveArgs...)
+			if err != nil {
+				return queryError(qReceive, err)
+			}
+			if rows, _ := r.RowsAffected(); rows == 0 {
+				return ErrNoBalance
+			}
+			return nil
+		})
+	}
+
+	return d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err = tx.Exec(qAddOrder, addOrderArgs...); err != nil {
+			return queryError(qAddOrder, err)
+		}
+		r, err := tx.Exec(qSpend, spendArgs...)
+		if err != nil {
+			return queryError(qSpend, err)
+		}
+		if rows, _ := r.RowsAffected(); rows == 0 {
+			return spendErr
+		}
+		r, err = tx.Exec(qReceive, receiveArgs...)
+		if err != nil {
+			return queryError(qReceive, err)
+		}
+		// The spent balance is rolled back when there's none to credit
+		if rows, _ := r.RowsAffected(); rows == 0 {
+			return ErrNoBalance
+		}
+
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Places an order resting in the order book until the price reaches
+// limitPrice, see FillLimitOrders. The usd or coin it needs is set aside
+// right away, so filling it can't fail for lack of them.
+func (d *DB) AddLimitOrder(userId int, coinId string, limitPrice m.Usd, isBuy bool, qty float64, virtualDate time.Time) (int, error) {
+	var (
+		id       int
+		spend    = `UPDATE "coin_balance" SET qty = qty - ? WHERE user_id = ? AND coin_id = ? AND qty >= ?`
+		args     = []interface{}{qty, userId, coinId, qty}
+		spendErr = ErrNotEnoughCoin
+		query    = `INSERT INTO "order" (user_id, coin_id, price, is_buy, qty, date, virtual_date, status)
+			VALUES (?, ?, ?, ?, ?, ?, ?, 'open') RETURNING id`
 	)
+	if isBuy {
+		value := m.UsdValue(limitPrice, qty)
+		spend = `UPDATE "user" SET usd_balance = usd_balance - ? WHERE id = ? AND usd_balance >= ?`
+		args = []interface{}{value, userId, value}
+		spendErr = ErrNotEnoughUsd
+	}
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
 
-	tx, err := d.db.Begin()
+		r, err := tx.Exec(tx.Rebind(spend), args...)
+		if err != nil {
+			return err
+		}
+		if rows, _ := r.RowsAffected(); rows == 0 {
+			return spendErr
+		}
+
+		now := time.Now().UTC().String()
+		err = tx.Get(&id, tx.Rebind(query), userId, coinId, limitPrice, isBuy, qty, now, virtualDate.Format(time.DateOnly))
+		if err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
-	defer tx.Rollback()
 
-	if _, err = tx.Exec(qAddOrder); err != nil {
-		return err
+	return id, nil
+}
+
+// Fills the open orders whose limit price the prices reached, at their limit
+// price on date, and returns them
+func (d *DB) FillLimitOrders(coins []m.Coin, date time.Time) ([]m.Order, error) {
+	var (
+		filled []m.Order
+		query  = `UPDATE "order" SET status = 'filled', virtual_date = ?
+			WHERE status = 'open' AND coin_id = ? AND ((is_buy AND price >= ?) OR (NOT is_buy AND price <= ?))
+			RETURNING *`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		filled = []m.Order{}
+
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for _, coin := range coins {
+			var orders []m.Order
+			if err = tx.Select(&orders, tx.Rebind(query), date.Format(time.DateOnly), coin.Id, coin.Price, coin.Price); err != nil {
+				return err
+			}
+			for _, order := range orders {
+				if err = creditOrder(tx, order); err != nil {
+					return err
+				}
+			}
+			filled = append(filled, orders...)
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		return nil, err
 	}
-	if _, err = tx.Exec(qUpdateFiat); err != nil {
-		return err
+
+	return filled, nil
+}
+
+// Cancels an open order of the user and gives back what was set aside for it
+func (d *DB) CancelOrder(userId int, orderId int) (m.Order, error) {
+	var (
+		order m.Order
+		query = `UPDATE "order" SET status = 'cancelled' WHERE id = ? AND user_id = ? AND status = 'open' RETURNING *`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err = tx.Get(&order, tx.Rebind(query), orderId, userId); errors.Is(err, sql.ErrNoRows) {
+			return ErrOrderNotFound
+		} else if err != nil {
+			return err
+		}
+
+		// The order is credited what it spent, as if it was the other side
+		refund := order
+		refund.IsBuy = !order.IsBuy
+		if err = creditOrder(tx, refund); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		return m.Order{}, err
 	}
-	if _, err = tx.Exec(qUpdateCoinBalance); err != nil {
-		return err
+
+	return order, nil
+}
+
+// Credits the user of a filled order with the coin bought or the usd a sale
+// made at its price
+func creditOrder(tx *sqlx.Tx, order m.Order) error {
+	query := `UPDATE "user" SET usd_balance = usd_balance + ? WHERE id = ?`
+	args := []interface{}{m.UsdValue(order.Price, order.Qty), order.UserId}
+	if order.IsBuy {
+		query = `UPDATE "coin_balance" SET qty = qty + ? WHERE user_id = ? AND coin_id = ?`
+		args = []interface{}{order.Qty, order.UserId, order.CoinId}
 	}
 
-	if err = tx.Commit(); err != nil {
+	r, err := tx.Exec(tx.Rebind(query), args...)
+	if err != nil {
 		return err
 	}
+	if rows, _ := r.RowsAffected(); rows == 0 {
+		return ErrNoBalance
+	}
 
 	return nil
 }
+
+func (d *DB) GetOrderBook(coinId string, includeUsers bool) ([]m.OrderBookLevel, error) {
+	var (
+		levels []m.OrderBookLevel
+		users  = "''"
+	)
+
+	if includeUsers {
+		users = d.groupConcat(`DISTINCT "user".email`)
+	}
+
+	query := fmt.Sprintf(
+		`SELECT o.is_buy, o.price, COUNT(*) AS count, SUM(o.qty) AS qty, %s AS users
+		FROM "order" o JOIN "user" ON "user".id = o.user_id
+		WHERE o.coin_id = ? AND o.status = 'open'
+		GROUP BY o.is_buy, o.price
+		ORDER BY o.price DESC`,
+		users,
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&levels, db.Rebind(query), coinId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return levels, nil
+}
+
+type OrderFilter struct {
+	CoinId string
+	IsBuy  *bool
+	From   string // Inclusive virtual date (YYYY-MM-DD)
+	To     string // Inclusive virtual date (YYYY-MM-DD)
+	// Keyset of the last order on the previous page
+	AfterVirtualDate string
+	AfterId          int
+	// Orders skipped before the first one, for page based pagination
+	Offset int
+	Limit  int
+}
+
+// Gets user orders newest first, ordered by virtual date and id
+func (d *DB) GetOrders(userId int, filter OrderFilter) ([]m.Order, error) {
+	orders := []m.Order{}
+	conditions, args := filter.conditions(userId)
+
+	if filter.AfterId != 0 {
+		conditions = append(conditions, "(virtual_date < ? OR (virtual_date = ? AND id < ?))")
+		args = append(args, filter.AfterVirtualDate, filter.AfterVirtualDate, filter.AfterId)
+	}
+
+	query := fmt.Sprintf(
+		`SELECT * FROM "order" WHERE %s ORDER BY virtual_date DESC, id DESC LIMIT ?`,
+		strings.Join(conditions, " AND "),
+	)
+	args = append(args, filter.Limit)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&orders, db.Rebind(query), args...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// Gets filled user orders newest first along with the name and symbol of
+// their coin, and how many match the filter in total
+func (d *DB) GetTrades(userId int, filter OrderFilter) ([]m.Trade, int, error) {
+	var (
+		trades []m.Trade
+		total  int
+	)
+	conditions, args := filter.conditions(userId)
+	conditions = append(conditions, "status = 'filled'")
+	where := strings.Join(conditions, " AND ")
+
+	query := fmt.Sprintf(
+		`SELECT o.id, o.coin_id, c.name AS coin_name, c.symbol AS coin_symbol, o.is_buy, o.qty, o.price,
+			%s AS total, o.virtual_date
+		FROM "order" o JOIN "coin" c ON c.id = o.coin_id
+		WHERE %s ORDER BY o.virtual_date DESC, o.id DESC LIMIT ? OFFSET ?`,
+		orderTotal, where,
+	)
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM "order" WHERE %s`, where)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		trades = []m.Trade{}
+		if err := db.Select(&trades, db.Rebind(query), append(args, filter.Limit, filter.Offset)...); err != nil {
+			return err
+		}
+		return db.Get(&total, db.Rebind(countQuery), args...)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return trades, total, nil
+}
+
+// Orders ExportOrders reads per query. The connection goes back to the pool
+// between batches instead of being held while the rows are written out.
+const exportBatchSize = 500
+
+// Real time of ledger entries to the second, as orders store it in the
+// format of time.Time.String and cash transactions in RFC 3339
+const ledgerTime = `REPLACE(SUBSTR(%s.date, 1, 19), 'T', ' ')`
+
+// Streams filtered user orders newest first along with the usd balance
+// after each of them
+func (d *DB) ExportOrders(userId int, filter OrderFilter, fn func(m.OrderExportRow) error) error {
+	conditions, args := filter.conditions(userId)
+	conditions = append(conditions, "(virtual_date < ? OR (virtual_date = ? AND id < ?))")
+
+	// Running balance is computed over the whole ledger before filtering:
+	// orders along with deposits, withdrawals and transfers, in the order
+	// they were made
+	query := fmt.Sprintf(
+		`SELECT id, user_id, coin_id, virtual_date, is_buy, qty, price, total, balance_after FROM (
+			SELECT l.*, u.usd_starting_balance + SUM(l.amount)
+				OVER (ORDER BY l.virtual_date, l.at, l.is_order, l.id) AS balance_after
+			FROM (
+				SELECT o.id, o.user_id, o.coin_id, o.virtual_date, o.is_buy, o.qty, o.price, %[1]s AS total,
+					CASE WHEN o.is_buy THEN -%[1]s ELSE %[1]s END AS amount, %[2]s AS at, 1 AS is_order
+				FROM "order" o WHERE o.user_id = ? AND o.status = 'filled'
+				UNION ALL
+				SELECT c.id, c.user_id, NULL, c.virtual_date, NULL, NULL, NULL, NULL, c.amount, %[3]s, 0
+				FROM "cash_transaction" c WHERE c.user_id = ?
+			) l JOIN "user" u ON u.id = l.user_id
+		) ledger WHERE is_order = 1 AND %[4]s ORDER BY virtual_date DESC, id DESC LIMIT ?`,
+		orderTotal, fmt.Sprintf(ledgerTime, "o"), fmt.Sprintf(ledgerTime, "c"), strings.Join(conditions, " AND "),
+	)
+	args = append([]interface{}{userId, userId}, args...)
+
+	// Starts after the newest possible order
+	afterDate, afterId := "9999-12-31", math.MaxInt32
+	for {
+		var rows []m.OrderExportRow
+		err := d.withRetry(func(db *sqlx.DB) error {
+			rows = nil
+			return db.Select(&rows, db.Rebind(query), append(args, afterDate, afterDate, afterId, exportBatchSize)...)
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if err = fn(row); err != nil {
+				return err
+			}
+		}
+		if len(rows) < exportBatchSize {
+			return nil
+		}
+		afterDate, afterId = rows[len(rows)-1].VirtualDate, rows[len(rows)-1].Id
+	}
+}
+
+func (f OrderFilter) conditions(userId int) ([]string, []interface{}) {
+	var (
+		conditions = []string{"user_id = ?"}
+		args       = []interface{}{userId}
+	)
+
+	if f.CoinId != "" {
+		conditions = append(conditions, "coin_id = ?")
+		args = append(args, f.CoinId)
+	}
+	if f.IsBuy != nil {
+		conditions = append(conditions, "is_buy = ?")
+		args = append(args, *f.IsBuy)
+	}
+	if f.From != "" {
+		conditions = append(conditions, "virtual_date >= ?")
+		args = append(args, f.From)
+	}
+	if f.To != "" {
+		conditions = append(conditions, "virtual_date <= ?")
+		args = append(args, f.To)
+	}
+
+	return conditions, args
+}
+
+// Gets filled user orders up to and including the virtual date, oldest first
+func (d *DB) GetFilledOrders(userId int, until string) ([]m.Order, error) {
+	var (
+		orders []m.Order
+		query  = `SELECT * FROM "order" WHERE user_id = ? AND status = 'filled' AND virtual_date <= ? ORDER BY virtual_date, id`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&orders, db.Rebind(query), userId, until)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,293 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"testing/quick"
+	"time"
+
+	m "govulnapi/models"
+)
+
+func TestBuySellConservesBalance(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, d *DB) {
+		for _, injectable := range []bool{true, false} {
+			d.SetSQLInjection(injectable)
+			user := addTestUser(t, d, fmt.Sprintf("injectable-%v@example.com", injectable))
+			date := time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+			// Buys up to 4 coins at any price the balance covers, then sells
+			// them again at the same price. Quantities are multiples of 1/64,
+			// which SQLite parses back exactly from injectable queries.
+			roundTrip := func(price m.Usd, sixtyFourths uint8) bool {
+				qty := float64(sixtyFourths%255+1) / 64
+				affordable := m.Usd(float64(user.UsdBalance) / qty)
+				price = 1 + (price%affordable+affordable)%affordable
+
+				if err := d.AddOrder(user.Id, "bitcoin", price, true, qty, date); err != nil {
+					t.Log(err)
+					return false
+				}
+				if err := d.AddOrder(user.Id, "bitcoin", price, false, qty, date); err != nil {
+					t.Log(err)
+					return false
+				}
+
+				after, err := d.GetUserById(user.Id)
+				if err != nil {
+					t.Log(err)
+					return false
+				}
+				return after.UsdBalance == user.UsdBalance
+			}
+
+			config := &quick.Config{MaxCount: 200, Rand: rand.New(rand.NewSource(1))}
+			if err := quick.Check(roundTrip, config); err != nil {
+				t.Errorf("sql injection %v: %v", injectable, err)
+			}
+		}
+	})
+}
+
+// Buys placed at once on a database file, where they share the pool with
+// readers, all wait for the write lock instead of failing
+func TestConcurrentBuys(t *testing.T) {
+	d := Init(filepath.Join(t.TempDir(), "govulnapi.db"))
+	t.Cleanup(d.Close)
+	user := addTestUser(t, d, "concurrent@example.com")
+	date := time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	const buys = 50
+	price, qty := m.UsdFromFloat(800), 0.125
+
+	var wg sync.WaitGroup
+	errs := make(chan error, buys)
+	for i := 0; i < buys; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- d.AddOrder(user.Id, "bitcoin", price, true, qty, date)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("buy failed: %v", err)
+		}
+	}
+
+	after, err := d.GetUserById(user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := user.UsdBalance - buys*m.UsdValue(price, qty); after.UsdBalance != want {
+		t.Errorf("balance = %d, want %d", after.UsdBalance, want)
+	}
+	for _, balance := range after.CoinBalances {
+		if balance.CoinId == "bitcoin" && balance.Qty != buys*qty {
+			t.Errorf("bitcoin = %v, want %v", balance.Qty, buys*qty)
+		}
+	}
+}
+
+func TestExportOrdersBalances(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, d *DB) {
+		user := addTestUser(t, d, "ledger@example.com")
+		first := time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)
+		second := first.AddDate(0, 0, 1)
+		price := m.UsdFromFloat(800)
+
+		if err := d.AddOrder(user.Id, "bitcoin", price, true, 1.5, first); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.AdjustCash(user.Id, m.UsdFromFloat(500), "deposit", second, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.AddOrder(user.Id, "bitcoin", price, false, 0.5, second); err != nil {
+			t.Fatal(err)
+		}
+
+		var balances []m.Usd
+		err := d.ExportOrders(user.Id, OrderFilter{}, func(row m.OrderExportRow) error {
+			balances = append(balances, row.BalanceAfter)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The deposit made between the orders counts towards the balance
+		start := user.UsdBalance
+		want := []m.Usd{start - m.UsdFromFloat(1200) + m.UsdFromFloat(500) + m.UsdFromFloat(400), start - m.UsdFromFloat(1200)}
+		if !reflect.DeepEqual(balances, want) {
+			t.Errorf("balances after orders are %v, want %v", balances, want)
+		}
+	})
+}
+
+// Rows are read in batches, so memory doesn't grow with the export and the
+// connection isn't held while they're handed to fn
+func TestExportOrdersStreams(t *testing.T) {
+	d := Init(MemoryDSN)
+	t.Cleanup(d.Close)
+	user := addTestUser(t, d, "export@example.com")
+
+	const orders = 5000
+	_, err := d.db.Exec(`WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ?)
+		INSERT INTO "order" (user_id, coin_id, price, is_buy, qty, date, virtual_date)
+		SELECT ?, 'bitcoin', 800000000, i % 2, 0.001, '2014-01-01 00:00:00 +0000 UTC', '2014-01-01' FROM n`, orders, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		rows     int
+		lastId   = orders + 1
+		baseline uint64
+		growth   uint64
+		stats    runtime.MemStats
+	)
+	err = d.ExportOrders(user.Id, OrderFilter{}, func(row m.OrderExportRow) error {
+		rows++
+		if row.Id >= lastId {
+			return fmt.Errorf("order %d came after %d", row.Id, lastId)
+		}
+		lastId = row.Id
+
+		if inUse := d.Stats().InUse; inUse != 0 {
+			return fmt.Errorf("%d connections in use while exporting", inUse)
+		}
+		if rows%1000 == 1 {
+			runtime.GC()
+			runtime.ReadMemStats(&stats)
+			if baseline == 0 {
+				baseline = stats.HeapAlloc
+			} else if stats.HeapAlloc > baseline {
+				growth = max(growth, stats.HeapAlloc-baseline)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rows != orders {
+		t.Errorf("exported %d orders, want %d", rows, orders)
+	}
+	if growth > 256<<10 {
+		t.Errorf("heap grew by %d bytes while exporting", growth)
+	}
+}
+
+func TestLimitOrders(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, d *DB) {
+		alice := addTestUser(t, d, "alice@example.com")
+		bob := addTestUser(t, d, "bob@example.com")
+		date := time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)
+		next := date.AddDate(0, 0, 1)
+
+		balances := func() (m.Usd, float64) {
+			t.Helper()
+			user, err := d.GetUserById(alice.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, balance := range user.CoinBalances {
+				if balance.CoinId == "bitcoin" {
+					return user.UsdBalance, balance.Qty
+				}
+			}
+			return user.UsdBalance, 0
+		}
+		consistent := func() {
+			t.Helper()
+			report, err := d.GetBalanceDiscrepancies()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Discrepancies) != 0 {
+				t.Errorf("balances differ from the orders: %+v", report.Discrepancies)
+			}
+		}
+
+		// The usd of a buy is set aside until it's filled
+		buyId, err := d.AddLimitOrder(alice.Id, "bitcoin", m.UsdFromFloat(700), true, 2, date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if usd, _ := balances(); usd != alice.UsdBalance-m.UsdFromFloat(1400) {
+			t.Errorf("usd is %v after placing the buy, want %v", usd, alice.UsdBalance-m.UsdFromFloat(1400))
+		}
+		consistent()
+
+		levels, err := d.GetOrderBook("bitcoin", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []m.OrderBookLevel{{IsBuy: true, Price: m.UsdFromFloat(700), Count: 1, Qty: 2}}
+		if !reflect.DeepEqual(levels, want) {
+			t.Errorf("order book is %+v, want %+v", levels, want)
+		}
+
+		// Filled once the price reaches the limit
+		filled, err := d.FillLimitOrders([]m.Coin{{Id: "bitcoin", Price: m.UsdFromFloat(750)}}, date)
+		if err != nil || len(filled) != 0 {
+			t.Errorf("filled %+v (%v) above the limit, want none", filled, err)
+		}
+		filled, err = d.FillLimitOrders([]m.Coin{{Id: "bitcoin", Price: m.UsdFromFloat(650)}}, next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(filled) != 1 || filled[0].Id != buyId || filled[0].Status != "filled" || filled[0].VirtualDate != "2014-01-02" {
+			t.Errorf("filled %+v, want the buy on 2014-01-02", filled)
+		}
+		if _, qty := balances(); qty != 2 {
+			t.Errorf("bitcoin is %v after the buy was filled, want 2", qty)
+		}
+		if levels, err = d.GetOrderBook("bitcoin", false); err != nil || len(levels) != 0 {
+			t.Errorf("order book is %+v (%v) after filling, want it empty", levels, err)
+		}
+		consistent()
+
+		// Cancelling a sell gives back the coin set aside
+		sell, err := d.AddLimitOrder(alice.Id, "bitcoin", m.UsdFromFloat(900), false, 1.5, next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, qty := balances(); qty != 0.5 {
+			t.Errorf("bitcoin is %v after placing the sell, want 0.5", qty)
+		}
+		consistent()
+
+		if _, err = d.CancelOrder(bob.Id, sell); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("cancelling the order of another user gave %v, want ErrOrderNotFound", err)
+		}
+		cancelled, err := d.CancelOrder(alice.Id, sell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cancelled.Status != "cancelled" {
+			t.Errorf("cancelled order is %s", cancelled.Status)
+		}
+		if _, qty := balances(); qty != 2 {
+			t.Errorf("bitcoin is %v after cancelling the sell, want 2", qty)
+		}
+		if _, err = d.CancelOrder(alice.Id, sell); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("cancelling twice gave %v, want ErrOrderNotFound", err)
+		}
+		consistent()
+
+		if _, err = d.AddLimitOrder(alice.Id, "bitcoin", m.UsdFromFloat(900), false, 3, next); !errors.Is(err, ErrNotEnoughCoin) {
+			t.Errorf("selling more than held gave %v, want ErrNotEnoughCoin", err)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"database/sql"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -8,8 +9,13 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
+var ErrTransactionNotFound = errors.New("Transaction not found!")
+
 func (d *DB) AddTransaction(senderId int, coinId string, address string, qty float64, note string) error {
 	user, err := d.GetUserById(senderId)
 	if err != nil {
@@ -51,41 +57,81 @@ func (d *DB) AddTransaction(senderId int, coinId string, address string, qty flo
 	}
 
 	// CWE-89:  SQL Injection
-	qBalanceReceiver := fmt.Sprintf(
-		"UPDATE 'coin_balance' SET qty=qty+%v WHERE address='%s'",
-		qty, address,
+	var (
+		now      = time.Now()
+		publicId = uuid.NewString()
+	)
+	qBalanceReceiver, balanceReceiverArgs := d.injectable(
+		fmt.Sprintf(
+			`UPDATE "coin_balance" SET qty=qty+%v WHERE address='%s'`,
+			qty, address,
+		),
+		`UPDATE "coin_balance" SET qty = qty + ? WHERE address = ?`, qty, address,
 	)
-	qBalanceSender := fmt.Sprintf(
-		"UPDATE 'coin_balance' SET qty=qty-%v WHERE address='%s'",
-		qty, senderBalance.Address,
+	qBalanceSender, balanceSenderArgs := d.injectable(
+		fmt.Sprintf(
+			`UPDATE "coin_balance" SET qty=qty-%v WHERE address='%s'`,
+			qty, senderBalance.Address,
+		),
+		`UPDATE "coin_balance" SET qty = qty - ? WHERE address = ?`, qty, senderBalance.Address,
 	)
-	qTransaction := fmt.Sprintf(
-		"INSERT INTO 'transaction' (sender_id,receiver_id,coin_id,address,qty,date,note) VALUES (%d, %d,'%v','%s',%v,'%v','%v')",
-		user.Id, receiverId, coinId, address, qty, time.Now(), note,
+	qTransaction, transactionArgs := d.injectable(
+		fmt.Sprintf(
+			`INSERT INTO "transaction" (public_id,sender_id,receiver_id,coin_id,address,qty,date,note) VALUES ('%s', %d, %d,'%v','%s',%v,'%v','%v')`,
+			publicId, user.Id, receiverId, coinId, address, qty, now, note,
+		),
+		`INSERT INTO "transaction" (public_id, sender_id, receiver_id, coin_id, address, qty, date, note) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		publicId, user.Id, receiverId, coinId, address, qty, now.String(), note,
 	)
 
-	tx, err := d.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+	return d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
 
-	r, _ := tx.Exec(qBalanceReceiver)
-	rows, _ := r.RowsAffected()
-	if rows == 0 {
-		return errors.New("Receiver address doesn't exist!")
-	}
+		r, _ := tx.Exec(qBalanceReceiver, balanceReceiverArgs...)
+		rows, _ := r.RowsAffected()
+		if rows == 0 {
+			return errors.New("Receiver address doesn't exist!")
+		}
 
-	if _, err = tx.Exec(qBalanceSender); err != nil {
-		return err
-	}
-	if _, err = tx.Exec(qTransaction); err != nil {
-		return err
-	}
+		if _, err = tx.Exec(qBalanceSender, balanceSenderArgs...); err != nil {
+			return err
+		}
+		if _, err = tx.Exec(qTransaction, transactionArgs...); err != nil {
+			return err
+		}
 
-	if err = tx.Commit(); err != nil {
-		return err
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Gets a transaction by its sequential id, whoever sent or received it
+func (d *DB) GetTransaction(transactionId int) (m.Transaction, error) {
+	return d.getTransaction(`SELECT * FROM "transaction" WHERE id = ?`, transactionId)
+}
+
+// Gets a transaction by the random id it was given on creation
+func (d *DB) GetTransactionByPublicId(publicId string) (m.Transaction, error) {
+	return d.getTransaction(`SELECT * FROM "transaction" WHERE public_id = ?`, publicId)
+}
+
+func (d *DB) getTransaction(query string, args ...interface{}) (m.Transaction, error) {
+	var transaction m.Transaction
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&transaction, db.Rebind(query), args...)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return m.Transaction{}, ErrTransactionNotFound
+	} else if err != nil {
+		return m.Transaction{}, err
 	}
 
-	return nil
+	return transaction, nil
 }
@@ -1,32 +1,58 @@
 package database
 
 import (
+	"database/sql"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	m "govulnapi/models"
+
+	"github.com/jmoiron/sqlx"
 )
 
-func (d *DB) getUser(queryUser string) (m.User, error) {
+func (d *DB) getUser(queryUser string, args ...interface{}) (m.User, error) {
 	// This function is inefficient as it fetches all user data
 	// (even when not called for), but made this way for simplicity
 
 	// Get user
 	var user m.User
-	if err := d.db.Get(&user, queryUser); err != nil {
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&user, queryUser, args...)
+	})
+	if err != nil {
 		return m.User{}, err
 	}
 
 	// CWE-89:  SQL Injection
-	qBalances := fmt.Sprintf("SELECT coin_id, address, qty FROM 'coin_balance' WHERE user_id = %d", user.Id)
-	qOrders := fmt.Sprintf("SELECT coin_id, price, is_buy, qty, date FROM 'order' WHERE user_id = %d", user.Id)
-	qTransactions := fmt.Sprintf("SELECT * FROM 'transaction' WHERE sender_id = %d OR receiver_id = %d", user.Id, user.Id)
-
-	d.db.Select(&user.CoinBalances, qBalances)     // Get user balances
-	d.db.Select(&user.Orders, qOrders)             // Get user orders
-	d.db.Select(&user.Transactions, qTransactions) // Get user transactions
+	qBalances, balancesArgs := d.injectable(
+		fmt.Sprintf(`SELECT coin_id, address, qty FROM "coin_balance" WHERE user_id = %d`, user.Id),
+		`SELECT coin_id, address, qty FROM "coin_balance" WHERE user_id = ?`, user.Id,
+	)
+	qOrders, ordersArgs := d.injectable(
+		fmt.Sprintf(`SELECT id, coin_id, price, is_buy, qty, date, virtual_date, status FROM "order" WHERE user_id = %d`, user.Id),
+		`SELECT id, coin_id, price, is_buy, qty, date, virtual_date, status FROM "order" WHERE user_id = ?`, user.Id,
+	)
+	qTransactions, transactionsArgs := d.injectable(
+		fmt.Sprintf(
+			`SELECT id, public_id, sender_id, receiver_id, coin_id, address, qty, date, note FROM "transaction"
+			WHERE sender_id = %d OR receiver_id = %d ORDER BY date, id`,
+			user.Id, user.Id,
+		),
+		`SELECT id, public_id, sender_id, receiver_id, coin_id, address, qty, date, note FROM "transaction"
+		WHERE sender_id = ? OR receiver_id = ? ORDER BY date, id`,
+		user.Id, user.Id,
+	)
+
+	d.withRetry(func(db *sqlx.DB) error {
+		db.Select(&user.CoinBalances, qBalances, balancesArgs...)         // Get user balances
+		db.Select(&user.Orders, qOrders, ordersArgs...)                   // Get user orders
+		db.Select(&user.Transactions, qTransactions, transactionsArgs...) // Get user transactions
+		return nil
+	})
 
 	return user, nil
 }
@@ -35,9 +61,12 @@ func (d *DB) GetUserByCredentials(email string, password string) (m.User, error)
 	password = md5sum(password)
 
 	// CWE-89:  SQL Injection
-	query := fmt.Sprintf("SELECT * FROM 'user' WHERE user.email = '%s' and user.password = '%s'", email, password)
+	query, args := d.injectable(
+		fmt.Sprintf(`SELECT * FROM "user" WHERE "user".email = '%s' and "user".password = '%s' AND "user".deleted_at IS NULL`, email, password),
+		`SELECT * FROM "user" WHERE "user".email = ? and "user".password = ? AND "user".deleted_at IS NULL`, email, password,
+	)
 
-	user, err := d.getUser(query)
+	user, err := d.getUser(query, args...)
 	if err != nil {
 		return m.User{}, errors.New("No user with matching credentials found!")
 	}
@@ -47,9 +76,12 @@ func (d *DB) GetUserByCredentials(email string, password string) (m.User, error)
 
 func (d *DB) GetUserByEmail(email string) (m.User, error) {
 	// CWE-89:  SQL Injection
-	query := fmt.Sprintf("SELECT * FROM 'user' WHERE user.email = '%s'", email)
+	query, args := d.injectable(
+		fmt.Sprintf(`SELECT * FROM "user" WHERE "user".email = '%s' AND "user".deleted_at IS NULL`, email),
+		`SELECT * FROM "user" WHERE "user".email = ? AND "user".deleted_at IS NULL`, email,
+	)
 
-	user, err := d.getUser(query)
+	user, err := d.getUser(query, args...)
 	if err != nil {
 		return m.User{}, errors.New("No user with matching email found!")
 	}
@@ -59,9 +91,12 @@ func (d *DB) GetUserByEmail(email string) (m.User, error) {
 
 func (d *DB) GetUserById(userId int) (m.User, error) {
 	// CWE-89:  SQL Injection
-	query := fmt.Sprintf("SELECT * FROM 'user' WHERE user.id = %d", userId)
+	query, args := d.injectable(
+		fmt.Sprintf(`SELECT * FROM "user" WHERE "user".id = %d AND "user".deleted_at IS NULL`, userId),
+		`SELECT * FROM "user" WHERE "user".id = ? AND "user".deleted_at IS NULL`, userId,
+	)
 
-	user, err := d.getUser(query)
+	user, err := d.getUser(query, args...)
 	if err != nil {
 		return m.User{}, errors.New("No user with matching id found!")
 	}
@@ -74,8 +109,16 @@ func (d *DB) AddUser(email string, password string) error {
 		return err
 	}
 
-	if _, err := d.GetUserByEmail(email); err == nil {
-		return errors.New("Email already registered!")
+	// Deleted users keep their email until they're purged
+	var registered int
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&registered, db.Rebind(`SELECT COUNT(*) FROM "user" WHERE email = ?`), email)
+	})
+	if err != nil {
+		return err
+	}
+	if registered > 0 {
+		return ErrEmailTaken
 	}
 
 	// CWE-521: Weak Password Requirements
@@ -86,12 +129,17 @@ func (d *DB) AddUser(email string, password string) error {
 	hashedPassword := md5sum(password)
 
 	// CWE-89:  SQL Injection
-	query := fmt.Sprintf("INSERT INTO 'user' (email, password) VALUES ('%s', '%s')", email, hashedPassword)
-	r, err := d.db.Exec(query)
+	query, args := d.injectable(
+		fmt.Sprintf(`INSERT INTO "user" (email, password) VALUES ('%s', '%s') RETURNING id`, email, hashedPassword),
+		`INSERT INTO "user" (email, password) VALUES (?, ?) RETURNING id`, email, hashedPassword,
+	)
+	var user_id int64
+	err = d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&user_id, query, args...)
+	})
 	if err != nil {
 		return err
 	}
-	user_id, _ := r.LastInsertId()
 
 	coins, err := d.GetCoins()
 	if err != nil {
@@ -100,15 +148,21 @@ func (d *DB) AddUser(email string, password string) error {
 
 	// Initialize empty balances for every coin
 	for _, coin := range coins {
-		addressData := fmt.Sprintf("%v-%v-%v", coin.Id, email, user_id)
-		address := base64.StdEncoding.EncodeToString([]byte(addressData))
+		address := coinAddress(coin.Id, email, int(user_id))
 
 		// CWE-89:  SQL Injection
-		query = fmt.Sprintf(
-			"INSERT INTO 'coin_balance' (user_id, coin_id, address, qty) VALUES (%d,'%v','%v',%v)",
+		query, args := d.injectable(
+			fmt.Sprintf(
+				`INSERT INTO "coin_balance" (user_id, coin_id, address, qty) VALUES (%d,'%v','%v',%v)`,
+				user_id, coin.Id, address, 0.0,
+			),
+			`INSERT INTO "coin_balance" (user_id, coin_id, address, qty) VALUES (?, ?, ?, ?)`,
 			user_id, coin.Id, address, 0.0,
 		)
-		d.db.Exec(query)
+		d.withRetry(func(db *sqlx.DB) error {
+			_, err := db.Exec(query, args...)
+			return err
+		})
 	}
 
 	// CWE-532: Insertion of Sensitive Information into Log File
@@ -117,15 +171,27 @@ func (d *DB) AddUser(email string, password string) error {
 	return nil
 }
 
+// Address of the balance of a coin held by a user
+func coinAddress(coinId string, email string, userId int) string {
+	addressData := fmt.Sprintf("%v-%v-%v", coinId, email, userId)
+	return base64.StdEncoding.EncodeToString([]byte(addressData))
+}
+
 func (d *DB) UpdateEmail(userId int, newEmail string) error {
 	// if err := validateEmail(newEmail); err != nil {
 	// 	return err
 	// }
 
 	// CWE-89:  SQL Injection
-	query := fmt.Sprintf("UPDATE 'user' SET email=%s WHERE id=%d", newEmail, userId)
+	query, args := d.injectable(
+		fmt.Sprintf(`UPDATE "user" SET email=%s WHERE id=%d`, newEmail, userId),
+		`UPDATE "user" SET email = ? WHERE id = ?`, newEmail, userId,
+	)
 
-	_, err := d.db.Exec(query)
+	err := d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(query, args...)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -139,9 +205,15 @@ func (d *DB) UpdatePassword(userId int, newPassword string) error {
 	newPassword = md5sum(newPassword)
 
 	// CWE-89:  SQL Injection
-	query := fmt.Sprintf("UPDATE 'user' SET password='%s' WHERE id=%d", newPassword, userId)
+	query, args := d.injectable(
+		fmt.Sprintf(`UPDATE "user" SET password='%s' WHERE id=%d`, newPassword, userId),
+		`UPDATE "user" SET password = ? WHERE id = ?`, newPassword, userId,
+	)
 
-	_, err := d.db.Exec(query)
+	err := d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(query, args...)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -150,3 +222,298 @@ func (d *DB) UpdatePassword(userId int, newPassword string) error {
 	// log.Printf("Updated password for user %d\n", userId)
 	return nil
 }
+
+var (
+	ErrWrongPassword = errors.New("Current password is wrong!")
+	ErrEmailTaken    = errors.New("Email already registered!")
+)
+
+// Changes the email and password of a user after verifying the current
+// password, empty values are left unchanged
+func (d *DB) UpdateCredentials(userId int, currentPassword string, newEmail string, newPassword string) error {
+	if newEmail != "" {
+		if err := validateEmail(newEmail); err != nil {
+			return err
+		}
+	}
+
+	return d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		var matches int
+		err = tx.Get(
+			&matches,
+			tx.Rebind(`SELECT COUNT(*) FROM "user" WHERE id = ? AND password = ? AND deleted_at IS NULL`),
+			userId, md5sum(currentPassword),
+		)
+		if err != nil {
+			return err
+		}
+		if matches == 0 {
+			return ErrWrongPassword
+		}
+
+		if newEmail != "" {
+			var taken int
+			err = tx.Get(&taken, tx.Rebind(`SELECT COUNT(*) FROM "user" WHERE email = ? AND id != ?`), newEmail, userId)
+			if err != nil {
+				return err
+			}
+			if taken > 0 {
+				return ErrEmailTaken
+			}
+			if _, err = tx.Exec(tx.Rebind(`UPDATE "user" SET email = ? WHERE id = ?`), newEmail, userId); err != nil {
+				return err
+			}
+		}
+		if newPassword != "" {
+			if _, err = tx.Exec(tx.Rebind(`UPDATE "user" SET password = ? WHERE id = ?`), md5sum(newPassword), userId); err != nil {
+				return err
+			}
+		}
+
+		return tx.Commit()
+	})
+}
+
+func (d *DB) UpdateDisplayName(userId int, displayName string) error {
+	query := `UPDATE "user" SET display_name = ? WHERE id = ?`
+	return d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(db.Rebind(query), displayName, userId)
+		return err
+	})
+}
+
+// Writes the display name, leaderboard visibility, usd balance and role of
+// the user as they are
+func (d *DB) UpdateUser(userId int, user m.User) error {
+	query := `UPDATE "user" SET display_name = ?, hide_from_leaderboard = ?, usd_balance = ?, role = ? WHERE id = ?`
+	return d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(db.Rebind(query), user.DisplayName, user.HideFromLeaderboard, user.UsdBalance, user.Role, userId)
+		return err
+	})
+}
+
+// Gets cash and coin holdings of every non-admin user
+func (d *DB) GetPortfolios() ([]m.Portfolio, error) {
+	var (
+		portfolios []m.Portfolio
+		balances   []struct {
+			UserId int `db:"user_id"`
+			m.CoinBalance
+		}
+	)
+
+	query := `SELECT id, display_name, hide_from_leaderboard, usd_balance FROM "user" WHERE role = 'user' AND deleted_at IS NULL`
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&portfolios, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	query = `SELECT user_id, coin_id, address, qty FROM "coin_balance" WHERE qty > 0`
+	err = d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&balances, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[int]int{}
+	for i, p := range portfolios {
+		index[p.UserId] = i
+	}
+	for _, b := range balances {
+		if i, ok := index[b.UserId]; ok {
+			portfolios[i].CoinBalances = append(portfolios[i].CoinBalances, b.CoinBalance)
+		}
+	}
+
+	return portfolios, nil
+}
+
+var ErrPortfolioNotFound = errors.New("No user with matching id found!")
+
+// Gets the portfolio of an active user of any role, including empty balances
+func (d *DB) GetPortfolio(userId int) (m.Portfolio, error) {
+	var portfolio m.Portfolio
+
+	query := `SELECT id, display_name, hide_from_leaderboard, usd_balance FROM "user" WHERE id = ? AND deleted_at IS NULL`
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&portfolio, db.Rebind(query), userId)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return m.Portfolio{}, ErrPortfolioNotFound
+	} else if err != nil {
+		return m.Portfolio{}, err
+	}
+
+	query = `SELECT coin_id, address, qty FROM "coin_balance" WHERE user_id = ? ORDER BY coin_id`
+	err = d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&portfolio.CoinBalances, db.Rebind(query), userId)
+	})
+	if err != nil {
+		return m.Portfolio{}, err
+	}
+
+	return portfolio, nil
+}
+
+func (d *DB) UpdateLeaderboardVisibility(userId int, hidden bool) error {
+	query := `UPDATE "user" SET hide_from_leaderboard = ? WHERE id = ?`
+	return d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(db.Rebind(query), hidden, userId)
+		return err
+	})
+}
+
+// Hides the user from lookups, keeping their orders and history until they're
+// purged
+func (d *DB) DeleteUser(userId int, virtualDate time.Time) error {
+	query := `UPDATE "user" SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`
+
+	var rows int64
+	err := d.withRetry(func(db *sqlx.DB) error {
+		r, err := db.Exec(db.Rebind(query), virtualDate.Format(time.DateOnly), userId)
+		if err != nil {
+			return err
+		}
+		rows, err = r.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("No user with matching id found!")
+	}
+
+	return nil
+}
+
+func (d *DB) RestoreUser(userId int) error {
+	query := `UPDATE "user" SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`
+
+	var rows int64
+	err := d.withRetry(func(db *sqlx.DB) error {
+		r, err := db.Exec(db.Rebind(query), userId)
+		if err != nil {
+			return err
+		}
+		rows, err = r.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("No deleted user with matching id found!")
+	}
+
+	return nil
+}
+
+// Permanently removes users deleted before the given virtual date along with
+// everything they own
+func (d *DB) PurgeDeletedUsers(before time.Time) (int64, error) {
+	var users int64
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		condition := `deleted_at IS NOT NULL AND deleted_at < ?`
+		if users, err = deleteUsers(tx, condition, before.Format(time.DateOnly)); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return users, nil
+}
+
+// Removes the account of a user after verifying their password, along with
+// everything they own
+func (d *DB) DeleteAccount(userId int, password string) error {
+	return d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		var matches int
+		err = tx.Get(
+			&matches,
+			tx.Rebind(`SELECT COUNT(*) FROM "user" WHERE id = ? AND password = ? AND deleted_at IS NULL`),
+			userId, md5sum(password),
+		)
+		if err != nil {
+			return err
+		}
+		if matches == 0 {
+			return ErrWrongPassword
+		}
+
+		if _, err = deleteUsers(tx, `id = ?`, userId); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
+}
+
+// Deletes users matching the condition on the user table and all rows
+// referencing them. Transfers to and from other users are removed too, cash
+// transactions of other users only lose their counterparty.
+func deleteUsers(tx *sqlx.Tx, condition string, arg interface{}) (int64, error) {
+	users := `SELECT id FROM "user" WHERE ` + condition
+	queries := []string{
+		`DELETE FROM "ctf_solve" WHERE user_id IN (` + users + `)`,
+		`DELETE FROM "ctf_hint" WHERE user_id IN (` + users + `)`,
+		`DELETE FROM "comment" WHERE user_id IN (` + users + `)`,
+		`DELETE FROM "avatar" WHERE user_id IN (` + users + `)`,
+		`DELETE FROM "watchlist" WHERE user_id IN (` + users + `)`,
+		`DELETE FROM "strategy" WHERE user_id IN (` + users + `)`,
+		`DELETE FROM "webhook_delivery" WHERE webhook_id IN (SELECT id FROM "webhook" WHERE user_id IN (` + users + `))`,
+		`DELETE FROM "webhook" WHERE user_id IN (` + users + `)`,
+		`DELETE FROM "notification" WHERE user_id IN (` + users + `)`,
+		`UPDATE "cash_transaction" SET counterparty_id = NULL WHERE counterparty_id IN (` + users + `)`,
+		`DELETE FROM "cash_transaction" WHERE user_id IN (` + users + `)`,
+		`DELETE FROM "idempotency_key" WHERE user_id IN (` + users + `)`,
+		`DELETE FROM "price_alert" WHERE user_id IN (` + users + `)`,
+		`DELETE FROM "transaction" WHERE sender_id IN (` + users + `) OR receiver_id IN (` + users + `)`,
+		`DELETE FROM "order" WHERE user_id IN (` + users + `)`,
+		`DELETE FROM "coin_balance" WHERE user_id IN (` + users + `)`,
+	}
+
+	// Every placeholder is the one of the condition
+	for _, query := range queries {
+		args := make([]interface{}, strings.Count(query, "?"))
+		for i := range args {
+			args[i] = arg
+		}
+		if _, err := tx.Exec(tx.Rebind(query), args...); err != nil {
+			return 0, err
+		}
+	}
+
+	r, err := tx.Exec(tx.Rebind(`DELETE FROM "user" WHERE `+condition), arg)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.RowsAffected()
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	m "govulnapi/models"
+)
+
+func TestAddUser(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, d *DB) {
+		user := addTestUser(t, d, "alice@example.com")
+		if user.UsdBalance != m.UsdFromFloat(10000) || user.Role != "user" {
+			t.Errorf("user = %+v, want the default balance and role", user)
+		}
+		if len(user.CoinBalances) == 0 {
+			t.Error("user has no coin balances")
+		}
+
+		if err := d.AddUser("alice@example.com", "password"); !errors.Is(err, ErrEmailTaken) {
+			t.Errorf("adding the email again gave %v, want ErrEmailTaken", err)
+		}
+		if err := d.AddUser("not an email", "password"); !errors.Is(err, ErrInvalidEmail) {
+			t.Errorf("adding an invalid email gave %v, want ErrInvalidEmail", err)
+		}
+
+		if _, err := d.GetUserByCredentials("alice@example.com", "password"); err != nil {
+			t.Errorf("logging in: %v", err)
+		}
+		if _, err := d.GetUserByCredentials("alice@example.com", "wrong"); err == nil {
+			t.Error("logged in with a wrong password")
+		}
+	})
+}
+
+func TestUpdateCredentials(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, d *DB) {
+		alice := addTestUser(t, d, "alice@example.com")
+		addTestUser(t, d, "bob@example.com")
+
+		err := d.UpdateCredentials(alice.Id, "wrong", "alice@example.org", "")
+		if !errors.Is(err, ErrWrongPassword) {
+			t.Errorf("updating with a wrong password gave %v, want ErrWrongPassword", err)
+		}
+		err = d.UpdateCredentials(alice.Id, "password", "bob@example.com", "")
+		if !errors.Is(err, ErrEmailTaken) {
+			t.Errorf("taking the email of bob gave %v, want ErrEmailTaken", err)
+		}
+
+		if err = d.UpdateCredentials(alice.Id, "password", "alice@example.org", "new password"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = d.GetUserByCredentials("alice@example.org", "new password"); err != nil {
+			t.Errorf("logging in with the new credentials: %v", err)
+		}
+	})
+}
@@ -16,9 +16,11 @@ func md5sum(password string) string {
 	return password
 }
 
+var ErrInvalidEmail = errors.New("Email invalid!")
+
 func validateEmail(email string) error {
 	if _, err := mail.ParseAddress(email); err != nil {
-		return errors.New("Email invalid!")
+		return ErrInvalidEmail
 	}
 
 	return nil
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// Gets vulnerabilities enabled or disabled at runtime, by id
+func (d *DB) GetVulnerabilitySettings() (map[string]bool, error) {
+	var (
+		settings []struct {
+			Id      string `db:"id"`
+			Enabled bool   `db:"enabled"`
+		}
+		query = `SELECT id, enabled FROM "vulnerability_setting"`
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&settings, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := map[string]bool{}
+	for _, setting := range settings {
+		enabled[setting.Id] = setting.Enabled
+	}
+
+	return enabled, nil
+}
+
+func (d *DB) SetVulnerabilitySetting(id string, enabled bool) error {
+	query := `INSERT INTO "vulnerability_setting" (id, enabled) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET enabled = excluded.enabled`
+
+	return d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(db.Rebind(query), id, enabled)
+		return err
+	})
+}
+
+// Forgets every vulnerability enabled or disabled at runtime
+func (d *DB) ClearVulnerabilitySettings() error {
+	return d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(`DELETE FROM "vulnerability_setting"`)
+		return err
+	})
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"errors"
+	m "govulnapi/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrAlreadyWatched = errors.New("Coin is already on your watchlist!")
+	ErrNotWatched     = errors.New("Coin isn't on your watchlist!")
+)
+
+func (d *DB) AddToWatchlist(userId int, coinId string, addedAt time.Time) error {
+	query := `INSERT INTO "watchlist" (user_id, coin_id, added_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`
+
+	var rows int64
+	err := d.withRetry(func(db *sqlx.DB) error {
+		r, err := db.Exec(db.Rebind(query), userId, coinId, addedAt.Format(time.RFC3339))
+		if err != nil {
+			return err
+		}
+		rows, _ = r.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrAlreadyWatched
+	}
+
+	return nil
+}
+
+func (d *DB) RemoveFromWatchlist(userId int, coinId string) error {
+	query := `DELETE FROM "watchlist" WHERE user_id = ? AND coin_id = ?`
+
+	var rows int64
+	err := d.withRetry(func(db *sqlx.DB) error {
+		r, err := db.Exec(db.Rebind(query), userId, coinId)
+		if err != nil {
+			return err
+		}
+		rows, _ = r.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotWatched
+	}
+
+	return nil
+}
+
+// Gets the coins on the watchlist of the user in the order they were added
+func (d *DB) GetWatchlist(userId int) ([]m.WatchlistEntry, error) {
+	entries := []m.WatchlistEntry{}
+	query := `SELECT coin_id, added_at FROM "watchlist" WHERE user_id = ? ORDER BY added_at, coin_id`
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&entries, db.Rebind(query), userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
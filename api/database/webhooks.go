@@ -0,0 +1,162 @@
+package database
+
+import (
+	m "govulnapi/models"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Events are stored as a comma separated list
+type webhookRow struct {
+	Id      int    `db:"id"`
+	UserId  int    `db:"user_id"`
+	Url     string `db:"url"`
+	Events  string `db:"events"`
+	Secret  string `db:"secret"`
+	Failing bool   `db:"failing"`
+}
+
+func (d *DB) AddWebhook(userId int, url string, events []string, secret string) (m.Webhook, error) {
+	query := `INSERT INTO "webhook" (user_id, url, events, secret) VALUES (?, ?, ?, ?) RETURNING id`
+
+	var id int64
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Get(&id, db.Rebind(query), userId, url, strings.Join(events, ","), secret)
+	})
+	if err != nil {
+		return m.Webhook{}, err
+	}
+
+	return m.Webhook{
+		Id:     int(id),
+		UserId: userId,
+		Url:    url,
+		Events: events,
+		Secret: secret,
+	}, nil
+}
+
+// Gets user webhooks without their secrets
+func (d *DB) GetWebhooks(userId int) ([]m.Webhook, error) {
+	var (
+		rows     []webhookRow
+		webhooks = []m.Webhook{}
+	)
+
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&rows, db.Rebind(`SELECT * FROM "webhook" WHERE user_id = ?`), userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		webhooks = append(webhooks, m.Webhook{
+			Id:      row.Id,
+			UserId:  row.UserId,
+			Url:     row.Url,
+			Events:  strings.Split(row.Events, ","),
+			Failing: row.Failing,
+		})
+	}
+
+	return webhooks, nil
+}
+
+// Queues a delivery for every user webhook subscribed to the event
+func (d *DB) EnqueueWebhookDeliveries(userId int, event string, payload string) error {
+	query := `INSERT INTO "webhook_delivery" (webhook_id, event, payload, next_attempt_at)
+		SELECT id, CAST(? AS TEXT), CAST(? AS TEXT), CAST(? AS TEXT) FROM "webhook"
+		WHERE user_id = ? AND ',' || events || ',' LIKE '%,' || ? || ',%'`
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	err := d.withRetry(func(db *sqlx.DB) error {
+		_, err := db.Exec(db.Rebind(query), event, payload, now, userId, event)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Gets pending deliveries that are due, oldest first
+func (d *DB) GetDueWebhookDeliveries(limit int) ([]m.WebhookDelivery, error) {
+	var (
+		deliveries = []m.WebhookDelivery{}
+		query      = `SELECT d.id, d.webhook_id, w.url, w.secret, d.event, d.payload, d.attempts
+			FROM "webhook_delivery" d JOIN "webhook" w ON w.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= ?
+			ORDER BY d.id LIMIT ?`
+	)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	err := d.withRetry(func(db *sqlx.DB) error {
+		return db.Select(&deliveries, db.Rebind(query), now, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (d *DB) CompleteWebhookDelivery(delivery m.WebhookDelivery) error {
+	return d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		query := `UPDATE "webhook_delivery" SET status = 'delivered', attempts = attempts + 1, last_error = NULL WHERE id = ?`
+		if _, err = tx.Exec(tx.Rebind(query), delivery.Id); err != nil {
+			return err
+		}
+		if _, err = tx.Exec(tx.Rebind(`UPDATE "webhook" SET failing = FALSE WHERE id = ?`), delivery.WebhookId); err != nil {
+			return err
+		}
+
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Records a failed attempt and schedules the next one. Without a next
+// attempt the delivery is given up and its webhook marked as failing.
+func (d *DB) FailWebhookDelivery(delivery m.WebhookDelivery, reason string, nextAttempt *time.Time) error {
+	return d.withRetry(func(db *sqlx.DB) error {
+		tx, err := db.Beginx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if nextAttempt != nil {
+			query := `UPDATE "webhook_delivery" SET attempts = attempts + 1, last_error = ?, next_attempt_at = ? WHERE id = ?`
+			if _, err = tx.Exec(tx.Rebind(query), reason, nextAttempt.UTC().Format(time.RFC3339), delivery.Id); err != nil {
+				return err
+			}
+		} else {
+			query := `UPDATE "webhook_delivery" SET status = 'failed', attempts = attempts + 1, last_error = ? WHERE id = ?`
+			if _, err = tx.Exec(tx.Rebind(query), reason, delivery.Id); err != nil {
+				return err
+			}
+			if _, err = tx.Exec(tx.Rebind(`UPDATE "webhook" SET failing = TRUE WHERE id = ?`), delivery.WebhookId); err != nil {
+				return err
+			}
+		}
+
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
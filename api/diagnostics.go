@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+
+	m "govulnapi/models"
+)
+
+const (
+	pingTimeout     = 3 * time.Second
+	pingDefaultPort = 80
+)
+
+var (
+	hostname = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*$`)
+	// Resolved address and round trip time in the output of ping
+	pingAddress = regexp.MustCompile(`PING [^ ]+ \(([0-9a-fA-F.:]+)\)`)
+	pingRtt     = regexp.MustCompile(`time=([0-9.]+) ?ms`)
+)
+
+var errInvalidHost = errors.New("Host needs to be a hostname or IP address!")
+
+// Checks whether the host can be reached from the API server
+func (a *Api) ping(ctx context.Context, request m.PingRequest) (m.PingResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	// CWE-78: Improper Neutralization of Special Elements used in an OS Command ('OS Command Injection')
+	// The host is concatenated into a shell command, so ; or $() run
+	// commands of their own, and their output is returned
+	if a.vulnerable(VulnDiagnosticsCommandInjection) {
+		return shellPing(ctx, request.Host), nil
+	}
+
+	if !hostname.MatchString(request.Host) && net.ParseIP(request.Host) == nil {
+		return m.PingResult{}, errInvalidHost
+	}
+	port := request.Port
+	if port == 0 {
+		port = pingDefaultPort
+	}
+	if port < 1 || port > 65535 {
+		return m.PingResult{}, errors.New("Port needs to be between 1 and 65535!")
+	}
+
+	return tcpPing(ctx, request.Host, port), nil
+}
+
+func shellPing(ctx context.Context, host string) m.PingResult {
+	result := m.PingResult{Host: host}
+
+	output, err := exec.CommandContext(ctx, "sh", "-c", "ping -c 1 -W 2 "+host).CombinedOutput()
+	result.Output = string(output)
+	result.Success = err == nil
+
+	if match := pingAddress.FindStringSubmatch(result.Output); match != nil {
+		result.ResolvedIp = match[1]
+	}
+	if match := pingRtt.FindStringSubmatch(result.Output); match != nil {
+		result.RttMs, _ = strconv.ParseFloat(match[1], 64)
+	}
+
+	return result
+}
+
+// Measures how long opening a TCP connection to the host takes, which needs
+// neither a shell nor the privileges of ICMP
+func tcpPing(ctx context.Context, host string, port int) m.PingResult {
+	result := m.PingResult{Host: host, Port: port}
+
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip", host)
+	if err != nil || len(ips) == 0 {
+		result.Error = "Unable to resolve host!"
+		return result
+	}
+	ip := ips[0]
+	for _, candidate := range ips {
+		if candidate.To4() != nil {
+			ip = candidate
+			break
+		}
+	}
+	result.ResolvedIp = ip.String()
+
+	var dialer net.Dialer
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), strconv.Itoa(port)))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	conn.Close()
+
+	result.RttMs = float64(time.Since(start).Microseconds()) / 1000
+	result.Success = true
+
+	return result
+}
@@ -0,0 +1,104 @@
+package api
+
+import m "govulnapi/models"
+
+// Difficulties Options.Difficulty can be set to
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+var Difficulties = []string{DifficultyEasy, DifficultyMedium, DifficultyHard}
+
+// Vulnerabilities enabled at each difficulty. Easy leaves everything
+// exploitable and answers errors in detail. Medium keeps the opt-in token
+// forgeries disabled and answers errors generically. Hard limits login
+// attempts, keeps transaction ids random and only leaves the vulnerabilities
+// that pay off when chained, e.g. emails leaked by the order book are the
+// targets of negative transfers, and comment scripts reach the admin through
+// the login redirect.
+var difficultyTable = []struct {
+	Vulnerability      string
+	Easy, Medium, Hard bool
+}{
+	{VulnSQLInjection, true, true, false},
+	{VulnNegativeTransfers, true, true, true},
+	{VulnOrderBookUsers, true, true, true},
+	{VulnWebhookSSRF, true, true, false},
+	{VulnNewsPreviewSSRF, true, true, false},
+	{VulnReportPathTraversal, true, true, false},
+	{VulnTradeImportXXE, true, true, false},
+	{VulnDiagnosticsCommandInjection, true, true, false},
+	{VulnTransactionIDOR, true, true, false},
+	{VulnProfileMassAssignment, true, true, false},
+	{VulnStrategyDeserialization, true, true, false},
+	{VulnLoginOpenRedirect, true, true, true},
+	{VulnCommentXSS, true, true, true},
+	{VulnOrderRaceCondition, true, true, false},
+	{VulnJWTAlgNone, true, false, false},
+	{VulnJWTWeakSecret, true, false, false},
+	{VulnAvatarUpload, true, true, false},
+	{VulnDebugResponses, true, false, false},
+	{VulnLoginBruteForce, true, true, false},
+}
+
+func isDifficulty(difficulty string) bool {
+	for _, d := range Difficulties {
+		if d == difficulty {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether the difficulty enables the vulnerability, vulnerabilities missing
+// from the table are disabled
+func difficultyEnables(difficulty string, name string) bool {
+	for _, row := range difficultyTable {
+		if row.Vulnerability != name {
+			continue
+		}
+		switch difficulty {
+		case DifficultyEasy:
+			return row.Easy
+		case DifficultyMedium:
+			return row.Medium
+		case DifficultyHard:
+			return row.Hard
+		}
+	}
+	return false
+}
+
+// Vulnerabilities in effect at the difficulty of the options, along with
+// the ones toggled one by one
+func (o Options) difficulty() m.Difficulty {
+	difficulty := m.Difficulty{
+		Difficulty:      o.Difficulty,
+		Vulnerabilities: map[string]bool{},
+		Overrides:       map[string]bool{},
+	}
+	for _, vulnerability := range Vulnerabilities {
+		difficulty.Vulnerabilities[vulnerability.Id] = o.vulnerable(vulnerability.Id)
+	}
+	for name, enabled := range o.Vulnerabilities {
+		difficulty.Overrides[name] = enabled
+	}
+	return difficulty
+}
+
+// Switches to the difficulty, dropping the vulnerabilities toggled one by
+// one so the whole preset applies
+func (a *Api) setDifficulty(difficulty string) error {
+	if err := a.db.ClearVulnerabilitySettings(); err != nil {
+		return err
+	}
+
+	a.updateOptions(func(o *Options) {
+		o.Difficulty = difficulty
+		o.Vulnerabilities = nil
+	})
+
+	return nil
+}
@@ -20,399 +20,6015 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/balances/coin": {
+        "/admin/backup": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Fetches coin balances",
+                "description": "Streams a consistent snapshot of the SQLite database, taken without stopping the api",
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
-                    "Trading"
+                    "Admin"
                 ],
-                "summary": "Get coin balances",
+                "summary": "Backup database",
                 "responses": {
                     "200": {
-                        "description": "ok"
+                        "description": "database snapshot"
                     },
                     "401": {
-                        "description": "unauthorized"
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "501": {
+                        "description": "not supported by the database driver",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     }
                 }
-            }
-        },
-        "/balances/usd": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Fetches usd balances",
+                "description": "Writes a consistent snapshot of the SQLite database to a new file in the backup directory, taken without\nstopping the api",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Trading"
+                    "Admin"
                 ],
-                "summary": "Get usd balances",
+                "summary": "Backup database to a file",
                 "responses": {
                     "200": {
-                        "description": "ok"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.BackupResult"
+                        }
                     },
                     "401": {
-                        "description": "unauthorized"
-                    }
-                }
-            }
-        },
-        "/coins": {
-            "get": {
-                "description": "Get data for coins",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Coins"
-                ],
-                "summary": "Coin data",
-                "responses": {
-                    "200": {
-                        "description": "ok"
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "another backup is still running",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     },
                     "500": {
-                        "description": "internal server error"
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "501": {
+                        "description": "not supported by the database driver",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     }
                 }
             }
         },
-        "/login": {
+        "/admin/coins/{id}": {
             "get": {
-                "description": "Provides JWT token for existing user",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets the name and symbol of a coin, the ETag header holds the version PATCH needs in If-Match",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Admin"
                 ],
-                "summary": "User login",
+                "summary": "Get coin listing",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "email",
-                        "name": "email",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "password",
-                        "name": "password",
-                        "in": "query",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "login successful"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.CoinListing"
+                        }
                     },
                     "401": {
-                        "description": "invalid credentials"
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     }
                 }
-            }
-        },
-        "/orders": {
-            "get": {
+            },
+            "patch": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Fetches past orders",
+                "description": "Changes the name and/or symbol of a coin. If-Match needs to hold the version of the ETag header the coin\nwas read with, so changes of other admins made in the meantime aren't overwritten.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Trading"
+                    "Admin"
+                ],
+                "summary": "Update coin listing",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version the coin was read at",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "New name and/or symbol",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.CoinPatch"
+                        }
+                    }
                 ],
-                "summary": "Get past orders",
                 "responses": {
                     "200": {
-                        "description": "ok"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.CoinListing"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     },
                     "401": {
-                        "description": "unauthorized"
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "412": {
+                        "description": "coin changed since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match missing",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/admin/comments/{id}": {
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Creates new buy/sell order",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Deletes a comment of any user",
                 "produces": [
                     "text/plain"
                 ],
                 "tags": [
-                    "Trading"
+                    "Admin"
                 ],
-                "summary": "Buy/sell coins",
+                "summary": "Moderate comment",
                 "parameters": [
                     {
-                        "description": "New order",
-                        "name": "order",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/govulnapi_models.Order"
-                        }
+                        "type": "integer",
+                        "description": "Comment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "order went through"
+                        "description": "comment deleted"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     },
                     "401": {
-                        "description": "unauthorized"
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     },
                     "404": {
-                        "description": "requested coin not found"
+                        "description": "comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     },
                     "500": {
-                        "description": "internal server error"
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     }
                 }
             }
         },
-        "/register": {
+        "/admin/consistency": {
             "get": {
-                "description": "Registers a user",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Recomputes the usd and coin balances of every user from their starting balance, cash transactions, orders\nand coin transfers, and lists the ones that differ from the stored balances",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "Auth"
-                ],
-                "summary": "User registration",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "email",
-                        "name": "email",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "password",
-                        "name": "password",
-                        "in": "query",
-                        "required": true
-                    }
+                    "Admin"
                 ],
+                "summary": "Check balance consistency",
                 "responses": {
                     "200": {
-                        "description": "registration successful"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.ConsistencyReport"
+                        }
                     },
-                    "409": {
-                        "description": "email already registered or invalid parameters"
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     }
                 }
             }
         },
-        "/transactions": {
+        "/admin/ctf/hints": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Fetches past transactions",
+                "description": "Get which hints users took, oldest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Transactions"
+                    "CTF"
                 ],
-                "summary": "Get past transactions",
+                "summary": "Hints taken",
                 "responses": {
                     "200": {
-                        "description": "ok"
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/govulnapi_models.HintTaken"
+                            }
+                        }
                     },
                     "401": {
-                        "description": "unauthorized"
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/admin/diagnostics/ping": {
             "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Creates new transaction",
+                "description": "Checks whether a host, e.g. the virtual Coingecko, can be reached from the api and how long it takes",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "Transactions"
+                    "Admin"
                 ],
-                "summary": "Send coins",
+                "summary": "Ping host",
                 "parameters": [
                     {
-                        "description": "New transaction",
-                        "name": "transaction",
+                        "description": "Host to ping",
+                        "name": "ping",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/govulnapi_models.Transaction"
+                            "$ref": "#/definitions/govulnapi_models.PingRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "transaction went through"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.PingResult"
+                        }
                     },
                     "400": {
-                        "description": "bad request"
+                        "description": "invalid host or port",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     },
                     "401": {
-                        "description": "unauthorized"
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     },
-                    "412": {
-                        "description": "not enough coin"
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     }
                 }
             }
         },
-        "/user/email": {
+        "/admin/difficulty": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get the difficulty along with the vulnerabilities in effect and the ones toggled one by one",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Difficulty",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Difficulty"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            },
             "put": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Updates user email",
+                "description": "Switches to the vulnerabilities of the difficulty without a restart, forgetting the ones toggled one by one",
                 "consumes": [
-                    "application/x-www-form-urlencoded"
+                    "application/json"
                 ],
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Admin"
                 ],
-                "summary": "Update email",
+                "summary": "Set difficulty",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "New email",
-                        "name": "email",
-                        "in": "formData",
-                        "required": true
+                        "description": "easy, medium or hard",
+                        "name": "difficulty",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.DifficultyUpdate"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "email updated"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Difficulty"
+                        }
                     },
                     "400": {
-                        "description": "bad request"
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     },
                     "401": {
-                        "description": "unauthorized"
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     }
                 }
             }
         },
-        "/user/password": {
-            "put": {
+        "/admin/flag": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Updates user password",
+                "description": "Get the flag only admins can see",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "CTF"
+                ],
+                "summary": "Admin flag",
+                "responses": {
+                    "200": {
+                        "description": "flag"
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/lab/reset": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes the data of the scope and seeds it again, other requests are answered with 503 meanwhile.\nall wipes everything and moves virtual time back to the start date, users wipes users with all their\ndata, trades sets balances back to the starting ones and replays the seeded trades, and prices wipes\nthe price history and moves virtual time back to the start date.",
                 "consumes": [
-                    "application/x-www-form-urlencoded"
+                    "application/json"
                 ],
                 "produces": [
                     "text/plain"
                 ],
                 "tags": [
-                    "User"
+                    "Admin"
                 ],
-                "summary": "Update password",
+                "summary": "Reset lab data",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "New password",
-                        "name": "password",
-                        "in": "formData",
-                        "required": true
+                        "description": "Scope and seeding, left out seeding fields keep their defaults",
+                        "name": "reset",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.ScopedLabReset"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "password changed"
+                        "description": "lab reset"
                     },
                     "400": {
-                        "description": "bad request"
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     },
                     "401": {
-                        "description": "unauthorized"
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "govulnapi_models.Order": {
-            "type": "object",
-            "properties": {
-                "coinId": {
-                    "type": "string",
-                    "example": "bitcoin"
-                },
-                "isBuy": {
-                    "type": "boolean"
-                },
-                "qty": {
-                    "type": "number",
-                    "example": 1
+        },
+        "/admin/notifications": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Sends a notification to every user",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Broadcast notification",
+                "parameters": [
+                    {
+                        "description": "Message",
+                        "name": "broadcast",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Broadcast"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
                 }
             }
         },
-        "govulnapi_models.Transaction": {
-            "type": "object",
-            "properties": {
-                "address": {
-                    "type": "string",
-                    "example": ""
-                },
-                "coinId": {
-                    "type": "string",
-                    "example": "bitcoin"
-                },
-                "note": {
-                    "type": "string"
-                },
-                "qty": {
-                    "type": "number",
-                    "example": 1
+        "/admin/reload-config": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Changes options that are safe to modify while running",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Reload options",
+                "parameters": [
+                    {
+                        "description": "Options to change",
+                        "name": "options",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.reloadableOptions"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reset": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes all users, balances, orders and history and seeds the lab again. The same seed always creates\nthe same users (student1@govulnapi.com:student1, ...) and trades, other requests are answered with 503 meanwhile.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Reset lab",
+                "parameters": [
+                    {
+                        "description": "Seeding, left out fields keep their defaults",
+                        "name": "reset",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.LabReset"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "lab reset"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/restore": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Replaces the database with a snapshot from /admin/backup. Writes are rejected with 503 while restoring,\nafterwards the coin list is reloaded and prices continue from the current virtual date.",
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Restore database",
+                "parameters": [
+                    {
+                        "description": "Database snapshot",
+                        "name": "snapshot",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "database restored"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "501": {
+                        "description": "not supported by the database driver",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/simulate": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Advances virtual time by the given number of days (max 365) without waiting, refreshing prices for each of them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Simulate days",
+                "parameters": [
+                    {
+                        "description": "Days to simulate",
+                        "name": "simulation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Simulation"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.SimulationResult"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stats": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get a snapshot of runtime statistics",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Runtime statistics",
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Hides the user from logins and the leaderboard, their orders and history are kept until they're purged after deleted_user_retention_days virtual days",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "user deleted"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "user not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/cash": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Credits (positive amount) or debits (negative amount) usd without daily limits",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Adjust user usd",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Amount",
+                        "name": "amount",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.CashAmount"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "usd adjusted"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "412": {
+                        "description": "not enough usd",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Restores a deleted user that wasn't purged yet",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Restore user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "user restored"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "deleted user not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vacuum": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Rebuilds the database to give space left unused by deleted rows back, runs every vacuum_interval too",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Vacuum database",
+                "responses": {
+                    "200": {
+                        "description": "database vacuumed"
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vulnerabilities": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get the vulnerabilities that can be toggled, along with their CWE, affected routes and whether they're enabled",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Vulnerabilities",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/govulnapi_models.Vulnerability"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vulnerabilities/{id}": {
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Enables or disables a vulnerability, the setting is kept across restarts and takes precedence over the configuration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Toggle vulnerability",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vulnerability id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Whether the vulnerable code path is taken",
+                        "name": "toggle",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.VulnerabilityToggle"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Vulnerability"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "unknown vulnerability",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/avatars/{user}": {
+            "get": {
+                "description": "Downloads the avatar of a user",
+                "produces": [
+                    "image/png",
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get avatar",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User id",
+                        "name": "user",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "avatar"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "avatar not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/balances/coin": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Fetches coin balances",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trading"
+                ],
+                "summary": "Get coin balances",
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/balances/usd": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Fetches usd balances",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trading"
+                ],
+                "summary": "Get usd balances",
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins": {
+            "get": {
+                "description": "Get data for coins along with their name and symbol. Fields limits every coin to the named fields, e.g. id,name.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Coins"
+                ],
+                "summary": "Coin data",
+                "parameters": [
+                    {
+                        "enum": [
+                            "market_cap_desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to keep, all by default",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.CoinList"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid sort order or unknown field",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/bulk-import": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Lists the coins of a JSON file holding an array of at most 1000 of them, in a single transaction. Coins\nlisted already are skipped, invalid ones are returned as errors and the others are still listed.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Bulk import coins",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "JSON array of m.NewCoin",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.CoinImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "415": {
+                        "description": "unsupported media type",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error, no coin was listed",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/export": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Downloads the tracked coins with their latest prices as CSV, named after the virtual date",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Export coins",
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/top-gainers": {
+            "get": {
+                "description": "Get coins with the largest price increase over the last days",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Coins"
+                ],
+                "summary": "Top gainers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Virtual days to compare against (default 7, max 90)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/govulnapi_models.CoinChange"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "not enough price history",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/top-losers": {
+            "get": {
+                "description": "Get coins with the largest price decrease over the last days",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Coins"
+                ],
+                "summary": "Top losers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Virtual days to compare against (default 7, max 90)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/govulnapi_models.CoinChange"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "not enough price history",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/{id}": {
+            "get": {
+                "description": "Get data for a coin along with its name, symbol and price change since the previous virtual day",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Coins"
+                ],
+                "summary": "Coin detail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id or symbol",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.CoinDetail"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed coin id or symbol",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "429": {
+                        "description": "too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/{id}/comments": {
+            "get": {
+                "description": "Fetches comments on the coin newest first, one page at a time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comments"
+                ],
+                "summary": "Get coin comments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "NextCursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.CommentPage"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Comments on the coin, bodies can't be longer than 500 characters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comments"
+                ],
+                "summary": "Add comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Comment",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.CommentBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/{id}/moving-average": {
+            "get": {
+                "description": "Get the simple moving average of the coin's daily closing prices up to the current virtual date",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Coins"
+                ],
+                "summary": "Moving average",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Virtual days averaged (default 7, min 2, max 200)",
+                        "name": "window",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.MovingAverage"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid window",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "not enough price history",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/{id}/news-preview": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Fetches a news page about the coin and returns its title and first paragraph",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Coins"
+                ],
+                "summary": "Preview coin news",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "News page",
+                        "name": "news",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.NewsPreviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.NewsPreview"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid or internal url",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "502": {
+                        "description": "news page couldn't be fetched",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/{id}/orderbook": {
+            "get": {
+                "description": "Get open buy and sell interest for a coin aggregated by price level",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Coins"
+                ],
+                "summary": "Order book",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include emails of users with open orders (vulnerable mode only)",
+                        "name": "include_users",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "404": {
+                        "description": "requested coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/{id}/page": {
+            "get": {
+                "description": "Renders the coin with its latest comments as an HTML page",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Comments"
+                ],
+                "summary": "Coin page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "404": {
+                        "description": "requested coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/{id}/price": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Sets the price of a coin until the next price refresh",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Override coin price",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New price",
+                        "name": "coin",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Coin"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/{id}/price-alert": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Creates alert triggered when coin price crosses a threshold",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alerts"
+                ],
+                "summary": "Create price alert",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New alert",
+                        "name": "alert",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.PriceAlert"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/{id}/price-change": {
+            "get": {
+                "description": "Get the percentage change of the coin's closing price over 1, 7, 30 and 90 virtual days, null for windows without enough price history",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Coins"
+                ],
+                "summary": "Price change",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.PriceChange"
+                        }
+                    },
+                    "404": {
+                        "description": "requested coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/{id}/similar": {
+            "get": {
+                "description": "Get coins whose recent daily prices correlate the most with the coin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Coins"
+                ],
+                "summary": "Similar coins",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/govulnapi_models.SimilarCoin"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "requested coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/coins/{id}/watchlist": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Adds a coin to the watchlist of the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watchlist"
+                ],
+                "summary": "Watch coin",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.WatchlistEntry"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "coin already on the watchlist",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Removes a coin from the watchlist of the user",
+                "tags": [
+                    "Watchlist"
+                ],
+                "summary": "Unwatch coin",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "removed"
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "coin not on the watchlist",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/comments/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Replaces the body of one of your comments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comments"
+                ],
+                "summary": "Edit comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Comment",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.CommentBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes one of your comments",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Comments"
+                ],
+                "summary": "Delete comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "comment deleted"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "comment not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/ctf/challenges/{id}/hints/{n}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get hint n of a challenge, which costs points on the scoreboard. Hints are given in order, so hints 1 to n-1 need to be taken first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "CTF"
+                ],
+                "summary": "Hint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Challenge id, see /admin/vulnerabilities",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Hint number, from 1",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Hint"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "previous hints not taken",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "unknown challenge or hint",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/ctf/scoreboard": {
+            "get": {
+                "description": "Get teams by the number of flags they found, updated at most every 5 seconds",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "CTF"
+                ],
+                "summary": "Scoreboard",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/govulnapi_models.ScoreboardEntry"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/ctf/submit": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Scores a flag for the user's team, submitting a flag again doesn't change when it was solved",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "CTF"
+                ],
+                "summary": "Submit flag",
+                "parameters": [
+                    {
+                        "description": "Flag",
+                        "name": "submission",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.FlagSubmission"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.FlagResult"
+                        }
+                    },
+                    "400": {
+                        "description": "wrong flag",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "admins can't submit flags",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/debug/vars": {
+            "get": {
+                "description": "Runtime state and configuration in the style of expvar's /debug/vars, only served while debug responses\nare enabled",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Debug variables",
+                "responses": {
+                    "200": {
+                        "description": "variables"
+                    },
+                    "404": {
+                        "description": "debug responses disabled",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/deposit": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Adds usd to the balance, limited per virtual day",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Transfers"
+                ],
+                "summary": "Deposit usd",
+                "parameters": [
+                    {
+                        "description": "Amount",
+                        "name": "amount",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.CashAmount"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "deposit went through"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "412": {
+                        "description": "daily limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/leaderboard": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get users with the highest portfolio value along with own rank, updated every price refresh",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Leaderboard",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of users (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "get": {
+                "description": "Provides JWT token for existing user",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "User login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "email",
+                        "name": "email",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "password",
+                        "name": "password",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Relative path or url of an allowed origin to redirect to once the cookie is set",
+                        "name": "redirect_to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "login successful"
+                    },
+                    "302": {
+                        "description": "login successful, redirecting to redirect_to"
+                    },
+                    "400": {
+                        "description": "redirect_to not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "429": {
+                        "description": "too many failed logins",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get own account data",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Profile",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Profile"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "user deleted",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Permanently deletes own account along with balances, orders, transactions, alerts and all other data, requires the password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Delete account",
+                "parameters": [
+                    {
+                        "description": "Password",
+                        "name": "deletion",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.AccountDeletion"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "account deleted"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized or wrong password",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Changes own display name, email and/or password, changing the email or password requires the current password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Update profile",
+                "parameters": [
+                    {
+                        "description": "New display name, email and/or password",
+                        "name": "update",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.ProfileUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Profile"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized or wrong current password",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "email taken",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/avatar": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Replaces your avatar, it needs to be a PNG or JPEG image of at most 1 MiB and 1024x1024 pixels",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Upload avatar",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Image",
+                        "name": "avatar",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Avatar"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "415": {
+                        "description": "unsupported media type",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/price-alerts": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Fetches price alerts that haven't triggered yet",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alerts"
+                ],
+                "summary": "Get price alerts",
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sandbox": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes your copy of the lab data in sandbox mode, your next request starts over from the shared data",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Reset sandbox",
+                "responses": {
+                    "200": {
+                        "description": "sandbox reset"
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "sandbox mode disabled",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/watchlist": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Fetches the coins on the watchlist of the user with their latest prices, in the order they were added",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watchlist"
+                ],
+                "summary": "Get watchlist",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/govulnapi_models.WatchlistEntry"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Fetches notifications newest first, one page at a time, along with the unread count",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Get notifications",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only unread notifications",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "NextCursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.NotificationPage"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/{id}/read": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Mark notification as read",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Notification id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Fetches past orders newest first, one page at a time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trading"
+                ],
+                "summary": "Get past orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "coin_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "buy or sell",
+                        "name": "side",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First virtual date (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last virtual date (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "NextCursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Creates new buy/sell order, filled at the current price unless it has a limit price the price hasn't reached yet",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Trading"
+                ],
+                "summary": "Buy/sell coins",
+                "parameters": [
+                    {
+                        "description": "New order",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Order"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "UUID identifying retries of the same order",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "order went through, limit order placed or retried order"
+                    },
+                    "400": {
+                        "description": "unknown fields in request body or invalid idempotency key",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "requested coin not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "order with the same idempotency key still in progress",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "412": {
+                        "description": "not enough usd or coin",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Cancels an open limit order and gives back the usd or coin set aside for it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trading"
+                ],
+                "summary": "Cancel order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid order id",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "open order not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/portfolio/pnl": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get realized and unrealized profit and loss with a daily equity curve",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Portfolio"
+                ],
+                "summary": "Profit and loss",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First virtual date (YYYY-MM-DD), defaults to 30 days before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last virtual date (YYYY-MM-DD), defaults to current virtual date",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/portfolio/positions/{coin_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Sells the whole balance of a coin at the current price, realized profit is matched against the oldest buys",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Portfolio"
+                ],
+                "summary": "Close position",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "coin_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.ClosedPosition"
+                        }
+                    },
+                    "400": {
+                        "description": "coin delisted",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "no position in coin",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "412": {
+                        "description": "not enough coin",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/portfolio/transactions": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Fetches filled orders newest first, one page at a time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Portfolio"
+                ],
+                "summary": "Get trade history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "coin_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "buy or sell",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First virtual date (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last virtual date (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (max 100)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.TradePage"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Reports whether the api is ready to serve traffic, i.e. coins are loaded, virtual days advance and prices are fresh",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Readiness",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Readiness"
+                        }
+                    },
+                    "503": {
+                        "description": "failed checks",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Readiness"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "get": {
+                "description": "Registers a user",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "User registration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "email",
+                        "name": "email",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "password",
+                        "name": "password",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "registration successful"
+                    },
+                    "409": {
+                        "description": "email already registered or invalid parameters"
+                    }
+                }
+            }
+        },
+        "/reports": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Lists the file names of the user's reports, a portfolio summary is generated at the end of every virtual month",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "List reports",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Writes the portfolio summary of the current virtual month so far, it's replaced by the final one at the\nend of the month",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Generate report",
+                "responses": {
+                    "200": {
+                        "description": "file name of the report"
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "admins have no portfolio",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/{filename}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Downloads one of the user's reports",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Download report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "portfolio-2014-01.csv",
+                        "description": "Report file name",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "report"
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "report not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/strategies": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Lists the user's saved strategies, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Strategies"
+                ],
+                "summary": "List strategies",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/govulnapi_models.SavedStrategy"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Saves a trading strategy preset to backtest later. Strategies are JSON documents, their signal buys once\nthe price falls BuyBelowPct percent below its moving average over Window days, and sells once it rises\nSellAbovePct percent above it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Strategies"
+                ],
+                "summary": "Save strategy",
+                "parameters": [
+                    {
+                        "description": "Strategy",
+                        "name": "strategy",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Strategy"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.SavedStrategy"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid strategy",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "415": {
+                        "description": "unsupported format",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/strategies/{id}/run": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Backtests a saved strategy against the coin's price history up to the current virtual date, trading at\nclosing prices, and compares its hypothetical profit and loss to buying on the first day and holding",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Strategies"
+                ],
+                "summary": "Run strategy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Strategy id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.StrategyRun"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "strategy not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "strategy can't be run",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Fetches past transactions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Get past transactions",
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Creates new transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Send coins",
+                "parameters": [
+                    {
+                        "description": "New transaction",
+                        "name": "transaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Transaction"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "transaction went through"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "412": {
+                        "description": "not enough coin",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/export": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Downloads past orders newest first as CSV, with the usd balance after each of them",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "Trading"
+                ],
+                "summary": "Export past orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coin id",
+                        "name": "coin_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "buy or sell",
+                        "name": "side",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First virtual date (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last virtual date (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/import": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Backfills past trades into the account, as JSON or as an XML document like\n\u003ctrades\u003e\u003ctrade\u003e\u003ccoinId\u003ebitcoin\u003c/coinId\u003e\u003cisBuy\u003etrue\u003c/isBuy\u003e\u003cqty\u003e0.5\u003c/qty\u003e\u003cprice\u003e800\u003c/price\u003e\u003cdate\u003e2013-12-20\u003c/date\u003e\u003c/trade\u003e\u003c/trades\u003e.\nEvery trade is checked like a live order, trades that fail are listed with their row.",
+                "consumes": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Import trades",
+                "parameters": [
+                    {
+                        "description": "Trades",
+                        "name": "trades",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.TradeImport"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.TradeImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid document",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Fetches a transaction the user sent or received by its id, a sequential number or a random UUID depending on the lab configuration",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Get transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Transaction"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "transaction not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfer": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Transfers usd to another user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Transfers"
+                ],
+                "summary": "Send usd",
+                "parameters": [
+                    {
+                        "description": "New transfer",
+                        "name": "transfer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Transfer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "transfer went through"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "412": {
+                        "description": "invalid amount, receiver or not enough usd",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfers": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Fetches sent (negative amount) and received usd transfers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transfers"
+                ],
+                "summary": "Get past transfers",
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/email": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Updates user email",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Update email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "New email",
+                        "name": "email",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "email updated"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/leaderboard": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Shows or hides user on the leaderboard from the next price refresh",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Update leaderboard visibility",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Show user on the leaderboard",
+                        "name": "visible",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "visibility updated"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Updates user password",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Update password",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "New password",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "password changed"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/portfolio": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get the usd and coin balances of a user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Portfolio"
+                ],
+                "summary": "User portfolio",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Portfolio"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "user not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "Build of the api that is deployed, version is dev for builds without -ldflags",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Version",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Version"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Fetches registered webhooks, failing ones gave up on a delivery after all attempts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/govulnapi_models.Webhook"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Registers url receiving POST requests on trade.executed and alert.triggered events.\nRequests are signed with HMAC-SHA256 of the body in X-Signature header, the secret is only returned here.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Register webhook",
+                "parameters": [
+                    {
+                        "description": "New webhook",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Webhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/withdraw": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Removes usd from the balance, limited per virtual day",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Transfers"
+                ],
+                "summary": "Withdraw usd",
+                "parameters": [
+                    {
+                        "description": "Amount",
+                        "name": "amount",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/govulnapi_models.CashAmount"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "withdrawal went through"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    },
+                    "412": {
+                        "description": "daily limit exceeded or not enough usd",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.APIError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "bad_request"
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Amount needs to be != 0!"
+                }
+            }
+        },
+        "api.reloadableOptions": {
+            "type": "object",
+            "properties": {
+                "dailyDepositLimit": {
+                    "type": "number"
+                },
+                "dailyWithdrawalLimit": {
+                    "type": "number"
+                },
+                "dayDuration": {
+                    "type": "string",
+                    "example": "30s"
+                },
+                "strictJSONParsing": {
+                    "type": "boolean"
+                },
+                "vulnerabilities": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    },
+                    "example": {
+                        "sql_injection": false
+                    }
+                },
+                "vulnerableMode": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "govulnapi_models.AccountDeletion": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "secret"
+                }
+            }
+        },
+        "govulnapi_models.Avatar": {
+            "type": "object",
+            "properties": {
+                "contentType": {
+                    "type": "string",
+                    "example": "image/png"
+                },
+                "fileName": {
+                    "type": "string",
+                    "example": "avatar.png"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "govulnapi_models.BackupResult": {
+            "type": "object",
+            "properties": {
+                "backup_path": {
+                    "type": "string",
+                    "example": "/var/backups/api_2024-01-01_120000.db"
+                },
+                "size_bytes": {
+                    "type": "integer",
+                    "example": 1234
+                }
+            }
+        },
+        "govulnapi_models.Broadcast": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Scheduled maintenance tonight"
+                }
+            }
+        },
+        "govulnapi_models.CashAmount": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                }
+            }
+        },
+        "govulnapi_models.ClosedPosition": {
+            "type": "object",
+            "properties": {
+                "coinId": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "qty": {
+                    "type": "number"
+                },
+                "realizedPnl": {
+                    "type": "number"
+                }
+            }
+        },
+        "govulnapi_models.Coin": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "marketCap": {
+                    "type": "number"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "volume24h": {
+                    "type": "number"
+                }
+            }
+        },
+        "govulnapi_models.CoinChange": {
+            "type": "object",
+            "properties": {
+                "changePercent": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "pastPrice": {
+                    "type": "number"
+                },
+                "price": {
+                    "type": "number"
+                }
+            }
+        },
+        "govulnapi_models.CoinDetail": {
+            "type": "object",
+            "properties": {
+                "change24hPercent": {
+                    "description": "Change of the price since the last one of the previous virtual day,\nnull without one",
+                    "type": "number",
+                    "example": 2.5
+                },
+                "id": {
+                    "type": "string"
+                },
+                "marketCap": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Bitcoin"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "symbol": {
+                    "type": "string",
+                    "example": "BTC"
+                },
+                "volume24h": {
+                    "type": "number"
+                }
+            }
+        },
+        "govulnapi_models.CoinImportResult": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CoinImportError"
+                    }
+                },
+                "inserted": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                }
+            }
+        },
+        "govulnapi_models.CoinList": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ListedCoin"
+                    }
+                }
+            }
+        },
+        "govulnapi_models.CoinListing": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "cardano"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Cardano"
+                },
+                "symbol": {
+                    "type": "string",
+                    "example": "ADA"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "govulnapi_models.CoinPatch": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Cardano"
+                },
+                "symbol": {
+                    "type": "string",
+                    "example": "ADA"
+                }
+            }
+        },
+        "govulnapi_models.Comment": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "example": "To the moon!"
+                },
+                "coinId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "displayName": {
+                    "description": "Of the author, empty when they didn't set one",
+                    "type": "string"
+                },
+                "editedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "virtualDate": {
+                    "type": "string"
+                }
+            }
+        },
+        "govulnapi_models.CommentBody": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "example": "To the moon!"
+                }
+            }
+        },
+        "govulnapi_models.CommentPage": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Comment"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "govulnapi_models.ConsistencyReport": {
+            "type": "object",
+            "properties": {
+                "discrepancies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BalanceDiscrepancy"
+                    }
+                },
+                "usersChecked": {
+                    "type": "integer"
+                }
+            }
+        },
+        "govulnapi_models.Difficulty": {
+            "type": "object",
+            "properties": {
+                "difficulty": {
+                    "type": "string",
+                    "example": "hard"
+                },
+                "overrides": {
+                    "description": "Toggled one by one, taking precedence over the difficulty",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                },
+                "vulnerabilities": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                }
+            }
+        },
+        "govulnapi_models.DifficultyUpdate": {
+            "type": "object",
+            "properties": {
+                "difficulty": {
+                    "type": "string",
+                    "example": "hard"
+                }
+            }
+        },
+        "govulnapi_models.FlagResult": {
+            "type": "object",
+            "properties": {
+                "firstSolve": {
+                    "description": "False when the flag was submitted before",
+                    "type": "boolean"
+                },
+                "flagId": {
+                    "type": "string",
+                    "example": "hidden_table"
+                }
+            }
+        },
+        "govulnapi_models.FlagSubmission": {
+            "type": "object",
+            "properties": {
+                "flag": {
+                    "type": "string",
+                    "example": "govulnapi{...}"
+                }
+            }
+        },
+        "govulnapi_models.Hint": {
+            "type": "object",
+            "properties": {
+                "challengeId": {
+                    "type": "string",
+                    "example": "sql_injection"
+                },
+                "number": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "penalty": {
+                    "description": "Points the hint costs on the scoreboard",
+                    "type": "integer",
+                    "example": 10
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "govulnapi_models.HintTaken": {
+            "type": "object",
+            "properties": {
+                "challengeId": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "takenAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "govulnapi_models.LabReset": {
+            "type": "object",
+            "properties": {
+                "seed": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "startingBalance": {
+                    "type": "number",
+                    "example": 10000
+                },
+                "tradesPerUser": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "users": {
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
+        "govulnapi_models.MovingAverage": {
+            "type": "object",
+            "properties": {
+                "as_of_date": {
+                    "type": "string",
+                    "example": "2014-06-01"
+                },
+                "coin_id": {
+                    "type": "string",
+                    "example": "bitcoin"
+                },
+                "sma": {
+                    "type": "number",
+                    "example": 45321.5
+                },
+                "window": {
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "govulnapi_models.NewsPreview": {
+            "type": "object",
+            "properties": {
+                "coinId": {
+                    "type": "string",
+                    "example": "bitcoin"
+                },
+                "paragraph": {
+                    "type": "string",
+                    "example": "Bitcoin rose above $60,000 on Monday."
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Bitcoin hits new high"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/bitcoin-news"
+                }
+            }
+        },
+        "govulnapi_models.NewsPreviewRequest": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/bitcoin-news"
+                }
+            }
+        },
+        "govulnapi_models.NotificationPage": {
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "type": "string"
+                },
+                "notifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Notification"
+                    }
+                },
+                "unreadCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "govulnapi_models.Order": {
+            "type": "object",
+            "properties": {
+                "coinId": {
+                    "type": "string",
+                    "example": "bitcoin"
+                },
+                "isBuy": {
+                    "type": "boolean"
+                },
+                "limitPrice": {
+                    "description": "Rests in the order book until the price reaches it when set",
+                    "type": "number",
+                    "example": 30000
+                },
+                "qty": {
+                    "type": "number",
+                    "example": 1
+                }
+            }
+        },
+        "govulnapi_models.PingRequest": {
+            "type": "object",
+            "properties": {
+                "host": {
+                    "type": "string",
+                    "example": "localhost"
+                },
+                "port": {
+                    "description": "TCP port probed when not running the vulnerable ping, defaults to 80",
+                    "type": "integer",
+                    "example": 8082
+                }
+            }
+        },
+        "govulnapi_models.PingResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "host": {
+                    "type": "string"
+                },
+                "output": {
+                    "description": "Output of the ping command, vulnerable mode only",
+                    "type": "string"
+                },
+                "port": {
+                    "type": "integer"
+                },
+                "resolvedIp": {
+                    "type": "string",
+                    "example": "127.0.0.1"
+                },
+                "rttMs": {
+                    "type": "number"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "govulnapi_models.Portfolio": {
+            "type": "object",
+            "properties": {
+                "coinBalances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CoinBalance"
+                    }
+                },
+                "displayName": {
+                    "type": "string"
+                },
+                "hideFromLeaderboard": {
+                    "type": "boolean"
+                },
+                "usdBalance": {
+                    "type": "number"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "govulnapi_models.PriceAlert": {
+            "type": "object",
+            "properties": {
+                "direction": {
+                    "type": "string",
+                    "example": "above"
+                },
+                "thresholdUsd": {
+                    "type": "number",
+                    "example": 1000
+                }
+            }
+        },
+        "govulnapi_models.PriceChange": {
+            "type": "object",
+            "properties": {
+                "1d": {
+                    "type": "number",
+                    "example": 1.2
+                },
+                "30d": {
+                    "type": "number"
+                },
+                "7d": {
+                    "type": "number",
+                    "example": -3.4
+                },
+                "90d": {
+                    "type": "number"
+                }
+            }
+        },
+        "govulnapi_models.Profile": {
+            "type": "object",
+            "properties": {
+                "displayName": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "user"
+                }
+            }
+        },
+        "govulnapi_models.ProfileUpdate": {
+            "type": "object",
+            "properties": {
+                "currentPassword": {
+                    "type": "string",
+                    "example": "secret"
+                },
+                "displayName": {
+                    "type": "string",
+                    "example": "satoshi"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "newPassword": {
+                    "type": "string",
+                    "example": "correct horse battery"
+                }
+            }
+        },
+        "govulnapi_models.Readiness": {
+            "type": "object",
+            "properties": {
+                "failedChecks": {
+                    "description": "Names of the checks that failed, e.g. no_coins or virtual_clock_stalled",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "virtual_clock_stalled"
+                    ]
+                },
+                "ready": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "govulnapi_models.SavedStrategy": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "govulnapi_models.ScopedLabReset": {
+            "type": "object",
+            "properties": {
+                "scope": {
+                    "type": "string",
+                    "example": "trades"
+                },
+                "seed": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "startingBalance": {
+                    "type": "number",
+                    "example": 10000
+                },
+                "tradesPerUser": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "users": {
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
+        "govulnapi_models.ScoreboardEntry": {
+            "type": "object",
+            "properties": {
+                "hintsTaken": {
+                    "type": "integer"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "score": {
+                    "description": "Points of solved flags minus the penalty of hints taken",
+                    "type": "integer"
+                },
+                "solves": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CTFSolve"
+                    }
+                },
+                "team": {
+                    "type": "string"
+                }
+            }
+        },
+        "govulnapi_models.SimilarCoin": {
+            "type": "object",
+            "properties": {
+                "correlation": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "govulnapi_models.Simulation": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "integer",
+                    "example": 30
+                }
+            }
+        },
+        "govulnapi_models.SimulationResult": {
+            "type": "object",
+            "properties": {
+                "finalVirtualDate": {
+                    "type": "string"
+                },
+                "simulatedDays": {
+                    "type": "integer"
+                }
+            }
+        },
+        "govulnapi_models.Strategy": {
+            "type": "object",
+            "properties": {
+                "coinId": {
+                    "type": "string",
+                    "example": "bitcoin"
+                },
+                "days": {
+                    "type": "integer",
+                    "example": 90
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Buy the dip"
+                },
+                "signal": {
+                    "$ref": "#/definitions/models.StrategySignal"
+                },
+                "startingUsd": {
+                    "type": "number",
+                    "example": 10000
+                }
+            }
+        },
+        "govulnapi_models.StrategyRun": {
+            "type": "object",
+            "properties": {
+                "buyAndHoldPnl": {
+                    "type": "number"
+                },
+                "coinId": {
+                    "type": "string"
+                },
+                "endingValue": {
+                    "type": "number"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pnl": {
+                    "type": "number"
+                },
+                "pnlPercent": {
+                    "type": "number"
+                },
+                "startingUsd": {
+                    "type": "number"
+                },
+                "strategyId": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "trades": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StrategyTrade"
+                    }
+                }
+            }
+        },
+        "govulnapi_models.TradeImport": {
+            "type": "object",
+            "properties": {
+                "trades": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportedTrade"
+                    }
+                }
+            }
+        },
+        "govulnapi_models.TradeImportResult": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TradeImportError"
+                    }
+                },
+                "imported": {
+                    "type": "integer"
+                }
+            }
+        },
+        "govulnapi_models.TradePage": {
+            "type": "object",
+            "properties": {
+                "page": {
+                    "type": "integer"
+                },
+                "perPage": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "trades": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Trade"
+                    }
+                }
+            }
+        },
+        "govulnapi_models.Transaction": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": ""
+                },
+                "coinId": {
+                    "type": "string",
+                    "example": "bitcoin"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "qty": {
+                    "type": "number",
+                    "example": 1
+                }
+            }
+        },
+        "govulnapi_models.Transfer": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "toEmail": {
+                    "type": "string",
+                    "example": "user@example.com"
+                }
+            }
+        },
+        "govulnapi_models.Version": {
+            "type": "object",
+            "properties": {
+                "built_at": {
+                    "type": "string",
+                    "example": "2024-01-01T00:00:00Z"
+                },
+                "commit": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.2.3"
+                }
+            }
+        },
+        "govulnapi_models.Vulnerability": {
+            "type": "object",
+            "properties": {
+                "cwe": {
+                    "type": "integer",
+                    "example": 89
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Queries are built by formatting user input into them"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "hints": {
+                    "description": "Ordered, CTF players take them one by one",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Which queries use the email you log in with?"
+                    ]
+                },
+                "id": {
+                    "type": "string",
+                    "example": "sql_injection"
+                },
+                "optIn": {
+                    "description": "Left disabled by VulnerableMode, only enabled by naming it in\nOptions.Vulnerabilities",
+                    "type": "boolean"
+                },
+                "routes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "GET /api/login"
+                    ]
+                }
+            }
+        },
+        "govulnapi_models.VulnerabilityToggle": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "govulnapi_models.WatchlistEntry": {
+            "type": "object",
+            "properties": {
+                "addedAt": {
+                    "type": "string"
+                },
+                "coinId": {
+                    "type": "string",
+                    "example": "bitcoin"
+                },
+                "price": {
+                    "description": "Latest price of the coin, null when it isn't tracked anymore",
+                    "type": "number",
+                    "example": 825.47
+                }
+            }
+        },
+        "govulnapi_models.Webhook": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "trade.executed",
+                        "alert.triggered"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/hook"
+                }
+            }
+        },
+        "models.BalanceDiscrepancy": {
+            "type": "object",
+            "properties": {
+                "actual": {
+                    "type": "number"
+                },
+                "asset": {
+                    "type": "string",
+                    "example": "usd"
+                },
+                "difference": {
+                    "type": "number"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "expected": {
+                    "type": "number"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CTFSolve": {
+            "type": "object",
+            "properties": {
+                "flagId": {
+                    "type": "string"
+                },
+                "solvedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CoinBalance": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "coinId": {
+                    "type": "string"
+                },
+                "qty": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CoinImportError": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Comment": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "example": "To the moon!"
+                },
+                "coinId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "displayName": {
+                    "description": "Of the author, empty when they didn't set one",
+                    "type": "string"
+                },
+                "editedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "virtualDate": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImportedTrade": {
+            "type": "object",
+            "properties": {
+                "coinId": {
+                    "type": "string",
+                    "example": "bitcoin"
+                },
+                "date": {
+                    "description": "Virtual date of the trade (YYYY-MM-DD)",
+                    "type": "string",
+                    "example": "2013-12-20"
+                },
+                "isBuy": {
+                    "type": "boolean"
+                },
+                "price": {
+                    "type": "number",
+                    "example": 800
+                },
+                "qty": {
+                    "type": "number",
+                    "example": 0.5
+                }
+            }
+        },
+        "models.ListedCoin": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "marketCap": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Bitcoin"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "symbol": {
+                    "type": "string",
+                    "example": "BTC"
+                },
+                "volume24h": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.Notification": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "readAt": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "virtualDate": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.StrategySignal": {
+            "type": "object",
+            "properties": {
+                "buyBelowPct": {
+                    "type": "number",
+                    "example": 5
+                },
+                "sellAbovePct": {
+                    "type": "number",
+                    "example": 5
+                },
+                "type": {
+                    "type": "string",
+                    "example": "sma_cross"
+                },
+                "window": {
+                    "type": "integer",
+                    "example": 20
+                }
+            }
+        },
+        "models.StrategyTrade": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "isBuy": {
+                    "type": "boolean"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "qty": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.Trade": {
+            "type": "object",
+            "properties": {
+                "coinId": {
+                    "type": "string"
+                },
+                "coinName": {
+                    "type": "string"
+                },
+                "coinSymbol": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isBuy": {
+                    "type": "boolean"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "qty": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "number"
+                },
+                "virtualDate": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TradeImportError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
                 }
             }
         }
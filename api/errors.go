@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"govulnapi/api/database"
+)
+
+// Error codes of APIError, one per kind of failure clients may handle
+// differently
+const (
+	codeBadRequest           = "bad_request"
+	codeInvalidJSON          = "invalid_json"
+	codeInvalidXML           = "invalid_xml"
+	codeUnknownFields        = "unknown_fields"
+	codeUnauthorized         = "unauthorized"
+	codeWrongPassword        = "wrong_password"
+	codeForbidden            = "forbidden"
+	codeNotFound             = "not_found"
+	codeMethodNotAllowed     = "method_not_allowed"
+	codeConflict             = "conflict"
+	codeEmailTaken           = "email_taken"
+	codeWrongFlag            = "wrong_flag"
+	codeHintLocked           = "hint_locked"
+	codePreconditionFailed   = "precondition_failed"
+	codePreconditionRequired = "precondition_required"
+	codeInsufficientHistory  = "insufficient_history"
+	codeUnsupportedMediaType = "unsupported_media_type"
+	codeTooManyRequests      = "too_many_requests"
+	codeInternal             = "internal_error"
+	codeNotImplemented       = "not_implemented"
+	codeBadGateway           = "bad_gateway"
+	codeUnavailable          = "unavailable"
+	codeTimeout              = "timeout"
+)
+
+// Body of every error response, Code is also sent as the X-Error-Code header
+type APIError struct {
+	Code    string            `example:"bad_request"`
+	Message string            `example:"Amount needs to be != 0!"`
+	Details map[string]string `json:",omitempty"`
+}
+
+func writeError(w http.ResponseWriter, status int, code string, msg string) {
+	writeAPIError(w, status, APIError{Code: code, Message: msg})
+}
+
+func writeAPIError(w http.ResponseWriter, status int, apiErr APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Error-Code", apiErr.Code)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiErr)
+}
+
+// Answers with 500 for an error the client can't do anything about. The
+// error is logged, and only sent to the client while debug responses are
+// enabled.
+func (a *Api) writeInternalError(w http.ResponseWriter, err error) {
+	log.Println(err)
+
+	if !a.vulnerable(VulnDebugResponses) {
+		writeError(w, http.StatusInternalServerError, codeInternal, "Internal server error!")
+		return
+	}
+
+	// CWE-209: Generation of Error Message Containing Sensitive Information
+	// Error types and messages, the failing SQL statement and the stack
+	// reveal how the API is built and which inputs break its queries
+	details := map[string]string{
+		"error_chain": errorChain(err),
+		"stack":       string(debug.Stack()),
+	}
+	var queryErr *database.QueryError
+	if errors.As(err, &queryErr) {
+		details["sql"] = queryErr.Query
+	}
+	writeAPIError(w, http.StatusInternalServerError, APIError{Code: codeInternal, Message: err.Error(), Details: details})
+}
+
+// Lists the type and message of every error err wraps, one per line
+func errorChain(err error) string {
+	var chain []string
+	for ; err != nil; err = errors.Unwrap(err) {
+		chain = append(chain, fmt.Sprintf("%T: %v", err, err))
+	}
+	return strings.Join(chain, "\n")
+}
+
+// Answers a request whose JSON body couldn't be decoded by decodeJSON
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var unknownErr *unknownFieldsError
+	if errors.As(err, &unknownErr) {
+		writeAPIError(w, http.StatusBadRequest, APIError{
+			Code:    codeUnknownFields,
+			Message: err.Error(),
+			Details: map[string]string{"fields": strings.Join(unknownErr.fields, ",")},
+		})
+		return
+	}
+
+	writeError(w, http.StatusBadRequest, codeInvalidJSON, err.Error())
+}
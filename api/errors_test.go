@@ -0,0 +1,224 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/mock/gomock"
+)
+
+const testJwtSecret = "test-secret-8c1f27d0"
+
+var routeParam = regexp.MustCompile(`\{[^}]+\}`)
+
+func TestSecretNotInResponses(t *testing.T) {
+	for _, debugResponses := range []bool{true, false} {
+		a, _ := NewForTesting(
+			WithJwtSecret(testJwtSecret),
+			WithVulnerabilities(map[string]bool{VulnDebugResponses: debugResponses}),
+		)
+		t.Cleanup(a.Shutdown)
+		tokens := []string{
+			"",
+			login(t, a, "alice@example.com", "password123"),
+			login(t, a, "admin@govulnapi.com", "admin123"),
+		}
+
+		// Every GET route, anonymously, as a user and as an admin
+		leaked := map[string]bool{}
+		err := chi.Walk(a.router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+			if method != http.MethodGet {
+				return nil
+			}
+			path := strings.TrimSuffix(routeParam.ReplaceAllString(route, "1"), "*")
+			for _, token := range tokens {
+				w := serve(a, httptest.NewRequest(http.MethodGet, path, nil), token)
+				if strings.Contains(w.Body.String(), testJwtSecret) || strings.Contains(strings.Join(w.Header().Values("Set-Cookie"), ";"), testJwtSecret) {
+					leaked[route] = true
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if debugResponses && !leaked["/api/debug/vars"] {
+			t.Errorf("the secret isn't in /debug/vars with debug responses, leaked by %v", leaked)
+		}
+		if !debugResponses && len(leaked) > 0 {
+			t.Errorf("the secret is in the responses of %v without debug responses", leaked)
+		}
+	}
+}
+
+func TestSecretNotInErrors(t *testing.T) {
+	for _, debugResponses := range []bool{true, false} {
+		a, repo := newMockedForTesting(t, WithJwtSecret(testJwtSecret), WithVulnerabilities(map[string]bool{VulnDebugResponses: debugResponses}))
+		repo.EXPECT().GetCoinNames().Return(nil, errors.New("connecting with "+testJwtSecret+" failed"))
+
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins", nil), "")
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("answered %d %s, want an internal error", w.Code, w.Body)
+		}
+		if leaked := strings.Contains(w.Body.String(), testJwtSecret); leaked != debugResponses {
+			t.Errorf("debug responses %v: answered %s", debugResponses, w.Body)
+		}
+	}
+}
+
+// Checks that w is the APIError envelope with the code, also sent as
+// X-Error-Code
+func checkAPIError(t *testing.T, name string, w *httptest.ResponseRecorder, code string) {
+	t.Helper()
+
+	if w.Code < 400 {
+		t.Errorf("%s answered %d %s, want %s", name, w.Code, w.Body, code)
+		return
+	}
+	if got := w.Header().Get("X-Error-Code"); got != code {
+		t.Errorf("%s answered with X-Error-Code %q, want %q", name, got, code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("%s answered with Content-Type %q, want application/json", name, got)
+	}
+
+	var apiErr APIError
+	decoder := json.NewDecoder(w.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&apiErr); err != nil {
+		t.Errorf("%s answered %q, not an APIError: %v", name, w.Body, err)
+		return
+	}
+	if apiErr.Code != code || apiErr.Message == "" {
+		t.Errorf("%s answered %+v, want code %s with a message", name, apiErr, code)
+	}
+}
+
+func TestErrorCodesUseEnvelope(t *testing.T) {
+	a, _ := NewForTesting(
+		WithCTFMode(true),
+		WithCoinDetailRateLimit(1),
+		WithVulnerabilities(map[string]bool{VulnNewsPreviewSSRF: true}),
+	)
+	t.Cleanup(a.Shutdown)
+	user := login(t, a, "alice@example.com", "password123")
+	admin := login(t, a, "admin@govulnapi.com", "admin123")
+
+	// Nothing listens on the port anymore
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.Close()
+
+	send := func(method string, path string, contentType string, body string, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api"+path, strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		return serve(a, r, token)
+	}
+	sendJSON := func(method string, path string, body string, token string) *httptest.ResponseRecorder {
+		return send(method, path, "application/json", body, token)
+	}
+	// Watched coins conflict, and the coin detail is rate limited to one
+	// request a minute
+	sendJSON(http.MethodPost, "/coins/bitcoin/watchlist", "", user)
+	serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/bitcoin", nil), "")
+
+	for _, test := range []struct {
+		code    string
+		request func() *httptest.ResponseRecorder
+	}{
+		{codeBadRequest, func() *httptest.ResponseRecorder {
+			return serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/top-gainers?days=many", nil), "")
+		}},
+		{codeInvalidJSON, func() *httptest.ResponseRecorder { return sendJSON(http.MethodPost, "/transfer", `{"ToEmail":`, user) }},
+		{codeInvalidXML, func() *httptest.ResponseRecorder {
+			return send(http.MethodPost, "/transactions/import", "application/xml", "<trades><trade>", user)
+		}},
+		{codeUnknownFields, func() *httptest.ResponseRecorder {
+			return sendJSON(http.MethodPost, "/transfer", `{"ToEmail":"bob@example.com","Amount":1,"Memo":"x"}`, user)
+		}},
+		{codeUnauthorized, func() *httptest.ResponseRecorder {
+			return serve(a, httptest.NewRequest(http.MethodGet, "/api/orders", nil), "")
+		}},
+		{codeWrongPassword, func() *httptest.ResponseRecorder {
+			return sendJSON(http.MethodDelete, "/me", `{"Password":"wrong password"}`, user)
+		}},
+		{codeForbidden, func() *httptest.ResponseRecorder {
+			return serve(a, httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil), user)
+		}},
+		{codeNotFound, func() *httptest.ResponseRecorder {
+			return serve(a, httptest.NewRequest(http.MethodGet, "/api/nothing", nil), "")
+		}},
+		{codeMethodNotAllowed, func() *httptest.ResponseRecorder { return sendJSON(http.MethodPut, "/coins", `{}`, "") }},
+		{codeConflict, func() *httptest.ResponseRecorder {
+			return sendJSON(http.MethodPost, "/coins/bitcoin/watchlist", "", user)
+		}},
+		{codeEmailTaken, func() *httptest.ResponseRecorder {
+			return serve(a, httptest.NewRequest(http.MethodGet, "/api/register?email=alice%40example.com&password=password123", nil), "")
+		}},
+		{codeWrongFlag, func() *httptest.ResponseRecorder {
+			return sendJSON(http.MethodPost, "/ctf/submit", `{"Flag":"govulnapi{wrong}"}`, user)
+		}},
+		{codeHintLocked, func() *httptest.ResponseRecorder {
+			return serve(a, httptest.NewRequest(http.MethodGet, "/api/ctf/challenges/"+VulnSQLInjection+"/hints/2", nil), user)
+		}},
+		{codePreconditionFailed, func() *httptest.ResponseRecorder {
+			return sendJSON(http.MethodPost, "/orders", `{"CoinId":"bitcoin","IsBuy":true,"Qty":1000}`, user)
+		}},
+		{codePreconditionRequired, func() *httptest.ResponseRecorder {
+			return sendJSON(http.MethodPatch, "/admin/coins/bitcoin", `{"Name":"Bitcoin"}`, admin)
+		}},
+		{codeInsufficientHistory, func() *httptest.ResponseRecorder {
+			return serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/bitcoin/moving-average", nil), "")
+		}},
+		{codeUnsupportedMediaType, func() *httptest.ResponseRecorder {
+			return send(http.MethodPost, "/orders", "text/plain", `{"CoinId":"bitcoin","IsBuy":true,"Qty":1}`, user)
+		}},
+		{codeTooManyRequests, func() *httptest.ResponseRecorder {
+			return serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/bitcoin", nil), "")
+		}},
+		{codeBadGateway, func() *httptest.ResponseRecorder {
+			return sendJSON(http.MethodPost, "/coins/bitcoin/news-preview", `{"Url":"http://`+listener.Addr().String()+`/news"}`, user)
+		}},
+		{codeUnavailable, func() *httptest.ResponseRecorder {
+			a.replacing.Store(true)
+			defer a.replacing.Store(false)
+			return serve(a, httptest.NewRequest(http.MethodGet, "/api/coins", nil), "")
+		}},
+		{codeTimeout, func() *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			Timeout(time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			return w
+		}},
+	} {
+		checkAPIError(t, test.code, test.request(), test.code)
+	}
+}
+
+func TestRepositoryErrorCodesUseEnvelope(t *testing.T) {
+	a, repo := newMockedForTesting(t, WithVulnerabilities(map[string]bool{VulnDebugResponses: false}))
+	_, admin, _ := a.tokenAuth().Encode(map[string]interface{}{"user_id": 1, "role": "admin"})
+	repo.EXPECT().GetUserById(1).Return(m.User{Id: 1, Role: "admin"}, nil).AnyTimes()
+	repo.EXPECT().GetCoinNames().Return(nil, errors.New("database is locked"))
+	repo.EXPECT().Backup(gomock.Any()).Return(database.ErrBackupUnsupported)
+
+	checkAPIError(t, codeInternal, serve(a, httptest.NewRequest(http.MethodGet, "/api/coins", nil), ""), codeInternal)
+	checkAPIError(t, codeNotImplemented, serve(a, httptest.NewRequest(http.MethodGet, "/api/admin/backup", nil), admin), codeNotImplemented)
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	m "govulnapi/models"
+	pb "govulnapi/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Serves the coins of the API over gRPC, see proto/coins.proto
+type coinGrpcService struct {
+	pb.UnimplementedCoinsServer
+	api *Api
+}
+
+// CWE-319: Cleartext Transmission of Sensitive Information
+// gRPC is served without TLS, like the HTTP API by default
+func newGrpcServer(a *Api) *grpc.Server {
+	server := grpc.NewServer()
+	pb.RegisterCoinsServer(server, coinGrpcService{api: a})
+	return server
+}
+
+func (s coinGrpcService) GetCoin(ctx context.Context, req *pb.GetCoinRequest) (*pb.Coin, error) {
+	coin, err := s.api.getCoin(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	coins, err := s.protoCoins([]m.Coin{coin})
+	if err != nil {
+		return nil, err
+	}
+	return coins[0], nil
+}
+
+func (s coinGrpcService) ListCoins(ctx context.Context, req *pb.ListCoinsRequest) (*pb.ListCoinsResponse, error) {
+	s.api.coinsMu.RLock()
+	coins := append([]m.Coin{}, s.api.coins...)
+	s.api.coinsMu.RUnlock()
+
+	listed, err := s.protoCoins(coins)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListCoinsResponse{Coins: listed}, nil
+}
+
+func (s coinGrpcService) StreamPrices(req *pb.StreamPricesRequest, stream pb.Coins_StreamPricesServer) error {
+	wanted := make(map[string]bool, len(req.GetIds()))
+	for _, id := range req.GetIds() {
+		if _, ok := s.api.trackedCoin(id); !ok {
+			return status.Errorf(codes.NotFound, "Coin %s doesn't exist!", id)
+		}
+		wanted[id] = true
+	}
+
+	// Subscribed before the current prices are read, so no refresh is missed
+	updates, unsubscribe := s.api.priceFeed.subscribe()
+	defer unsubscribe()
+
+	s.api.coinsMu.RLock()
+	coins := append([]m.Coin{}, s.api.coins...)
+	s.api.coinsMu.RUnlock()
+
+	for {
+		var filtered []m.Coin
+		for _, coin := range coins {
+			if len(wanted) == 0 || wanted[coin.Id] {
+				filtered = append(filtered, coin)
+			}
+		}
+		listed, err := s.protoCoins(filtered)
+		if err != nil {
+			return err
+		}
+		update := &pb.PriceUpdate{VirtualDate: s.api.virtualDate().Format(time.DateOnly), Coins: listed}
+		if err = stream.Send(update); err != nil {
+			return err
+		}
+
+		select {
+		case coins = <-updates:
+		case <-stream.Context().Done():
+			return nil
+		case <-s.api.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Converts the coins with their names and symbols
+func (s coinGrpcService) protoCoins(coins []m.Coin) ([]*pb.Coin, error) {
+	listed, err := s.api.listCoins(coins)
+	if err != nil {
+		log.Println(err)
+		return nil, status.Error(codes.Internal, "Internal server error!")
+	}
+
+	converted := make([]*pb.Coin, 0, len(listed))
+	for _, coin := range listed {
+		converted = append(converted, &pb.Coin{
+			Id:         coin.Id,
+			Name:       coin.Name,
+			Symbol:     coin.Symbol,
+			Price:      coin.Price.Float64(),
+			MarketCap:  coin.MarketCap,
+			Volume_24H: coin.Volume24h,
+		})
+	}
+	return converted, nil
+}
+
+// Hands the coins of every price refresh to the StreamPrices calls
+type priceFeed struct {
+	mu          sync.Mutex
+	subscribers map[chan []m.Coin]struct{}
+}
+
+func (f *priceFeed) subscribe() (updates <-chan []m.Coin, unsubscribe func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.subscribers == nil {
+		f.subscribers = map[chan []m.Coin]struct{}{}
+	}
+	ch := make(chan []m.Coin, 1)
+	f.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.subscribers, ch)
+	}
+}
+
+// Never blocks, subscribers that didn't receive the previous coins yet only
+// get the latest ones
+func (f *priceFeed) publish(coins []m.Coin) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- coins
+	}
+}
+
+// Stops the gRPC server once its calls returned, or right away when ctx is
+// done first
+func (a *Api) stopGrpc(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		a.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		a.grpcServer.Stop()
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"govulnapi/mockgecko"
+	pb "govulnapi/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Serves the api over gRPC on an in-memory listener and connects a client
+func newGrpcClient(t *testing.T, a *Api) pb.CoinsClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := newGrpcServer(a)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewCoinsClient(conn)
+}
+
+func TestGrpcGetCoin(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	client := newGrpcClient(t, a)
+	ctx := context.Background()
+
+	for _, id := range []string{"bitcoin", "BTC"} {
+		coin, err := client.GetCoin(ctx, &pb.GetCoinRequest{Id: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if coin.Id != "bitcoin" || coin.Name != "Bitcoin" || coin.Symbol != "BTC" || coin.Price != 800 {
+			t.Errorf("got %v for %s, want bitcoin at 800", coin, id)
+		}
+	}
+
+	if _, err := client.GetCoin(ctx, &pb.GetCoinRequest{Id: "nocoin"}); status.Code(err) != codes.NotFound {
+		t.Errorf("got %v for a missing coin, want NotFound", err)
+	}
+}
+
+func TestGrpcListCoins(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	client := newGrpcClient(t, a)
+
+	list, err := client.ListCoins(context.Background(), &pb.ListCoinsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Coins) != len(TestPrices) {
+		t.Fatalf("listed %d coins, want %d", len(list.Coins), len(TestPrices))
+	}
+	for i, coin := range list.Coins {
+		if coin.Id != TestPrices[i].Id || coin.Price != TestPrices[i].Price.Float64() || coin.Name == "" {
+			t.Errorf("listed %v, want %s named at %v", coin, TestPrices[i].Id, TestPrices[i].Price)
+		}
+	}
+}
+
+func TestGrpcStreamPrices(t *testing.T) {
+	prices := mockgecko.New()
+	a, _ := NewForTesting(WithPriceProvider(prices))
+	t.Cleanup(a.Shutdown)
+	client := newGrpcClient(t, a)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.StreamPrices(ctx, &pb.StreamPricesRequest{Ids: []string{"bitcoin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The current prices come first, then the ones of every refresh
+	for day := 0; day < 3; day++ {
+		if day > 0 {
+			a.advanceDays(1)
+		}
+		update, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		date := a.virtualDate()
+		want, err := prices.Prices(ctx, date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if update.VirtualDate != date.Format(time.DateOnly) || len(update.Coins) != 1 ||
+			update.Coins[0].Id != "bitcoin" || update.Coins[0].Price != want[0].Price.Float64() {
+			t.Errorf("got %v on day %d, want bitcoin at %v on %s", update, day, want[0].Price, date.Format(time.DateOnly))
+		}
+	}
+
+	stream, err = client.StreamPrices(ctx, &pb.StreamPricesRequest{Ids: []string{"nocoin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stream.Recv(); status.Code(err) != codes.NotFound {
+		t.Errorf("got %v streaming a missing coin, want NotFound", err)
+	}
+}
@@ -1,32 +1,108 @@
 package api
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"govulnapi/api/database"
 	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
 )
 
 // @Summary		  Coin data
-// @Description	Get data for coins
+// @Description	Get data for coins along with their name and symbol. Fields limits every coin to the named fields, e.g. id,name.
 // @Tags			  Coins
 // @Produce		  json
-// @Success	   	200	"ok"
-// @Failure	    500	"internal server error"
+// @Param		    sort	query		string	false	"Sort order"	Enums(market_cap_desc)
+// @Param		    fields	query		string	false	"Comma separated fields to keep, all by default"
+// @Success	   	200	{object}	m.CoinList
+// @Failure	    400	{object}	APIError	"invalid sort order or unknown field"
+// @Failure	    500	{object}	APIError	"internal server error"
 // @Router			/coins [get]
 func (s *Api) getCoins(w http.ResponseWriter, r *http.Request) {
-	var response []byte
-	coins, err := json.Marshal(s.coins)
+	s.coinsMu.RLock()
+	sorted := append([]m.Coin{}, s.coins...)
+	s.coinsMu.RUnlock()
+
+	switch r.FormValue("sort") {
+	case "":
+	case "market_cap_desc":
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].MarketCap > sorted[j].MarketCap
+		})
+	default:
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Sort needs to be 'market_cap_desc'!")
+		return
+	}
 
+	listed, err := s.listCoins(sorted)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		response = []byte(err.Error())
-	} else {
+		s.writeInternalError(w, err)
+		return
+	}
+	list := m.CoinList{Data: listed, Count: len(listed)}
+
+	if r.FormValue("fields") == "" {
 		w.Header().Set("Content-Type", "application/json")
-		response = coins
+		json.NewEncoder(w).Encode(list)
+		return
 	}
 
-	w.Write(response)
+	sparse, err := sparseFields(list.Data, strings.Split(r.FormValue("fields"), ","))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Data  []map[string]json.RawMessage `json:"data"`
+		Count int                          `json:"count"`
+	}{sparse, list.Count})
+}
+
+// @Summary		  Coin detail
+// @Description	Get data for a coin along with its name, symbol and price change since the previous virtual day
+// @Tags			  Coins
+// @Produce		  json
+// @Param		    id	path		string	true	"Coin id or symbol"
+// @Success	   	200	{object}	m.CoinDetail
+// @Failure	    400	{object}	APIError	"malformed coin id or symbol"
+// @Failure	    404	{object}	APIError	"requested coin not found"
+// @Failure	    429	{object}	APIError	"too many requests"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/{id} [get]
+func (a *Api) getCoinDetail(w http.ResponseWriter, r *http.Request) {
+	coinId := chi.URLParam(r, "id")
+	if len(coinId) > 64 || (!coinIdPattern.MatchString(coinId) && !coinSymbolPattern.MatchString(strings.ToUpper(coinId))) {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Coin needs to be an id like bitcoin or a symbol like BTC!")
+		return
+	}
+
+	coin, err := a.getCoin(coinId)
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	detail, err := a.coinDetail(coin)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
 }
 
 // @Summary		  Get coin balances
@@ -34,7 +110,7 @@ func (s *Api) getCoins(w http.ResponseWriter, r *http.Request) {
 // @Tags		    Trading
 // @Produce	    json
 // @Success	    200	"ok"
-// @Failure	    401	"unauthorized"
+// @Failure	    401	{object}	APIError	"unauthorized"
 // @Router			/balances/coin [get]
 // @Security		Bearer
 func (s *Api) getCoinBalances(w http.ResponseWriter, r *http.Request) {
@@ -49,14 +125,14 @@ func (s *Api) getCoinBalances(w http.ResponseWriter, r *http.Request) {
 // @Tags		    Trading
 // @Produce	    json
 // @Success	    200	"ok"
-// @Failure	    401	"unauthorized"
+// @Failure	    401	{object}	APIError	"unauthorized"
 // @Router			/balances/usd [get]
 // @Security		Bearer
 func (s *Api) getUsdBalances(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value("user").(m.User)
 
 	w.Header().Set("Content-Type", "application/json")
-	usdBalances := map[string]float64{
+	usdBalances := map[string]m.Usd{
 		"UsdBalance":         user.UsdBalance,
 		"UsdStartingBalance": user.UsdStartingBalance,
 	}
@@ -64,37 +140,177 @@ func (s *Api) getUsdBalances(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary		  Get past orders
-// @Description	Fetches past orders
+// @Description	Fetches past orders newest first, one page at a time
 // @Tags		    Trading
 // @Produce	    json
+// @Param		    coin_id	query		string	false	"Coin id"
+// @Param		    side		query		string	false	"buy or sell"
+// @Param		    from		query		string	false	"First virtual date (YYYY-MM-DD)"
+// @Param		    to			query		string	false	"Last virtual date (YYYY-MM-DD)"
+// @Param		    cursor	query		string	false	"NextCursor of the previous page"
+// @Param		    limit		query		int			false	"Page size (max 100)"
 // @Success	    200	"ok"
-// @Failure	    401	"unauthorized"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    500	{object}	APIError	"internal server error"
 // @Router			/orders [get]
 // @Security		Bearer
 func (s *Api) getOrders(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value("user").(m.User)
 
+	filter, err := parseOrderFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	orders, err := s.repo(r).GetOrders(user.Id, filter)
+	if err != nil {
+		s.writeInternalError(w, err)
+		return
+	}
+
+	page := m.OrderPage{Orders: orders}
+	if len(orders) == filter.Limit {
+		last := orders[len(orders)-1]
+		page.NextCursor = encodeCursor(cursor{VirtualDate: last.VirtualDate, Id: last.Id})
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(user.Orders)
+	json.NewEncoder(w).Encode(page)
+}
+
+// @Summary		  Export past orders
+// @Description	Downloads past orders newest first as CSV, with the usd balance after each of them
+// @Tags		    Trading
+// @Produce	    text/csv
+// @Param		    coin_id	query		string	false	"Coin id"
+// @Param		    side		query		string	false	"buy or sell"
+// @Param		    from		query		string	false	"First virtual date (YYYY-MM-DD)"
+// @Param		    to			query		string	false	"Last virtual date (YYYY-MM-DD)"
+// @Success	    200	"ok"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Router			/transactions/export [get]
+// @Security		Bearer
+func (s *Api) exportTransactions(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	filter, err := parseExportFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="transactions.csv"`)
+
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write([]string{"id", "virtual_date", "coin", "side", "qty", "unit_price", "total", "usd_balance_after"})
+
+	err = s.repo(r).ExportOrders(user.Id, filter, func(row m.OrderExportRow) error {
+		// Stops once the client is gone or the deadline passed
+		if err := r.Context().Err(); err != nil {
+			return err
+		}
+
+		side := "sell"
+		if row.IsBuy {
+			side = "buy"
+		}
+		return csvWriter.Write([]string{
+			strconv.Itoa(row.Id),
+			row.VirtualDate,
+			row.CoinId,
+			side,
+			strconv.FormatFloat(row.Qty, 'f', -1, 64),
+			row.Price.String(),
+			row.Total.String(),
+			row.BalanceAfter.String(),
+		})
+	})
+	// Headers are already sent, so errors can only be logged
+	if err != nil {
+		log.Println(err)
+	}
+
+	csvWriter.Flush()
+}
+
+// Parses the filters of exports, which aren't paged
+func parseExportFilter(r *http.Request) (database.OrderFilter, error) {
+	filter := database.OrderFilter{
+		CoinId: r.FormValue("coin_id"),
+		From:   r.FormValue("from"),
+		To:     r.FormValue("to"),
+	}
+
+	switch r.FormValue("side") {
+	case "":
+	case "buy":
+		isBuy := true
+		filter.IsBuy = &isBuy
+	case "sell":
+		isBuy := false
+		filter.IsBuy = &isBuy
+	default:
+		return filter, errors.New("Side needs to be 'buy' or 'sell'!")
+	}
+
+	for _, date := range []string{filter.From, filter.To} {
+		if _, err := time.Parse(time.DateOnly, date); date != "" && err != nil {
+			return filter, errors.New("Dates need to be in YYYY-MM-DD format!")
+		}
+	}
+
+	return filter, nil
+}
+
+func parseOrderFilter(r *http.Request) (database.OrderFilter, error) {
+	filter, err := parseExportFilter(r)
+	if err != nil {
+		return filter, err
+	}
+	filter.Limit = 50
+
+	if token := r.FormValue("cursor"); token != "" {
+		c, err := decodeCursor(token)
+		if err != nil {
+			return filter, err
+		}
+		filter.AfterVirtualDate = c.VirtualDate
+		filter.AfterId = c.Id
+	}
+
+	if limit := r.FormValue("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 100 {
+			return filter, errors.New("Limit needs to be between 1 and 100!")
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
 }
 
 // @Summary		  Buy/sell coins
-// @Description	Creates new buy/sell order
+// @Description	Creates new buy/sell order, filled at the current price unless it has a limit price the price hasn't reached yet
 // @Tags		    Trading
 // @Accept	    json
 // @Produce	    plain
 // @Param		    order	body		m.Order	true	"New order"
-// @Success	    200	"order went through"
-// @Failure	    401	"unauthorized"
-// @Failure	    404	"requested coin not found"
-// @Failure	    500	"internal server error"
+// @Param		    Idempotency-Key	header	string	false	"UUID identifying retries of the same order"
+// @Success	    200	"order went through, limit order placed or retried order"
+// @Failure	    400	{object}	APIError	"unknown fields in request body or invalid idempotency key"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"requested coin not found"
+// @Failure	    409	{object}	APIError	"order with the same idempotency key still in progress"
+// @Failure	    412	{object}	APIError	"not enough usd or coin"
+// @Failure	    500	{object}	APIError	"internal server error"
 // @Router			/orders [post]
 // @Security		Bearer
 func (s *Api) addOrder(w http.ResponseWriter, r *http.Request) {
-	var (
-		response = "Order successfully made!"
-		user     = r.Context().Value("user").(m.User)
-	)
+	user := r.Context().Value("user").(m.User)
 
 	var order m.Order
 	// CWE-472: External Control of Assumed-Immutable Web Parameter
@@ -106,19 +322,140 @@ func (s *Api) addOrder(w http.ResponseWriter, r *http.Request) {
 	order.UserId = user.Id
 
 	// CWE-20: Improper Input Validation
-	json.NewDecoder(r.Body).Decode(&order)
+	// Only unknown fields are rejected, malformed values are ignored
+	err := s.decodeJSON(r, &order)
+	var unknownErr *unknownFieldsError
+	if errors.As(err, &unknownErr) {
+		writeDecodeError(w, err)
+		return
+	}
 
 	coin, err := s.getCoin(order.CoinId)
+	virtualDate := s.virtualDate()
 
 	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		response = err.Error()
-	} else if err = s.db.AddOrder(order.UserId, coin.Id, coin.Price, order.IsBuy, order.Qty); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		response = err.Error()
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+	if order.LimitPrice < 0 {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Limit price needs to be > 0!")
+		return
+	}
+	// Limit orders the price already reached are filled right away, the
+	// others rest in the order book
+	if order.LimitPrice > 0 && (order.IsBuy && coin.Price > order.LimitPrice || !order.IsBuy && coin.Price < order.LimitPrice) {
+		s.addLimitOrder(w, r, order, virtualDate)
+		return
+	}
+
+	err = s.repo(r).AddOrder(order.UserId, coin.Id, coin.Price, order.IsBuy, order.Qty, virtualDate)
+	if errors.Is(err, database.ErrNotEnoughUsd) || errors.Is(err, database.ErrNotEnoughCoin) {
+		writeError(w, http.StatusPreconditionFailed, codePreconditionFailed, err.Error())
+		return
+	} else if err != nil {
+		s.writeInternalError(w, err)
+		return
+	}
+
+	// Orders are filled immediately at the current price
+	order.CoinId, order.Price = coin.Id, coin.Price
+	order.VirtualDate, order.Status = virtualDate.Format(time.DateOnly), "filled"
+	if err = s.Notify(order.UserId, notificationOrderFilled, order); err != nil {
+		log.Println(err)
 	}
 
-	w.Write([]byte(response))
+	w.Write([]byte("Order successfully made!"))
+}
+
+func (s *Api) addLimitOrder(w http.ResponseWriter, r *http.Request, order m.Order, virtualDate time.Time) {
+	if order.Qty <= 0 {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Quantity needs to be > 0!")
+		return
+	}
+
+	_, err := s.repo(r).AddLimitOrder(order.UserId, order.CoinId, order.LimitPrice, order.IsBuy, order.Qty, virtualDate)
+	if errors.Is(err, database.ErrNotEnoughUsd) || errors.Is(err, database.ErrNotEnoughCoin) {
+		writeError(w, http.StatusPreconditionFailed, codePreconditionFailed, err.Error())
+		return
+	} else if err != nil {
+		s.writeInternalError(w, err)
+		return
+	}
+
+	w.Write([]byte("Limit order placed!"))
+}
+
+// @Summary		  Cancel order
+// @Description	Cancels an open limit order and gives back the usd or coin set aside for it
+// @Tags		    Trading
+// @Produce	    json
+// @Param		    id	path		int	true	"Order id"
+// @Success	    200	{object}	m.Order
+// @Failure	    400	{object}	APIError	"invalid order id"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"open order not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/orders/{id} [delete]
+// @Security		Bearer
+func (s *Api) cancelOrder(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	orderId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Order id needs to be a number!")
+		return
+	}
+
+	order, err := s.repo(r).CancelOrder(user.Id, orderId)
+	if errors.Is(err, database.ErrOrderNotFound) {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	} else if err != nil {
+		s.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// @Summary		  Import trades
+// @Description	Backfills past trades into the account, as JSON or as an XML document like
+// @Description	<trades><trade><coinId>bitcoin</coinId><isBuy>true</isBuy><qty>0.5</qty><price>800</price><date>2013-12-20</date></trade></trades>.
+// @Description	Every trade is checked like a live order, trades that fail are listed with their row.
+// @Tags		    Transactions
+// @Accept	    json
+// @Accept	    xml
+// @Produce	    json
+// @Param		    trades	body m.TradeImport	true	"Trades"
+// @Success	    200	{object}	m.TradeImportResult
+// @Failure	    400	{object}	APIError	"invalid document"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Router			/transactions/import [post]
+// @Security		Bearer
+func (a *Api) importTransactions(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	var doc m.TradeImport
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		if err := a.decodeJSON(r, &doc); err != nil {
+			writeDecodeError(w, err)
+			return
+		}
+	} else {
+		body, err := io.ReadAll(io.LimitReader(r.Body, importMaxBytes))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+			return
+		}
+		if doc, err = a.decodeTradeImportXML(body); err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidXML, err.Error())
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.importTrades(a.repo(r), user.Id, doc.Trades))
 }
 
 // @Summary		  Get past transactions
@@ -126,14 +463,64 @@ func (s *Api) addOrder(w http.ResponseWriter, r *http.Request) {
 // @Tags		    Transactions
 // @Produce	    json
 // @Success	    200	"ok"
-// @Failure	    401	"unauthorized"
+// @Failure	    401	{object}	APIError	"unauthorized"
 // @Router			/transactions [get]
 // @Security		Bearer
-func (s *Api) getTransactions(w http.ResponseWriter, r *http.Request) {
+func (a *Api) getTransactions(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value("user").(m.User)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(user.Transactions)
+	json.NewEncoder(w).Encode(a.transactionsView(user.Transactions))
+}
+
+// @Summary		  Get transaction
+// @Description	Fetches a transaction the user sent or received by its id, a sequential number or a random UUID depending on the lab configuration
+// @Tags		    Transactions
+// @Produce	    json
+// @Param		    id	path		string	true	"Transaction id"
+// @Success	    200	{object}	m.Transaction
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"transaction not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/transactions/{id} [get]
+// @Security		Bearer
+func (a *Api) getTransaction(w http.ResponseWriter, r *http.Request) {
+	var (
+		user        = r.Context().Value("user").(m.User)
+		id          = chi.URLParam(r, "id")
+		transaction m.Transaction
+		err         error
+	)
+
+	// CWE-639: Authorization Bypass Through User-Controlled Key
+	// Transactions are looked up by their sequential id without checking who
+	// they belong to, so counting ids up reads every user's transactions
+	if a.vulnerable(VulnTransactionIDOR) {
+		transactionId, convErr := strconv.Atoi(id)
+		if convErr != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Transaction id needs to be a number!")
+			return
+		}
+		transaction, err = a.repo(r).GetTransaction(transactionId)
+	} else {
+		transaction, err = a.repo(r).GetTransactionByPublicId(id)
+		// Transactions of other users are reported as missing, so their ids
+		// can't be told apart from ones that don't exist
+		if err == nil && !canAccessUser(user, transaction.SenderId, transaction.ReceiverId) {
+			err = database.ErrTransactionNotFound
+		}
+	}
+	if errors.Is(err, database.ErrTransactionNotFound) {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	} else if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.transactionsView([]m.Transaction{transaction})[0])
 }
 
 // @Summary		  Send coins
@@ -143,30 +530,227 @@ func (s *Api) getTransactions(w http.ResponseWriter, r *http.Request) {
 // @Produce	    plain
 // @Param		    transaction	body m.Transaction	true	"New transaction"
 // @Success	    200	"transaction went through"
-// @Failure	    400	"bad request"
-// @Failure	    401	"unauthorized"
-// @Failure	    412	"not enough coin"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    412	{object}	APIError	"not enough coin"
 // @Router			/transactions [post]
 // @Security		Bearer
 func (a *Api) addTransaction(w http.ResponseWriter, r *http.Request) {
-	var (
-		response = "Transaction successfully made!"
-		user     = r.Context().Value("user").(m.User)
-	)
+	user := r.Context().Value("user").(m.User)
 
 	var transaction m.Transaction
-	err := json.NewDecoder(r.Body).Decode(&transaction)
+	if err := a.decodeJSON(r, &transaction); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
 
+	err := a.repo(r).AddTransaction(user.Id, transaction.CoinId, transaction.Address, transaction.Qty, transaction.Note)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		response = err.Error()
-	} else {
-		err := a.db.AddTransaction(user.Id, transaction.CoinId, transaction.Address, transaction.Qty, transaction.Note)
-		if err != nil {
-			w.WriteHeader(http.StatusPreconditionFailed)
-			response = err.Error()
+		writeError(w, http.StatusPreconditionFailed, codePreconditionFailed, err.Error())
+		return
+	}
+
+	w.Write([]byte("Transaction successfully made!"))
+}
+
+// @Summary		  Order book
+// @Description	Get open buy and sell interest for a coin aggregated by price level
+// @Tags			  Coins
+// @Produce		  json
+// @Param		    id		path		string	true	"Coin id"
+// @Param		    include_users	query		bool	false	"Include emails of users with open orders (vulnerable mode only)"
+// @Success	   	200	"ok"
+// @Failure	    404	{object}	APIError	"requested coin not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/{id}/orderbook [get]
+func (a *Api) getOrderBook(w http.ResponseWriter, r *http.Request) {
+	coin, err := a.getCoin(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	// CWE-200: Exposure of Sensitive Information to an Unauthorized Actor
+	// Anyone can list who has open orders on a coin
+	includeUsers := a.vulnerable(VulnOrderBookUsers) && r.FormValue("include_users") == "true"
+
+	levels, err := a.repo(r).GetOrderBook(coin.Id, includeUsers)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	orderBook := m.OrderBook{
+		CoinId:      coin.Id,
+		MarketPrice: coin.Price,
+		Bids:        []m.OrderBookLevel{},
+		Asks:        []m.OrderBookLevel{},
+	}
+	for _, level := range levels {
+		if level.IsBuy {
+			orderBook.Bids = append(orderBook.Bids, level)
+		} else {
+			orderBook.Asks = append(orderBook.Asks, level)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orderBook)
+}
+
+// @Summary		  Similar coins
+// @Description	Get coins whose recent daily prices correlate the most with the coin
+// @Tags			  Coins
+// @Produce		  json
+// @Param		    id	path		string	true	"Coin id"
+// @Success	    200	{array}	m.SimilarCoin
+// @Failure	    404	{object}	APIError	"requested coin not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/{id}/similar [get]
+func (a *Api) getSimilarCoins(w http.ResponseWriter, r *http.Request) {
+	coin, err := a.getCoin(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	similar, err := a.similarCoins(coin.Id)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(similar)
+}
+
+// @Summary		  Moving average
+// @Description	Get the simple moving average of the coin's daily closing prices up to the current virtual date
+// @Tags			  Coins
+// @Produce		  json
+// @Param		    id		path		string	true	"Coin id"
+// @Param		    window	query		int		false	"Virtual days averaged (default 7, min 2, max 200)"
+// @Success	    200	{object}	m.MovingAverage
+// @Failure	    400	{object}	APIError	"invalid window"
+// @Failure	    404	{object}	APIError	"requested coin not found"
+// @Failure	    422	{object}	APIError	"not enough price history"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/{id}/moving-average [get]
+func (a *Api) getMovingAverage(w http.ResponseWriter, r *http.Request) {
+	window := movingAverageWindow
+	if value := r.FormValue("window"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < movingAverageMinWindow || n > movingAverageMaxWindow {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Window needs to be between 2 and 200!")
+			return
 		}
+		window = n
+	}
+
+	coin, err := a.getCoin(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	average, ok, err := a.movingAverage(coin.Id, window)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusUnprocessableEntity, codeInsufficientHistory, "Not enough price history!")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(average)
+}
+
+// @Summary		  Price change
+// @Description	Get the percentage change of the coin's closing price over 1, 7, 30 and 90 virtual days, null for windows without enough price history
+// @Tags			  Coins
+// @Produce		  json
+// @Param		    id	path		string	true	"Coin id"
+// @Success	    200	{object}	m.PriceChange
+// @Failure	    404	{object}	APIError	"requested coin not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/{id}/price-change [get]
+func (a *Api) getPriceChange(w http.ResponseWriter, r *http.Request) {
+	coin, err := a.getCoin(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
 	}
 
-	w.Write([]byte(response))
+	change, err := a.priceChange(coin.Id)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(change)
+}
+
+// @Summary		  Top gainers
+// @Description	Get coins with the largest price increase over the last days
+// @Tags			  Coins
+// @Produce		  json
+// @Param		    days	query		int	false	"Virtual days to compare against (default 7, max 90)"
+// @Success	    200	{array}	m.CoinChange
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    404	{object}	APIError	"not enough price history"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/top-gainers [get]
+func (a *Api) getTopGainers(w http.ResponseWriter, r *http.Request) {
+	a.writeTopMovers(w, r, false)
+}
+
+// @Summary		  Top losers
+// @Description	Get coins with the largest price decrease over the last days
+// @Tags			  Coins
+// @Produce		  json
+// @Param		    days	query		int	false	"Virtual days to compare against (default 7, max 90)"
+// @Success	    200	{array}	m.CoinChange
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    404	{object}	APIError	"not enough price history"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/top-losers [get]
+func (a *Api) getTopLosers(w http.ResponseWriter, r *http.Request) {
+	a.writeTopMovers(w, r, true)
+}
+
+func (a *Api) writeTopMovers(w http.ResponseWriter, r *http.Request, losers bool) {
+	days := topMoversDays
+	if value := r.FormValue("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > topMoversMaxDays {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Days needs to be between 1 and 90!")
+			return
+		}
+		days = n
+	}
+
+	changes, ok, err := a.coinChanges(days)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, codeNotFound, "Not enough price history!")
+		return
+	}
+
+	if losers {
+		for i, j := 0, len(changes)-1; i < j; i, j = i+1, j-1 {
+			changes[i], changes[j] = changes[j], changes[i]
+		}
+	}
+	if len(changes) > topMoversCount {
+		changes = changes[:topMoversCount]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
 }
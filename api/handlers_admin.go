@@ -0,0 +1,914 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth/v5"
+)
+
+// @Summary		  Override coin price
+// @Description	Sets the price of a coin until the next price refresh
+// @Tags		    Admin
+// @Accept	    json
+// @Produce	    json
+// @Param		    id		path		string	true	"Coin id"
+// @Param		    coin	body		m.Coin	true	"New price"
+// @Success	    200	"ok"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    404	{object}	APIError	"requested coin not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/{id}/price [post]
+// @Security		Bearer
+func (a *Api) overrideCoinPrice(w http.ResponseWriter, r *http.Request) {
+	var newCoin m.Coin
+	if err := a.decodeJSON(r, &newCoin); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if newCoin.Price <= 0 {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Price needs to be > 0!")
+		return
+	}
+
+	coin, err := a.setCoinPrice(chi.URLParam(r, "id"), newCoin.Price)
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	if err = a.db.AddPriceHistory([]m.Coin{coin}, a.virtualDate(), true); err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+	a.history.invalidate()
+
+	if err = a.fillLimitOrders([]m.Coin{coin}, a.virtualDate()); err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+	if err = a.triggerPriceAlerts([]m.Coin{coin}, a.virtualDate()); err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(coin)
+}
+
+// @Summary		  Runtime statistics
+// @Description	Get a snapshot of runtime statistics
+// @Tags		    Admin
+// @Produce	    json
+// @Success	    200	"ok"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Router			/admin/stats [get]
+// @Security		Bearer
+func (a *Api) getStats(w http.ResponseWriter, r *http.Request) {
+	a.coinsMu.RLock()
+	coinsTracked := len(a.coins)
+	virtualDate := a.currentDate
+	a.coinsMu.RUnlock()
+
+	var lastRefresh string
+	if nanos := a.metrics.lastRefresh.Load(); nanos != 0 {
+		lastRefresh = time.Unix(0, nanos).UTC().Format(time.RFC3339)
+	}
+
+	dbStats := a.db.Stats()
+	stats := map[string]interface{}{
+		"CoinsTracked":       coinsTracked,
+		"VirtualDate":        virtualDate.Format(time.DateOnly),
+		"RequestsTotal":      a.metrics.requests.Load(),
+		"AvgLatencyMs":       float64(a.metrics.averageLatency().Microseconds()) / 1000,
+		"DbOpenConnections":  dbStats.OpenConnections,
+		"DbInUse":            dbStats.InUse,
+		"DbIdle":             dbStats.Idle,
+		"DbWaitCount":        dbStats.WaitCount,
+		"LastRefresh":        lastRefresh,
+		"HistoryCacheHits":   a.metrics.historyCacheHits.Load(),
+		"HistoryCacheMisses": a.metrics.historyCacheMisses.Load(),
+		"Panics":             a.metrics.panics.Load(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// Options that can be changed at runtime, others need a restart
+type reloadableOptions struct {
+	DayDuration          *string `example:"30s"`
+	VulnerableMode       *bool
+	Vulnerabilities      map[string]bool `example:"sql_injection:false"`
+	DailyDepositLimit    *float64
+	DailyWithdrawalLimit *float64
+	StrictJSONParsing    *bool
+}
+
+// @Summary		  Reload options
+// @Description	Changes options that are safe to modify while running
+// @Tags		    Admin
+// @Accept	    json
+// @Produce	    json
+// @Param		    options	body		reloadableOptions	true	"Options to change"
+// @Success	    200	"ok"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Router			/admin/reload-config [post]
+// @Security		Bearer
+func (a *Api) reloadConfig(w http.ResponseWriter, r *http.Request) {
+	var (
+		changes     reloadableOptions
+		dayDuration time.Duration
+		err         error
+	)
+
+	if err = a.decodeJSON(r, &changes); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if changes.DayDuration != nil {
+		dayDuration, err = time.ParseDuration(*changes.DayDuration)
+		if err != nil || dayDuration <= 0 {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Day duration needs to be a positive duration!")
+			return
+		}
+	}
+
+	for name := range changes.Vulnerabilities {
+		if !isVulnerability(name) {
+			writeError(w, http.StatusBadRequest, codeBadRequest, fmt.Sprintf("Unknown vulnerability %s!", name))
+			return
+		}
+	}
+
+	for _, limit := range []*float64{changes.DailyDepositLimit, changes.DailyWithdrawalLimit} {
+		if limit != nil && *limit <= 0 {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Daily limits need to be > 0!")
+			return
+		}
+	}
+
+	options := a.updateOptions(func(o *Options) {
+		if changes.DayDuration != nil {
+			o.DayDuration = dayDuration
+		}
+		if changes.VulnerableMode != nil {
+			o.VulnerableMode = *changes.VulnerableMode
+		}
+		if changes.Vulnerabilities != nil {
+			o.Vulnerabilities = mergeVulnerabilities(o.Vulnerabilities, changes.Vulnerabilities)
+		}
+		if changes.DailyDepositLimit != nil {
+			o.DailyDepositLimit = *changes.DailyDepositLimit
+		}
+		if changes.DailyWithdrawalLimit != nil {
+			o.DailyWithdrawalLimit = *changes.DailyWithdrawalLimit
+		}
+		if changes.StrictJSONParsing != nil {
+			o.StrictJSONParsing = *changes.StrictJSONParsing
+		}
+	})
+
+	dayDurationStr := options.DayDuration.String()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reloadableOptions{
+		DayDuration:          &dayDurationStr,
+		VulnerableMode:       &options.VulnerableMode,
+		Vulnerabilities:      options.Vulnerabilities,
+		DailyDepositLimit:    &options.DailyDepositLimit,
+		DailyWithdrawalLimit: &options.DailyWithdrawalLimit,
+		StrictJSONParsing:    &options.StrictJSONParsing,
+	})
+}
+
+// @Summary		  Vulnerabilities
+// @Description	Get the vulnerabilities that can be toggled, along with their CWE, affected routes and whether they're enabled
+// @Tags		    Admin
+// @Produce	    json
+// @Success	    200	{array}		m.Vulnerability
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Router			/admin/vulnerabilities [get]
+// @Security		Bearer
+func (a *Api) getVulnerabilities(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.vulnerabilityCatalog())
+}
+
+// @Summary		  Toggle vulnerability
+// @Description	Enables or disables a vulnerability, the setting is kept across restarts and takes precedence over the configuration
+// @Tags		    Admin
+// @Accept	    json
+// @Produce	    json
+// @Param		    id			path		string								true	"Vulnerability id"
+// @Param		    toggle	body		m.VulnerabilityToggle	true	"Whether the vulnerable code path is taken"
+// @Success	    200	{object}	m.Vulnerability
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    404	{object}	APIError	"unknown vulnerability"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/admin/vulnerabilities/{id} [patch]
+// @Security		Bearer
+func (a *Api) toggleVulnerability(w http.ResponseWriter, r *http.Request) {
+	var (
+		id     = chi.URLParam(r, "id")
+		toggle m.VulnerabilityToggle
+	)
+
+	if !isVulnerability(id) {
+		writeError(w, http.StatusNotFound, codeNotFound, fmt.Sprintf("Unknown vulnerability %s!", id))
+		return
+	}
+
+	if err := a.decodeJSON(r, &toggle); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if toggle.Enabled == nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Enabled is required!")
+		return
+	}
+
+	if err := a.setVulnerability(id, *toggle.Enabled); err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	for _, vulnerability := range a.vulnerabilityCatalog() {
+		if vulnerability.Id == id {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(vulnerability)
+		}
+	}
+}
+
+// @Summary		  Difficulty
+// @Description	Get the difficulty along with the vulnerabilities in effect and the ones toggled one by one
+// @Tags		    Admin
+// @Produce	    json
+// @Success	    200	{object}	m.Difficulty
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Router			/admin/difficulty [get]
+// @Security		Bearer
+func (a *Api) getDifficulty(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.getOptions().difficulty())
+}
+
+// @Summary		  Set difficulty
+// @Description	Switches to the vulnerabilities of the difficulty without a restart, forgetting the ones toggled one by one
+// @Tags		    Admin
+// @Accept	    json
+// @Produce	    json
+// @Param		    difficulty	body		m.DifficultyUpdate	true	"easy, medium or hard"
+// @Success	    200	{object}	m.Difficulty
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/admin/difficulty [put]
+// @Security		Bearer
+func (a *Api) updateDifficulty(w http.ResponseWriter, r *http.Request) {
+	var update m.DifficultyUpdate
+	if err := a.decodeJSON(r, &update); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if !isDifficulty(update.Difficulty) {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Difficulty needs to be easy, medium or hard!")
+		return
+	}
+
+	if err := a.setDifficulty(update.Difficulty); err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.getOptions().difficulty())
+}
+
+// @Summary		  Adjust user usd
+// @Description	Credits (positive amount) or debits (negative amount) usd without daily limits
+// @Tags		    Admin
+// @Accept	    json
+// @Produce	    plain
+// @Param		    id			path		int						true	"User id"
+// @Param		    amount	body		m.CashAmount	true	"Amount"
+// @Success	    200	"usd adjusted"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    412	{object}	APIError	"not enough usd"
+// @Router			/admin/users/{id}/cash [post]
+// @Security		Bearer
+func (a *Api) adjustUserCash(w http.ResponseWriter, r *http.Request) {
+	var cash m.CashAmount
+
+	userId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "User id needs to be a number!")
+		return
+	}
+
+	if err = a.decodeJSON(r, &cash); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if cash.Amount == 0 {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Amount needs to be != 0!")
+		return
+	}
+
+	if err = a.db.AdjustCash(userId, cash.Amount, "adjustment", a.virtualDate(), 0); err != nil {
+		writeError(w, http.StatusPreconditionFailed, codePreconditionFailed, err.Error())
+		return
+	}
+
+	w.Write([]byte("Usd successfully adjusted!"))
+}
+
+// @Summary		  Delete user
+// @Description	Hides the user from logins and the leaderboard, their orders and history are kept until they're purged after deleted_user_retention_days virtual days
+// @Tags		    Admin
+// @Produce	    plain
+// @Param		    id	path		int	true	"User id"
+// @Success	    200	"user deleted"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    404	{object}	APIError	"user not found"
+// @Router			/admin/users/{id} [delete]
+// @Security		Bearer
+func (a *Api) deleteUser(w http.ResponseWriter, r *http.Request) {
+	admin := r.Context().Value("user").(m.User)
+
+	userId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "User id needs to be a number!")
+		return
+	}
+
+	if userId == admin.Id {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Admins can't delete their own account!")
+		return
+	}
+
+	if err = a.db.DeleteUser(userId, a.virtualDate()); err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	w.Write([]byte("User successfully deleted!"))
+}
+
+// @Summary		  Restore user
+// @Description	Restores a deleted user that wasn't purged yet
+// @Tags		    Admin
+// @Produce	    plain
+// @Param		    id	path		int	true	"User id"
+// @Success	    200	"user restored"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    404	{object}	APIError	"deleted user not found"
+// @Router			/admin/users/{id}/restore [post]
+// @Security		Bearer
+func (a *Api) restoreUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "User id needs to be a number!")
+		return
+	}
+
+	if err = a.db.RestoreUser(userId); err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	w.Write([]byte("User successfully restored!"))
+}
+
+// @Summary		  Simulate days
+// @Description	Advances virtual time by the given number of days (max 365) without waiting, refreshing prices for each of them
+// @Tags		    Admin
+// @Accept	    json
+// @Produce	    json
+// @Param		    simulation	body		m.Simulation	true	"Days to simulate"
+// @Success	    200	{object}	m.SimulationResult
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Router			/admin/simulate [post]
+// @Security		Bearer
+func (a *Api) simulateDays(w http.ResponseWriter, r *http.Request) {
+	var simulation m.Simulation
+	if err := a.decodeJSON(r, &simulation); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if simulation.Days < 1 || simulation.Days > 365 {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Days need to be between 1 and 365!")
+		return
+	}
+
+	finalDate := a.advanceDays(simulation.Days)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m.SimulationResult{
+		SimulatedDays:    simulation.Days,
+		FinalVirtualDate: finalDate.Format(time.DateOnly),
+	})
+}
+
+// @Summary		  Ping host
+// @Description	Checks whether a host, e.g. the virtual Coingecko, can be reached from the api and how long it takes
+// @Tags		    Admin
+// @Accept	    json
+// @Produce	    json
+// @Param		    ping	body		m.PingRequest	true	"Host to ping"
+// @Success	    200	{object}	m.PingResult
+// @Failure	    400	{object}	APIError	"invalid host or port"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Router			/admin/diagnostics/ping [post]
+// @Security		Bearer
+func (a *Api) pingHost(w http.ResponseWriter, r *http.Request) {
+	var request m.PingRequest
+	if err := a.decodeJSON(r, &request); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	result, err := a.ping(r.Context(), request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// @Summary		  Backup database
+// @Description	Streams a consistent snapshot of the SQLite database, taken without stopping the api
+// @Tags		    Admin
+// @Produce	    octet-stream
+// @Success	    200	"database snapshot"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Failure	    501	{object}	APIError	"not supported by the database driver"
+// @Router			/admin/backup [get]
+// @Security		Bearer
+func (a *Api) backupDatabase(w http.ResponseWriter, r *http.Request) {
+	var snapshot bytes.Buffer
+	if err := a.db.Backup(&snapshot); err != nil {
+		if errors.Is(err, database.ErrBackupUnsupported) {
+			writeError(w, http.StatusNotImplemented, codeNotImplemented, err.Error())
+		} else {
+			a.writeInternalError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", `attachment; filename="govulnapi-backup.db"`)
+	snapshot.WriteTo(w)
+}
+
+// @Summary		  Backup database to a file
+// @Description	Writes a consistent snapshot of the SQLite database to a new file in the backup directory, taken without
+// @Description	stopping the api
+// @Tags		    Admin
+// @Produce	    json
+// @Success	    200	{object}	m.BackupResult
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    409	{object}	APIError	"another backup is still running"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Failure	    501	{object}	APIError	"not supported by the database driver"
+// @Router			/admin/backup [post]
+// @Security		Bearer
+func (a *Api) createBackup(w http.ResponseWriter, r *http.Request) {
+	backup, err := a.backupToDir()
+	switch {
+	case errors.Is(err, errBackupRunning):
+		writeError(w, http.StatusConflict, codeConflict, err.Error())
+		return
+	case errors.Is(err, database.ErrBackupUnsupported):
+		writeError(w, http.StatusNotImplemented, codeNotImplemented, err.Error())
+		return
+	case err != nil:
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backup)
+}
+
+// @Summary		  Check balance consistency
+// @Description	Recomputes the usd and coin balances of every user from their starting balance, cash transactions, orders
+// @Description	and coin transfers, and lists the ones that differ from the stored balances
+// @Tags		    Admin
+// @Produce	    json
+// @Success	    200	{object}	m.ConsistencyReport
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/admin/consistency [get]
+// @Security		Bearer
+func (a *Api) getConsistency(w http.ResponseWriter, r *http.Request) {
+	report, err := a.db.GetBalanceDiscrepancies()
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// @Summary		  Vacuum database
+// @Description	Rebuilds the database to give space left unused by deleted rows back, runs every vacuum_interval too
+// @Tags		    Admin
+// @Produce	    plain
+// @Success	    200	"database vacuumed"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/admin/vacuum [post]
+// @Security		Bearer
+func (a *Api) vacuumDatabase(w http.ResponseWriter, r *http.Request) {
+	if err := a.db.Vacuum(); err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Write([]byte("Database successfully vacuumed!"))
+}
+
+// @Summary		  Restore database
+// @Description	Replaces the database with a snapshot from /admin/backup. Writes are rejected with 503 while restoring,
+// @Description	afterwards the coin list is reloaded and prices continue from the current virtual date.
+// @Tags		    Admin
+// @Accept	    octet-stream
+// @Produce	    plain
+// @Param		    snapshot	body		string	true	"Database snapshot"
+// @Success	    200	"database restored"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Failure	    501	{object}	APIError	"not supported by the database driver"
+// @Router			/admin/restore [post]
+// @Security		Bearer
+func (a *Api) restoreBackup(w http.ResponseWriter, r *http.Request) {
+	if err := a.restoreDatabase(r.Body); err != nil {
+		switch {
+		case errors.Is(err, database.ErrBackupUnsupported):
+			writeError(w, http.StatusNotImplemented, codeNotImplemented, err.Error())
+		case errors.Is(err, database.ErrInvalidSnapshot):
+			writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		default:
+			a.writeInternalError(w, err)
+		}
+		return
+	}
+
+	w.Write([]byte("Database successfully restored!"))
+}
+
+// @Summary		  Reset lab
+// @Description	Deletes all users, balances, orders and history and seeds the lab again. The same seed always creates
+// @Description	the same users (student1@govulnapi.com:student1, ...) and trades, other requests are answered with 503 meanwhile.
+// @Tags		    Admin
+// @Accept	    json
+// @Produce	    plain
+// @Param		    reset	body		m.LabReset	true	"Seeding, left out fields keep their defaults"
+// @Success	    200	"lab reset"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/admin/reset [post]
+// @Security		Bearer
+func (a *Api) resetLab(w http.ResponseWriter, r *http.Request) {
+	reset := defaultLabReset()
+	if err := a.decodeJSON(r, &reset); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if err := checkLabReset(reset); err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	if err := a.reseedLab(reset); err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Write([]byte("Lab successfully reset!"))
+}
+
+// @Summary		  Bulk import coins
+// @Description	Lists the coins of a JSON file holding an array of at most 1000 of them, in a single transaction. Coins
+// @Description	listed already are skipped, invalid ones are returned as errors and the others are still listed.
+// @Tags		    Admin
+// @Accept	    mpfd
+// @Produce	    json
+// @Param		    file	formData	file	true	"JSON array of m.NewCoin"
+// @Success	    200	{object}	m.CoinImportResult
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    415	{object}	APIError	"unsupported media type"
+// @Failure	    500	{object}	APIError	"internal server error, no coin was listed"
+// @Router			/coins/bulk-import [post]
+// @Security		Bearer
+func (a *Api) importCoins(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, importMaxBytes)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Coins need to be uploaded as the file form file!")
+		return
+	}
+	defer file.Close()
+
+	body, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+	var coins []m.NewCoin
+	if err = a.decodeJSONBytes(body, &coins); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if len(coins) > coinImportMaxCoins {
+		writeError(w, http.StatusBadRequest, codeBadRequest, fmt.Sprintf("Imports can't list more than %d coins!", coinImportMaxCoins))
+		return
+	}
+
+	valid, errs := checkNewCoins(coins)
+	inserted, err := a.db.AddCoins(valid)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m.CoinImportResult{Inserted: inserted, Skipped: len(valid) - inserted, Errors: errs})
+}
+
+// @Summary		  Export coins
+// @Description	Downloads the tracked coins with their latest prices as CSV, named after the virtual date
+// @Tags		    Admin
+// @Produce	    text/csv
+// @Success	    200	"ok"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/export [get]
+// @Security		Bearer
+func (a *Api) exportCoins(w http.ResponseWriter, r *http.Request) {
+	names, err := a.db.GetCoinNames()
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+	byId := make(map[string]m.NewCoin, len(names))
+	for _, name := range names {
+		byId[name.Id] = name
+	}
+
+	a.coinsMu.RLock()
+	coins := append([]m.Coin{}, a.coins...)
+	virtualDate := a.currentDate
+	a.coinsMu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="coins_%s.csv"`, virtualDate.Format(time.DateOnly)))
+
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write([]string{"id", "name", "symbol", "price", "market_cap", "volume_24h"})
+	for _, coin := range coins {
+		csvWriter.Write([]string{
+			coin.Id,
+			byId[coin.Id].Name,
+			byId[coin.Id].Symbol,
+			coin.Price.String(),
+			strconv.FormatFloat(coin.MarketCap, 'f', -1, 64),
+			strconv.FormatFloat(coin.Volume24h, 'f', -1, 64),
+		})
+	}
+
+	csvWriter.Flush()
+	// Headers are already sent, so errors can only be logged
+	if err = csvWriter.Error(); err != nil {
+		log.Println(err)
+	}
+}
+
+// @Summary		  Get coin listing
+// @Description	Gets the name and symbol of a coin, the ETag header holds the version PATCH needs in If-Match
+// @Tags		    Admin
+// @Produce	    json
+// @Param		    id	path		string	true	"Coin id"
+// @Success	    200	{object}	m.CoinListing
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    404	{object}	APIError	"coin not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/admin/coins/{id} [get]
+// @Security		Bearer
+func (a *Api) getCoinListing(w http.ResponseWriter, r *http.Request) {
+	listing, err := a.db.GetCoinListing(chi.URLParam(r, "id"))
+	if errors.Is(err, database.ErrCoinNotFound) {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	} else if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", coinETag(listing))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
+
+// @Summary		  Update coin listing
+// @Description	Changes the name and/or symbol of a coin. If-Match needs to hold the version of the ETag header the coin
+// @Description	was read with, so changes of other admins made in the meantime aren't overwritten.
+// @Tags		    Admin
+// @Accept	    json
+// @Produce	    json
+// @Param		    id				path		string			true	"Coin id"
+// @Param		    If-Match	header	string			true	"Version the coin was read at"
+// @Param		    patch			body		m.CoinPatch	true	"New name and/or symbol"
+// @Success	    200	{object}	m.CoinListing
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    404	{object}	APIError	"coin not found"
+// @Failure	    412	{object}	APIError	"coin changed since it was read"
+// @Failure	    428	{object}	APIError	"If-Match missing"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/admin/coins/{id} [patch]
+// @Security		Bearer
+func (a *Api) updateCoinListing(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("If-Match") == "" {
+		writeError(w, http.StatusPreconditionRequired, codePreconditionRequired, "If-Match needs to hold the version of the coin!")
+		return
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(r.Header.Get("If-Match"), "W/"), `"`))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "If-Match needs to be the ETag of the coin!")
+		return
+	}
+
+	var patch m.CoinPatch
+	if err = a.decodeJSON(r, &patch); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	listing, err := a.db.GetCoinListing(chi.URLParam(r, "id"))
+	if errors.Is(err, database.ErrCoinNotFound) {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	} else if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	if patch.Name != nil {
+		listing.Name = strings.TrimSpace(*patch.Name)
+	}
+	if patch.Symbol != nil {
+		listing.Symbol = *patch.Symbol
+	}
+	if err = checkNewCoin(m.NewCoin{Id: listing.Id, Name: listing.Name, Symbol: listing.Symbol}); err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	// The version is checked by the update itself, so of two admins patching
+	// the same version only the first one succeeds
+	listing, err = a.db.UpdateCoinListing(listing.Id, listing.Name, listing.Symbol, version)
+	if errors.Is(err, database.ErrVersionMismatch) {
+		w.Header().Set("ETag", coinETag(listing))
+		writeError(w, http.StatusPreconditionFailed, codePreconditionFailed, err.Error())
+		return
+	} else if errors.Is(err, database.ErrCoinNotFound) {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	} else if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", coinETag(listing))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
+
+func coinETag(listing m.CoinListing) string {
+	return `"` + strconv.Itoa(listing.Version) + `"`
+}
+
+// @Summary		  Reset lab data
+// @Description	Deletes the data of the scope and seeds it again, other requests are answered with 503 meanwhile.
+// @Description	all wipes everything and moves virtual time back to the start date, users wipes users with all their
+// @Description	data, trades sets balances back to the starting ones and replays the seeded trades, and prices wipes
+// @Description	the price history and moves virtual time back to the start date.
+// @Tags		    Admin
+// @Accept	    json
+// @Produce	    plain
+// @Param		    reset	body		m.ScopedLabReset	true	"Scope and seeding, left out seeding fields keep their defaults"
+// @Success	    200	"lab reset"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/admin/lab/reset [post]
+// @Security		Bearer
+func (a *Api) resetLabData(w http.ResponseWriter, r *http.Request) {
+	// The user may be gone once the lab is reset, only the token tells who
+	// the admin was
+	_, claims, _ := jwtauth.FromContext(r.Context())
+	adminId, _ := claims["user_id"].(float64)
+
+	reset := m.ScopedLabReset{LabReset: defaultLabReset()}
+	if err := a.decodeJSON(r, &reset); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if err := checkLabReset(reset.LabReset); err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	start := time.Now()
+	err := a.resetLabScope(reset.Scope, reset.LabReset)
+	slog.Info(
+		"audit",
+		slog.String("action", "lab_reset"),
+		slog.String("scope", reset.Scope),
+		slog.Int64("seed", reset.Seed),
+		slog.Int("user_id", int(adminId)),
+		slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+		slog.Bool("succeeded", err == nil),
+	)
+	if errors.Is(err, database.ErrUnknownResetScope) {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	} else if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Write([]byte("Lab successfully reset!"))
+}
+
+func checkLabReset(reset m.LabReset) error {
+	if reset.Users < 0 || reset.Users > 100 {
+		return errors.New("Users need to be between 0 and 100!")
+	}
+	if reset.StartingBalance <= 0 {
+		return errors.New("Starting balance needs to be > 0!")
+	}
+	if reset.TradesPerUser < 0 || reset.TradesPerUser > 30 {
+		return errors.New("Trades per user need to be between 0 and 30!")
+	}
+	return nil
+}
@@ -0,0 +1,482 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+)
+
+func FuzzImportCoins(f *testing.F) {
+	f.Add([]byte(`[{"Id":"cardano","Name":"Cardano","Symbol":"ADA"}]`))
+	f.Add([]byte(`[{"Id":"bitcoin","Name":"Bitcoin","Symbol":"BTC"},{"Id":"Not An Id","Name":"","Symbol":"lower"}]`))
+	f.Add([]byte(`[{"Id":"nul\u0000byte","Name":"\u0000","Symbol":"NUL"}]`))
+	f.Add([]byte(`[{"Id":"` + strings.Repeat("a", 65) + `","Name":"` + strings.Repeat("n", 101) + `","Symbol":"TOOLONGSYMBOL"}]`))
+	f.Add([]byte(`[{"Id":"x","Name":"X","Symbol":"X","Price":1e309}]`))
+	f.Add([]byte(strings.Repeat(`[`, 1000) + strings.Repeat(`]`, 1000)))
+	f.Add([]byte(`{"Id":"cardano"}`))
+	f.Add([]byte(`null`))
+	f.Add([]byte(``))
+
+	a, _ := NewForTesting()
+	f.Cleanup(a.Shutdown)
+	token := login(f, a, "admin@govulnapi.com", "admin123")
+
+	f.Fuzz(func(t *testing.T, file []byte) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", "coins.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(file)
+		form.Close()
+
+		r := httptest.NewRequest(http.MethodPost, "/api/coins/bulk-import", &body)
+		r.Header.Set("Content-Type", form.FormDataContentType())
+		w := serve(a, r, token)
+		checkFuzzedResponse(t, a, w)
+	})
+}
+
+func TestPingCommandInjection(t *testing.T) {
+	for _, commandInjection := range []bool{true, false} {
+		a, _ := NewForTesting(WithVulnerabilities(map[string]bool{VulnDiagnosticsCommandInjection: commandInjection}))
+		t.Cleanup(a.Shutdown)
+		token := login(t, a, "admin@govulnapi.com", "admin123")
+		dir := t.TempDir()
+
+		// Each payload creates a file of the same name when it's executed
+		for name, host := range map[string]string{
+			"semicolon":    "127.0.0.1; touch " + filepath.Join(dir, "semicolon"),
+			"substitution": "$(touch " + filepath.Join(dir, "substitution") + ")127.0.0.1",
+		} {
+			body, _ := json.Marshal(m.PingRequest{Host: host})
+			r := httptest.NewRequest(http.MethodPost, "/api/admin/diagnostics/ping", bytes.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			w := serve(a, r, token)
+
+			_, err := os.Stat(filepath.Join(dir, name))
+			if commandInjection && err != nil {
+				t.Errorf("pinging %q answered %d %s with command injection, the payload didn't run", host, w.Code, w.Body)
+			}
+			if !commandInjection && (w.Code != http.StatusBadRequest || err == nil) {
+				t.Errorf("pinging %q answered %d %s without command injection, want it rejected and not run", host, w.Code, w.Body)
+			}
+		}
+	}
+}
+
+func TestUpdateCoinListingConcurrently(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "admin@govulnapi.com", "admin123")
+
+	patch := func(ifMatch string, name string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPatch, "/api/admin/coins/bitcoin", strings.NewReader(`{"Name":"`+name+`"}`))
+		r.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			r.Header.Set("If-Match", ifMatch)
+		}
+		return serve(a, r, token)
+	}
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/admin/coins/bitcoin", nil), token)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("getting the listing answered %d %s with ETag %q", w.Code, w.Body, etag)
+	}
+	if w := patch("", "Bitcoin"); w.Code != http.StatusPreconditionRequired {
+		t.Errorf("patching without If-Match answered %d, want 428", w.Code)
+	}
+
+	// Admins patching the version they read at the same time
+	const admins = 8
+	var (
+		wg        sync.WaitGroup
+		responses = make([]*httptest.ResponseRecorder, admins)
+	)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = patch(etag, fmt.Sprintf("Bitcoin %d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	winner := -1
+	for i, w := range responses {
+		switch w.Code {
+		case http.StatusOK:
+			if winner >= 0 {
+				t.Errorf("admins %d and %d both patched version %s", winner, i, etag)
+			}
+			winner = i
+		case http.StatusPreconditionFailed:
+			if w.Header().Get("ETag") == etag {
+				t.Errorf("admin %d got 412 with the stale ETag %s", i, etag)
+			}
+		default:
+			t.Errorf("admin %d answered %d %s, want 200 or 412", i, w.Code, w.Body)
+		}
+	}
+	if winner < 0 {
+		t.Fatal("no admin could patch the version they read")
+	}
+
+	w = serve(a, httptest.NewRequest(http.MethodGet, "/api/admin/coins/bitcoin", nil), token)
+	if got := w.Header().Get("ETag"); got != responses[winner].Header().Get("ETag") || got == etag {
+		t.Errorf("ETag is %s after the patches, want %s of the winner", got, responses[winner].Header().Get("ETag"))
+	}
+	if !strings.Contains(w.Body.String(), fmt.Sprintf("Bitcoin %d", winner)) {
+		t.Errorf("listing is %s, want the name of admin %d", w.Body, winner)
+	}
+	// The stale version keeps failing
+	if w := patch(etag, "Bitcoin"); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("patching the stale version answered %d, want 412", w.Code)
+	}
+}
+
+func TestCreateBackup(t *testing.T) {
+	dir := t.TempDir()
+	a, _ := NewForTesting(WithBackupDir(dir))
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "admin@govulnapi.com", "admin123")
+	login(t, a, "alice@example.com", "password123")
+
+	w := serve(a, httptest.NewRequest(http.MethodPost, "/api/admin/backup", nil), token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("backing up answered %d %s", w.Code, w.Body)
+	}
+	var backup m.BackupResult
+	if err := json.NewDecoder(w.Body).Decode(&backup); err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(backup.BackupPath) != dir {
+		t.Errorf("backed up to %s, want a file in %s", backup.BackupPath, dir)
+	}
+	if info, err := os.Stat(backup.BackupPath); err != nil || info.Size() != backup.SizeBytes {
+		t.Errorf("got %v (%v), want a file of %d bytes", info, err, backup.SizeBytes)
+	}
+
+	// The backup is a database of its own holding the data
+	db, err := sql.Open(database.DriverSQLite, backup.BackupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	var integrity string
+	if err = db.QueryRow(`PRAGMA integrity_check`).Scan(&integrity); err != nil || integrity != "ok" {
+		t.Errorf("integrity check gave %q (%v), want ok", integrity, err)
+	}
+	var users int
+	if err = db.QueryRow(`SELECT COUNT(*) FROM "user" WHERE email = 'alice@example.com'`).Scan(&users); err != nil || users != 1 {
+		t.Errorf("got %d backed up users (%v), want alice", users, err)
+	}
+
+	// Temporary files of the snapshot are gone
+	files, err := os.ReadDir(dir)
+	if err != nil || len(files) != 1 {
+		t.Errorf("got %v (%v) in the backup directory, want only the backup", files, err)
+	}
+
+	// Only one backup runs at a time
+	a.backupMu.Lock()
+	w = serve(a, httptest.NewRequest(http.MethodPost, "/api/admin/backup", nil), token)
+	a.backupMu.Unlock()
+	if w.Code != http.StatusConflict {
+		t.Errorf("backing up during another backup answered %d, want 409", w.Code)
+	}
+}
+
+func TestOverrideCoinPrice(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	adminToken := login(t, a, "admin@govulnapi.com", "admin123")
+	token := login(t, a, "alice@example.com", "password123")
+
+	override := func(coinId string, body string, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/coins/"+coinId+"/price", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		return serve(a, r, token)
+	}
+	getPrice := func() m.Usd {
+		t.Helper()
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/bitcoin", nil), "")
+		var coin m.CoinDetail
+		if err := json.NewDecoder(w.Body).Decode(&coin); err != nil {
+			t.Fatal(err)
+		}
+		return coin.Price
+	}
+
+	w := override("bitcoin", `{"Price":1234.5}`, adminToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("overriding the price answered %d %s", w.Code, w.Body)
+	}
+	var coin m.Coin
+	if err := json.NewDecoder(w.Body).Decode(&coin); err != nil {
+		t.Fatal(err)
+	}
+	want := m.UsdFromFloat(1234.5)
+	if coin.Id != "bitcoin" || coin.Price != want {
+		t.Errorf("got %+v, want bitcoin at %v", coin, want)
+	}
+
+	// Served right away and recorded as a manual price of the day
+	if price := getPrice(); price != want {
+		t.Errorf("bitcoin costs %v after the override, want %v", price, want)
+	}
+	date := a.virtualDate().Format(time.DateOnly)
+	history, err := a.db.GetDailyPrices(date, date, "bitcoin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Price != want || !history[0].Manual {
+		t.Errorf("recorded %+v, want the manual price", history)
+	}
+
+	for _, test := range []struct {
+		coinId, body, token string
+		want                int
+	}{
+		{"bitcoin", `{"Price":1}`, token, http.StatusForbidden},
+		{"bitcoin", `{"Price":1}`, "", http.StatusUnauthorized},
+		{"bitcoin", `{"Price":0}`, adminToken, http.StatusBadRequest},
+		{"bitcoin", `{"Price":-1}`, adminToken, http.StatusBadRequest},
+		{"nocoin", `{"Price":1}`, adminToken, http.StatusNotFound},
+	} {
+		if w := override(test.coinId, test.body, test.token); w.Code != test.want {
+			t.Errorf("overriding %s with %s answered %d, want %d", test.coinId, test.body, w.Code, test.want)
+		}
+	}
+	if price := getPrice(); price != want {
+		t.Errorf("bitcoin costs %v after rejected overrides, want %v", price, want)
+	}
+
+	// Until the next refresh
+	a.advanceDays(1)
+	if price := getPrice(); price != TestPrices[0].Price {
+		t.Errorf("bitcoin costs %v the next day, want %v", price, TestPrices[0].Price)
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	adminToken := login(t, a, "admin@govulnapi.com", "admin123")
+	token := login(t, a, "alice@example.com", "password123")
+
+	for i := 0; i < 5; i++ {
+		serve(a, httptest.NewRequest(http.MethodGet, "/api/coins", nil), "")
+	}
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil), adminToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("getting stats answered %d %s", w.Code, w.Body)
+	}
+	var stats struct {
+		CoinsTracked  int
+		VirtualDate   string
+		RequestsTotal int64
+		AvgLatencyMs  float64
+		LastRefresh   string
+	}
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	// The registrations, logins and coin lists, not the stats request itself
+	if stats.RequestsTotal < 9 {
+		t.Errorf("counted %d requests, want at least 9", stats.RequestsTotal)
+	}
+	if stats.AvgLatencyMs <= 0 {
+		t.Errorf("average latency is %vms, want it measured", stats.AvgLatencyMs)
+	}
+	if stats.CoinsTracked != len(TestPrices) || stats.VirtualDate != "2014-01-01" {
+		t.Errorf("got %d coins on %s, want %d on 2014-01-01", stats.CoinsTracked, stats.VirtualDate, len(TestPrices))
+	}
+	if _, err := time.Parse(time.RFC3339, stats.LastRefresh); err != nil {
+		t.Errorf("last refresh is %q, want a timestamp: %v", stats.LastRefresh, err)
+	}
+
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil), token); w.Code != http.StatusForbidden {
+		t.Errorf("getting stats as a user answered %d, want 403", w.Code)
+	}
+}
+
+// Waits for the virtual date to reach date, and paceDays to wait on the clock
+// for the next day
+func waitForNextDay(t *testing.T, a *Api, clock *FakeClock, date string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		clock.mu.Lock()
+		waiting := len(clock.waiters)
+		clock.mu.Unlock()
+		if waiting == 1 && a.virtualDate().Format(time.DateOnly) == date {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("still on %s, want %s", a.virtualDate().Format(time.DateOnly), date)
+}
+
+func TestReloadDayDuration(t *testing.T) {
+	a, clock := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "admin@govulnapi.com", "admin123")
+	waitForNextDay(t, a, clock, "2014-01-01")
+
+	r := httptest.NewRequest(http.MethodPost, "/api/admin/reload-config", strings.NewReader(`{"DayDuration":"1h"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := serve(a, r, token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("reloading answered %d %s", w.Code, w.Body)
+	}
+	if got := a.getOptions().DayDuration; got != time.Hour {
+		t.Fatalf("day duration is %v after reloading, want 1h", got)
+	}
+
+	// The day already scheduled keeps the previous duration, the next tick
+	// uses the new one
+	clock.Advance(time.Minute)
+	waitForNextDay(t, a, clock, "2014-01-02")
+	clock.Advance(59 * time.Minute)
+	clock.mu.Lock()
+	waiting := len(clock.waiters)
+	clock.mu.Unlock()
+	if waiting != 1 {
+		t.Errorf("the next day started before the new day duration passed")
+	}
+	clock.Advance(time.Minute)
+	waitForNextDay(t, a, clock, "2014-01-03")
+
+	for _, body := range []string{`{"DayDuration":"0s"}`, `{"DayDuration":"-1m"}`, `{"DayDuration":"a day"}`} {
+		r := httptest.NewRequest(http.MethodPost, "/api/admin/reload-config", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		checkAPIError(t, body, serve(a, r, token), codeBadRequest)
+	}
+	if got := a.getOptions().DayDuration; got != time.Hour {
+		t.Errorf("day duration is %v after bad reloads, want it kept at 1h", got)
+	}
+}
+
+func TestSimulateDays(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "admin@govulnapi.com", "admin123")
+
+	simulate := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/admin/simulate", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		return serve(a, r, token)
+	}
+	countPrices := func() map[string]int {
+		t.Helper()
+		prices, err := a.db.GetDailyPrices("2014-01-01", "2015-01-01")
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts := map[string]int{}
+		for _, price := range prices {
+			counts[price.CoinId]++
+		}
+		return counts
+	}
+
+	before := countPrices()
+	w := simulate(`{"Days":10}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("simulating answered %d %s", w.Code, w.Body)
+	}
+	var result m.SimulationResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.SimulatedDays != 10 || result.FinalVirtualDate != "2014-01-11" {
+		t.Errorf("got %+v, want 10 days simulated up to 2014-01-11", result)
+	}
+	if date := a.virtualDate().Format(time.DateOnly); date != "2014-01-11" {
+		t.Errorf("virtual date is %s, want 2014-01-11", date)
+	}
+
+	after := countPrices()
+	for _, coin := range TestPrices {
+		if added := after[coin.Id] - before[coin.Id]; added != 10 {
+			t.Errorf("%d prices of %s were added, want 10", added, coin.Id)
+		}
+	}
+
+	for _, body := range []string{`{"Days":0}`, `{"Days":366}`, `{"Days":-1}`} {
+		checkAPIError(t, body, simulate(body), codeBadRequest)
+	}
+	if date := a.virtualDate().Format(time.DateOnly); date != "2014-01-11" {
+		t.Errorf("virtual date is %s after rejected simulations, want 2014-01-11", date)
+	}
+}
+
+func TestVacuumDatabase(t *testing.T) {
+	a, _ := NewForTesting(WithDatabase(database.DriverSQLite, filepath.Join(t.TempDir(), "api.db")))
+	t.Cleanup(a.Shutdown)
+	adminToken := login(t, a, "admin@govulnapi.com", "admin123")
+	token := login(t, a, "alice@example.com", "password123")
+
+	if w := serve(a, httptest.NewRequest(http.MethodPost, "/api/admin/vacuum", nil), adminToken); w.Code != http.StatusOK {
+		t.Errorf("vacuuming answered %d %s", w.Code, w.Body)
+	}
+	if w := serve(a, httptest.NewRequest(http.MethodPost, "/api/admin/vacuum", nil), token); w.Code != http.StatusForbidden {
+		t.Errorf("vacuuming as a user answered %d, want 403", w.Code)
+	}
+}
+
+func TestExportCoins(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	adminToken := login(t, a, "admin@govulnapi.com", "admin123")
+	token := login(t, a, "alice@example.com", "password123")
+	a.advanceDays(2)
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/export", nil), adminToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("exporting coins answered %d %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/csv" {
+		t.Errorf("answered with Content-Type %q, want text/csv", got)
+	}
+	if got, want := w.Header().Get("Content-Disposition"), `attachment; filename="coins_2014-01-03.csv"`; got != want {
+		t.Errorf("answered with Content-Disposition %q, want %q", got, want)
+	}
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(TestPrices)+1 {
+		t.Fatalf("exported %d rows, want the header and %d coins", len(rows), len(TestPrices))
+	}
+	if header := strings.Join(rows[0], ","); header != "id,name,symbol,price,market_cap,volume_24h" {
+		t.Errorf("exported header %s", header)
+	}
+	if bitcoin := strings.Join(rows[1], ","); bitcoin != "bitcoin,Bitcoin,BTC,800,9700000000,23000000" {
+		t.Errorf("exported %s, want bitcoin with its listing and market data", bitcoin)
+	}
+
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/export", nil), token); w.Code != http.StatusForbidden {
+		t.Errorf("exporting as a user answered %d, want 403", w.Code)
+	}
+}
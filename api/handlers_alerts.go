@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary		  Get price alerts
+// @Description	Fetches price alerts that haven't triggered yet
+// @Tags		    Alerts
+// @Produce	    json
+// @Success	    200	"ok"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/me/price-alerts [get]
+// @Security		Bearer
+func (a *Api) getPriceAlerts(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	alerts, err := a.repo(r).GetActivePriceAlerts(user.Id)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alerts)
+}
+
+// @Summary		  Create price alert
+// @Description	Creates alert triggered when coin price crosses a threshold
+// @Tags		    Alerts
+// @Accept	    json
+// @Produce	    json
+// @Param		    id		path		string	true	"Coin id"
+// @Param		    alert	body		m.PriceAlert	true	"New alert"
+// @Success	    200	"ok"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"requested coin not found"
+// @Router			/coins/{id}/price-alert [post]
+// @Security		Bearer
+func (a *Api) addPriceAlert(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	var newAlert m.PriceAlert
+	if err := a.decodeJSON(r, &newAlert); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	coin, err := a.getCoin(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	alert, err := a.repo(r).AddPriceAlert(user.Id, coin.Id, newAlert.ThresholdUsd, newAlert.Direction)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alert)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	m "govulnapi/models"
+)
+
+func TestPriceAlertsTriggerOnNextDay(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "alice@example.com", "password123")
+
+	// Bitcoin stays at $800 in TestPrices
+	for _, alert := range []string{
+		`{"ThresholdUsd":900,"Direction":"below"}`,
+		`{"ThresholdUsd":700,"Direction":"below"}`,
+		`{"ThresholdUsd":800,"Direction":"above"}`,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/api/coins/BTC/price-alert", strings.NewReader(alert))
+		r.Header.Set("Content-Type", "application/json")
+		if w := serve(a, r, token); w.Code != http.StatusOK {
+			t.Fatalf("adding %s answered %d %s", alert, w.Code, w.Body)
+		}
+	}
+	r := httptest.NewRequest(http.MethodPost, "/api/coins/bitcoin/price-alert", strings.NewReader(`{"ThresholdUsd":900,"Direction":"sideways"}`))
+	r.Header.Set("Content-Type", "application/json")
+	if w := serve(a, r, token); w.Code != http.StatusBadRequest {
+		t.Errorf("adding an alert without a direction answered %d, want 400", w.Code)
+	}
+
+	activeAlerts := func() []m.PriceAlert {
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/me/price-alerts", nil), token)
+		if w.Code != http.StatusOK {
+			t.Fatalf("getting the alerts answered %d %s", w.Code, w.Body)
+		}
+		var alerts []m.PriceAlert
+		if err := json.NewDecoder(w.Body).Decode(&alerts); err != nil {
+			t.Fatal(err)
+		}
+		return alerts
+	}
+	if alerts := activeAlerts(); len(alerts) != 3 {
+		t.Fatalf("%d alerts are active before refreshing, want 3", len(alerts))
+	}
+
+	a.advanceDays(1)
+
+	alerts := activeAlerts()
+	if len(alerts) != 1 || alerts[0].ThresholdUsd != m.UsdFromFloat(700) {
+		t.Errorf("active alerts are %+v after refreshing, want the one below $700", alerts)
+	}
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/notifications", nil), token)
+	var page m.NotificationPage
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	triggered := 0
+	for _, notification := range page.Notifications {
+		if notification.Type == notificationPriceAlert {
+			triggered++
+		}
+	}
+	if triggered != 2 {
+		t.Errorf("got %d price alert notifications, want 2", triggered)
+	}
+}
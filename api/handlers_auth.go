@@ -1,8 +1,12 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
+
+	"govulnapi/api/database"
 )
 
 // @Summary		  User login
@@ -11,47 +15,72 @@ import (
 // @Produce   	plain
 // @Param	    	email		  query		string	true	"email"
 // @Param	    	password	query		string	true	"password"
+// @Param	    	redirect_to	query		string	false	"Relative path or url of an allowed origin to redirect to once the cookie is set"
 // @Success   	200				"login successful"
-// @Failure   	401			  "invalid credentials"
+// @Success   	302				"login successful, redirecting to redirect_to"
+// @Failure   	400			  {object}	APIError	"redirect_to not allowed"
+// @Failure   	401			  {object}	APIError	"invalid credentials"
+// @Failure   	429			  {object}	APIError	"too many failed logins"
 // @Router			/login [get]
 func (s *Api) loginUser(w http.ResponseWriter, r *http.Request) {
 	// CWE-598: Use of GET Request Method With Sensitive Query Strings
 	// CWE-523: Unprotected Transport of Credentials
 	var (
-		email    = r.FormValue("email")
-		password = r.FormValue("password")
-		response string
+		email      = r.FormValue("email")
+		password   = r.FormValue("password")
+		redirectTo = r.FormValue("redirect_to")
 	)
 
+	if redirectTo != "" {
+		if err := s.checkRedirect(redirectTo); err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+			return
+		}
+	}
+
+	// CWE-307: Improper Restriction of Excessive Authentication Attempts
+	// Unless brute forcing is enabled, an email that failed to log in too
+	// often has to wait, even with the right password
+	if wait := s.loginRetryAfter(email); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		writeError(w, http.StatusTooManyRequests, codeTooManyRequests, "Too many failed logins, try again later!")
+		return
+	}
+
 	user, err := s.db.GetUserByCredentials(email, password)
+	s.recordLogin(email, err == nil)
 	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		response = err.Error()
-	} else {
-		// Potential for CWE-284, CWE-1270
-		// jwtAuth := jwtauth.New("HS256", []byte(password), nil)
+		writeError(w, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
 
-		// CWE-613: Insufficient Session Expiration
-		// Token never expires
-		_, token, _ := s.jwtAuth.Encode(map[string]interface{}{"user_id": user.Id})
-		response = token
+	// Potential for CWE-284, CWE-1270
+	// jwtAuth := jwtauth.New("HS256", []byte(password), nil)
 
-		// CWE-614: Sensitive Cookie in HTTPS Session Without 'Secure' Attribute
-		// CWE-1004: Sensitive Cookie Without 'HttpOnly' Flag
-		http.SetCookie(w, &http.Cookie{
-			Name:    "jwt",
-			Value:   token,
-			Expires: time.Now().Add(time.Hour * 24 * 30),
-			Path:    "/",
-			// Secure:   true,
-			// HttpOnly: true,
-		})
-	}
+	// CWE-613: Insufficient Session Expiration
+	// Token never expires
+	_, token, _ := s.tokenAuth().Encode(map[string]interface{}{"user_id": user.Id, "role": user.Role})
+
+	// CWE-614: Sensitive Cookie in HTTPS Session Without 'Secure' Attribute
+	// CWE-1004: Sensitive Cookie Without 'HttpOnly' Flag
+	http.SetCookie(w, &http.Cookie{
+		Name:    "jwt",
+		Value:   token,
+		Expires: time.Now().Add(time.Hour * 24 * 30),
+		Path:    "/",
+		// Secure:   true,
+		// HttpOnly: true,
+	})
 
 	// CWE-778: Insufficient Logging
 	// log.Println("Issued JWT token to user id %d", user.Id)
 
-	w.Write([]byte(response))
+	if redirectTo != "" {
+		http.Redirect(w, r, redirectTo, http.StatusFound)
+		return
+	}
+
+	w.Write([]byte(token))
 }
 
 // @Summary		  User registration
@@ -69,16 +98,17 @@ func (s *Api) registerUser(w http.ResponseWriter, r *http.Request) {
 	var (
 		email    = r.FormValue("email")
 		password = r.FormValue("password")
-		response string
 	)
 
 	// CWE-262: Not Using Password Aging
 	if err := s.db.AddUser(email, password); err != nil {
-		w.WriteHeader(http.StatusConflict)
-		response = err.Error()
-	} else {
-		response = "User successfully registered!"
+		code := codeConflict
+		if errors.Is(err, database.ErrEmailTaken) {
+			code = codeEmailTaken
+		}
+		writeError(w, http.StatusConflict, code, err.Error())
+		return
 	}
 
-	w.Write([]byte(response))
+	w.Write([]byte("User successfully registered!"))
 }
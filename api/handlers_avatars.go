@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary		  Upload avatar
+// @Description	Replaces your avatar, it needs to be a PNG or JPEG image of at most 1 MiB and 1024x1024 pixels
+// @Tags		    User
+// @Accept	    mpfd
+// @Produce	    json
+// @Param		    avatar	formData	file	true	"Image"
+// @Success	    200	{object}	m.Avatar
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    415	{object}	APIError	"unsupported media type"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/me/avatar [post]
+// @Security		Bearer
+func (a *Api) uploadAvatar(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	r.Body = http.MaxBytesReader(w, r.Body, avatarRequestMaxBytes)
+	_, header, err := r.FormFile("avatar")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Avatar needs to be uploaded as the avatar form file!")
+		return
+	}
+
+	avatar, data, err := a.checkAvatar(header)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	avatar, err = a.saveAvatar(user.Id, avatar, data)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(avatar)
+}
+
+// @Summary		  Get avatar
+// @Description	Downloads the avatar of a user
+// @Tags		    User
+// @Produce	    png
+// @Produce	    jpeg
+// @Param		    user	path		int	true	"User id"
+// @Success	    200	"avatar"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    404	{object}	APIError	"avatar not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/avatars/{user} [get]
+func (a *Api) getAvatar(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(chi.URLParam(r, "user"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "User id needs to be a number!")
+		return
+	}
+
+	avatar, file, err := a.openAvatar(userId)
+	if errors.Is(err, database.ErrAvatarNotFound) {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	} else if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", avatar.ContentType)
+	if a.vulnerable(VulnAvatarUpload) {
+		// Replaces the API's CSP, which would keep scripts of uploaded pages
+		// from running. Timeout buffers headers, so it can't be deleted.
+		w.Header().Set("Content-Security-Policy", "default-src * 'unsafe-inline'")
+	} else {
+		// Along with nosniff of SecurityHeaders, browsers neither guess
+		// another type nor render the file in place
+		w.Header().Set("Content-Disposition", `attachment; filename="`+avatar.FileName+`"`)
+	}
+	io.Copy(w, file)
+}
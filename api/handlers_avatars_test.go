@@ -0,0 +1,91 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Uploads the file as the avatar, with the name and content type the client
+// claims
+func uploadAvatar(t *testing.T, a *Api, token string, fileName string, contentType string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="avatar"; filename="%s"`, fileName))
+	header.Set("Content-Type", contentType)
+	part, err := form.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	form.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/me/avatar", &body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	return serve(a, r, token)
+}
+
+func TestPolyglotAvatar(t *testing.T) {
+	// A valid PNG carrying a script in a text chunk and an HTML page after
+	// its end
+	polyglot, err := os.ReadFile("testdata/polyglot.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, avatarUpload := range []bool{true, false} {
+		a, _ := NewForTesting(
+			WithAvatarsDir(t.TempDir()),
+			WithVulnerabilities(map[string]bool{VulnAvatarUpload: avatarUpload}),
+		)
+		t.Cleanup(a.Shutdown)
+		token := login(t, a, "alice@example.com", "password123")
+		decoded, err := a.tokenAuth().Decode(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		avatarPath := fmt.Sprintf("/api/avatars/%v", decoded.PrivateClaims()["user_id"])
+
+		if w := uploadAvatar(t, a, token, "avatar.html", "text/html", polyglot); w.Code != http.StatusOK {
+			t.Fatalf("avatar upload %v: uploading the polyglot answered %d %s", avatarUpload, w.Code, w.Body)
+		}
+		w := serve(a, httptest.NewRequest(http.MethodGet, avatarPath, nil), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("avatar upload %v: getting the avatar answered %d %s", avatarUpload, w.Code, w.Body)
+		}
+
+		if avatarUpload {
+			// Served as the page the client claimed it is, scripts included
+			if w.Header().Get("Content-Type") != "text/html" || !strings.Contains(w.Body.String(), "<script>") {
+				t.Errorf("got %s %q with unrestricted uploads, want the page", w.Header().Get("Content-Type"), w.Body)
+			}
+			continue
+		}
+
+		// Only the pixels are kept and the file is downloaded, not rendered
+		if w.Header().Get("Content-Type") != "image/png" || strings.Contains(w.Body.String(), "script") {
+			t.Errorf("got %s %q, want the re-encoded PNG", w.Header().Get("Content-Type"), w.Body)
+		}
+		if disposition := w.Header().Get("Content-Disposition"); !strings.HasPrefix(disposition, "attachment") || strings.Contains(disposition, ".html") {
+			t.Errorf("Content-Disposition is %q, want an attachment named after the PNG", disposition)
+		}
+		if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("X-Content-Type-Options is %q, want nosniff", w.Header().Get("X-Content-Type-Options"))
+		}
+
+		// Files that aren't images at all are rejected
+		page := []byte("<html><script>alert(document.domain)</script></html>")
+		if w := uploadAvatar(t, a, token, "avatar.png", "image/png", page); w.Code != http.StatusBadRequest {
+			t.Errorf("uploading a page as PNG answered %d %s, want 400", w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary		  Get coin comments
+// @Description	Fetches comments on the coin newest first, one page at a time
+// @Tags		    Comments
+// @Produce	    json
+// @Param		    id			path		string	true	"Coin id"
+// @Param		    cursor	query		string	false	"NextCursor of the previous page"
+// @Param		    limit		query		int			false	"Page size (max 100)"
+// @Success	    200	{object}	m.CommentPage
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    404	{object}	APIError	"requested coin not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/{id}/comments [get]
+func (a *Api) getComments(w http.ResponseWriter, r *http.Request) {
+	coin, err := a.getCoin(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	filter, err := parseCommentFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	comments, err := a.repo(r).GetComments(coin.Id, filter)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	page := m.CommentPage{Comments: comments}
+	if len(comments) == filter.Limit {
+		last := comments[len(comments)-1]
+		page.NextCursor = encodeCursor(cursor{VirtualDate: last.VirtualDate, Id: last.Id})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func parseCommentFilter(r *http.Request) (database.CommentFilter, error) {
+	filter := database.CommentFilter{Limit: commentPageSize}
+
+	if token := r.FormValue("cursor"); token != "" {
+		c, err := decodeCursor(token)
+		if err != nil {
+			return filter, err
+		}
+		filter.AfterVirtualDate = c.VirtualDate
+		filter.AfterId = c.Id
+	}
+
+	if limit := r.FormValue("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 100 {
+			return filter, errors.New("Limit needs to be between 1 and 100!")
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
+
+// @Summary		  Coin page
+// @Description	Renders the coin with its latest comments as an HTML page
+// @Tags		    Comments
+// @Produce	    html
+// @Param		    id	path		string	true	"Coin id"
+// @Success	    200	"ok"
+// @Failure	    404	{object}	APIError	"requested coin not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/{id}/page [get]
+func (a *Api) getCoinPage(w http.ResponseWriter, r *http.Request) {
+	coin, err := a.getCoin(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	comments, err := a.repo(r).GetComments(coin.Id, database.CommentFilter{Limit: commentPageSize})
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	// Replaces the API's CSP, which would keep injected scripts from running.
+	// Timeout buffers headers, so it can't be deleted.
+	if a.vulnerable(VulnCommentXSS) {
+		w.Header().Set("Content-Security-Policy", "default-src * 'unsafe-inline'")
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err = a.renderCoinPage(w, coinPage{Coin: coin, Comments: comments}); err != nil {
+		log.Println(err)
+	}
+}
+
+// @Summary		  Add comment
+// @Description	Comments on the coin, bodies can't be longer than 500 characters
+// @Tags		    Comments
+// @Accept	    json
+// @Produce	    json
+// @Param		    id			path		string				true	"Coin id"
+// @Param		    comment	body		m.CommentBody	true	"Comment"
+// @Success	    200	{object}	m.Comment
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"requested coin not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/{id}/comments [post]
+// @Security		Bearer
+func (a *Api) addComment(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	var newComment m.CommentBody
+	if err := a.decodeJSON(r, &newComment); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	coin, err := a.getCoin(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	body, err := a.checkComment(newComment.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	comment, err := a.repo(r).AddComment(user.Id, coin.Id, body, a.virtualDate(), time.Now())
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// @Summary		  Edit comment
+// @Description	Replaces the body of one of your comments
+// @Tags		    Comments
+// @Accept	    json
+// @Produce	    json
+// @Param		    id			path		int						true	"Comment id"
+// @Param		    comment	body		m.CommentBody	true	"Comment"
+// @Success	    200	{object}	m.Comment
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"comment not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/comments/{id} [put]
+// @Security		Bearer
+func (a *Api) updateComment(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	comment, ok := a.ownComment(w, r, user)
+	if !ok {
+		return
+	}
+
+	var update m.CommentBody
+	if err := a.decodeJSON(r, &update); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	body, err := a.checkComment(update.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	comment, err = a.repo(r).UpdateComment(comment.Id, body, time.Now())
+	if errors.Is(err, database.ErrCommentNotFound) {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	} else if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// @Summary		  Delete comment
+// @Description	Deletes one of your comments
+// @Tags		    Comments
+// @Produce	    plain
+// @Param		    id	path		int	true	"Comment id"
+// @Success	    200	"comment deleted"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"comment not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/comments/{id} [delete]
+// @Security		Bearer
+func (a *Api) deleteComment(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	comment, ok := a.ownComment(w, r, user)
+	if !ok {
+		return
+	}
+
+	a.removeComment(w, r, comment.Id)
+}
+
+// @Summary		  Moderate comment
+// @Description	Deletes a comment of any user
+// @Tags		    Admin
+// @Produce	    plain
+// @Param		    id	path		int	true	"Comment id"
+// @Success	    200	"comment deleted"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    404	{object}	APIError	"comment not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/admin/comments/{id} [delete]
+// @Security		Bearer
+func (a *Api) moderateComment(w http.ResponseWriter, r *http.Request) {
+	commentId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Comment id needs to be a number!")
+		return
+	}
+
+	a.removeComment(w, r, commentId)
+}
+
+// Gets the comment of the id path parameter, answering with 404 when it
+// doesn't exist or belongs to another user
+func (a *Api) ownComment(w http.ResponseWriter, r *http.Request, user m.User) (m.Comment, bool) {
+	commentId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Comment id needs to be a number!")
+		return m.Comment{}, false
+	}
+
+	comment, err := a.repo(r).GetComment(commentId)
+	if err == nil && comment.UserId != user.Id {
+		err = database.ErrCommentNotFound
+	}
+	if errors.Is(err, database.ErrCommentNotFound) {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return m.Comment{}, false
+	} else if err != nil {
+		a.writeInternalError(w, err)
+		return m.Comment{}, false
+	}
+
+	return comment, true
+}
+
+func (a *Api) removeComment(w http.ResponseWriter, r *http.Request, commentId int) {
+	err := a.repo(r).DeleteComment(commentId)
+	if errors.Is(err, database.ErrCommentNotFound) {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	} else if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Write([]byte("Comment successfully deleted!"))
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary		  Submit flag
+// @Description	Scores a flag for the user's team, submitting a flag again doesn't change when it was solved
+// @Tags		    CTF
+// @Accept	    json
+// @Produce	    json
+// @Param		    submission	body		m.FlagSubmission	true	"Flag"
+// @Success	    200	{object}	m.FlagResult
+// @Failure	    400	{object}	APIError	"wrong flag"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"admins can't submit flags"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/ctf/submit [post]
+// @Security		Bearer
+func (a *Api) submitFlag(w http.ResponseWriter, r *http.Request) {
+	var (
+		user       = r.Context().Value("user").(m.User)
+		submission m.FlagSubmission
+	)
+
+	// The admin account holds a flag itself
+	if user.Role == "admin" {
+		writeError(w, http.StatusForbidden, codeForbidden, "Admins can't submit flags!")
+		return
+	}
+
+	if err := a.decodeJSON(r, &submission); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	flagId, ok, err := a.checkFlag(submission.Flag)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusBadRequest, codeWrongFlag, "Wrong flag!")
+		return
+	}
+
+	firstSolve, err := a.db.AddSolve(user.Id, flagId, a.clock.Now())
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m.FlagResult{FlagId: flagId, FirstSolve: firstSolve})
+}
+
+// @Summary		  Scoreboard
+// @Description	Get teams by the number of flags they found, updated at most every 5 seconds
+// @Tags		    CTF
+// @Produce	    json
+// @Success	    200	{array}		m.ScoreboardEntry
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/ctf/scoreboard [get]
+func (a *Api) getScoreboard(w http.ResponseWriter, r *http.Request) {
+	entries, err := a.getScoreboardEntries()
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// @Summary		  Hint
+// @Description	Get hint n of a challenge, which costs points on the scoreboard. Hints are given in order, so hints 1 to n-1 need to be taken first.
+// @Tags		    CTF
+// @Produce	    json
+// @Param		    id	path		string	true	"Challenge id, see /admin/vulnerabilities"
+// @Param		    n		path		int			true	"Hint number, from 1"
+// @Success	    200	{object}	m.Hint
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"previous hints not taken"
+// @Failure	    404	{object}	APIError	"unknown challenge or hint"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/ctf/challenges/{id}/hints/{n} [get]
+// @Security		Bearer
+func (a *Api) getHint(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	challenge, ok := findVulnerability(chi.URLParam(r, "id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, codeNotFound, "Unknown challenge!")
+		return
+	}
+
+	n, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil || n < 1 || n > len(challenge.Hints) {
+		writeError(w, http.StatusNotFound, codeNotFound, fmt.Sprintf("Challenge has hints 1 to %d!", len(challenge.Hints)))
+		return
+	}
+
+	err = a.db.TakeHint(user.Id, challenge.Id, n, a.clock.Now())
+	if errors.Is(err, database.ErrHintLocked) {
+		writeError(w, http.StatusForbidden, codeHintLocked, err.Error())
+		return
+	}
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m.Hint{
+		ChallengeId: challenge.Id,
+		Number:      n,
+		Text:        challenge.Hints[n-1],
+		Penalty:     a.getOptions().CTFHintPenalty,
+	})
+}
+
+// @Summary		  Hints taken
+// @Description	Get which hints users took, oldest first
+// @Tags		    CTF
+// @Produce	    json
+// @Success	    200	{array}		m.HintTaken
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/admin/ctf/hints [get]
+// @Security		Bearer
+func (a *Api) getHintsTaken(w http.ResponseWriter, r *http.Request) {
+	hints, err := a.db.GetHintsTaken()
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hints)
+}
+
+// @Summary		  Admin flag
+// @Description	Get the flag only admins can see
+// @Tags		    CTF
+// @Produce	    plain
+// @Success	    200	"flag"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Router			/admin/flag [get]
+// @Security		Bearer
+func (a *Api) getAdminFlag(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(a.flags[flagAdminRoute]))
+}
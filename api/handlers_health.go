@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+
+	m "govulnapi/models"
+)
+
+// @Summary		  Readiness
+// @Description	Reports whether the api is ready to serve traffic, i.e. coins are loaded, virtual days advance and prices are fresh
+// @Tags		    Health
+// @Produce	    json
+// @Success	    200	{object}	m.Readiness
+// @Failure	    503	{object}	m.Readiness	"failed checks"
+// @Router			/readyz [get]
+func (a *Api) getReadiness(w http.ResponseWriter, r *http.Request) {
+	failed := a.failedReadinessChecks()
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(failed) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(m.Readiness{Ready: len(failed) == 0, FailedChecks: failed})
+}
+
+// @Summary		  Version
+// @Description	Build of the api that is deployed, version is dev for builds without -ldflags
+// @Tags		    Health
+// @Produce	    json
+// @Success	    200	{object}	m.Version
+// @Router			/version [get]
+func (a *Api) getVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m.Version{Version: Version, Commit: Commit, BuiltAt: BuiltAt})
+}
+
+// @Summary		  Debug variables
+// @Description	Runtime state and configuration in the style of expvar's /debug/vars, only served while debug responses
+// @Description	are enabled
+// @Tags		    Health
+// @Produce	    json
+// @Success	    200	"variables"
+// @Failure	    404	{object}	APIError	"debug responses disabled"
+// @Router			/debug/vars [get]
+func (a *Api) getDebugVars(w http.ResponseWriter, r *http.Request) {
+	if !a.vulnerable(VulnDebugResponses) {
+		writeError(w, http.StatusNotFound, codeNotFound, "Route not found!")
+		return
+	}
+
+	// CWE-215: Insertion of Sensitive Information Into Debugging Code
+	// Options include JwtSecret, so anyone can sign tokens after reading them
+	options := a.getOptions()
+	options.Repository, options.Prices, options.Clock = nil, nil, nil
+
+	env := map[string]string{}
+	for _, pair := range os.Environ() {
+		if name, value, _ := strings.Cut(pair, "="); strings.HasPrefix(name, "GOVULN_") || strings.HasPrefix(name, "GOVULNAPI_") {
+			env[name] = value
+		}
+	}
+
+	var memstats runtime.MemStats
+	runtime.ReadMemStats(&memstats)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"cmdline":    os.Args,
+		"version":    m.Version{Version: Version, Commit: Commit, BuiltAt: BuiltAt},
+		"goroutines": runtime.NumGoroutine(),
+		"memstats":   memstats,
+		"options":    options,
+		"env":        env,
+	})
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	m "govulnapi/models"
+)
+
+// Gets the readiness, failing unless it's answered with 200 when ready and
+// 503 otherwise
+func getReadiness(t *testing.T, a *Api) m.Readiness {
+	t.Helper()
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/readyz", nil), "")
+	var readiness m.Readiness
+	if err := json.NewDecoder(w.Body).Decode(&readiness); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[bool]int{true: http.StatusOK, false: http.StatusServiceUnavailable}[readiness.Ready]; w.Code != want {
+		t.Errorf("answered %+v with %d, want %d", readiness, w.Code, want)
+	}
+	return readiness
+}
+
+func checkReadiness(t *testing.T, a *Api, failed ...string) {
+	t.Helper()
+
+	readiness := getReadiness(t, a)
+	if readiness.Ready != (len(failed) == 0) || !slices.Equal(readiness.FailedChecks, failed) {
+		t.Errorf("got %+v, want the failed checks %v", readiness, failed)
+	}
+}
+
+func TestReadinessNoCoins(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	checkReadiness(t, a)
+
+	a.coinsMu.Lock()
+	a.coins = nil
+	a.coinsMu.Unlock()
+	checkReadiness(t, a, checkNoCoins)
+}
+
+func TestReadinessClockStalled(t *testing.T) {
+	a, clock := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	dayDuration := a.getOptions().DayDuration
+	waitForNextDay(t, a, clock, "2014-01-01")
+
+	// Days can't advance while daysMu is held, e.g. by a slow refresh
+	a.daysMu.Lock()
+	clock.Advance(stalledClockPeriods * dayDuration)
+	checkReadiness(t, a)
+	clock.Advance(time.Second)
+	checkReadiness(t, a, checkClockStalled)
+
+	a.daysMu.Unlock()
+	waitForNextDay(t, a, clock, "2014-01-02")
+	checkReadiness(t, a)
+}
+
+func TestReadinessStalePrices(t *testing.T) {
+	for _, acceptStale := range []bool{false, true} {
+		a, _ := NewForTesting(WithPriceWarmup(time.Second, acceptStale))
+		t.Cleanup(a.Shutdown)
+
+		// The coins loaded from the database are still served
+		a.prices = unreachablePrices{}
+		a.warmupPrices()
+		if acceptStale {
+			checkReadiness(t, a)
+		} else {
+			checkReadiness(t, a, checkStalePrices)
+		}
+
+		a.prices = TestPrices
+		a.advanceDays(1)
+		checkReadiness(t, a)
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+
+	getVersion := func() map[string]string {
+		t.Helper()
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/version", nil), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("getting the version answered %d %s", w.Code, w.Body)
+		}
+		var version map[string]string
+		if err := json.NewDecoder(w.Body).Decode(&version); err != nil {
+			t.Fatal(err)
+		}
+		return version
+	}
+
+	if version := getVersion(); version["version"] != "dev" {
+		t.Errorf("got %v, want version dev without -ldflags", version)
+	}
+
+	// As set by -ldflags "-X govulnapi/api.Version=1.2.3 ..."
+	defer func(version, commit, builtAt string) { Version, Commit, BuiltAt = version, commit, builtAt }(Version, Commit, BuiltAt)
+	Version, Commit, BuiltAt = "1.2.3", "abc123", "2024-01-01T00:00:00Z"
+	want := map[string]string{"version": "1.2.3", "commit": "abc123", "built_at": "2024-01-01T00:00:00Z"}
+	if version := getVersion(); !maps.Equal(version, want) {
+		t.Errorf("got %v, want %v", version, want)
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary		  Preview coin news
+// @Description	Fetches a news page about the coin and returns its title and first paragraph
+// @Tags		    Coins
+// @Accept	    json
+// @Produce	    json
+// @Param		    id		path		string	true	"Coin id"
+// @Param		    news	body		m.NewsPreviewRequest	true	"News page"
+// @Success	    200	{object}	m.NewsPreview
+// @Failure	    400	{object}	APIError	"invalid or internal url"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"requested coin not found"
+// @Failure	    502	{object}	APIError	"news page couldn't be fetched"
+// @Router			/coins/{id}/news-preview [post]
+// @Security		Bearer
+func (a *Api) previewNews(w http.ResponseWriter, r *http.Request) {
+	var request m.NewsPreviewRequest
+	if err := a.decodeJSON(r, &request); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	coin, err := a.getCoin(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	// CWE-918: Server-Side Request Forgery (SSRF)
+	// The page is fetched from inside the lab network, so it can be the
+	// virtual coingecko, cloud metadata endpoints or admin routes only
+	// reachable from localhost, and the preview shows their response
+	if err := a.validateUrl(request.Url, VulnNewsPreviewSSRF); err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	preview, err := a.fetchNewsPreview(request.Url)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, codeBadGateway, err.Error())
+		return
+	}
+	preview.CoinId = coin.Id
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary		  Get notifications
+// @Description	Fetches notifications newest first, one page at a time, along with the unread count
+// @Tags		    Notifications
+// @Produce	    json
+// @Param		    unread	query		bool		false	"Only unread notifications"
+// @Param		    cursor	query		string	false	"NextCursor of the previous page"
+// @Param		    limit		query		int			false	"Page size (max 100)"
+// @Success	    200	{object}	m.NotificationPage
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/notifications [get]
+// @Security		Bearer
+func (a *Api) getNotifications(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	filter, err := parseNotificationFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	notifications, err := a.db.GetNotifications(user.Id, filter)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	unread, err := a.db.CountUnreadNotifications(user.Id)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	page := m.NotificationPage{Notifications: notifications, UnreadCount: unread}
+	if len(notifications) == filter.Limit {
+		last := notifications[len(notifications)-1]
+		page.NextCursor = encodeCursor(cursor{VirtualDate: last.VirtualDate, Id: last.Id})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func parseNotificationFilter(r *http.Request) (database.NotificationFilter, error) {
+	filter := database.NotificationFilter{Limit: 50}
+
+	if unread := r.FormValue("unread"); unread != "" {
+		unreadOnly, err := strconv.ParseBool(unread)
+		if err != nil {
+			return filter, errors.New("Unread needs to be 'true' or 'false'!")
+		}
+		filter.UnreadOnly = unreadOnly
+	}
+
+	if token := r.FormValue("cursor"); token != "" {
+		c, err := decodeCursor(token)
+		if err != nil {
+			return filter, err
+		}
+		filter.AfterVirtualDate = c.VirtualDate
+		filter.AfterId = c.Id
+	}
+
+	if limit := r.FormValue("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 100 {
+			return filter, errors.New("Limit needs to be between 1 and 100!")
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
+
+// @Summary		  Mark notification as read
+// @Tags		    Notifications
+// @Param		    id	path		int	true	"Notification id"
+// @Success	    200	"ok"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"notification not found"
+// @Router			/notifications/{id}/read [post]
+// @Security		Bearer
+func (a *Api) readNotification(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	notificationId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Notification id needs to be a number!")
+		return
+	}
+
+	if err = a.db.MarkNotificationRead(user.Id, notificationId, a.virtualDate()); err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	w.Write([]byte("Notification marked as read!"))
+}
+
+// @Summary		  Broadcast notification
+// @Description	Sends a notification to every user
+// @Tags		    Admin
+// @Accept	    json
+// @Param		    broadcast	body		m.Broadcast	true	"Message"
+// @Success	    200	"ok"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"forbidden"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/admin/notifications [post]
+// @Security		Bearer
+func (a *Api) broadcastNotification(w http.ResponseWriter, r *http.Request) {
+	var broadcast m.Broadcast
+	if err := a.decodeJSON(r, &broadcast); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if broadcast.Message == "" {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Message can't be empty!")
+		return
+	}
+
+	if err := a.notifyAll(notificationBroadcast, broadcast); err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Write([]byte("Notification successfully broadcast!"))
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	m "govulnapi/models"
+)
+
+func TestFilledLimitOrderNotifiesOnce(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "alice@example.com", "password123")
+	adminToken := login(t, a, "admin@govulnapi.com", "admin123")
+
+	post := func(path, body, token string) {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if w := serve(a, r, token); w.Code != http.StatusOK {
+			t.Fatalf("posting %s to %s answered %d %s", body, path, w.Code, w.Body)
+		}
+	}
+	getNotifications := func(query string) m.NotificationPage {
+		t.Helper()
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/notifications"+query, nil), token)
+		var page m.NotificationPage
+		if err := json.NewDecoder(w.Body).Decode(&page); w.Code != http.StatusOK || err != nil {
+			t.Fatalf("getting notifications answered %d (%v)", w.Code, err)
+		}
+		return page
+	}
+
+	// Bitcoin is at $800 in TestPrices
+	post("/api/orders", `{"CoinId":"bitcoin","IsBuy":true,"Qty":1,"LimitPrice":700}`, token)
+	if page := getNotifications(""); len(page.Notifications) != 0 || page.UnreadCount != 0 {
+		t.Fatalf("got %+v before the order was filled, want none", page)
+	}
+
+	// Reaching the limit twice and moving to the next day fills it once
+	post("/api/coins/bitcoin/price", `{"Price":690}`, adminToken)
+	post("/api/coins/bitcoin/price", `{"Price":680}`, adminToken)
+	a.advanceDays(1)
+
+	page := getNotifications("?unread=true")
+	if len(page.Notifications) != 1 || page.UnreadCount != 1 {
+		t.Fatalf("got %+v, want one unread notification", page)
+	}
+	notification := page.Notifications[0]
+	var order m.Order
+	if err := json.Unmarshal(notification.Payload, &order); err != nil {
+		t.Fatal(err)
+	}
+	if notification.Type != notificationOrderFilled || order.Status != "filled" || order.Price != m.UsdFromFloat(700) {
+		t.Errorf("got %s of %+v, want the order filled at $700", notification.Type, order)
+	}
+
+	post(fmt.Sprintf("/api/notifications/%d/read", notification.Id), "", token)
+	if page = getNotifications("?unread=true"); len(page.Notifications) != 0 || page.UnreadCount != 0 {
+		t.Errorf("got %+v after reading it, want no unread notifications", page)
+	}
+	if page = getNotifications(""); len(page.Notifications) != 1 || page.Notifications[0].ReadAt == nil {
+		t.Errorf("got %+v, want the read notification listed", page)
+	}
+}
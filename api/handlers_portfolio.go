@@ -0,0 +1,294 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary		  Profit and loss
+// @Description	Get realized and unrealized profit and loss with a daily equity curve
+// @Tags		    Portfolio
+// @Produce	    json
+// @Param		    from	query		string	false	"First virtual date (YYYY-MM-DD), defaults to 30 days before to"
+// @Param		    to		query		string	false	"Last virtual date (YYYY-MM-DD), defaults to current virtual date"
+// @Success	    200	"ok"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/portfolio/pnl [get]
+// @Security		Bearer
+func (a *Api) getPnl(w http.ResponseWriter, r *http.Request) {
+	var (
+		user = r.Context().Value("user").(m.User)
+		to   = a.virtualDate()
+		from = to.AddDate(0, 0, -29)
+		err  error
+	)
+
+	if value := r.FormValue("to"); value != "" {
+		if to, err = time.Parse(time.DateOnly, value); err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Dates need to be in YYYY-MM-DD format!")
+			return
+		}
+		from = to.AddDate(0, 0, -29)
+	}
+	if value := r.FormValue("from"); value != "" {
+		if from, err = time.Parse(time.DateOnly, value); err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Dates need to be in YYYY-MM-DD format!")
+			return
+		}
+	}
+
+	if from.After(to) || to.Sub(from) > time.Hour*24*366 {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Range needs to be between 1 and 366 days!")
+		return
+	}
+
+	until := to.Format(time.DateOnly)
+	orders, err := a.repo(r).GetFilledOrders(user.Id, until)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+	// Only prices of coins the user traded are needed for the equity curve
+	traded := map[string]bool{}
+	coinIds := []string{}
+	for _, order := range orders {
+		if !traded[order.CoinId] {
+			traded[order.CoinId] = true
+			coinIds = append(coinIds, order.CoinId)
+		}
+	}
+	prices := []m.PriceHistory{}
+	if len(coinIds) > 0 {
+		key := fmt.Sprintf("daily-prices/%s/until/%s", strings.Join(coinIds, ","), until)
+		prices, err = cachedHistory(a, a.virtualDate(), key, func() ([]m.PriceHistory, error) {
+			return a.db.GetDailyPrices("", until, coinIds...)
+		})
+	}
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	report := computePnl(orders, prices, user.UsdStartingBalance, from, to)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// @Summary		  User portfolio
+// @Description	Get the usd and coin balances of a user
+// @Tags		    Portfolio
+// @Produce	    json
+// @Param		    id	path		int	true	"User id"
+// @Success	    200	{object}	m.Portfolio
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"user not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/users/{id}/portfolio [get]
+// @Security		Bearer
+func (a *Api) getUserPortfolio(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	userId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "User id needs to be a number!")
+		return
+	}
+
+	// CWE-639: Authorization Bypass Through User-Controlled Key
+	// Any logged in user can read the balances of every other user. Other
+	// users' portfolios are reported as missing when fixed, so ids can't be
+	// probed for existing users.
+	if !a.vulnerable(VulnTransactionIDOR) && !canAccessUser(user, userId) {
+		writeError(w, http.StatusNotFound, codeNotFound, database.ErrPortfolioNotFound.Error())
+		return
+	}
+
+	portfolio, err := a.repo(r).GetPortfolio(userId)
+	if errors.Is(err, database.ErrPortfolioNotFound) {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	} else if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(portfolio)
+}
+
+// @Summary		  Close position
+// @Description	Sells the whole balance of a coin at the current price, realized profit is matched against the oldest buys
+// @Tags		    Portfolio
+// @Produce	    json
+// @Param		    coin_id	path		string	true	"Coin id"
+// @Success	    200	{object}	m.ClosedPosition
+// @Failure	    400	{object}	APIError	"coin delisted"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"no position in coin"
+// @Failure	    412	{object}	APIError	"not enough coin"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/portfolio/positions/{coin_id} [delete]
+// @Security		Bearer
+func (a *Api) closePosition(w http.ResponseWriter, r *http.Request) {
+	var (
+		user   = r.Context().Value("user").(m.User)
+		coinId = chi.URLParam(r, "coin_id")
+		qty    float64
+	)
+
+	for _, balance := range user.CoinBalances {
+		if balance.CoinId == coinId {
+			qty = balance.Qty
+		}
+	}
+	if qty <= 0 {
+		writeError(w, http.StatusNotFound, codeNotFound, "No position in requested coin!")
+		return
+	}
+
+	// Coins stay in balances after they stop getting prices
+	coin, err := a.getCoin(coinId)
+	if err != nil || coin.Price <= 0 {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Requested coin is delisted!")
+		return
+	}
+
+	virtualDate := a.virtualDate()
+	orders, err := a.repo(r).GetFilledOrders(user.Id, virtualDate.Format(time.DateOnly))
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	lots := fifoLots{}
+	for _, order := range orders {
+		if order.CoinId != coinId {
+			continue
+		}
+		if order.IsBuy {
+			lots.buy(order.CoinId, order.Qty, order.Price)
+		} else {
+			lots.sell(order.CoinId, order.Qty, order.Price)
+		}
+	}
+
+	err = a.repo(r).AddOrder(user.Id, coin.Id, coin.Price, false, qty, virtualDate)
+	if errors.Is(err, database.ErrNotEnoughCoin) {
+		writeError(w, http.StatusPreconditionFailed, codePreconditionFailed, err.Error())
+		return
+	} else if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	order := m.Order{
+		UserId:      user.Id,
+		CoinId:      coin.Id,
+		Price:       coin.Price,
+		IsBuy:       false,
+		Qty:         qty,
+		VirtualDate: virtualDate.Format(time.DateOnly),
+		Status:      "filled",
+	}
+	if err = a.Notify(user.Id, notificationOrderFilled, order); err != nil {
+		log.Println(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m.ClosedPosition{
+		CoinId:      coin.Id,
+		Qty:         qty,
+		Price:       coin.Price,
+		RealizedPnl: lots.sell(coin.Id, qty, coin.Price),
+	})
+}
+
+// @Summary		  Get trade history
+// @Description	Fetches filled orders newest first, one page at a time
+// @Tags		    Portfolio
+// @Produce	    json
+// @Param		    coin_id		query		string	false	"Coin id"
+// @Param		    type			query		string	false	"buy or sell"
+// @Param		    from			query		string	false	"First virtual date (YYYY-MM-DD)"
+// @Param		    to				query		string	false	"Last virtual date (YYYY-MM-DD)"
+// @Param		    page			query		int			false	"Page number, starting at 1"
+// @Param		    per_page	query		int			false	"Page size (max 100)"
+// @Success	    200	{object}	m.TradePage
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/portfolio/transactions [get]
+// @Security		Bearer
+func (a *Api) getTrades(w http.ResponseWriter, r *http.Request) {
+	var (
+		user    = r.Context().Value("user").(m.User)
+		page    = 1
+		perPage = 50
+		filter  = database.OrderFilter{
+			CoinId: r.FormValue("coin_id"),
+			From:   r.FormValue("from"),
+			To:     r.FormValue("to"),
+		}
+		err error
+	)
+
+	switch r.FormValue("type") {
+	case "":
+	case "buy", "sell":
+		isBuy := r.FormValue("type") == "buy"
+		filter.IsBuy = &isBuy
+	default:
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Type needs to be 'buy' or 'sell'!")
+		return
+	}
+
+	for _, date := range []string{filter.From, filter.To} {
+		if _, err = time.Parse(time.DateOnly, date); date != "" && err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Dates need to be in YYYY-MM-DD format!")
+			return
+		}
+	}
+
+	if value := r.FormValue("page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil || page < 1 {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Page needs to be >= 1!")
+			return
+		}
+	}
+	if value := r.FormValue("per_page"); value != "" {
+		if perPage, err = strconv.Atoi(value); err != nil || perPage < 1 || perPage > 100 {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Per page needs to be between 1 and 100!")
+			return
+		}
+	}
+	filter.Limit, filter.Offset = perPage, (page-1)*perPage
+
+	trades, total, err := a.repo(r).GetTrades(user.Id, filter)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m.TradePage{
+		Trades:  trades,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+	})
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	m "govulnapi/models"
+)
+
+func TestClosePosition(t *testing.T) {
+	a, _ := NewForTesting(WithPriceProvider(seriesPrices{
+		"bitcoin":  {800, 900, 1000},
+		"litecoin": {25, 25, 0},
+	}))
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "alice@example.com", "password123")
+
+	order := func(body string) {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if w := serve(a, r, token); w.Code != http.StatusOK {
+			t.Fatalf("ordering %s answered %d %s", body, w.Code, w.Body)
+		}
+	}
+	closePosition := func(coinId string) *httptest.ResponseRecorder {
+		return serve(a, httptest.NewRequest(http.MethodDelete, "/api/portfolio/positions/"+coinId, nil), token)
+	}
+
+	// Lots of 1 at $800 and 1 at $900 are left after selling the oldest
+	order(`{"CoinId":"bitcoin","IsBuy":true,"Qty":2}`)
+	order(`{"CoinId":"litecoin","IsBuy":true,"Qty":4}`)
+	a.advanceDays(1)
+	order(`{"CoinId":"bitcoin","IsBuy":true,"Qty":1}`)
+	order(`{"CoinId":"bitcoin","IsBuy":false,"Qty":1}`)
+	a.advanceDays(1)
+
+	w := closePosition("bitcoin")
+	if w.Code != http.StatusOK {
+		t.Fatalf("closing the position answered %d %s", w.Code, w.Body)
+	}
+	var closed m.ClosedPosition
+	if err := json.NewDecoder(w.Body).Decode(&closed); err != nil {
+		t.Fatal(err)
+	}
+	want := m.ClosedPosition{
+		CoinId:      "bitcoin",
+		Qty:         2,
+		Price:       m.UsdFromFloat(1000),
+		RealizedPnl: m.UsdFromFloat(300), // (1000 - 800) + (1000 - 900)
+	}
+	if closed != want {
+		t.Errorf("got %+v, want %+v", closed, want)
+	}
+
+	var balances map[string]m.Usd
+	w = serve(a, httptest.NewRequest(http.MethodGet, "/api/balances/usd", nil), token)
+	if err := json.NewDecoder(w.Body).Decode(&balances); err != nil {
+		t.Fatal(err)
+	}
+	// 10000 - 2 * 800 - 4 * 25 - 900 + 900 + 2 * 1000
+	if want := m.UsdFromFloat(10300); balances["UsdBalance"] != want {
+		t.Errorf("usd balance is %v after closing, want %v", balances["UsdBalance"], want)
+	}
+
+	for coinId, want := range map[string]string{
+		"bitcoin":  codeNotFound,   // Closed already
+		"dogecoin": codeNotFound,   // Never bought
+		"litecoin": codeBadRequest, // Without a price anymore
+	} {
+		checkAPIError(t, "closing "+coinId, closePosition(coinId), want)
+	}
+
+	w = serve(a, httptest.NewRequest(http.MethodDelete, "/api/portfolio/positions/bitcoin", nil), "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("closing without a token answered %d, want 401", w.Code)
+	}
+}
+
+func TestGetTradesFilters(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "alice@example.com", "password123")
+	bob := login(t, a, "bob@example.com", "password123")
+
+	order := func(token, body string) {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if w := serve(a, r, token); w.Code != http.StatusOK {
+			t.Fatalf("ordering %s answered %d %s", body, w.Code, w.Body)
+		}
+	}
+
+	order(token, `{"CoinId":"bitcoin","IsBuy":true,"Qty":1}`)
+	order(token, `{"CoinId":"litecoin","IsBuy":true,"Qty":2}`)
+	order(bob, `{"CoinId":"bitcoin","IsBuy":true,"Qty":3}`)
+	a.advanceDays(1)
+	order(token, `{"CoinId":"bitcoin","IsBuy":false,"Qty":0.5}`)
+	order(token, `{"CoinId":"dogecoin","IsBuy":true,"Qty":1000}`)
+	// Open limit orders aren't trades yet
+	order(token, `{"CoinId":"bitcoin","IsBuy":true,"Qty":1,"LimitPrice":1}`)
+	a.advanceDays(1)
+	order(token, `{"CoinId":"litecoin","IsBuy":false,"Qty":1}`)
+	order(token, `{"CoinId":"bitcoin","IsBuy":true,"Qty":0.25}`)
+
+	// Trades are named by side, quantity, coin and day of January
+	for _, test := range []struct {
+		query string
+		want  []string
+		total int
+	}{
+		{"", []string{"buy 0.25 bitcoin 3", "sell 1 litecoin 3", "buy 1000 dogecoin 2", "sell 0.5 bitcoin 2", "buy 2 litecoin 1", "buy 1 bitcoin 1"}, 6},
+		{"coin_id=bitcoin", []string{"buy 0.25 bitcoin 3", "sell 0.5 bitcoin 2", "buy 1 bitcoin 1"}, 3},
+		{"type=sell", []string{"sell 1 litecoin 3", "sell 0.5 bitcoin 2"}, 2},
+		{"type=buy&coin_id=litecoin", []string{"buy 2 litecoin 1"}, 1},
+		{"from=2014-01-02", []string{"buy 0.25 bitcoin 3", "sell 1 litecoin 3", "buy 1000 dogecoin 2", "sell 0.5 bitcoin 2"}, 4},
+		{"to=2014-01-02", []string{"buy 1000 dogecoin 2", "sell 0.5 bitcoin 2", "buy 2 litecoin 1", "buy 1 bitcoin 1"}, 4},
+		{"from=2014-01-02&to=2014-01-02", []string{"buy 1000 dogecoin 2", "sell 0.5 bitcoin 2"}, 2},
+		{"from=2014-01-02&to=2014-01-03&type=buy", []string{"buy 0.25 bitcoin 3", "buy 1000 dogecoin 2"}, 2},
+		{"coin_id=bitcoin&type=buy&from=2014-01-01&to=2014-01-02", []string{"buy 1 bitcoin 1"}, 1},
+		{"coin_id=ripple", []string{}, 0},
+		{"from=2014-01-03&to=2014-01-01", []string{}, 0},
+		// Pages are counted over the filtered trades
+		{"per_page=2", []string{"buy 0.25 bitcoin 3", "sell 1 litecoin 3"}, 6},
+		{"per_page=2&page=2", []string{"buy 1000 dogecoin 2", "sell 0.5 bitcoin 2"}, 6},
+		{"per_page=4&page=2", []string{"buy 2 litecoin 1", "buy 1 bitcoin 1"}, 6},
+		{"per_page=2&page=4", []string{}, 6},
+		{"coin_id=bitcoin&per_page=1&page=2", []string{"sell 0.5 bitcoin 2"}, 3},
+		{"type=buy&from=2014-01-01&per_page=2&page=2", []string{"buy 2 litecoin 1", "buy 1 bitcoin 1"}, 4},
+	} {
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/portfolio/transactions?"+test.query, nil), token)
+		if w.Code != http.StatusOK {
+			t.Fatalf("getting trades with %q answered %d %s", test.query, w.Code, w.Body)
+		}
+		var page m.TradePage
+		if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+			t.Fatal(err)
+		}
+
+		got := []string{}
+		for _, trade := range page.Trades {
+			side := map[bool]string{true: "buy", false: "sell"}[trade.IsBuy]
+			got = append(got, fmt.Sprintf("%s %v %s %s", side, trade.Qty, trade.CoinId, strings.TrimPrefix(trade.VirtualDate[:10], "2014-01-0")))
+		}
+		if !slices.Equal(got, test.want) || page.Total != test.total {
+			t.Errorf("%q listed %v of %d, want %v of %d", test.query, got, page.Total, test.want, test.total)
+		}
+	}
+
+	// Rows carry the coin's listing and the value of the trade
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/portfolio/transactions?coin_id=litecoin&type=buy", nil), token)
+	var page m.TradePage
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil || len(page.Trades) != 1 {
+		t.Fatalf("got %+v (%v), want the litecoin buy", page, err)
+	}
+	trade := page.Trades[0]
+	if trade.CoinName != "Litecoin" || trade.CoinSymbol != "LTC" || trade.Price != m.UsdFromFloat(25) || trade.Total != m.UsdFromFloat(50) {
+		t.Errorf("got %+v, want 2 Litecoin (LTC) for $50", trade)
+	}
+
+	for _, query := range []string{"type=hold", "from=01/01/2014", "to=yesterday", "page=0", "per_page=101", "per_page=x"} {
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/portfolio/transactions?"+query, nil), token)
+		checkAPIError(t, query, w, codeBadRequest)
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary		  List reports
+// @Description	Lists the file names of the user's reports, a portfolio summary is generated at the end of every virtual month
+// @Tags		    Reports
+// @Produce	    json
+// @Success	    200	{array}	string
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/reports [get]
+// @Security		Bearer
+func (a *Api) getReports(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	names, err := a.listReports(user.Id)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(names)
+}
+
+// @Summary		  Generate report
+// @Description	Writes the portfolio summary of the current virtual month so far, it's replaced by the final one at the
+// @Description	end of the month
+// @Tags		    Reports
+// @Produce	    plain
+// @Success	    200	"file name of the report"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    403	{object}	APIError	"admins have no portfolio"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/reports [post]
+// @Security		Bearer
+func (a *Api) addReport(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	if user.Role != "user" {
+		writeError(w, http.StatusForbidden, codeForbidden, "Only users have a portfolio!")
+		return
+	}
+
+	name, err := a.generateMonthToDateReport(a.repo(r), user.Id)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Write([]byte(name))
+}
+
+// @Summary		  Download report
+// @Description	Downloads one of the user's reports
+// @Tags		    Reports
+// @Produce	    text/csv
+// @Param		    filename	path		string	true	"Report file name"	example(portfolio-2014-01.csv)
+// @Success	    200	"report"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"report not found"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/reports/{filename} [get]
+// @Security		Bearer
+func (a *Api) getReport(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	file, err := a.openReport(user.Id, chi.URLParam(r, "filename"))
+	if errors.Is(err, errReportNotFound) || errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, codeNotFound, errReportNotFound.Error())
+		return
+	}
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err != nil || info.IsDir() {
+		writeError(w, http.StatusNotFound, codeNotFound, errReportNotFound.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="report.csv"`)
+	io.Copy(w, file)
+}
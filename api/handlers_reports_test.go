@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetReportPathTraversal(t *testing.T) {
+	for _, pathTraversal := range []bool{true, false} {
+		dir := t.TempDir()
+		secret := filepath.Join(dir, "secret.csv")
+		if err := os.WriteFile(secret, []byte("top,secret\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		a, _ := NewForTesting(
+			WithReportsDir(filepath.Join(dir, "reports")),
+			WithVulnerabilities(map[string]bool{VulnReportPathTraversal: pathTraversal}),
+		)
+		t.Cleanup(a.Shutdown)
+		token := login(t, a, "alice@example.com", "password123")
+
+		// Reports of the user are served either way
+		w := serve(a, httptest.NewRequest(http.MethodPost, "/api/reports", nil), token)
+		if w.Code != http.StatusOK {
+			t.Fatalf("generating a report answered %d %s", w.Code, w.Body)
+		}
+		if w := serve(a, httptest.NewRequest(http.MethodGet, "/api/reports/"+w.Body.String(), nil), token); w.Code != http.StatusOK {
+			t.Errorf("downloading the report answered %d %s", w.Code, w.Body)
+		}
+
+		// Reports are in a directory of the user within the reports
+		// directory, so the secret is two levels up
+		for _, name := range []string{
+			"..%2f..%2fsecret.csv",
+			"%2e%2e%2f%2e%2e%2fsecret.csv",
+			"%2E%2E%2F%2E%2E%2Fsecret.csv",
+			"..%2f..%2f..%2f" + filepath.Base(dir) + "%2fsecret.csv",
+		} {
+			w := serve(a, httptest.NewRequest(http.MethodGet, "/api/reports/"+name, nil), token)
+			if pathTraversal && (w.Code != http.StatusOK || w.Body.String() != "top,secret\n") {
+				t.Errorf("downloading %s answered %d %s with path traversal, want the secret", name, w.Code, w.Body)
+			}
+			if !pathTraversal && w.Code != http.StatusNotFound {
+				t.Errorf("downloading %s answered %d %s without path traversal, want 404", name, w.Code, w.Body)
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary		  Save strategy
+// @Description	Saves a trading strategy preset to backtest later. Strategies are JSON documents, their signal buys once
+// @Description	the price falls BuyBelowPct percent below its moving average over Window days, and sells once it rises
+// @Description	SellAbovePct percent above it.
+// @Tags		    Strategies
+// @Accept	    json
+// @Produce	    json
+// @Param		    strategy	body		m.Strategy	true	"Strategy"
+// @Success	    200	{object}	m.SavedStrategy
+// @Failure	    400	{object}	APIError	"invalid strategy"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    415	{object}	APIError	"unsupported format"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/strategies [post]
+// @Security		Bearer
+func (a *Api) addStrategy(w http.ResponseWriter, r *http.Request) {
+	var (
+		user    = r.Context().Value("user").(m.User)
+		format  = strategyFormatJSON
+		data    []byte
+		decoded strategy
+		err     error
+	)
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		var definition m.Strategy
+		if err = a.decodeJSON(r, &definition); err != nil {
+			writeDecodeError(w, err)
+			return
+		}
+		if decoded, err = a.strategyFromDefinition(definition); err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+			return
+		}
+		data, _ = json.Marshal(definition)
+	} else {
+		format = strategyFormatGob
+		if data, err = io.ReadAll(io.LimitReader(r.Body, strategyMaxBytes)); err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+			return
+		}
+		decoded, err = a.decodeStrategy(format, data)
+		if errors.Is(err, errStrategyFormat) {
+			writeError(w, http.StatusUnsupportedMediaType, codeUnsupportedMediaType, "Strategies need to be JSON!")
+			return
+		} else if err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+			return
+		}
+	}
+
+	saved, err := a.db.AddStrategy(user.Id, decoded.Name, format, data, time.Now())
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// @Summary		  List strategies
+// @Description	Lists the user's saved strategies, oldest first
+// @Tags		    Strategies
+// @Produce	    json
+// @Success	    200	{array}	m.SavedStrategy
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/strategies [get]
+// @Security		Bearer
+func (a *Api) getStrategies(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	strategies, err := a.db.GetStrategies(user.Id)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(strategies)
+}
+
+// @Summary		  Run strategy
+// @Description	Backtests a saved strategy against the coin's price history up to the current virtual date, trading at
+// @Description	closing prices, and compares its hypothetical profit and loss to buying on the first day and holding
+// @Tags		    Strategies
+// @Produce	    json
+// @Param		    id	path		int	true	"Strategy id"
+// @Success	    200	{object}	m.StrategyRun
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"strategy not found"
+// @Failure	    422	{object}	APIError	"strategy can't be run"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/strategies/{id}/run [get]
+// @Security		Bearer
+func (a *Api) runSavedStrategy(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	strategyId, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Strategy id needs to be a number!")
+		return
+	}
+
+	saved, err := a.db.GetStrategy(user.Id, strategyId)
+	if errors.Is(err, database.ErrStrategyNotFound) {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	} else if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	decoded, err := a.decodeStrategy(saved.Format, saved.Data)
+	if errors.Is(err, errStrategyFormat) {
+		writeError(w, http.StatusUnprocessableEntity, codeUnsupportedMediaType, err.Error())
+		return
+	} else if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, codeBadRequest, err.Error())
+		return
+	}
+
+	run, err := a.runStrategy(r.Context(), saved.Id, decoded)
+	var signalErr signalError
+	switch {
+	case errors.Is(err, errStrategyNoHistory):
+		writeError(w, http.StatusUnprocessableEntity, codeInsufficientHistory, err.Error())
+		return
+	case errors.As(err, &signalErr):
+		writeError(w, http.StatusUnprocessableEntity, codeBadRequest, err.Error())
+		return
+	case err != nil:
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
@@ -0,0 +1,648 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"govulnapi/mockgecko"
+	m "govulnapi/models"
+)
+
+func FuzzAddOrder(f *testing.F) {
+	f.Add([]byte(`{"CoinId":"bitcoin","IsBuy":true,"Qty":0.5}`))
+	f.Add([]byte(`{"CoinId":"BTC","IsBuy":false,"Qty":0.25}`))
+	f.Add([]byte(`{"CoinId":"bitcoin","IsBuy":true,"Qty":1e308}`))
+	f.Add([]byte(`{"CoinId":"dogecoin","IsBuy":false,"Qty":-1e308}`))
+	f.Add([]byte(`{"CoinId":"litecoin","IsBuy":true,"Qty":5e-324}`))
+	f.Add([]byte(`{"CoinId":"bit\u0000coin","IsBuy":true,"Qty":1}`))
+	f.Add([]byte(`{"UserId":1,"CoinId":"bitcoin","IsBuy":true,"Qty":1,"Price":-99999999999999999999}`))
+	f.Add([]byte(`{"CoinId":"bitcoin","Qty":"1","Unknown":true}`))
+	f.Add([]byte(`{"CoinId":` + strings.Repeat("[", 1000) + strings.Repeat("]", 1000) + `}`))
+	f.Add([]byte(`null`))
+	f.Add([]byte(``))
+
+	a, _ := NewForTesting()
+	f.Cleanup(a.Shutdown)
+	token := login(f, a, "fuzzer@example.com", "password123")
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		r := httptest.NewRequest(http.MethodPost, "/api/orders", bytes.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := serve(a, r, token)
+		checkFuzzedResponse(t, a, w)
+	})
+}
+
+func TestGetCoinsAddsNames(t *testing.T) {
+	a, repo := newMockedForTesting(t)
+	repo.EXPECT().GetCoinNames().Return([]m.NewCoin{
+		{Id: "bitcoin", Name: "Bitcoin", Symbol: "BTC"},
+		{Id: "litecoin", Name: "Litecoin", Symbol: "LTC"},
+	}, nil)
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins?sort=market_cap_desc", nil), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("listing coins answered %d %s", w.Code, w.Body)
+	}
+	var list m.CoinList
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Count != len(TestPrices) || list.Data[0].Id != "bitcoin" || list.Data[0].Symbol != "BTC" {
+		t.Errorf("listed %+v, want every test coin starting with bitcoin", list)
+	}
+	for _, coin := range list.Data {
+		if coin.Id == "dogecoin" && coin.Name != "" {
+			t.Errorf("dogecoin is named %q without a listing", coin.Name)
+		}
+	}
+}
+
+func TestGetCoinsSparseFields(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+
+	for fields, want := range map[string][]string{
+		"id,name":         {"Id", "Name"},
+		"ID, symbol ":     {"Id", "Symbol"},
+		"price":           {"Price"},
+		"name,name,price": {"Name", "Price"},
+	} {
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins?fields="+url.QueryEscape(fields), nil), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("listing %s answered %d %s", fields, w.Code, w.Body)
+		}
+		var list struct {
+			Data  []map[string]json.RawMessage `json:"data"`
+			Count int                          `json:"count"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+			t.Fatal(err)
+		}
+		if list.Count != len(TestPrices) || len(list.Data) != len(TestPrices) {
+			t.Errorf("listing %s gave %d coins of %d, want %d", fields, len(list.Data), list.Count, len(TestPrices))
+		}
+		for _, coin := range list.Data {
+			keys := make([]string, 0, len(coin))
+			for key := range coin {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			if !reflect.DeepEqual(keys, want) {
+				t.Errorf("listing %s kept %v, want %v", fields, keys, want)
+			}
+		}
+	}
+
+	for _, fields := range []string{"id,nope", "nope", ",", "id,"} {
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins?fields="+url.QueryEscape(fields), nil), "")
+		if w.Code != http.StatusBadRequest || w.Header().Get("X-Error-Code") != codeBadRequest {
+			t.Errorf("listing %q answered %d %s, want a bad request", fields, w.Code, w.Header().Get("X-Error-Code"))
+		}
+	}
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins?fields=id,nope", nil), "")
+	if !strings.Contains(w.Body.String(), `\"nope\"`) || strings.Contains(w.Body.String(), `\"id\"`) {
+		t.Errorf("listing an unknown field answered %s, want only it named", w.Body)
+	}
+}
+
+func TestGetCoinsHidesRepositoryErrors(t *testing.T) {
+	a, repo := newMockedForTesting(t, WithVulnerabilities(map[string]bool{VulnDebugResponses: false}))
+	repo.EXPECT().GetCoinNames().Return(nil, errors.New("database is locked"))
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins", nil), "")
+	if w.Code != http.StatusInternalServerError || w.Header().Get("X-Error-Code") != codeInternal {
+		t.Fatalf("answered %d %s, want an internal error", w.Code, w.Header().Get("X-Error-Code"))
+	}
+	if strings.Contains(w.Body.String(), "locked") {
+		t.Errorf("answered %s, leaking the error", w.Body)
+	}
+}
+
+func TestGetCoinDetail(t *testing.T) {
+	a, _ := NewForTesting(WithPriceProvider(dailyPrices{100, 110}))
+	t.Cleanup(a.Shutdown)
+	a.advanceDays(1)
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/bitcoin", nil), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("getting bitcoin answered %d %s", w.Code, w.Body)
+	}
+	var detail m.CoinDetail
+	if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+		t.Fatal(err)
+	}
+	if detail.Id != "bitcoin" || detail.Name != "Bitcoin" || detail.Symbol != "BTC" || detail.Price != m.UsdFromFloat(110) {
+		t.Errorf("detail = %+v, want bitcoin at 110", detail)
+	}
+	// 100 on 2014-01-01, 110 on 2014-01-02
+	if detail.Change24hPercent == nil || *detail.Change24hPercent != percentChange(m.UsdFromFloat(100), m.UsdFromFloat(110)) {
+		t.Errorf("change = %v, want 10%%", detail.Change24hPercent)
+	}
+
+	for id, code := range map[string]string{
+		"nocoin":         codeNotFound,
+		"Bitcoin":        codeNotFound,
+		"bit%20coin":     codeBadRequest,
+		"bitcoin--cash":  codeBadRequest,
+		"%27%20OR%201=1": codeBadRequest,
+	} {
+		checkAPIError(t, "getting "+id, serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/"+id, nil), ""), code)
+	}
+}
+
+func TestGetCoinDetailBySymbol(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+
+	for _, symbol := range []string{"BTC", "btc", "Btc"} {
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/"+symbol, nil), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("getting %s answered %d %s", symbol, w.Code, w.Body)
+		}
+		var detail m.CoinDetail
+		if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+			t.Fatal(err)
+		}
+		if detail.Id != "bitcoin" || detail.Symbol != "BTC" || detail.Name != "Bitcoin" || detail.Price != m.UsdFromFloat(800) {
+			t.Errorf("got %+v for %s, want bitcoin", detail, symbol)
+		}
+	}
+}
+
+func TestGetPriceChange(t *testing.T) {
+	a, _ := NewForTesting(WithPriceProvider(mockgecko.New()))
+	t.Cleanup(a.Shutdown)
+	a.advanceDays(90)
+
+	// Closing prices of the fixtures on 2014-04-01 and 1, 7, 30 and 90 days
+	// before
+	now := m.UsdFromFloat(478.7163)
+	want := map[string]float64{
+		"1d":  percentChange(m.UsdFromFloat(457.399), now),
+		"7d":  percentChange(m.UsdFromFloat(582.55), now),
+		"30d": percentChange(m.UsdFromFloat(561.35), now),
+		"90d": percentChange(m.UsdFromFloat(767.74), now),
+	}
+
+	// The second request is served from the cached history
+	for i := 0; i < 2; i++ {
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/bitcoin/price-change", nil), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("getting the price change answered %d %s", w.Code, w.Body)
+		}
+		var change map[string]*float64
+		if err := json.NewDecoder(w.Body).Decode(&change); err != nil {
+			t.Fatal(err)
+		}
+		for window, percent := range want {
+			if change[window] == nil {
+				t.Errorf("no %s change, want %v", window, percent)
+			} else if *change[window] != percent {
+				t.Errorf("%s change = %v, want %v", window, *change[window], percent)
+			}
+		}
+	}
+}
+
+func TestGetPriceChangeInsufficientHistory(t *testing.T) {
+	a, _ := NewForTesting(WithPriceProvider(mockgecko.New()))
+	t.Cleanup(a.Shutdown)
+
+	getChange := func(coinId string) map[string]*float64 {
+		t.Helper()
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/"+coinId+"/price-change", nil), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("getting the price change of %s answered %d %s", coinId, w.Code, w.Body)
+		}
+		var change map[string]*float64
+		if err := json.NewDecoder(w.Body).Decode(&change); err != nil {
+			t.Fatal(err)
+		}
+		return change
+	}
+
+	// Days are advanced one after the other. Windows reaching before the
+	// first virtual day are null, as are the ones of dogecoin on 2014-01-10,
+	// which the fixtures have no price of.
+	for _, test := range []struct {
+		days    int
+		coinId  string
+		changed []string
+	}{
+		{days: 0, coinId: "bitcoin", changed: nil},
+		{days: 1, coinId: "bitcoin", changed: []string{"1d"}},
+		{days: 7, coinId: "bitcoin", changed: []string{"1d", "7d"}},
+		{days: 1, coinId: "dogecoin", changed: nil},
+		{days: 1, coinId: "dogecoin", changed: []string{"7d"}},
+		{days: 20, coinId: "bitcoin", changed: []string{"1d", "7d", "30d"}},
+	} {
+		a.advanceDays(test.days)
+		change := getChange(test.coinId)
+		for _, window := range []string{"1d", "7d", "30d", "90d"} {
+			want := false
+			for _, changed := range test.changed {
+				want = want || changed == window
+			}
+			if got := change[window] != nil; got != want {
+				t.Errorf("%s on %s has a %s change: %t, want %t", test.coinId, a.virtualDate().Format(time.DateOnly), window, got, want)
+			}
+		}
+	}
+}
+
+// Prices bitcoin at the given closing price on each virtual day from
+// 2014-01-01 on, keeping the last one afterwards
+type dailyPrices []float64
+
+func (d dailyPrices) Prices(_ context.Context, date time.Time) ([]m.Coin, error) {
+	day := int(date.Sub(time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	if day >= len(d) {
+		day = len(d) - 1
+	}
+	return []m.Coin{{Id: "bitcoin", Price: m.UsdFromFloat(d[day])}}, nil
+}
+
+func TestSma(t *testing.T) {
+	for _, test := range []struct {
+		prices []float64
+		want   float64
+	}{
+		{prices: []float64{10, 20}, want: 15},
+		{prices: []float64{1, 2, 3, 4, 5, 6, 7}, want: 4},
+		{prices: []float64{800, 800, 800}, want: 800},
+		{prices: []float64{45000, 45321.5, 45643}, want: 45321.5},
+		{prices: []float64{0.0004, 0.0006}, want: 0.0005},
+	} {
+		if got := sma(test.prices); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("sma(%v) = %v, want %v", test.prices, got, test.want)
+		}
+	}
+}
+
+func TestGetMovingAverage(t *testing.T) {
+	a, _ := NewForTesting(WithPriceProvider(dailyPrices{10, 11, 13, 16, 20, 25, 31, 38, 46}))
+	t.Cleanup(a.Shutdown)
+	a.advanceDays(8)
+
+	for query, want := range map[string]float64{
+		"":          27, // (13 + 16 + 20 + 25 + 31 + 38 + 46) / 7
+		"?window=2": 42, // (38 + 46) / 2
+		"?window=5": 32, // (20 + 25 + 31 + 38 + 46) / 5
+		"?window=9": 210.0 / 9,
+	} {
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/bitcoin/moving-average"+query, nil), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("getting the moving average with %q answered %d %s", query, w.Code, w.Body)
+		}
+		var average m.MovingAverage
+		if err := json.NewDecoder(w.Body).Decode(&average); err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(average.Sma-want) > 1e-9 || average.CoinId != "bitcoin" || average.AsOfDate != "2014-01-09" {
+			t.Errorf("got %+v with %q, want an sma of %v as of 2014-01-09", average, query, want)
+		}
+	}
+
+	for query, want := range map[string]int{
+		"?window=10":  http.StatusUnprocessableEntity, // 9 days of history
+		"?window=1":   http.StatusBadRequest,
+		"?window=201": http.StatusBadRequest,
+		"?window=a":   http.StatusBadRequest,
+	} {
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/bitcoin/moving-average"+query, nil), "")
+		if w.Code != want {
+			t.Errorf("getting the moving average with %q answered %d, want %d", query, w.Code, want)
+		}
+	}
+}
+
+func TestGetOrdersPages(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "alice@example.com", "password123")
+
+	coins := []string{"bitcoin", "litecoin", "ripple", "litecoin", "namecoin"}
+	for _, coin := range coins {
+		r := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(`{"CoinId":"`+coin+`","IsBuy":true,"Qty":1}`))
+		r.Header.Set("Content-Type", "application/json")
+		if w := serve(a, r, token); w.Code != http.StatusOK {
+			t.Fatalf("buying %s answered %d %s", coin, w.Code, w.Body)
+		}
+	}
+
+	getPage := func(query string) m.OrderPage {
+		t.Helper()
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/orders?"+query, nil), token)
+		if w.Code != http.StatusOK {
+			t.Fatalf("getting orders with %s answered %d %s", query, w.Code, w.Body)
+		}
+		var page m.OrderPage
+		if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+			t.Fatal(err)
+		}
+		return page
+	}
+
+	// Pages of 2 list the orders newest first
+	var listed []string
+	for page, query := getPage("limit=2"), ""; ; page = getPage(query) {
+		for _, order := range page.Orders {
+			listed = append(listed, order.CoinId)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		if len(listed) > len(coins) {
+			t.Fatalf("listed %v, more orders than were made", listed)
+		}
+		query = url.Values{"limit": {"2"}, "cursor": {page.NextCursor}}.Encode()
+	}
+	if len(listed) != len(coins) {
+		t.Fatalf("listed %v, want %v newest first", listed, coins)
+	}
+	for i, coin := range listed {
+		if coin != coins[len(coins)-1-i] {
+			t.Fatalf("listed %v, want %v newest first", listed, coins)
+		}
+	}
+
+	if page := getPage("coin_id=litecoin&side=buy"); len(page.Orders) != 2 || page.NextCursor != "" {
+		t.Errorf("filtered page is %+v, want both litecoin orders", page)
+	}
+	for _, query := range []string{"limit=0", "limit=101", "cursor=not-a-cursor", "side=hold", "from=2014-1-1"} {
+		if w := serve(a, httptest.NewRequest(http.MethodGet, "/api/orders?"+query, nil), token); w.Code != http.StatusBadRequest {
+			t.Errorf("getting orders with %s answered %d, want 400", query, w.Code)
+		}
+	}
+}
+
+func TestExportTransactions(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "alice@example.com", "password123")
+
+	for _, order := range []string{
+		`{"CoinId":"bitcoin","IsBuy":true,"Qty":1.5}`,
+		`{"CoinId":"bitcoin","IsBuy":false,"Qty":0.5}`,
+		`{"CoinId":"litecoin","IsBuy":true,"Qty":4}`,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(order))
+		r.Header.Set("Content-Type", "application/json")
+		if w := serve(a, r, token); w.Code != http.StatusOK {
+			t.Fatalf("ordering %s answered %d %s", order, w.Code, w.Body)
+		}
+	}
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/transactions/export?coin_id=bitcoin", nil), token)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("exporting answered %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if disposition := w.Header().Get("Content-Disposition"); !strings.Contains(disposition, `filename="transactions.csv"`) {
+		t.Errorf("Content-Disposition is %q, want a transactions.csv attachment", disposition)
+	}
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bitcoin is at $800 in TestPrices, the balance starts at $10000
+	want := [][]string{
+		{"virtual_date", "coin", "side", "qty", "unit_price", "total", "usd_balance_after"},
+		{"2014-01-01", "bitcoin", "sell", "0.5", "800", "400", "9200"},
+		{"2014-01-01", "bitcoin", "buy", "1.5", "800", "1200", "8800"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("exported %q, want %q", rows, want)
+	}
+	for i, row := range rows {
+		// Skips the id, which depends on the orders of other users
+		if strings.Join(row[1:], ",") != strings.Join(want[i], ",") {
+			t.Errorf("row %d is %q, want %q", i, row[1:], want[i])
+		}
+	}
+
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/api/transactions/export?side=hold", nil), token); w.Code != http.StatusBadRequest {
+		t.Errorf("exporting with an invalid side answered %d, want 400", w.Code)
+	}
+	// Exports aren't paged, so a limit the order list rejects is ignored
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/api/transactions/export?limit=1000", nil), token); w.Code != http.StatusOK {
+		t.Errorf("exporting with a limit answered %d, want 200", w.Code)
+	}
+}
+
+func TestLimitOrdersInOrderBook(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "alice@example.com", "password123")
+	adminToken := login(t, a, "admin@govulnapi.com", "admin123")
+
+	getOrderBook := func() m.OrderBook {
+		t.Helper()
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/bitcoin/orderbook", nil), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("getting the order book answered %d %s", w.Code, w.Body)
+		}
+		var orderBook m.OrderBook
+		if err := json.NewDecoder(w.Body).Decode(&orderBook); err != nil {
+			t.Fatal(err)
+		}
+		return orderBook
+	}
+
+	// Bitcoin is at $800 in TestPrices, so the sell limit is reached already
+	for _, order := range []struct{ body, want string }{
+		{`{"CoinId":"bitcoin","IsBuy":true,"Qty":2,"LimitPrice":700}`, "Limit order placed!"},
+		{`{"CoinId":"bitcoin","IsBuy":true,"Qty":1}`, "Order successfully made!"},
+		{`{"CoinId":"bitcoin","IsBuy":false,"Qty":0.5,"LimitPrice":750}`, "Order successfully made!"},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(order.body))
+		r.Header.Set("Content-Type", "application/json")
+		if w := serve(a, r, token); w.Code != http.StatusOK || w.Body.String() != order.want {
+			t.Fatalf("ordering %s answered %d %s, want %s", order.body, w.Code, w.Body, order.want)
+		}
+	}
+
+	orderBook := getOrderBook()
+	bids := []m.OrderBookLevel{{IsBuy: true, Price: m.UsdFromFloat(700), Count: 1, Qty: 2}}
+	if orderBook.MarketPrice != m.UsdFromFloat(800) || !reflect.DeepEqual(orderBook.Bids, bids) || len(orderBook.Asks) != 0 {
+		t.Errorf("order book is %+v, want the bid at $700", orderBook)
+	}
+
+	// The price falling to the limit fills the bid
+	r := httptest.NewRequest(http.MethodPost, "/api/coins/bitcoin/price", strings.NewReader(`{"Price":690}`))
+	r.Header.Set("Content-Type", "application/json")
+	if w := serve(a, r, adminToken); w.Code != http.StatusOK {
+		t.Fatalf("overriding the price answered %d %s", w.Code, w.Body)
+	}
+	if orderBook = getOrderBook(); len(orderBook.Bids) != 0 {
+		t.Errorf("bids are %+v after the price fell, want none", orderBook.Bids)
+	}
+
+	// Listed newest first, the limit order was placed first
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/orders", nil), token)
+	var page m.OrderPage
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Orders) != 3 {
+		t.Fatalf("listed %+v, want 3 orders", page.Orders)
+	}
+	limitOrder := page.Orders[2]
+	if limitOrder.Status != "filled" || limitOrder.Price != m.UsdFromFloat(700) {
+		t.Errorf("limit order is %+v, want filled at $700", limitOrder)
+	}
+
+	if w := serve(a, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/orders/%d", limitOrder.Id), nil), token); w.Code != http.StatusNotFound {
+		t.Errorf("cancelling a filled order answered %d, want 404", w.Code)
+	}
+
+	// Open ones can be cancelled
+	r = httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(`{"CoinId":"bitcoin","IsBuy":true,"Qty":1,"LimitPrice":600}`))
+	r.Header.Set("Content-Type", "application/json")
+	if w := serve(a, r, token); w.Code != http.StatusOK {
+		t.Fatalf("placing a limit order answered %d %s", w.Code, w.Body)
+	}
+	w = serve(a, httptest.NewRequest(http.MethodGet, "/api/orders?limit=1", nil), token)
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil || len(page.Orders) != 1 || page.Orders[0].Status != "open" {
+		t.Fatalf("newest order is %+v (%v), want the open one", page.Orders, err)
+	}
+	if w := serve(a, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/orders/%d", page.Orders[0].Id), nil), token); w.Code != http.StatusOK {
+		t.Errorf("cancelling an open order answered %d %s, want 200", w.Code, w.Body)
+	}
+	if orderBook = getOrderBook(); len(orderBook.Bids) != 0 {
+		t.Errorf("bids are %+v after cancelling, want none", orderBook.Bids)
+	}
+}
+
+func TestGetTopMovers(t *testing.T) {
+	a, _ := NewForTesting(WithPriceProvider(seriesPrices{
+		"bitcoin":  {100, 100, 100, 100, 100, 200, 200, 150},
+		"litecoin": {10, 10, 10, 10, 10, 6, 6, 9},
+		"dogecoin": {1, 1, 1, 1, 1, 3, 3, 3},
+		"namecoin": {5, 5, 5, 5, 5, 5, 5, 5},
+		"ripple":   {2, 2, 2, 2, 2, 2, 2, 1},
+	}))
+	t.Cleanup(a.Shutdown)
+	a.advanceDays(7)
+
+	for _, test := range []struct {
+		path string
+		want []string
+	}{
+		// +200%, +50%, 0%, -10% and -50% since 7 days ago
+		{"/api/coins/top-gainers", []string{"dogecoin", "bitcoin", "namecoin", "litecoin", "ripple"}},
+		{"/api/coins/top-losers", []string{"ripple", "litecoin", "namecoin", "bitcoin", "dogecoin"}},
+		// +50%, 0%, 0%, -25% and -50% since 2 days ago
+		{"/api/coins/top-gainers?days=2", []string{"litecoin", "dogecoin", "namecoin", "bitcoin", "ripple"}},
+		{"/api/coins/top-losers?days=2", []string{"ripple", "bitcoin", "namecoin", "dogecoin", "litecoin"}},
+	} {
+		w := serve(a, httptest.NewRequest(http.MethodGet, test.path, nil), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("getting %s answered %d %s", test.path, w.Code, w.Body)
+		}
+		var changes []m.CoinChange
+		if err := json.NewDecoder(w.Body).Decode(&changes); err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, change := range changes {
+			ids = append(ids, change.Id)
+		}
+		if !slices.Equal(ids, test.want) {
+			t.Errorf("%s ranked %v, want %v", test.path, ids, test.want)
+		}
+	}
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/top-gainers", nil), "")
+	var changes []m.CoinChange
+	if err := json.NewDecoder(w.Body).Decode(&changes); err != nil {
+		t.Fatal(err)
+	}
+	if top := changes[0]; top.Price != m.UsdFromFloat(3) || top.PastPrice != m.UsdFromFloat(1) || math.Abs(top.ChangePercent-200) > 1e-9 {
+		t.Errorf("top gainer is %+v, want dogecoin up 200%% from $1 to $3", top)
+	}
+
+	checkAPIError(t, "30 days", serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/top-gainers?days=30", nil), ""), codeNotFound)
+	for _, days := range []string{"0", "91", "week"} {
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/top-losers?days="+days, nil), "")
+		checkAPIError(t, days+" days", w, codeBadRequest)
+	}
+}
+
+func TestMarketCapsOfRefreshedCoins(t *testing.T) {
+	server := httptest.NewServer(mockgecko.New().Handler())
+	t.Cleanup(server.Close)
+
+	// Volumes and market caps of the fixtures on 2014-01-01
+	want := map[string][2]float64{
+		"bitcoin":  {9358693020, 23448600},
+		"litecoin": {601777194, 8159590},
+		"dogecoin": {8079013, 307264},
+	}
+
+	// Coins are fetched all at once, or one by one by the workers
+	for _, workers := range []int{1, 3} {
+		prices := coingeckoPrices{
+			baseUrl: server.URL,
+			workers: workers,
+			coins:   func() ([]m.Coin, error) { return TestPrices, nil },
+		}
+		a, _ := NewForTesting(WithPriceProvider(prices))
+		t.Cleanup(a.Shutdown)
+
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins?sort=market_cap_desc", nil), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("getting the coins answered %d %s", w.Code, w.Body)
+		}
+		var list m.CoinList
+		if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, coin := range list.Data {
+			ids = append(ids, coin.Id)
+			if [2]float64{coin.MarketCap, coin.Volume24h} != want[coin.Id] {
+				t.Errorf("%d workers: %s has a market cap and volume of %v and %v, want %v", workers, coin.Id, coin.MarketCap, coin.Volume24h, want[coin.Id])
+			}
+		}
+		if !slices.Equal(ids, []string{"bitcoin", "litecoin", "dogecoin"}) {
+			t.Errorf("%d workers: sorted %v, want the largest market cap first", workers, ids)
+		}
+
+		w = serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/litecoin", nil), "")
+		var coin m.CoinDetail
+		if err := json.NewDecoder(w.Body).Decode(&coin); err != nil {
+			t.Fatal(err)
+		}
+		if [2]float64{coin.MarketCap, coin.Volume24h} != want["litecoin"] {
+			t.Errorf("%d workers: got %+v, want litecoin with its market cap and volume", workers, coin)
+		}
+
+		history, err := a.db.GetDailyPrices("2014-01-01", "2014-01-01", "bitcoin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 1 || [2]float64{history[0].MarketCap, history[0].Volume24h} != want["bitcoin"] {
+			t.Errorf("%d workers: recorded %+v, want the market cap and volume of bitcoin", workers, history)
+		}
+	}
+
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins?sort=price", nil), "")
+	checkAPIError(t, "sorting by price", w, codeBadRequest)
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	m "govulnapi/models"
+)
+
+// @Summary		  Send usd
+// @Description	Transfers usd to another user
+// @Tags		    Transfers
+// @Accept	    json
+// @Produce	    plain
+// @Param		    transfer	body m.Transfer	true	"New transfer"
+// @Success	    200	"transfer went through"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    412	{object}	APIError	"invalid amount, receiver or not enough usd"
+// @Router			/transfer [post]
+// @Security		Bearer
+func (a *Api) addTransfer(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	var transfer m.Transfer
+	if err := a.decodeJSON(r, &transfer); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	err := a.repo(r).TransferCash(user.Id, transfer.ToEmail, transfer.Amount, a.virtualDate(), a.vulnerable(VulnNegativeTransfers))
+	if err != nil {
+		writeError(w, http.StatusPreconditionFailed, codePreconditionFailed, err.Error())
+		return
+	}
+
+	w.Write([]byte("Transfer successfully made!"))
+}
+
+// @Summary		  Get past transfers
+// @Description	Fetches sent (negative amount) and received usd transfers
+// @Tags		    Transfers
+// @Produce	    json
+// @Success	    200	"ok"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/transfers [get]
+// @Security		Bearer
+func (a *Api) getTransfers(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	transfers, err := a.repo(r).GetCashTransactions(user.Id, "transfer")
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfers)
+}
+
+// @Summary		  Deposit usd
+// @Description	Adds usd to the balance, limited per virtual day
+// @Tags		    Transfers
+// @Accept	    json
+// @Produce	    plain
+// @Param		    amount	body m.CashAmount	true	"Amount"
+// @Success	    200	"deposit went through"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    412	{object}	APIError	"daily limit exceeded"
+// @Router			/deposit [post]
+// @Security		Bearer
+func (a *Api) deposit(w http.ResponseWriter, r *http.Request) {
+	a.adjustCash(w, r, "deposit", 1, a.getOptions().DailyDepositLimit)
+}
+
+// @Summary		  Withdraw usd
+// @Description	Removes usd from the balance, limited per virtual day
+// @Tags		    Transfers
+// @Accept	    json
+// @Produce	    plain
+// @Param		    amount	body m.CashAmount	true	"Amount"
+// @Success	    200	"withdrawal went through"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    412	{object}	APIError	"daily limit exceeded or not enough usd"
+// @Router			/withdraw [post]
+// @Security		Bearer
+func (a *Api) withdraw(w http.ResponseWriter, r *http.Request) {
+	a.adjustCash(w, r, "withdrawal", -1, a.getOptions().DailyWithdrawalLimit)
+}
+
+func (a *Api) adjustCash(w http.ResponseWriter, r *http.Request, transactionType string, sign m.Usd, dailyLimit float64) {
+	user := r.Context().Value("user").(m.User)
+
+	var cash m.CashAmount
+	if err := a.decodeJSON(r, &cash); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if cash.Amount <= 0 {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Amount needs to be > 0!")
+		return
+	}
+
+	err := a.repo(r).AdjustCash(user.Id, sign*cash.Amount, transactionType, a.virtualDate(), m.UsdFromFloat(dailyLimit))
+	if err != nil {
+		writeError(w, http.StatusPreconditionFailed, codePreconditionFailed, err.Error())
+		return
+	}
+
+	w.Write([]byte("Usd successfully updated!"))
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	m "govulnapi/models"
+)
+
+func TestTransfers(t *testing.T) {
+	for _, negativeTransfers := range []bool{false, true} {
+		a, _ := NewForTesting(WithVulnerabilities(map[string]bool{VulnNegativeTransfers: negativeTransfers}))
+		t.Cleanup(a.Shutdown)
+		alice := login(t, a, "alice@example.com", "password123")
+		bob := login(t, a, "bob@example.com", "password123")
+
+		transfer := func(token string, transfer string) int {
+			r := httptest.NewRequest(http.MethodPost, "/api/transfer", strings.NewReader(transfer))
+			r.Header.Set("Content-Type", "application/json")
+			return serve(a, r, token).Code
+		}
+		balance := func(token string) m.Usd {
+			var balances map[string]m.Usd
+			w := serve(a, httptest.NewRequest(http.MethodGet, "/api/balances/usd", nil), token)
+			if err := json.NewDecoder(w.Body).Decode(&balances); err != nil {
+				t.Fatal(err)
+			}
+			return balances["UsdBalance"]
+		}
+
+		if code := transfer(alice, `{"ToEmail":"bob@example.com","Amount":100.25}`); code != http.StatusOK {
+			t.Fatalf("transferring to bob answered %d", code)
+		}
+		for _, failing := range []string{
+			`{"ToEmail":"bob@example.com","Amount":1000000}`,
+			`{"ToEmail":"alice@example.com","Amount":1}`,
+			`{"ToEmail":"nobody@example.com","Amount":1}`,
+		} {
+			if code := transfer(alice, failing); code != http.StatusPreconditionFailed {
+				t.Errorf("transferring %s answered %d, want 412", failing, code)
+			}
+		}
+
+		// CWE-839: Negative amounts pull usd from the receiver when the
+		// vulnerability is enabled
+		code := transfer(alice, `{"ToEmail":"bob@example.com","Amount":-50}`)
+		if want := map[bool]int{false: http.StatusPreconditionFailed, true: http.StatusOK}[negativeTransfers]; code != want {
+			t.Errorf("negative transfers %v: transferring -50 answered %d, want %d", negativeTransfers, code, want)
+		}
+
+		sent := m.UsdFromFloat(100.25)
+		if negativeTransfers {
+			sent -= m.UsdFromFloat(50)
+		}
+		start := m.UsdFromFloat(10000)
+		if got := balance(alice); got != start-sent {
+			t.Errorf("negative transfers %v: alice has %v, want %v", negativeTransfers, got, start-sent)
+		}
+		if got := balance(bob); got != start+sent {
+			t.Errorf("negative transfers %v: bob has %v, want %v", negativeTransfers, got, start+sent)
+		}
+
+		var transfers []m.CashTransaction
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/transfers", nil), bob)
+		if err := json.NewDecoder(w.Body).Decode(&transfers); err != nil {
+			t.Fatal(err)
+		}
+		received := false
+		for _, transfer := range transfers {
+			if transfer.Amount == m.UsdFromFloat(100.25) && *transfer.CounterpartyEmail == "alice@example.com" {
+				received = true
+			}
+		}
+		if !received {
+			t.Errorf("negative transfers %v: transfers of bob are %+v, want the one from alice", negativeTransfers, transfers)
+		}
+	}
+}
+
+func TestTransactionIDOR(t *testing.T) {
+	for _, idor := range []bool{true, false} {
+		a, _ := NewForTesting(WithVulnerabilities(map[string]bool{VulnTransactionIDOR: idor}))
+		t.Cleanup(a.Shutdown)
+		alice := login(t, a, "alice@example.com", "password123")
+		bob := login(t, a, "bob@example.com", "password123")
+		mallory := login(t, a, "mallory@example.com", "password123")
+
+		post := func(token string, path string, body string) {
+			t.Helper()
+			r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			if w := serve(a, r, token); w.Code != http.StatusOK {
+				t.Fatalf("posting %s to %s answered %d %s", body, path, w.Code, w.Body)
+			}
+		}
+		get := func(token string, path string, v interface{}) int {
+			t.Helper()
+			w := serve(a, httptest.NewRequest(http.MethodGet, path, nil), token)
+			if w.Code == http.StatusOK && v != nil {
+				if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			return w.Code
+		}
+
+		var balances []m.CoinBalance
+		get(bob, "/api/balances/coin", &balances)
+		var address string
+		for _, balance := range balances {
+			if balance.CoinId == "bitcoin" {
+				address = balance.Address
+			}
+		}
+		post(alice, "/api/orders", `{"CoinId":"bitcoin","IsBuy":true,"Qty":1}`)
+		post(alice, "/api/transactions", `{"CoinId":"bitcoin","Address":"`+address+`","Qty":0.5}`)
+
+		var transactions []m.Transaction
+		get(alice, "/api/transactions", &transactions)
+		if len(transactions) != 1 {
+			t.Fatalf("alice has transactions %+v, want the one to bob", transactions)
+		}
+		sent := transactions[0]
+		id := sent.PublicId
+		if idor {
+			id = strconv.Itoa(sent.Id)
+		}
+
+		// Both sides of the transaction can read it
+		for name, token := range map[string]string{"alice": alice, "bob": bob} {
+			if code := get(token, "/api/transactions/"+id, nil); code != http.StatusOK {
+				t.Errorf("IDOR %v: %s reading the transaction answered %d", idor, name, code)
+			}
+		}
+		if code := get(alice, fmt.Sprintf("/api/users/%d/portfolio", sent.SenderId), nil); code != http.StatusOK {
+			t.Errorf("IDOR %v: alice reading her portfolio answered %d", idor, code)
+		}
+
+		// Others get 404 when fixed, as if it didn't exist
+		want := map[bool]int{true: http.StatusOK, false: http.StatusNotFound}[idor]
+		if code := get(mallory, "/api/transactions/"+id, nil); code != want {
+			t.Errorf("IDOR %v: mallory reading alice's transaction answered %d, want %d", idor, code, want)
+		}
+		if code := get(mallory, fmt.Sprintf("/api/users/%d/portfolio", sent.SenderId), nil); code != want {
+			t.Errorf("IDOR %v: mallory reading alice's portfolio answered %d, want %d", idor, code, want)
+		}
+		if !idor {
+			// Sequential ids aren't listed and don't find the transaction
+			if sent.Id != 0 {
+				t.Errorf("the sequential id %d is listed when fixed", sent.Id)
+			}
+			if code := get(alice, "/api/transactions/1", nil); code != http.StatusNotFound {
+				t.Errorf("reading transaction 1 answered %d when fixed, want 404", code)
+			}
+		}
+	}
+}
@@ -1,8 +1,14 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"govulnapi/api/database"
 	m "govulnapi/models"
 	"net/http"
+	"strconv"
+
+	"github.com/go-chi/jwtauth/v5"
 )
 
 // @Summary		  Update email
@@ -12,19 +18,18 @@ import (
 // @Produce	    plain
 // @Param		    email	formData string	true "New email"
 // @Success	    200	"email updated"
-// @Failure	    400	"bad request"
-// @Failure	    401	"unauthorized"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
 // @Router			/user/email [put]
 // @Security		Bearer
 func (a *Api) updateEmail(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value("user").(m.User)
 	newEmail := r.FormValue("email")
 
-	err := a.db.UpdateEmail(user.Id, newEmail)
+	err := a.repo(r).UpdateEmail(user.Id, newEmail)
 
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
 	} else {
 		w.Write([]byte("Email successfully updated!"))
 	}
@@ -37,8 +42,8 @@ func (a *Api) updateEmail(w http.ResponseWriter, r *http.Request) {
 // @Produce	    plain
 // @Param		    password formData string true "New password"
 // @Success	    200	"password changed"
-// @Failure	    400	"bad request"
-// @Failure	    401	"unauthorized"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
 // @Router			/user/password [put]
 // @Security		Bearer
 // CWE-549: Missing Password Field Masking
@@ -47,12 +52,270 @@ func (a *Api) updatePassword(w http.ResponseWriter, r *http.Request) {
 	newPassword := r.FormValue("password")
 
 	// CWE-620: Unverified Password Change
-	err := a.db.UpdatePassword(user.Id, newPassword)
+	err := a.repo(r).UpdatePassword(user.Id, newPassword)
 
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
 	} else {
 		w.Write([]byte("Password successfully updated!"))
 	}
 }
+
+// @Summary		  Update leaderboard visibility
+// @Description	Shows or hides user on the leaderboard from the next price refresh
+// @Tags		    User
+// @Accept	    x-www-form-urlencoded
+// @Produce	    plain
+// @Param		    visible formData bool true "Show user on the leaderboard"
+// @Success	    200	"visibility updated"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Router			/user/leaderboard [put]
+// @Security		Bearer
+func (a *Api) updateLeaderboardVisibility(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	visible, err := strconv.ParseBool(r.FormValue("visible"))
+	if err == nil {
+		err = a.repo(r).UpdateLeaderboardVisibility(user.Id, !visible)
+	}
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+	} else {
+		w.Write([]byte("Leaderboard visibility successfully updated!"))
+	}
+}
+
+// @Summary		  Profile
+// @Description	Get own account data
+// @Tags		    User
+// @Produce	    json
+// @Success	    200	{object}	m.Profile
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"user deleted"
+// @Router			/me [get]
+// @Security		Bearer
+func (a *Api) getMe(w http.ResponseWriter, r *http.Request) {
+	_, creds, _ := jwtauth.FromContext(r.Context())
+	userId, _ := creds["user_id"].(float64)
+
+	user, err := a.repo(r).GetUserById(int(userId))
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile(user))
+}
+
+// @Summary		  Reset sandbox
+// @Description	Deletes your copy of the lab data in sandbox mode, your next request starts over from the shared data
+// @Tags		    User
+// @Produce	    plain
+// @Success	    200	"sandbox reset"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"sandbox mode disabled"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/me/sandbox [delete]
+// @Security		Bearer
+func (a *Api) resetSandbox(w http.ResponseWriter, r *http.Request) {
+	_, creds, _ := jwtauth.FromContext(r.Context())
+	userId, _ := creds["user_id"].(float64)
+
+	if !a.getOptions().SandboxMode {
+		writeError(w, http.StatusNotFound, codeNotFound, "Sandbox mode is disabled!")
+		return
+	}
+
+	// Works without the user in the sandbox, e.g. once its table was dropped
+	if err := a.removeSandbox(int(userId)); err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Write([]byte("Sandbox successfully reset!"))
+}
+
+// Body of a profile update decoded over the whole user, along with the
+// passwords the user model has no fields for
+type profilePatch struct {
+	m.User
+	CurrentPassword string
+	NewPassword     string
+}
+
+// @Summary		  Update profile
+// @Description	Changes own display name, email and/or password, changing the email or password requires the current password
+// @Tags		    User
+// @Accept	    json
+// @Produce	    json
+// @Param		    update	body		m.ProfileUpdate	true	"New display name, email and/or password"
+// @Success	    200	{object}	m.Profile
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized or wrong current password"
+// @Failure	    409	{object}	APIError	"email taken"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/me [patch]
+// @Security		Bearer
+func (a *Api) updateMe(w http.ResponseWriter, r *http.Request) {
+	var (
+		user    = r.Context().Value("user").(m.User)
+		update  m.ProfileUpdate
+		patched *m.User
+	)
+
+	// CWE-915: Improperly Controlled Modification of Dynamically-Determined Object Attributes
+	// Every field of the user can be set, e.g. {"Role": "admin", "UsdBalance": 999999}
+	if a.vulnerable(VulnProfileMassAssignment) {
+		patch := profilePatch{User: user}
+		if err := a.decodeJSON(r, &patch); err != nil {
+			writeDecodeError(w, err)
+			return
+		}
+
+		update = m.ProfileUpdate{
+			DisplayName:     patch.DisplayName,
+			CurrentPassword: patch.CurrentPassword,
+			NewPassword:     patch.NewPassword,
+		}
+		if patch.Email != user.Email {
+			update.Email = patch.Email
+		}
+		patched = &patch.User
+	} else {
+		if err := a.decodeJSON(r, &update); err != nil {
+			writeDecodeError(w, err)
+			return
+		}
+		if update.DisplayName == "" && update.Email == "" && update.NewPassword == "" {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Display name, email or new password is required!")
+			return
+		}
+	}
+
+	if len(update.DisplayName) > 32 {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Display name can't be longer than 32 characters!")
+		return
+	}
+	if update.NewPassword != "" && len(update.NewPassword) < 12 {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "New password needs to be at least 12 characters long!")
+		return
+	}
+
+	var err error
+	if update.Email != "" || update.NewPassword != "" {
+		err = a.repo(r).UpdateCredentials(user.Id, update.CurrentPassword, update.Email, update.NewPassword)
+	}
+	switch {
+	case errors.Is(err, database.ErrWrongPassword):
+		writeError(w, http.StatusUnauthorized, codeWrongPassword, err.Error())
+		return
+	case errors.Is(err, database.ErrEmailTaken):
+		writeError(w, http.StatusConflict, codeEmailTaken, err.Error())
+		return
+	case errors.Is(err, database.ErrInvalidEmail):
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	case err != nil:
+		a.writeInternalError(w, err)
+		return
+	}
+
+	if patched != nil {
+		err = a.repo(r).UpdateUser(user.Id, *patched)
+	} else if update.DisplayName != "" {
+		err = a.repo(r).UpdateDisplayName(user.Id, update.DisplayName)
+	}
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	if user, err = a.repo(r).GetUserById(user.Id); err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile(user))
+}
+
+// @Summary		  Delete account
+// @Description	Permanently deletes own account along with balances, orders, transactions, alerts and all other data, requires the password
+// @Tags		    User
+// @Accept	    json
+// @Produce	    plain
+// @Param		    deletion	body		m.AccountDeletion	true	"Password"
+// @Success	    204	"account deleted"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized or wrong password"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/me [delete]
+// @Security		Bearer
+func (a *Api) deleteMe(w http.ResponseWriter, r *http.Request) {
+	var (
+		user     = r.Context().Value("user").(m.User)
+		deletion m.AccountDeletion
+	)
+
+	if user.Role == "admin" {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "Admins can't delete their own account!")
+		return
+	}
+
+	if err := a.decodeJSON(r, &deletion); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	err := a.repo(r).DeleteAccount(user.Id, deletion.Password)
+	if errors.Is(err, database.ErrWrongPassword) {
+		writeError(w, http.StatusUnauthorized, codeWrongPassword, "Password is wrong!")
+		return
+	}
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func profile(user m.User) m.Profile {
+	return m.Profile{
+		Id:          user.Id,
+		Email:       user.Email,
+		DisplayName: user.DisplayName,
+		Role:        user.Role,
+	}
+}
+
+// @Summary		  Leaderboard
+// @Description	Get users with the highest portfolio value along with own rank, updated every price refresh
+// @Tags		    User
+// @Produce	    json
+// @Param		    limit	query		int	false	"Number of users (max 100)"
+// @Success	    200	"ok"
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Router			/leaderboard [get]
+// @Security		Bearer
+func (a *Api) getLeaderboard(w http.ResponseWriter, r *http.Request) {
+	var (
+		user  = r.Context().Value("user").(m.User)
+		limit = 10
+		err   error
+	)
+
+	if value := r.FormValue("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > 100 {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Limit needs to be between 1 and 100!")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.leaderboardFor(user.Id, limit))
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	m "govulnapi/models"
+)
+
+func TestUpdateMeCredentials(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+
+	token := login(t, a, "alice@example.com", "password123")
+	login(t, a, "bob@example.com", "password123")
+
+	for _, test := range []struct {
+		name string
+		body string
+		want int
+		code string
+	}{
+		{"short new password", `{"CurrentPassword":"password123","NewPassword":"short"}`, http.StatusBadRequest, codeBadRequest},
+		{"wrong current password", `{"CurrentPassword":"wrong","NewPassword":"a long new password"}`, http.StatusUnauthorized, codeWrongPassword},
+		{"taken email", `{"CurrentPassword":"password123","Email":"bob@example.com"}`, http.StatusConflict, codeEmailTaken},
+	} {
+		r := httptest.NewRequest(http.MethodPatch, "/api/me", strings.NewReader(test.body))
+		r.Header.Set("Content-Type", "application/json")
+		w := serve(a, r, token)
+		if w.Code != test.want || w.Header().Get("X-Error-Code") != test.code {
+			t.Errorf("%s answered %d %s, want %d %s", test.name, w.Code, w.Header().Get("X-Error-Code"), test.want, test.code)
+		}
+	}
+
+	// None of them changed the credentials
+	login(t, a, "alice@example.com", "password123")
+}
+
+func TestUpdateMeMassAssignment(t *testing.T) {
+	for _, massAssignment := range []bool{true, false} {
+		a, _ := NewForTesting(WithVulnerabilities(map[string]bool{VulnProfileMassAssignment: massAssignment}))
+		t.Cleanup(a.Shutdown)
+		token := login(t, a, "alice@example.com", "password123")
+
+		r := httptest.NewRequest(http.MethodPatch, "/api/me", strings.NewReader(`{"Role":"admin","UsdBalance":999999}`))
+		r.Header.Set("Content-Type", "application/json")
+		w := serve(a, r, token)
+
+		user, err := a.db.GetUserByEmail("alice@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats := serve(a, httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil), login(t, a, "alice@example.com", "password123"))
+
+		if massAssignment {
+			if w.Code != http.StatusOK || user.Role != "admin" || user.UsdBalance != m.UsdFromFloat(999999) {
+				t.Errorf("patching with mass assignment answered %d, user is %s with %v usd, want an admin with 999999", w.Code, user.Role, user.UsdBalance)
+			}
+			if stats.Code != http.StatusOK {
+				t.Errorf("admin route answered %d after logging in again, want 200", stats.Code)
+			}
+		} else {
+			if w.Code != http.StatusBadRequest || user.Role != "user" || user.UsdBalance != m.UsdFromFloat(10000) {
+				t.Errorf("patching without mass assignment answered %d, user is %s with %v usd, want 400 and them unchanged", w.Code, user.Role, user.UsdBalance)
+			}
+			if stats.Code != http.StatusForbidden {
+				t.Errorf("admin route answered %d after logging in again, want 403", stats.Code)
+			}
+		}
+	}
+}
+
+func TestGetMe(t *testing.T) {
+	a, repo := newMockedForTesting(t)
+	_, token, _ := a.tokenAuth().Encode(map[string]interface{}{"user_id": 7, "role": "user"})
+	repo.EXPECT().GetUserById(7).Return(m.User{
+		Id:          7,
+		Email:       "alice@example.com",
+		Password:    "482c811da5d5b4bc6d497ffa98491e38",
+		UsdBalance:  m.UsdFromFloat(10000),
+		Role:        "user",
+		DisplayName: "Alice",
+	}, nil)
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/me", nil), token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("getting me answered %d %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "Password") || strings.Contains(w.Body.String(), "482c811da5d5b4bc6d497ffa98491e38") {
+		t.Errorf("answered %s, leaking the password hash", w.Body)
+	}
+	var me m.Profile
+	if err := json.NewDecoder(w.Body).Decode(&me); err != nil {
+		t.Fatal(err)
+	}
+	if want := (m.Profile{Id: 7, Email: "alice@example.com", DisplayName: "Alice", Role: "user"}); me != want {
+		t.Errorf("got %+v, want %+v", me, want)
+	}
+}
+
+func TestGetMeDeleted(t *testing.T) {
+	a, repo := newMockedForTesting(t)
+	_, token, _ := a.tokenAuth().Encode(map[string]interface{}{"user_id": 7, "role": "user"})
+	// Deleted users aren't found by id anymore
+	repo.EXPECT().GetUserById(7).Return(m.User{}, errors.New("No user with matching id found!"))
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/me", nil), token)
+	if w.Code != http.StatusNotFound || w.Header().Get("X-Error-Code") != codeNotFound {
+		t.Errorf("getting a deleted me answered %d %s, want 404", w.Code, w.Body)
+	}
+}
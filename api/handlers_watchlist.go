@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary		  Get watchlist
+// @Description	Fetches the coins on the watchlist of the user with their latest prices, in the order they were added
+// @Tags		    Watchlist
+// @Produce	    json
+// @Success	    200	{array}	m.WatchlistEntry
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/me/watchlist [get]
+// @Security		Bearer
+func (a *Api) getWatchlist(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	entries, err := a.repo(r).GetWatchlist(user.Id)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+	for i := range entries {
+		if coin, err := a.getCoin(entries[i].CoinId); err == nil {
+			entries[i].Price = &coin.Price
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// @Summary		  Watch coin
+// @Description	Adds a coin to the watchlist of the user
+// @Tags		    Watchlist
+// @Produce	    json
+// @Param		    id	path		string	true	"Coin id"
+// @Success	    200	{object}	m.WatchlistEntry
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"requested coin not found"
+// @Failure	    409	{object}	APIError	"coin already on the watchlist"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/{id}/watchlist [post]
+// @Security		Bearer
+func (a *Api) watchCoin(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	coin, err := a.getCoin(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+
+	addedAt := a.clock.Now()
+	err = a.repo(r).AddToWatchlist(user.Id, coin.Id, addedAt)
+	if errors.Is(err, database.ErrAlreadyWatched) {
+		writeError(w, http.StatusConflict, codeConflict, err.Error())
+		return
+	}
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m.WatchlistEntry{
+		CoinId:  coin.Id,
+		AddedAt: addedAt.Format(time.RFC3339),
+		Price:   &coin.Price,
+	})
+}
+
+// @Summary		  Unwatch coin
+// @Description	Removes a coin from the watchlist of the user
+// @Tags		    Watchlist
+// @Param		    id	path		string	true	"Coin id"
+// @Success	    204	"removed"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    404	{object}	APIError	"coin not on the watchlist"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/coins/{id}/watchlist [delete]
+// @Security		Bearer
+func (a *Api) unwatchCoin(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	err := a.repo(r).RemoveFromWatchlist(user.Id, chi.URLParam(r, "id"))
+	if errors.Is(err, database.ErrNotWatched) {
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "govulnapi/models"
+)
+
+func TestWatchlist(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	alice := login(t, a, "alice@example.com", "password123")
+	bob := login(t, a, "bob@example.com", "password123")
+
+	getWatchlist := func(token string) []m.WatchlistEntry {
+		t.Helper()
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/me/watchlist", nil), token)
+		if w.Code != http.StatusOK {
+			t.Fatalf("getting the watchlist answered %d %s", w.Code, w.Body)
+		}
+		var entries []m.WatchlistEntry
+		if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+			t.Fatal(err)
+		}
+		return entries
+	}
+	watchlistOf := func(entries []m.WatchlistEntry) map[string]m.Usd {
+		coins := map[string]m.Usd{}
+		for _, entry := range entries {
+			if entry.Price == nil || entry.AddedAt == "" {
+				t.Errorf("listed %+v, want its price and when it was added", entry)
+				continue
+			}
+			coins[entry.CoinId] = *entry.Price
+		}
+		return coins
+	}
+
+	if entries := getWatchlist(alice); len(entries) != 0 {
+		t.Fatalf("started with %+v, want an empty watchlist", entries)
+	}
+
+	// Coins are added by id or symbol, and stored by id
+	for _, test := range []struct {
+		method, coin string
+		status       int
+	}{
+		{method: http.MethodPost, coin: "bitcoin", status: http.StatusOK},
+		{method: http.MethodPost, coin: "DOGE", status: http.StatusOK},
+		{method: http.MethodPost, coin: "litecoin", status: http.StatusOK},
+		{method: http.MethodPost, coin: "bitcoin", status: http.StatusConflict},
+		{method: http.MethodPost, coin: "BTC", status: http.StatusConflict},
+		{method: http.MethodPost, coin: "nocoin", status: http.StatusNotFound},
+		{method: http.MethodDelete, coin: "litecoin", status: http.StatusNoContent},
+		{method: http.MethodDelete, coin: "litecoin", status: http.StatusNotFound},
+		{method: http.MethodDelete, coin: "namecoin", status: http.StatusNotFound},
+	} {
+		w := serve(a, httptest.NewRequest(test.method, "/api/coins/"+test.coin+"/watchlist", nil), alice)
+		if w.Code != test.status {
+			t.Errorf("%s %s answered %d %s, want %d", test.method, test.coin, w.Code, w.Body, test.status)
+		}
+	}
+
+	want := map[string]m.Usd{"bitcoin": m.UsdFromFloat(800), "dogecoin": m.UsdFromFloat(0.0005)}
+	entries := getWatchlist(alice)
+	if got := watchlistOf(entries); len(entries) != len(want) || got["bitcoin"] != want["bitcoin"] || got["dogecoin"] != want["dogecoin"] {
+		t.Errorf("listed %+v, want bitcoin and dogecoin at their prices", entries)
+	}
+	if entries[0].CoinId != "bitcoin" {
+		t.Errorf("listed %+v, want the coins in the order they were added", entries)
+	}
+
+	// Watchlists are per user
+	if entries := getWatchlist(bob); len(entries) != 0 {
+		t.Errorf("bob's watchlist is %+v, want it empty", entries)
+	}
+	if w := serve(a, httptest.NewRequest(http.MethodDelete, "/api/coins/bitcoin/watchlist", nil), bob); w.Code != http.StatusNotFound {
+		t.Errorf("removing a coin of alice's watchlist as bob answered %d, want 404", w.Code)
+	}
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/api/me/watchlist", nil), ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("getting a watchlist without a token answered %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	m "govulnapi/models"
+)
+
+// @Summary		  Register webhook
+// @Description	Registers url receiving POST requests on trade.executed and alert.triggered events.
+// @Description	Requests are signed with HMAC-SHA256 of the body in X-Signature header, the secret is only returned here.
+// @Tags		    Webhooks
+// @Accept	    json
+// @Produce	    json
+// @Param		    webhook	body		m.Webhook	true	"New webhook"
+// @Success	    200	{object}	m.Webhook
+// @Failure	    400	{object}	APIError	"bad request"
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/webhooks [post]
+// @Security		Bearer
+func (a *Api) addWebhook(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	var newWebhook m.Webhook
+	if err := a.decodeJSON(r, &newWebhook); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if len(newWebhook.Events) == 0 {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "At least one event is required!")
+		return
+	}
+	for _, event := range newWebhook.Events {
+		if event != webhookTradeExecuted && event != webhookAlertTriggered {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Events need to be 'trade.executed' or 'alert.triggered'!")
+			return
+		}
+	}
+
+	if err := a.validateWebhookUrl(newWebhook.Url); err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	webhook, err := a.db.AddWebhook(user.Id, newWebhook.Url, newWebhook.Events, secret)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// @Summary		  Get webhooks
+// @Description	Fetches registered webhooks, failing ones gave up on a delivery after all attempts
+// @Tags		    Webhooks
+// @Produce	    json
+// @Success	    200	{array}	m.Webhook
+// @Failure	    401	{object}	APIError	"unauthorized"
+// @Failure	    500	{object}	APIError	"internal server error"
+// @Router			/webhooks [get]
+// @Security		Bearer
+func (a *Api) getWebhooks(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(m.User)
+
+	webhooks, err := a.db.GetWebhooks(user.Id)
+	if err != nil {
+		a.writeInternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks)
+}
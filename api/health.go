@@ -0,0 +1,38 @@
+package api
+
+import (
+	"time"
+)
+
+// Readiness checks, named after what they found wrong
+const (
+	checkNoCoins        = "no_coins"
+	checkClockStalled   = "virtual_clock_stalled"
+	checkStalePrices    = "stale_prices"
+	stalledClockPeriods = 2
+)
+
+// Lists the readiness checks that fail: coins need to be loaded, virtual
+// days need to advance at least every other DayDuration and prices need to
+// be fetched, unless AcceptStalePrices
+func (a *Api) failedReadinessChecks() []string {
+	failed := []string{}
+
+	a.coinsMu.RLock()
+	coins := len(a.coins)
+	a.coinsMu.RUnlock()
+	if coins == 0 {
+		failed = append(failed, checkNoCoins)
+	}
+
+	if a.metrics.stalePrices.Load() && !a.getOptions().AcceptStalePrices {
+		failed = append(failed, checkStalePrices)
+	}
+
+	lastAdvance := time.Unix(0, a.metrics.lastAdvance.Load())
+	if a.clock.Now().Sub(lastAdvance) > stalledClockPeriods*a.getOptions().DayDuration {
+		failed = append(failed, checkClockStalled)
+	}
+
+	return failed
+}
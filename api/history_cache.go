@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Results computed from the price history for a single virtual date.
+// Concurrent identical computations run once and share the result.
+type historyCache struct {
+	mu         sync.Mutex
+	date       time.Time
+	generation int // Incremented whenever entries are dropped
+	entries    map[string]interface{}
+	flights    singleflight.Group
+}
+
+// Drops every entry, computations running meanwhile aren't cached
+func (c *historyCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.entries = nil
+}
+
+// Gets the result cached for the key on date, computing it when there's
+// none. Entries of other dates are dropped.
+func cachedHistory[T any](a *Api, date time.Time, key string, compute func() (T, error)) (T, error) {
+	c := &a.history
+
+	c.mu.Lock()
+	if !c.date.Equal(date) {
+		c.date = date
+		c.generation++
+		c.entries = nil
+	}
+	if cached, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		a.metrics.historyCacheHits.Add(1)
+		return cached.(T), nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	computed := false
+	result, err, _ := c.flights.Do(fmt.Sprintf("%d/%s", generation, key), func() (interface{}, error) {
+		computed = true
+		result, err := compute()
+		if err != nil {
+			return result, err
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.generation == generation {
+			if c.entries == nil {
+				c.entries = map[string]interface{}{}
+			}
+			c.entries[key] = result
+		}
+		return result, nil
+	})
+	if computed {
+		a.metrics.historyCacheMisses.Add(1)
+	} else {
+		a.metrics.historyCacheHits.Add(1)
+	}
+
+	return result.(T), err
+}
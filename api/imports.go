@@ -0,0 +1,256 @@
+package api
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+)
+
+const (
+	importMaxBytes = 1 << 20
+	// Coins a single bulk import may list
+	coinImportMaxCoins = 1000
+)
+
+var (
+	coinIdPattern     = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	coinSymbolPattern = regexp.MustCompile(`^[A-Z0-9]{1,10}$`)
+	xmlEntityDecl     = regexp.MustCompile(`ENTITY\s+([\w.-]+)\s+(?:SYSTEM\s+["']([^"']*)["']|["']([^"']*)["'])`)
+	// Elements a trade import may contain, by the element they're nested in
+	tradeImportSchema = map[string][]string{
+		"":       {"trades"},
+		"trades": {"trade"},
+		"trade":  {"coinId", "isBuy", "qty", "price", "date"},
+	}
+)
+
+// Decodes an XML trade import
+func (a *Api) decodeTradeImportXML(body []byte) (m.TradeImport, error) {
+	var doc m.TradeImport
+
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+
+	// CWE-611: Improper Restriction of XML External Entity Reference
+	// Entities declared in the DOCTYPE are expanded, and external ones are
+	// read from files or urls the server can access
+	if a.vulnerable(VulnTradeImportXXE) {
+		entities, err := resolveXMLEntities(body)
+		if err != nil {
+			return doc, err
+		}
+		decoder.Entity = entities
+	} else if err := validateTradeImportXML(body); err != nil {
+		return doc, err
+	}
+
+	if err := decoder.Decode(&doc); err != nil {
+		return doc, fmt.Errorf("Invalid XML: %w", err)
+	}
+
+	return doc, nil
+}
+
+// Collects the entities declared in the DOCTYPE of the document, reading
+// the contents of SYSTEM ones
+func resolveXMLEntities(body []byte) (map[string]string, error) {
+	entities := map[string]string{}
+
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return entities, nil
+		}
+		if err != nil {
+			// Syntax errors are reported when decoding the document
+			return entities, nil
+		}
+		directive, ok := token.(xml.Directive)
+		if !ok {
+			if _, ok = token.(xml.StartElement); ok {
+				return entities, nil
+			}
+			continue
+		}
+
+		for _, decl := range xmlEntityDecl.FindAllStringSubmatch(string(directive), -1) {
+			if decl[2] == "" {
+				entities[decl[1]] = decl[3]
+				continue
+			}
+			value, err := readExternalEntity(decl[2])
+			if err != nil {
+				return nil, fmt.Errorf("Unable to resolve entity %s: %w", decl[1], err)
+			}
+			entities[decl[1]] = value
+		}
+	}
+}
+
+func readExternalEntity(systemId string) (string, error) {
+	u, err := url.Parse(systemId)
+	if err != nil {
+		return "", err
+	}
+
+	var r io.ReadCloser
+	switch u.Scheme {
+	case "file":
+		r, err = os.Open(u.Path)
+	case "http", "https":
+		var resp *http.Response
+		resp, err = (&http.Client{Timeout: newsTimeout}).Get(systemId)
+		if resp != nil {
+			r = resp.Body
+		}
+	default:
+		// Relative to the working directory, like a parser without a base url
+		r, err = os.Open(systemId)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	value, err := io.ReadAll(io.LimitReader(r, importMaxBytes))
+	return string(value), err
+}
+
+// Rejects documents with a DOCTYPE, which could declare entities, and
+// elements or attributes other than those of a trade import
+func validateTradeImportXML(body []byte) error {
+	var (
+		decoder = xml.NewDecoder(bytes.NewReader(body))
+		parents = []string{""}
+		fields  = map[string]bool{}
+	)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Invalid XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.Directive:
+			return errors.New("DOCTYPE declarations aren't allowed!")
+		case xml.StartElement:
+			parent := parents[len(parents)-1]
+			if !isSchemaElement(parent, t.Name.Local) || t.Name.Space != "" || len(t.Attr) > 0 {
+				return fmt.Errorf("Unexpected element <%s> in <%s>!", t.Name.Local, parent)
+			}
+			if parent == "trade" {
+				if fields[t.Name.Local] {
+					return fmt.Errorf("Duplicate element <%s> in <trade>!", t.Name.Local)
+				}
+				fields[t.Name.Local] = true
+			}
+			parents = append(parents, t.Name.Local)
+		case xml.EndElement:
+			if t.Name.Local == "trade" {
+				for _, field := range tradeImportSchema["trade"] {
+					if !fields[field] {
+						return fmt.Errorf("Element <%s> missing in <trade>!", field)
+					}
+				}
+				fields = map[string]bool{}
+			}
+			parents = parents[:len(parents)-1]
+		case xml.CharData:
+			parent := parents[len(parents)-1]
+			if parent != "" && tradeImportSchema[parent] != nil && len(bytes.TrimSpace(t)) > 0 {
+				return fmt.Errorf("Unexpected text in <%s>!", parent)
+			}
+		}
+	}
+}
+
+func isSchemaElement(parent string, name string) bool {
+	for _, allowed := range tradeImportSchema[parent] {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Backfills past trades into the user's account one by one, with the same
+// balance checks as live orders, collecting the errors of failed rows
+func (a *Api) importTrades(repo database.Repository, userId int, trades []m.ImportedTrade) m.TradeImportResult {
+	result := m.TradeImportResult{Errors: []m.TradeImportError{}}
+	today := a.virtualDate()
+
+	for i, trade := range trades {
+		err := a.importTrade(repo, userId, trade, today)
+		if err != nil {
+			result.Errors = append(result.Errors, m.TradeImportError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		result.Imported++
+	}
+
+	return result
+}
+
+func (a *Api) importTrade(repo database.Repository, userId int, trade m.ImportedTrade, today time.Time) error {
+	if _, err := a.getCoin(trade.CoinId); err != nil {
+		return fmt.Errorf("Coin '%s' doesn't exist!", trade.CoinId)
+	}
+	if trade.Qty <= 0 || trade.Price <= 0 {
+		return errors.New("Qty and price need to be > 0!")
+	}
+	date, err := time.Parse(time.DateOnly, trade.Date)
+	if err != nil {
+		return errors.New("Date needs to be in YYYY-MM-DD format!")
+	}
+	if date.After(today) {
+		return errors.New("Date can't be after the current virtual date!")
+	}
+
+	return repo.AddOrder(userId, trade.CoinId, trade.Price, trade.IsBuy, trade.Qty, date)
+}
+
+// Lists the valid coins of a bulk import, the others are returned as errors
+func checkNewCoins(coins []m.NewCoin) ([]m.NewCoin, []m.CoinImportError) {
+	var (
+		valid    []m.NewCoin
+		rejected = []m.CoinImportError{}
+	)
+
+	for _, coin := range coins {
+		if err := checkNewCoin(coin); err != nil {
+			rejected = append(rejected, m.CoinImportError{Id: coin.Id, Reason: err.Error()})
+		} else {
+			valid = append(valid, coin)
+		}
+	}
+
+	return valid, rejected
+}
+
+func checkNewCoin(coin m.NewCoin) error {
+	if len(coin.Id) > 64 || !coinIdPattern.MatchString(coin.Id) {
+		return errors.New("Id needs to be lowercase letters and digits separated by dashes, at most 64 characters!")
+	}
+	if name := strings.TrimSpace(coin.Name); name == "" || len(name) > 100 {
+		return errors.New("Name needs to be between 1 and 100 characters!")
+	}
+	if !coinSymbolPattern.MatchString(coin.Symbol) {
+		return errors.New("Symbol needs to be 1 to 10 uppercase letters or digits!")
+	}
+	return nil
+}
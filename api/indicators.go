@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	m "govulnapi/models"
+)
+
+const (
+	movingAverageWindow    = 7
+	movingAverageMinWindow = 2
+	movingAverageMaxWindow = 200
+)
+
+// Gets the simple moving average of the coin's closing prices over the last
+// window virtual days. Returns false when a day in the window has no price.
+func (a *Api) movingAverage(coinId string, window int) (m.MovingAverage, bool, error) {
+	date := a.virtualDate()
+	from := date.AddDate(0, 0, -window+1).Format(time.DateOnly)
+
+	key := fmt.Sprintf("daily-prices/%s/%s", coinId, from)
+	prices, err := cachedHistory(a, date, key, func() ([]m.PriceHistory, error) {
+		return a.db.GetDailyPrices(from, date.Format(time.DateOnly), coinId)
+	})
+	if err != nil {
+		return m.MovingAverage{}, false, err
+	}
+	if len(prices) < window {
+		return m.MovingAverage{}, false, nil
+	}
+
+	closes := make([]float64, len(prices))
+	for i, price := range prices {
+		closes[i] = price.Price.Float64()
+	}
+
+	return m.MovingAverage{
+		CoinId:   coinId,
+		Window:   window,
+		Sma:      sma(closes),
+		AsOfDate: prices[len(prices)-1].Date,
+	}, true, nil
+}
+
+func sma(prices []float64) float64 {
+	var sum float64
+	for _, price := range prices {
+		sum += price
+	}
+	return sum / float64(len(prices))
+}
+
+// Change from the past price to the current one in percent, past needs to
+// be > 0
+func percentChange(past m.Usd, current m.Usd) float64 {
+	return float64(current-past) / float64(past) * 100
+}
+
+// Gets the change of the coin's closing price over 1, 7, 30 and 90 virtual
+// days up to the current one
+func (a *Api) priceChange(coinId string) (m.PriceChange, error) {
+	date := a.virtualDate()
+	windows := []int{0, 1, 7, 30, 90}
+	dates := make([]string, len(windows))
+	for i, days := range windows {
+		dates[i] = date.AddDate(0, 0, -days).Format(time.DateOnly)
+	}
+
+	prices, err := cachedHistory(a, date, "prices-on/"+coinId, func() ([]*m.Usd, error) {
+		return a.db.GetPricesOn(coinId, dates...)
+	})
+	if err != nil {
+		return m.PriceChange{}, err
+	}
+
+	change := func(past *m.Usd) *float64 {
+		if prices[0] == nil || past == nil || *past <= 0 {
+			return nil
+		}
+		percent := percentChange(*past, *prices[0])
+		return &percent
+	}
+
+	return m.PriceChange{
+		OneDay:     change(prices[1]),
+		SevenDays:  change(prices[2]),
+		ThirtyDays: change(prices[3]),
+		NinetyDays: change(prices[4]),
+	}, nil
+}
@@ -0,0 +1,296 @@
+package api_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"govulnapi/api"
+	"govulnapi/api/database"
+	m "govulnapi/models"
+	"govulnapi/testutil"
+)
+
+// Sends the request to the server with token unless it's empty, and returns
+// the status code and body of the response
+func request(t *testing.T, server *testutil.TestServer, method, path, token, body string) (int, string) {
+	t.Helper()
+
+	r, err := http.NewRequest(method, server.BaseURL()+"/api"+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := server.Client.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	read, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode, string(read)
+}
+
+// Registers a user over HTTP and logs them in
+func register(t *testing.T, server *testutil.TestServer, email string) string {
+	t.Helper()
+
+	query := url.Values{"email": {email}, "password": {"password123"}}.Encode()
+	if code, body := request(t, server, http.MethodGet, "/register?"+query, "", ""); code != http.StatusOK {
+		t.Fatalf("registering %s: %d %s", email, code, body)
+	}
+	code, token := request(t, server, http.MethodGet, "/login?"+query, "", "")
+	if code != http.StatusOK {
+		t.Fatalf("logging in as %s: %d %s", email, code, token)
+	}
+	return token
+}
+
+func usdBalance(t *testing.T, server *testutil.TestServer, token string) m.Usd {
+	t.Helper()
+
+	code, body := request(t, server, http.MethodGet, "/balances/usd", token, "")
+	if code != http.StatusOK {
+		t.Fatalf("getting the usd balance: %d %s", code, body)
+	}
+	var balances map[string]m.Usd
+	if err := json.Unmarshal([]byte(body), &balances); err != nil {
+		t.Fatal(err)
+	}
+	return balances["UsdBalance"]
+}
+
+func TestBuyOverHTTP(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	token := register(t, server, "alice@example.com")
+	before := usdBalance(t, server, token)
+
+	order := `{"CoinId":"bitcoin","IsBuy":true,"Qty":0.5}`
+	if code, body := request(t, server, http.MethodPost, "/orders", token, order); code != http.StatusOK {
+		t.Fatalf("buying bitcoin: %d %s", code, body)
+	}
+
+	// Bitcoin is at $800 in api.TestPrices
+	if after, want := usdBalance(t, server, token), before-m.UsdFromFloat(400); after != want {
+		t.Errorf("usd balance is %v after buying, want %v", after, want)
+	}
+
+	code, body := request(t, server, http.MethodGet, "/balances/coin", token, "")
+	if code != http.StatusOK {
+		t.Fatalf("getting the coin balances: %d %s", code, body)
+	}
+	var balances []m.CoinBalance
+	if err := json.Unmarshal([]byte(body), &balances); err != nil {
+		t.Fatal(err)
+	}
+	for _, balance := range balances {
+		if balance.CoinId == "bitcoin" && balance.Qty != 0.5 {
+			t.Errorf("bitcoin balance is %v, want 0.5", balance.Qty)
+		}
+	}
+}
+
+func TestRetriedOrderIsMadeOnce(t *testing.T) {
+	server := testutil.NewTestServer(t)
+	token := register(t, server, "alice@example.com")
+	before := usdBalance(t, server, token)
+
+	order := `{"CoinId":"litecoin","IsBuy":true,"Qty":2}`
+	for i := 0; i < 3; i++ {
+		r, err := http.NewRequest(http.MethodPost, server.BaseURL()+"/api/orders", strings.NewReader(order))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Authorization", "Bearer "+token)
+		r.Header.Set("Idempotency-Key", "1b4e28ba-2fa1-41d2-883f-0016d3cca427")
+
+		response, err := server.Client.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			t.Fatalf("attempt %d answered %d", i+1, response.StatusCode)
+		}
+	}
+
+	// Litecoin is at $25 in api.TestPrices
+	if after, want := usdBalance(t, server, token), before-m.UsdFromFloat(50); after != want {
+		t.Errorf("usd balance is %v after retrying the order, want %v", after, want)
+	}
+}
+
+func TestOrdersFollowVirtualDays(t *testing.T) {
+	const dayDuration = time.Hour
+	server := testutil.NewTestServer(t, api.WithDayDuration(dayDuration))
+	token := register(t, server, "alice@example.com")
+
+	// Buys a ripple for $0.03 and returns the virtual date of the order
+	orderDate := func() string {
+		order := `{"CoinId":"ripple","IsBuy":true,"Qty":1}`
+		if code, body := request(t, server, http.MethodPost, "/orders", token, order); code != http.StatusOK {
+			t.Fatalf("buying ripple: %d %s", code, body)
+		}
+		code, body := request(t, server, http.MethodGet, "/orders?limit=1", token, "")
+		if code != http.StatusOK {
+			t.Fatalf("getting the orders: %d %s", code, body)
+		}
+		var page m.OrderPage
+		if err := json.Unmarshal([]byte(body), &page); err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Orders) != 1 {
+			t.Fatalf("got %d orders, want the last one", len(page.Orders))
+		}
+		return page.Orders[0].VirtualDate
+	}
+
+	first := orderDate()
+	if !strings.HasPrefix(first, "2014-01-01") {
+		t.Fatalf("first order was made on %s, want 2014-01-01", first)
+	}
+
+	// The next day may be scheduled only after the server became ready, so
+	// the clock is advanced again while the day doesn't change
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		server.Clock.Advance(dayDuration)
+		time.Sleep(50 * time.Millisecond)
+
+		if next := orderDate(); next > first {
+			return
+		}
+	}
+	t.Error("virtual date didn't change after advancing the clock")
+}
+
+func TestDeleteMeRemovesAllData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "govulnapi.db")
+	server := testutil.NewTestServer(t,
+		api.WithDatabase(database.DriverSQLite, path),
+		// Lets the webhook point at localhost
+		api.WithVulnerabilities(map[string]bool{api.VulnWebhookSSRF: true}),
+	)
+	alice := register(t, server, "alice@example.com")
+	bob := register(t, server, "bob@example.com")
+	query := url.Values{"email": {"admin@govulnapi.com"}, "password": {"admin123"}}.Encode()
+	code, admin := request(t, server, http.MethodGet, "/login?"+query, "", "")
+	if code != http.StatusOK {
+		t.Fatalf("logging in as the admin: %d %s", code, admin)
+	}
+
+	code, body := request(t, server, http.MethodGet, "/balances/coin", bob, "")
+	var balances []m.CoinBalance
+	if err := json.Unmarshal([]byte(body), &balances); code != http.StatusOK || err != nil {
+		t.Fatalf("getting bob's balances: %d %s", code, body)
+	}
+	var bobsAddress string
+	for _, balance := range balances {
+		if balance.CoinId == "bitcoin" {
+			bobsAddress = balance.Address
+		}
+	}
+
+	for _, step := range []struct {
+		token string
+		path  string
+		body  string
+	}{
+		{alice, "/orders", `{"CoinId":"bitcoin","IsBuy":true,"Qty":1}`},
+		{alice, "/transactions", `{"CoinId":"bitcoin","Address":"` + bobsAddress + `","Qty":0.25}`},
+		{alice, "/coins/bitcoin/price-alert", `{"ThresholdUsd":1000,"Direction":"above"}`},
+		{alice, "/webhooks", `{"Url":"http://127.0.0.1:1/hook","Events":["trade.executed"]}`},
+		{alice, "/deposit", `{"Amount":100}`},
+		{alice, "/transfer", `{"ToEmail":"bob@example.com","Amount":10}`},
+		{bob, "/transfer", `{"ToEmail":"alice@example.com","Amount":5}`},
+		{admin, "/admin/notifications", `{"Message":"Scheduled maintenance tonight"}`},
+	} {
+		if code, body := request(t, server, http.MethodPost, step.path, step.token, step.body); code != http.StatusOK && code != http.StatusCreated {
+			t.Fatalf("posting %s to %s: %d %s", step.body, step.path, code, body)
+		}
+	}
+
+	code, body = request(t, server, http.MethodGet, "/me", alice, "")
+	var me m.Profile
+	if err := json.Unmarshal([]byte(body), &me); code != http.StatusOK || err != nil {
+		t.Fatalf("getting alice: %d %s", code, body)
+	}
+
+	db, err := sql.Open(database.DriverSQLite, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	tables := map[string]string{
+		`"user"`:           `id = ?`,
+		`"order"`:          `user_id = ?`,
+		`"transaction"`:    `sender_id = ? OR receiver_id = ?`,
+		`price_alert`:      `user_id = ?`,
+		`webhook`:          `user_id = ?`,
+		`notification`:     `user_id = ?`,
+		`cash_transaction`: `user_id = ? OR counterparty_id = ?`,
+		`coin_balance`:     `user_id = ?`,
+	}
+	rows := func(table string) int {
+		t.Helper()
+		condition := tables[table]
+		args := []interface{}{me.Id}
+		if strings.Count(condition, "?") == 2 {
+			args = append(args, me.Id)
+		}
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE `+condition, args...).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	for table := range tables {
+		if rows(table) == 0 {
+			t.Fatalf("alice has no rows in %s to delete", table)
+		}
+	}
+
+	// A wrong password keeps everything
+	if code, body := request(t, server, http.MethodDelete, "/me", alice, `{"Password":"wrong password"}`); code != http.StatusUnauthorized {
+		t.Fatalf("deleting with a wrong password answered %d %s, want 401", code, body)
+	}
+	for table := range tables {
+		if rows(table) == 0 {
+			t.Errorf("rows of alice in %s are gone after a wrong password", table)
+		}
+	}
+
+	if code, body := request(t, server, http.MethodDelete, "/me", alice, `{"Password":"password123"}`); code != http.StatusNoContent {
+		t.Fatalf("deleting alice answered %d %s, want 204", code, body)
+	}
+	for table := range tables {
+		if n := rows(table); n > 0 {
+			t.Errorf("%d rows of alice are left in %s", n, table)
+		}
+	}
+
+	// Bob keeps his account and his own cash transactions
+	if usdBalance(t, server, bob) == 0 {
+		t.Error("bob lost his balance")
+	}
+	if code, _ := request(t, server, http.MethodGet, "/me", alice, ""); code != http.StatusNotFound && code != http.StatusUnauthorized {
+		t.Errorf("alice's token answered %d after the deletion", code)
+	}
+}
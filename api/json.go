@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type unknownFieldsError struct {
+	fields []string
+}
+
+func (e *unknownFieldsError) Error() string {
+	quoted := make([]string, len(e.fields))
+	for i, field := range e.fields {
+		quoted[i] = strconv.Quote(field)
+	}
+	return fmt.Sprintf("Unknown fields in request body: %s!", strings.Join(quoted, ", "))
+}
+
+// Decodes the JSON request body into v, rejecting fields that v doesn't
+// declare when strict parsing is enabled
+func (a *Api) decodeJSON(r *http.Request, v interface{}) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	return a.decodeJSONBytes(body, v)
+}
+
+// Decodes a JSON document read from elsewhere than the request body, like
+// decodeJSON
+func (a *Api) decodeJSONBytes(body []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if a.getOptions().StrictJSONParsing {
+		decoder.DisallowUnknownFields()
+	}
+
+	err := decoder.Decode(v)
+	if err != nil && strings.HasPrefix(err.Error(), "json: unknown field ") {
+		fields := unknownFields(body, v)
+		if len(fields) == 0 {
+			field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+			fields = []string{field}
+		}
+		return &unknownFieldsError{fields: fields}
+	}
+
+	return err
+}
+
+// Lists top-level keys of body that don't match any field of v, using the
+// same case-insensitive matching as encoding/json
+func unknownFields(body []byte, v interface{}) []string {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return nil
+	}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	known := jsonFieldNames(t)
+
+	var unknown []string
+	for key := range keys {
+		found := false
+		for _, name := range known {
+			if strings.EqualFold(key, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+
+	return unknown
+}
+
+// Names of the JSON keys of struct type t, including the fields of
+// embedded structs that encoding/json promotes
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			names = append(names, jsonFieldNames(fieldType)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// Encodes items as JSON objects keeping only the requested fields, matched
+// case-insensitively like encoding/json does. Fields that none of the items
+// has are rejected.
+func sparseFields[T any](items []T, fields []string) ([]map[string]json.RawMessage, error) {
+	sparse := make([]map[string]json.RawMessage, 0, len(items))
+	found := map[string]bool{}
+
+	for _, item := range items {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var keys map[string]json.RawMessage
+		if err = json.Unmarshal(encoded, &keys); err != nil {
+			return nil, err
+		}
+
+		kept := map[string]json.RawMessage{}
+		for key, value := range keys {
+			for _, field := range fields {
+				if strings.EqualFold(key, strings.TrimSpace(field)) {
+					kept[key] = value
+					found[field] = true
+				}
+			}
+		}
+		sparse = append(sparse, kept)
+	}
+
+	var unknown []string
+	for _, field := range fields {
+		if !found[field] && len(items) > 0 {
+			unknown = append(unknown, strconv.Quote(strings.TrimSpace(field)))
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("Unknown fields: %s!", strings.Join(unknown, ", "))
+	}
+
+	return sparse, nil
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Every endpoint decoding a JSON body, with a body of fields it declares
+var jsonEndpoints = []struct {
+	method, path, body string
+	admin              bool
+	ifMatch            string
+}{
+	{method: http.MethodPost, path: "/orders", body: `{"CoinId":"bitcoin","IsBuy":true,"Qty":1}`},
+	{method: http.MethodPost, path: "/transfer", body: `{"ToEmail":"admin@govulnapi.com","Amount":1}`},
+	{method: http.MethodPost, path: "/transactions", body: `{"ReceiverId":1,"CoinId":"bitcoin","Qty":1}`},
+	{method: http.MethodPost, path: "/transactions/import", body: `{"Trades":[]}`},
+	{method: http.MethodPatch, path: "/me", body: `{"DisplayName":"alice"}`},
+	{method: http.MethodPost, path: "/webhooks", body: `{"Url":"http://127.0.0.1:1"}`},
+	{method: http.MethodPost, path: "/strategies", body: `{"CoinId":"bitcoin"}`},
+	{method: http.MethodPost, path: "/coins/bitcoin/news-preview", body: `{"Url":"http://127.0.0.1:1"}`},
+	{method: http.MethodPost, path: "/coins/bitcoin/comments", body: `{"Body":"hi"}`},
+	{method: http.MethodPut, path: "/comments/1", body: `{"Body":"hi"}`},
+	{method: http.MethodPost, path: "/coins/bitcoin/price-alert", body: `{"ThresholdUsd":900,"Direction":"above"}`},
+	{method: http.MethodPost, path: "/ctf/submit", body: `{"Flag":"x"}`},
+	{method: http.MethodPost, path: "/coins/bitcoin/price", body: `{"Price":1}`, admin: true},
+	{method: http.MethodPost, path: "/admin/reload-config", body: `{}`, admin: true},
+	{method: http.MethodPatch, path: "/admin/vulnerabilities/sql_injection", body: `{"Enabled":true}`, admin: true},
+	{method: http.MethodPut, path: "/admin/difficulty", body: `{"Difficulty":"easy"}`, admin: true},
+	{method: http.MethodPost, path: "/admin/users/2/cash", body: `{"Amount":1}`, admin: true},
+	{method: http.MethodPost, path: "/admin/simulate", body: `{"Days":1}`, admin: true},
+	{method: http.MethodPost, path: "/admin/diagnostics/ping", body: `{"Host":"127.0.0.1"}`, admin: true},
+	{method: http.MethodPatch, path: "/admin/coins/bitcoin", body: `{"Name":"Bitcoin"}`, admin: true, ifMatch: `"1"`},
+	{method: http.MethodPost, path: "/admin/notifications", body: `{"Message":"hi"}`, admin: true},
+	// Last, as they remove the user and the data
+	{method: http.MethodDelete, path: "/me", body: `{"Password":"password123"}`},
+	{method: http.MethodPost, path: "/admin/reset", body: `{}`, admin: true},
+	{method: http.MethodPost, path: "/admin/lab/reset", body: `{}`, admin: true},
+}
+
+// Adds an unknown field to the JSON object
+func withUnknownField(body string) string {
+	if body == "{}" {
+		return `{"Bogus":1}`
+	}
+	return strings.Replace(body, "{", `{"Bogus":1,`, 1)
+}
+
+// Sends every endpoint its body with an unknown field, as alice or the admin
+func sendUnknownFields(t *testing.T, a *Api, check func(name string, w *httptest.ResponseRecorder)) {
+	t.Helper()
+
+	user := login(t, a, "alice@example.com", "password123")
+	admin := login(t, a, "admin@govulnapi.com", "admin123")
+
+	// The comment of PUT /comments/1
+	r := httptest.NewRequest(http.MethodPost, "/api/coins/bitcoin/comments", strings.NewReader(`{"Body":"hi"}`))
+	r.Header.Set("Content-Type", "application/json")
+	if w := serve(a, r, user); w.Code != http.StatusOK && w.Code != http.StatusCreated {
+		t.Fatalf("commenting answered %d %s", w.Code, w.Body)
+	}
+
+	for _, endpoint := range jsonEndpoints {
+		token := user
+		if endpoint.admin {
+			token = admin
+		}
+		r := httptest.NewRequest(endpoint.method, "/api"+endpoint.path, strings.NewReader(withUnknownField(endpoint.body)))
+		r.Header.Set("Content-Type", "application/json")
+		if endpoint.ifMatch != "" {
+			r.Header.Set("If-Match", endpoint.ifMatch)
+		}
+		check(endpoint.method+" "+endpoint.path, serve(a, r, token))
+	}
+}
+
+func TestStrictJSONParsing(t *testing.T) {
+	a, _ := NewForTesting(WithCTFMode(true))
+	t.Cleanup(a.Shutdown)
+
+	sendUnknownFields(t, a, func(name string, w *httptest.ResponseRecorder) {
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s answered %d %s, want 400", name, w.Code, w.Body)
+			return
+		}
+		var apiErr APIError
+		if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+			t.Fatal(err)
+		}
+		checkAPIError(t, name, w, codeUnknownFields)
+		if apiErr.Details["fields"] != "Bogus" {
+			t.Errorf("%s named %q, want Bogus", name, apiErr.Details["fields"])
+		}
+	})
+}
+
+// Unknown fields are ignored when strict parsing is off, the endpoints may
+// still reject the values
+func TestRelaxedJSONParsing(t *testing.T) {
+	a, _ := NewForTesting(WithCTFMode(true), WithStrictJSONParsing(false))
+	t.Cleanup(a.Shutdown)
+
+	sendUnknownFields(t, a, func(name string, w *httptest.ResponseRecorder) {
+		code := w.Header().Get("X-Error-Code")
+		if code == codeUnknownFields || code == codeInvalidJSON || w.Code == http.StatusUnauthorized || w.Code == http.StatusForbidden {
+			t.Errorf("%s answered %d %s without strict parsing", name, w.Code, w.Body)
+		}
+	})
+}
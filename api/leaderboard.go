@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	m "govulnapi/models"
+)
+
+type leaderboard struct {
+	ranked []m.LeaderboardEntry       // Visible users, best first
+	all    map[int]m.LeaderboardEntry // Every user by id, unranked
+}
+
+// Recomputes portfolio values at the given prices, called once per price refresh
+func (a *Api) refreshLeaderboard(coins []m.Coin) {
+	portfolios, err := a.db.GetPortfolios()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	prices := map[string]m.Usd{}
+	for _, coin := range coins {
+		prices[coin.Id] = coin.Price
+	}
+
+	board := leaderboard{all: map[int]m.LeaderboardEntry{}}
+	for _, p := range portfolios {
+		entry := m.LeaderboardEntry{
+			UserId:      p.UserId,
+			DisplayName: p.DisplayName,
+			Value:       p.UsdBalance,
+		}
+		if entry.DisplayName == "" {
+			entry.DisplayName = fmt.Sprintf("User #%d", p.UserId)
+		}
+		for _, balance := range p.CoinBalances {
+			entry.Value += m.UsdValue(prices[balance.CoinId], balance.Qty)
+		}
+
+		board.all[p.UserId] = entry
+		if !p.HideFromLeaderboard {
+			board.ranked = append(board.ranked, entry)
+		}
+	}
+
+	sort.SliceStable(board.ranked, func(i, j int) bool {
+		return board.ranked[i].Value > board.ranked[j].Value
+	})
+	for i := range board.ranked {
+		board.ranked[i].Rank = i + 1
+	}
+
+	a.coinsMu.Lock()
+	a.leaderboard = board
+	a.coinsMu.Unlock()
+}
+
+// Gets the top n users and the rank the given user has or would have among them
+func (a *Api) leaderboardFor(userId int, n int) m.Leaderboard {
+	a.coinsMu.RLock()
+	defer a.coinsMu.RUnlock()
+
+	board := m.Leaderboard{Top: []m.LeaderboardEntry{}}
+	for i := 0; i < n && i < len(a.leaderboard.ranked); i++ {
+		board.Top = append(board.Top, a.leaderboard.ranked[i])
+	}
+
+	me, ok := a.leaderboard.all[userId]
+	if !ok {
+		return board
+	}
+	me.Rank = 1
+	for _, entry := range a.leaderboard.ranked {
+		if entry.Value > me.Value {
+			me.Rank++
+		}
+	}
+	board.Me = me
+
+	return board
+}
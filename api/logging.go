@@ -0,0 +1,74 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// Logs every completed request as a structured record, see requestLogEntry
+func (s *Api) requestLogger() func(http.Handler) http.Handler {
+	return middleware.RequestLogger(&slogFormatter{logger: slog.Default(), verify: s.verifyRequest})
+}
+
+type slogFormatter struct {
+	logger *slog.Logger
+	verify func(r *http.Request) (jwt.Token, error)
+}
+
+func (f *slogFormatter) NewLogEntry(r *http.Request) middleware.LogEntry {
+	return &requestLogEntry{slogFormatter: f, request: r}
+}
+
+// Record of a request, written by chi once the handler returned with the
+// status and bytes its wrapped response writer saw
+type requestLogEntry struct {
+	*slogFormatter
+	request *http.Request
+}
+
+func (e *requestLogEntry) Write(status int, bytes int, _ http.Header, elapsed time.Duration, _ interface{}) {
+	// Handlers that write nothing answer with an implicit 200
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	attrs := []any{
+		slog.String("method", e.request.Method),
+		slog.String("path", e.request.URL.Path),
+		slog.Int("status_code", status),
+		slog.Int("bytes_written", bytes),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+		slog.String("request_id", middleware.GetReqID(e.request.Context())),
+	}
+	if userId, ok := e.userId(); ok {
+		attrs = append(attrs, slog.Int("user_id", userId))
+	}
+	attrs = append(attrs, slog.String("remote_addr", e.request.RemoteAddr))
+
+	e.logger.Info("request", attrs...)
+}
+
+func (e *requestLogEntry) Panic(v interface{}, stack []byte) {
+	e.logger.Error(
+		"panic",
+		slog.String("request_id", middleware.GetReqID(e.request.Context())),
+		slog.Any("panic", v),
+		slog.String("stack", string(stack)),
+	)
+}
+
+// Id of the user whose token the request was sent with. The logger runs
+// before any route's verifier, so the token is verified here once more.
+func (e *requestLogEntry) userId() (int, bool) {
+	token, err := e.verify(e.request)
+	if err != nil || token == nil {
+		return 0, false
+	}
+
+	userId, ok := token.PrivateClaims()["user_id"].(float64)
+	return int(userId), ok
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestRequestLogger(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "alice@example.com", "password123")
+	alice, err := a.db.GetUserByEmail("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The routes log to the default logger, the same records are written to
+	// logs here
+	var logs bytes.Buffer
+	logger := middleware.RequestLogger(&slogFormatter{
+		logger: slog.New(slog.NewJSONHandler(&logs, nil)),
+		verify: a.verifyRequest,
+	})
+	handler := middleware.RequestID(logger(a.Handler()))
+	logRequest := func(path, token string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		t.Helper()
+		logs.Reset()
+
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set(middleware.RequestIDHeader, "log-test")
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		var record map[string]interface{}
+		if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+			t.Fatalf("logged %q: %v", logs.String(), err)
+		}
+		return w, record
+	}
+
+	w, record := logRequest("/api/balances/usd", token)
+	want := map[string]interface{}{
+		"level":         "INFO",
+		"msg":           "request",
+		"method":        http.MethodGet,
+		"path":          "/api/balances/usd",
+		"status_code":   float64(http.StatusOK),
+		"bytes_written": float64(w.Body.Len()),
+		"request_id":    "log-test",
+		"user_id":       float64(alice.Id),
+		"remote_addr":   "192.0.2.1:1234",
+	}
+	for name, value := range want {
+		if record[name] != value {
+			t.Errorf("logged %s = %v, want %v", name, record[name], value)
+		}
+	}
+	if duration, ok := record["duration_ms"].(float64); !ok || duration < 0 {
+		t.Errorf("logged duration_ms = %v, want the time it took", record["duration_ms"])
+	}
+
+	// Requests without a valid token are logged without a user
+	for _, token := range []string{"", "not-a-token"} {
+		w, record = logRequest("/api/coins/nocoin", token)
+		if record["status_code"] != float64(http.StatusNotFound) || record["bytes_written"] != float64(w.Body.Len()) {
+			t.Errorf("logged %v, want the 404 written", record)
+		}
+		if _, ok := record["user_id"]; ok {
+			t.Errorf("logged %v with token %q, want no user", record, token)
+		}
+	}
+}
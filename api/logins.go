@@ -0,0 +1,76 @@
+package api
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Failed logins of an email after which further attempts are refused
+	loginMaxFailures = 5
+	// Real time the failures of an email are counted over
+	loginFailureWindow = 15 * time.Minute
+)
+
+// Failed logins by email, see VulnLoginBruteForce
+type loginThrottle struct {
+	mu       sync.Mutex
+	failures map[string][]time.Time
+}
+
+// Failures of the email within loginFailureWindow before now, dropping older
+// ones. Needs mu to be held.
+func (l *loginThrottle) recent(email string, now time.Time) []time.Time {
+	var recent []time.Time
+	for _, failure := range l.failures[email] {
+		if now.Sub(failure) < loginFailureWindow {
+			recent = append(recent, failure)
+		}
+	}
+	if len(recent) == 0 {
+		delete(l.failures, email)
+	} else {
+		l.failures[email] = recent
+	}
+	return recent
+}
+
+// Tells how long the email has to wait before logging in again, 0 when it
+// doesn't have to
+func (a *Api) loginRetryAfter(email string) time.Duration {
+	if a.vulnerable(VulnLoginBruteForce) {
+		return 0
+	}
+
+	a.logins.mu.Lock()
+	defer a.logins.mu.Unlock()
+
+	now := a.clock.Now()
+	recent := a.logins.recent(strings.ToLower(email), now)
+	if len(recent) < loginMaxFailures {
+		return 0
+	}
+	return loginFailureWindow - now.Sub(recent[0])
+}
+
+// Counts a failed login of the email, or forgets its failures once it logged
+// in
+func (a *Api) recordLogin(email string, succeeded bool) {
+	if a.vulnerable(VulnLoginBruteForce) {
+		return
+	}
+
+	a.logins.mu.Lock()
+	defer a.logins.mu.Unlock()
+
+	email = strings.ToLower(email)
+	if succeeded {
+		delete(a.logins.failures, email)
+		return
+	}
+	if a.logins.failures == nil {
+		a.logins.failures = map[string][]time.Time{}
+	}
+	a.logins.failures[email] = append(a.logins.recent(email, a.clock.Now()), a.clock.Now())
+}
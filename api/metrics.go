@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+type metrics struct {
+	requests     atomic.Int64
+	latencyTotal atomic.Int64 // Nanoseconds
+	lastRefresh  atomic.Int64 // Unix nanoseconds of last successful price refresh
+	lastAdvance  atomic.Int64 // Unix nanoseconds of when the last virtual day started
+	stalePrices  atomic.Bool  // Prices are the ones saved in the database since the warmup failed
+
+	historyCacheHits   atomic.Int64 // Results of historyCache read or shared with a running computation
+	historyCacheMisses atomic.Int64 // Computations historyCache ran
+
+	panics atomic.Int64 // Handlers that panicked, see recoverer
+}
+
+func (s *Api) countRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+
+		s.metrics.requests.Add(1)
+		s.metrics.latencyTotal.Add(int64(time.Since(start)))
+	})
+}
+
+func (m *metrics) averageLatency() time.Duration {
+	requests := m.requests.Load()
+	if requests == 0 {
+		return 0
+	}
+	return time.Duration(m.latencyTotal.Load() / requests)
+}
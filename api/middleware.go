@@ -1,12 +1,52 @@
 package api
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
 	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/jwtauth/v5"
+	"github.com/google/uuid"
 )
 
+// Rejects requests without a valid token, like jwtauth.Authenticator but
+// answering with an APIError. verifier already checked the token claims.
+func (s *Api) authenticator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _, err := jwtauth.FromContext(r.Context())
+
+		if err != nil || token == nil {
+			reason := "no token found"
+			if err != nil {
+				reason = err.Error()
+			}
+			writeAPIError(w, http.StatusUnauthorized, APIError{
+				Code:    codeUnauthorized,
+				Message: "Valid token required!",
+				Details: map[string]string{"reason": reason},
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *Api) userDispatcher(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var (
@@ -14,11 +54,10 @@ func (s *Api) userDispatcher(next http.Handler) http.Handler {
 			user_id     = int(creds["user_id"].(float64))
 		)
 
-		user, err := s.db.GetUserById(user_id)
+		user, err := s.repo(r).GetUserById(user_id)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(err.Error()))
+			s.writeInternalError(w, err)
 			return
 		}
 
@@ -28,3 +67,486 @@ func (s *Api) userDispatcher(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+func (s *Api) adminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, creds, _ := jwtauth.FromContext(r.Context())
+
+		if role, _ := creds["role"].(string); role != "admin" {
+			writeError(w, http.StatusForbidden, codeForbidden, "Admin privileges required!")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Answers with 503 while lab data is being restored or reset, so requests
+// don't see it half replaced
+func (a *Api) rejectWhileReplacing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.replacing.Load() {
+			writeError(w, http.StatusServiceUnavailable, codeUnavailable, "Lab data is being replaced, try again later!")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Rejects requests that may write while lab data is being restored or reset,
+// in-flight ones are finished before that starts
+func (a *Api) quiesceWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !a.replaceMu.TryRLock() {
+			writeError(w, http.StatusServiceUnavailable, codeUnavailable, "Lab data is being replaced, try again later!")
+			return
+		}
+		defer a.replaceMu.RUnlock()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Rejects POST, PUT and PATCH requests with a body that isn't one of the
+// given media types, parameters like charset are ignored
+func ContentTypes(types ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			for _, t := range types {
+				if mediaType == t {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			writeError(w, http.StatusUnsupportedMediaType, codeUnsupportedMediaType, fmt.Sprintf("Content-Type needs to be %s!", strings.Join(types, " or ")))
+		})
+	}
+}
+
+// Decompresses request bodies sent with Content-Encoding gzip. Reading more
+// than maxBytes of the decompressed body fails, so small compressed bodies
+// can't expand into gigabytes.
+func DecompressBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.TrimSpace(r.Header.Get("Content-Encoding"))
+			if encoding == "" || strings.EqualFold(encoding, "identity") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !strings.EqualFold(encoding, "gzip") {
+				writeError(w, http.StatusUnsupportedMediaType, codeUnsupportedMediaType, "Content-Encoding needs to be gzip!")
+				return
+			}
+
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, codeBadRequest, "Request body isn't valid gzip!")
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, &gzipBody{Reader: gz, body: r.Body}, maxBytes)
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Decompressed request body, closing it closes the compressed one
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// Answers with 429 once a client address sent limit requests within the
+// current window, windows starting with the first request of the address.
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset tell the
+// limit, the requests left and the unix time the window ends. The limit is
+// read on every request so it can change while running, a limit <= 0 lets
+// every request through.
+func RateLimit(limit func() int, window time.Duration) func(http.Handler) http.Handler {
+	type clientWindow struct {
+		start    time.Time
+		requests int
+	}
+
+	var (
+		mu      sync.Mutex
+		clients = map[string]*clientWindow{}
+		swept   time.Time
+	)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := limit()
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			client, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				client = r.RemoteAddr
+			}
+
+			mu.Lock()
+			now := time.Now()
+			// Forgets clients whose window is over, so the map doesn't grow
+			// with every address ever seen
+			if now.Sub(swept) >= window {
+				for address, c := range clients {
+					if now.Sub(c.start) >= window {
+						delete(clients, address)
+					}
+				}
+				swept = now
+			}
+			c, ok := clients[client]
+			if !ok || now.Sub(c.start) >= window {
+				c = &clientWindow{start: now}
+				clients[client] = c
+			}
+			c.requests++
+			exceeded, retryAfter := c.requests > limit, window-now.Sub(c.start)
+			remaining, reset := max(limit-c.requests, 0), c.start.Add(window)
+			mu.Unlock()
+
+			// Sent with every response so clients can slow down before
+			// they're refused
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+
+			if exceeded {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+				writeError(w, http.StatusTooManyRequests, codeTooManyRequests, "Too many requests, try again later!")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Cancels the request context after d and answers with 503 when the handler
+// hasn't finished by then. Responses are buffered until the handler returns,
+// so the 503 can still be sent.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			var (
+				tw       = &timeoutWriter{header: http.Header{}, statusCode: http.StatusOK}
+				done     = make(chan struct{})
+				panicked = make(chan interface{}, 1)
+			)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				for name, values := range tw.header {
+					w.Header()[name] = values
+				}
+				w.WriteHeader(tw.statusCode)
+				w.Write(tw.body.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				tw.timedOut = true
+				writeError(w, http.StatusServiceUnavailable, codeTimeout, "Request timed out!")
+			}
+		})
+	}
+}
+
+// Applies the read timeout to GET and HEAD requests and the write timeout to
+// all others
+func MethodTimeouts(read time.Duration, write time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		reads, writes := Timeout(read)(next), Timeout(write)(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				reads.ServeHTTP(w, r)
+			default:
+				writes.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// Cancels the request context after d without buffering the response, for
+// handlers streaming it, which stop once the context is done
+func Deadline(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Buffers the response of a handler running under Timeout, and discards
+// what it writes after timing out
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	body        bytes.Buffer
+	statusCode  int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(statusCode int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.statusCode = statusCode
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.wroteHeader = true
+	return tw.body.Write(b)
+}
+
+// Answers panicking handlers with a 500 APIError carrying the request id,
+// like middleware.Recoverer but in JSON. The stack goes to the request log.
+func (a *Api) recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			// Handlers abort responses with it on purpose, the server
+			// handles it
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			a.metrics.panics.Add(1)
+			if entry := middleware.GetLogEntry(r); entry != nil {
+				entry.Panic(p, debug.Stack())
+			} else {
+				log.Printf("panic: %v\n%s", p, debug.Stack())
+			}
+
+			writeAPIError(w, http.StatusInternalServerError, APIError{
+				Code:    codeInternal,
+				Message: "Internal server error!",
+				Details: map[string]string{"request_id": middleware.GetReqID(r.Context())},
+			})
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Sets headers hardening browsers against the API responses. HSTS is only
+// sent over TLS and when hstsMaxAge isn't 0, servers without TLS pass 0.
+func SecurityHeaders(hstsMaxAge time.Duration) func(http.Handler) http.Handler {
+	hsts := fmt.Sprintf("max-age=%d; includeSubDomains; preload", int64(hstsMaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			headers := w.Header()
+			headers.Set("X-Content-Type-Options", "nosniff")
+			headers.Set("X-Frame-Options", "DENY")
+			headers.Set("Referrer-Policy", "no-referrer")
+			headers.Set("Content-Security-Policy", "default-src 'none'")
+			if hstsMaxAge > 0 && r.TLS != nil {
+				headers.Set("Strict-Transport-Security", hsts)
+			}
+
+			next.ServeHTTP(&poweredByStripper{ResponseWriter: w}, r)
+		})
+	}
+}
+
+// Policy of the Swagger UI pages, which run inline scripts and styles
+const docsContentSecurityPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:"
+
+// Replaces the Content-Security-Policy set by SecurityHeaders, for pages
+// needing more than API responses do
+func ContentSecurityPolicy(policy string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Security-Policy", policy)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Removes X-Powered-By right before headers are sent, so handlers can't
+// leak it either
+type poweredByStripper struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (s *poweredByStripper) WriteHeader(statusCode int) {
+	if !s.wroteHeader {
+		s.wroteHeader = true
+		s.Header().Del("X-Powered-By")
+	}
+	s.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (s *poweredByStripper) Write(b []byte) (int, error) {
+	if !s.wroteHeader {
+		s.WriteHeader(http.StatusOK)
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Replays the stored response instead of running the handler again when a
+// request is retried with the same Idempotency-Key header
+func (s *Api) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			user = r.Context().Value("user").(m.User)
+			key  = r.Header.Get("Idempotency-Key")
+		)
+
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, err := uuid.Parse(key); err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "Idempotency key needs to be a valid UUID!")
+			return
+		}
+
+		reserved, err := s.repo(r).ReserveIdempotencyKey(user.Id, key)
+		if err != nil {
+			s.writeInternalError(w, err)
+			return
+		}
+
+		if !reserved {
+			response, done, err := s.repo(r).GetIdempotentResponse(user.Id, key)
+			if err != nil {
+				s.writeInternalError(w, err)
+			} else if !done {
+				writeError(w, http.StatusConflict, codeConflict, "Request with this idempotency key is still in progress!")
+			} else {
+				for name, values := range response.Header {
+					w.Header()[name] = values
+				}
+				w.WriteHeader(response.StatusCode)
+				w.Write([]byte(response.Body))
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK, before: w.Header().Clone()}
+		next.ServeHTTP(recorder, r)
+
+		// Server errors are not final, so the request can be retried
+		if recorder.statusCode >= http.StatusInternalServerError {
+			err = s.repo(r).ReleaseIdempotencyKey(user.Id, key)
+		} else {
+			err = s.repo(r).SaveIdempotentResponse(user.Id, key, m.IdempotentResponse{
+				StatusCode: recorder.statusCode,
+				Header:     recorder.header,
+				Body:       recorder.body.String(),
+			})
+		}
+		if err != nil {
+			log.Println(err)
+		}
+	})
+}
+
+// Passes the response through while keeping a copy of its status, body and
+// the headers set since before
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	before      http.Header
+	header      http.Header
+	body        bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	if !rr.wroteHeader {
+		rr.wroteHeader = true
+		rr.statusCode = statusCode
+		rr.header = http.Header{}
+		for name, values := range rr.Header() {
+			if !slices.Equal(values, rr.before[name]) {
+				rr.header[name] = slices.Clone(values)
+			}
+		}
+	}
+	rr.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if !rr.wroteHeader {
+		rr.WriteHeader(http.StatusOK)
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
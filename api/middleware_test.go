@@ -0,0 +1,520 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+var securityHeaders = map[string]string{
+	"X-Content-Type-Options":  "nosniff",
+	"X-Frame-Options":         "DENY",
+	"Referrer-Policy":         "no-referrer",
+	"Content-Security-Policy": "default-src 'none'",
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		handler http.HandlerFunc
+		tls     bool
+	}{
+		{"writing the body", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Powered-By", "Go")
+			w.Write([]byte("ok"))
+		}, false},
+		{"writing the status", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Powered-By", "Go")
+			w.WriteHeader(http.StatusTeapot)
+		}, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			w := httptest.NewRecorder()
+			SecurityHeaders(time.Hour)(test.handler).ServeHTTP(w, r)
+
+			for name, want := range securityHeaders {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s is %q, want %q", name, got, want)
+				}
+			}
+			if poweredBy := w.Header().Get("X-Powered-By"); poweredBy != "" {
+				t.Errorf("X-Powered-By %q was sent", poweredBy)
+			}
+
+			hsts := w.Header().Get("Strict-Transport-Security")
+			if want := "max-age=3600; includeSubDomains; preload"; test.tls && hsts != want {
+				t.Errorf("Strict-Transport-Security is %q over TLS, want %q", hsts, want)
+			} else if !test.tls && hsts != "" {
+				t.Errorf("Strict-Transport-Security %q was sent without TLS", hsts)
+			}
+		})
+	}
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressBody(t *testing.T) {
+	body := []byte(`{"CoinId":"bitcoin","IsBuy":true,"Qty":1}`)
+
+	// Echoes the body the handler got, or the error reading it
+	handler := DecompressBody(64)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if encoding := r.Header.Get("Content-Encoding"); strings.EqualFold(encoding, "gzip") {
+			http.Error(w, "Content-Encoding "+encoding+" was left", http.StatusInternalServerError)
+			return
+		}
+		w.Write(data)
+	}))
+
+	for _, test := range []struct {
+		name     string
+		encoding string
+		body     []byte
+		code     int
+	}{
+		{"gzip", "gzip", gzipped(t, body), http.StatusOK},
+		{"gzip in capitals", "GZIP", gzipped(t, body), http.StatusOK},
+		{"uncompressed", "", body, http.StatusOK},
+		{"identity", "identity", body, http.StatusOK},
+		{"not gzip", "gzip", body, http.StatusBadRequest},
+		{"empty gzip", "gzip", nil, http.StatusBadRequest},
+		{"other encoding", "br", body, http.StatusUnsupportedMediaType},
+		{"decompression bomb", "gzip", gzipped(t, bytes.Repeat([]byte(" "), 1<<20)), http.StatusRequestEntityTooLarge},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(test.body))
+		r.Header.Set("Content-Encoding", test.encoding)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != test.code {
+			t.Errorf("%s answered %d %s, want %d", test.name, w.Code, w.Body, test.code)
+		} else if test.code == http.StatusOK && !bytes.Equal(w.Body.Bytes(), body) {
+			t.Errorf("%s got body %q, want %q", test.name, w.Body, body)
+		}
+	}
+
+	// Truncated bodies fail to read past the valid gzip header
+	truncated := gzipped(t, body)
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(truncated[:len(truncated)-10]))
+	r.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code == http.StatusOK {
+		t.Errorf("a truncated body answered %d %s, want a read error", w.Code, w.Body)
+	}
+}
+
+func TestGzippedOrder(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "alice@example.com", "password123")
+
+	for encoding, body := range map[string][]byte{
+		"gzip": gzipped(t, []byte(`{"CoinId":"bitcoin","IsBuy":true,"Qty":1}`)),
+		"bad":  []byte(`{"CoinId":"bitcoin","IsBuy":true,"Qty":1}`),
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/api/orders", bytes.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Content-Encoding", "gzip")
+		w := serve(a, r, token)
+
+		if want := map[string]int{"gzip": http.StatusOK, "bad": http.StatusBadRequest}[encoding]; w.Code != want {
+			t.Errorf("ordering with a %s body answered %d %s, want %d", encoding, w.Code, w.Body, want)
+		}
+	}
+
+	// Only the gzipped order was placed
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/orders", nil), token)
+	var page m.OrderPage
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Orders) != 1 || page.Orders[0].CoinId != "bitcoin" || page.Orders[0].Qty != 1 {
+		t.Errorf("got orders %+v, want the one of 1 bitcoin", page.Orders)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	limit := 3
+	handler := RateLimit(func() int { return limit }, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	start := time.Now()
+	for i, want := range []struct {
+		code      int
+		remaining string
+	}{
+		{http.StatusOK, "2"},
+		{http.StatusOK, "1"},
+		{http.StatusOK, "0"},
+		{http.StatusTooManyRequests, "0"},
+		{http.StatusTooManyRequests, "0"},
+	} {
+		w := request("192.0.2.1:1234")
+		if w.Code != want.code {
+			t.Errorf("request %d answered %d, want %d", i+1, w.Code, want.code)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "3" {
+			t.Errorf("request %d has a limit of %q, want 3", i+1, got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != want.remaining {
+			t.Errorf("request %d has %q remaining, want %s", i+1, got, want.remaining)
+		}
+		reset, err := strconv.ParseInt(w.Header().Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil || reset < start.Add(time.Minute).Unix() || reset > time.Now().Add(time.Minute).Unix() {
+			t.Errorf("request %d resets at %d (%v), want a minute after the first one", i+1, reset, err)
+		}
+		if retryAfter := w.Header().Get("Retry-After"); (want.code == http.StatusTooManyRequests) != (retryAfter != "") {
+			t.Errorf("request %d answered %d with Retry-After %q", i+1, w.Code, retryAfter)
+		}
+	}
+
+	// Other clients have requests of their own, other ports of the same
+	// address don't
+	if w := request("192.0.2.2:1234"); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != "2" {
+		t.Errorf("another client answered %d with %q remaining, want 200 and 2", w.Code, w.Header().Get("X-RateLimit-Remaining"))
+	}
+	if w := request("192.0.2.1:4321"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("another port answered %d, want 429", w.Code)
+	}
+
+	// The limit is read on every request, 7 were sent so far
+	limit = 10
+	if w := request("192.0.2.1:1234"); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != "3" {
+		t.Errorf("raising the limit answered %d with %q remaining, want 200 and 3", w.Code, w.Header().Get("X-RateLimit-Remaining"))
+	}
+	limit = 0
+	if w := request("192.0.2.1:1234"); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("no limit answered %d with headers %v, want 200 without rate limit headers", w.Code, w.Header())
+	}
+}
+
+func TestCoinDetailRateLimit(t *testing.T) {
+	a, _ := NewForTesting(WithCoinDetailRateLimit(2))
+	t.Cleanup(a.Shutdown)
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/bitcoin", nil), "")
+		if w.Code != want {
+			t.Errorf("request %d answered %d, want %d", i+1, w.Code, want)
+		}
+		if got, want := w.Header().Get("X-RateLimit-Remaining"), strconv.Itoa(max(1-i, 0)); got != want {
+			t.Errorf("request %d has %q remaining, want %s", i+1, got, want)
+		}
+	}
+
+	// Only the coin detail is limited
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins", nil), ""); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("listing coins answered %d with limit %q, want 200 without one", w.Code, w.Header().Get("X-RateLimit-Limit"))
+	}
+}
+
+func TestHSTS(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		opts []Option
+		tls  bool
+		want string
+	}{
+		{"tls", []Option{WithSelfSignedTLS(true)}, true, "max-age=63072000; includeSubDomains; preload"},
+		{"custom max age", []Option{WithSelfSignedTLS(true), WithHSTSMaxAge(time.Hour)}, true, "max-age=3600; includeSubDomains; preload"},
+		{"no max age", []Option{WithSelfSignedTLS(true), WithHSTSMaxAge(0)}, true, ""},
+		{"plain http of a tls api", []Option{WithSelfSignedTLS(true)}, false, ""},
+		{"plain http", nil, false, ""},
+		{"tls in front of a plain http api", nil, true, ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			a, _ := NewForTesting(test.opts...)
+			t.Cleanup(a.Shutdown)
+
+			server := httptest.NewUnstartedServer(a.Handler())
+			if test.tls {
+				config, err := newTLSConfig(a.getOptions())
+				if err != nil {
+					t.Fatal(err)
+				}
+				server.TLS = config
+				server.StartTLS()
+			} else {
+				server.Start()
+			}
+			t.Cleanup(server.Close)
+
+			client := server.Client()
+			if test.tls {
+				client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+			}
+			resp, err := client.Get(server.URL + "/api/coins")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if got := resp.Header.Get("Strict-Transport-Security"); got != test.want {
+				t.Errorf("Strict-Transport-Security is %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRoutesSendSecurityHeaders(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+
+	paths := []string{"/api/coins", "/api/coins/nocoin", "/api/balances/usd", "/favicon.ico", "/robots.txt"}
+	for _, path := range paths {
+		w := serve(a, httptest.NewRequest(http.MethodGet, path, nil), "")
+		for name, want := range securityHeaders {
+			if got := w.Header().Get(name); got != want {
+				t.Errorf("%s answered %d with %s %q, want %q", path, w.Code, name, got, want)
+			}
+		}
+	}
+
+	// Swagger UI gets the same headers, with a policy letting it run
+	for _, path := range []string{"/index.html", "/swagger-ui.css", "/doc.json"} {
+		w := serve(a, httptest.NewRequest(http.MethodGet, path, nil), "")
+		if w.Code != http.StatusOK {
+			t.Errorf("%s answered %d", path, w.Code)
+		}
+		for name, want := range securityHeaders {
+			if name == "Content-Security-Policy" {
+				want = docsContentSecurityPolicy
+			}
+			if got := w.Header().Get(name); got != want {
+				t.Errorf("%s answered with %s %q, want %q", path, name, got, want)
+			}
+		}
+	}
+}
+
+func TestRecovererAnswersJSON(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	adminToken := login(t, a, "admin@govulnapi.com", "admin123")
+
+	panicking := middleware.RequestID(a.recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	})))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(middleware.RequestIDHeader, "test-request")
+	w := httptest.NewRecorder()
+	panicking.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("answered %d %s, want a JSON 500", w.Code, w.Header().Get("Content-Type"))
+	}
+	var apiErr APIError
+	if err := json.NewDecoder(w.Body).Decode(&apiErr); err != nil {
+		t.Fatal(err)
+	}
+	if apiErr.Code != codeInternal || apiErr.Details["request_id"] != "test-request" {
+		t.Errorf("answered %+v, want an internal error with the request id", apiErr)
+	}
+
+	w = serve(a, httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil), adminToken)
+	var stats map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats["Panics"] != float64(1) {
+		t.Errorf("stats count %v panics, want 1", stats["Panics"])
+	}
+
+	// Aborted responses are left to the server
+	aborting := a.recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", p)
+		}
+		if panics := a.metrics.panics.Load(); panics != 1 {
+			t.Errorf("counted %d panics after aborting, want 1", panics)
+		}
+	}()
+	aborting.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestIdempotentReplaysResponse(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+	token := login(t, a, "alice@example.com", "password123")
+
+	order := func(key string, order string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(order))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Idempotency-Key", key)
+		return serve(a, r, token)
+	}
+
+	for _, test := range []struct {
+		name  string
+		order string
+		want  int
+	}{
+		{"made order", `{"CoinId":"bitcoin","IsBuy":true,"Qty":1}`, http.StatusOK},
+		{"unaffordable order", `{"CoinId":"bitcoin","IsBuy":true,"Qty":1000}`, http.StatusPreconditionFailed},
+		{"order of an unknown coin", `{"CoinId":"nocoin","IsBuy":true,"Qty":1}`, http.StatusNotFound},
+	} {
+		key := uuid.NewString()
+		first := order(key, test.order)
+		if first.Code != test.want {
+			t.Fatalf("%s answered %d %s, want %d", test.name, first.Code, first.Body, test.want)
+		}
+
+		// The retry is answered from the stored response even though the
+		// order would be affordable now
+		retry := order(key, `{"CoinId":"ripple","IsBuy":true,"Qty":1}`)
+		if retry.Code != first.Code || retry.Body.String() != first.Body.String() {
+			t.Errorf("%s was replayed as %d %s, want %d %s", test.name, retry.Code, retry.Body, first.Code, first.Body)
+		}
+		for _, name := range []string{"Content-Type", "X-Error-Code"} {
+			if got, want := retry.Header().Values(name), first.Header().Values(name); !slices.Equal(got, want) {
+				t.Errorf("%s was replayed with %s %q, want %q", test.name, name, got, want)
+			}
+		}
+	}
+
+	var balances []m.CoinBalance
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/balances/coin", nil), token)
+	if err := json.NewDecoder(w.Body).Decode(&balances); err != nil {
+		t.Fatal(err)
+	}
+	for _, balance := range balances {
+		if balance.CoinId == "ripple" && balance.Qty != 0 {
+			t.Errorf("retries bought %v ripple", balance.Qty)
+		}
+	}
+}
+
+func TestContentTypes(t *testing.T) {
+	handler := ContentTypes("application/json", "multipart/form-data")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, test := range []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"json", http.MethodPost, "application/json", `{}`, http.StatusNoContent},
+		{"json with charset", http.MethodPatch, "application/json; charset=utf-8", `{}`, http.StatusNoContent},
+		{"second type", http.MethodPut, "multipart/form-data; boundary=x", "--x--", http.StatusNoContent},
+		{"wrong type", http.MethodPost, "text/plain", `{}`, http.StatusUnsupportedMediaType},
+		{"form", http.MethodPut, "application/x-www-form-urlencoded", "a=b", http.StatusUnsupportedMediaType},
+		{"missing header", http.MethodPost, "", `{}`, http.StatusUnsupportedMediaType},
+		{"malformed header", http.MethodPost, "application/", `{}`, http.StatusUnsupportedMediaType},
+		{"empty body", http.MethodPost, "", "", http.StatusNoContent},
+		{"empty body of the wrong type", http.MethodPost, "text/plain", "", http.StatusNoContent},
+		{"GET", http.MethodGet, "text/plain", `{}`, http.StatusNoContent},
+		{"DELETE", http.MethodDelete, "text/plain", `{}`, http.StatusNoContent},
+	} {
+		r := httptest.NewRequest(test.method, "/", strings.NewReader(test.body))
+		if test.contentType != "" {
+			r.Header.Set("Content-Type", test.contentType)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != test.want {
+			t.Errorf("%s answered %d, want %d", test.name, w.Code, test.want)
+		}
+		if test.want == http.StatusUnsupportedMediaType && w.Header().Get("X-Error-Code") != codeUnsupportedMediaType {
+			t.Errorf("%s answered with error code %q", test.name, w.Header().Get("X-Error-Code"))
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	cancelled := make(chan error, 1)
+
+	handler := MethodTimeouts(timeout, 4*timeout)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sleep, _ := time.ParseDuration(r.FormValue("sleep"))
+		select {
+		case <-time.After(sleep):
+		case <-r.Context().Done():
+			cancelled <- r.Context().Err()
+		}
+		w.Header().Set("X-Slept", sleep.String())
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("done"))
+	}))
+	serveSleep := func(method string, sleep time.Duration) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "/?sleep="+sleep.String(), nil))
+		return w
+	}
+
+	// Sleeping past the timeout gets a 503 and cancels the handler, which
+	// writes nothing more
+	w := serveSleep(http.MethodGet, time.Minute)
+	checkAPIError(t, "sleeping GET", w, codeTimeout)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("X-Slept") != "" {
+		t.Errorf("sleeping past the timeout answered %d %v, want a bare 503", w.Code, w.Header())
+	}
+	select {
+	case err := <-cancelled:
+		if err != context.DeadlineExceeded {
+			t.Errorf("handler context ended with %v, want the deadline exceeded", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("handler context wasn't cancelled")
+	}
+
+	// Writes get longer, and handlers finishing in time are answered as
+	// they wrote
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		w := serveSleep(method, 0)
+		if w.Code != http.StatusCreated || w.Header().Get("X-Slept") != "0s" || w.Body.String() != "done" {
+			t.Errorf("%s in time answered %d %v %s, want the handler's response", method, w.Code, w.Header(), w.Body)
+		}
+	}
+	if w := serveSleep(http.MethodPost, 2*timeout); w.Code != http.StatusCreated {
+		t.Errorf("POST sleeping past the read timeout answered %d, want the write timeout to apply", w.Code)
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"sort"
+	"time"
+
+	m "govulnapi/models"
+)
+
+const (
+	topMoversCount   = 10
+	topMoversDays    = 7
+	topMoversMaxDays = 90
+)
+
+// Gets price changes of coins since the given number of virtual days ago,
+// largest gain first. Returns false when no prices were recorded on that day.
+func (a *Api) coinChanges(days int) ([]m.CoinChange, bool, error) {
+	a.coinsMu.RLock()
+	coins := append([]m.Coin{}, a.coins...)
+	past := a.currentDate.AddDate(0, 0, -days).Format(time.DateOnly)
+	a.coinsMu.RUnlock()
+
+	prices, err := a.db.GetDailyPrices(past, past)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(prices) == 0 {
+		return nil, false, nil
+	}
+
+	pastPrices := map[string]m.Usd{}
+	for _, price := range prices {
+		pastPrices[price.CoinId] = price.Price
+	}
+
+	changes := []m.CoinChange{}
+	for _, coin := range coins {
+		pastPrice, ok := pastPrices[coin.Id]
+		if !ok || pastPrice <= 0 {
+			continue
+		}
+		changes = append(changes, m.CoinChange{
+			Id:            coin.Id,
+			Price:         coin.Price,
+			PastPrice:     pastPrice,
+			ChangePercent: percentChange(pastPrice, coin.Price),
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].ChangePercent != changes[j].ChangePercent {
+			return changes[i].ChangePercent > changes[j].ChangePercent
+		}
+		return changes[i].Id < changes[j].Id
+	})
+
+	return changes, true, nil
+}
+
+// Adds the name, symbol and change since the last price of the previous
+// virtual day to the coin
+func (a *Api) coinDetail(coin m.Coin) (m.CoinDetail, error) {
+	detail := m.CoinDetail{Coin: coin}
+
+	listing, err := a.db.GetCoinListing(coin.Id)
+	if err != nil {
+		return m.CoinDetail{}, err
+	}
+	detail.Name, detail.Symbol = listing.Name, listing.Symbol
+
+	today := a.virtualDate()
+	prices, err := a.db.GetDailyPrices(
+		today.AddDate(0, 0, -1).Format(time.DateOnly), today.Format(time.DateOnly), coin.Id,
+	)
+	if err != nil {
+		return m.CoinDetail{}, err
+	}
+	if n := len(prices); n >= 2 && prices[n-2].Price > 0 {
+		change := percentChange(prices[n-2].Price, prices[n-1].Price)
+		detail.Change24hPercent = &change
+	}
+
+	return detail, nil
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	m "govulnapi/models"
+)
+
+const (
+	newsTimeout      = 5 * time.Second
+	newsMaxBytes     = 1 << 20
+	newsMaxRedirects = 5
+	// Characters of a page that isn't HTML shown in place of a paragraph
+	newsExcerptLength = 500
+)
+
+var (
+	newsTitle     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	newsParagraph = regexp.MustCompile(`(?is)<p[^>]*>(.*?)</p>`)
+	htmlTag       = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// Fetches a news page and extracts its title and first paragraph, rawUrl
+// needs to be checked with validateUrl first
+func (a *Api) fetchNewsPreview(rawUrl string) (m.NewsPreview, error) {
+	preview := m.NewsPreview{Url: rawUrl}
+
+	client := a.newGuardedClient(VulnNewsPreviewSSRF, newsTimeout)
+	// Every redirect target is validated like the requested url
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= newsMaxRedirects {
+			return errors.New("Too many redirects!")
+		}
+		return a.validateUrl(req.URL.String(), VulnNewsPreviewSSRF)
+	}
+
+	resp, err := client.Get(rawUrl)
+	if err != nil {
+		return preview, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return preview, fmt.Errorf("News page responded with %s!", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, newsMaxBytes))
+	if err != nil {
+		return preview, err
+	}
+	page := string(body)
+
+	if match := newsTitle.FindStringSubmatch(page); match != nil {
+		preview.Title = htmlText(match[1])
+	}
+	if match := newsParagraph.FindStringSubmatch(page); match != nil {
+		preview.Paragraph = htmlText(match[1])
+	} else if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		preview.Paragraph = strings.TrimSpace(page)
+		if len(preview.Paragraph) > newsExcerptLength {
+			preview.Paragraph = preview.Paragraph[:newsExcerptLength]
+		}
+	}
+
+	return preview, nil
+}
+
+// Strips tags and entities from an HTML fragment, collapsing whitespace
+func htmlText(fragment string) string {
+	text := html.UnescapeString(htmlTag.ReplaceAllString(fragment, " "))
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+)
+
+const (
+	notificationPriceAlert  = "price_alert"
+	notificationOrderFilled = "order_filled"
+	notificationBroadcast   = "broadcast"
+)
+
+// Stores a notification with JSON encoded payload in the user inbox and
+// queues matching webhook deliveries
+func (a *Api) Notify(userId int, notificationType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if err = a.db.AddNotification(userId, notificationType, string(data), a.virtualDate()); err != nil {
+		return err
+	}
+
+	if event, ok := webhookEvents[notificationType]; ok {
+		return a.enqueueWebhooks(userId, event, data)
+	}
+
+	return nil
+}
+
+func (a *Api) notifyAll(notificationType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return a.db.BroadcastNotification(notificationType, string(data), a.virtualDate())
+}
+
+// Drops read notifications older than the retention period
+func (a *Api) cleanupNotifications() {
+	days := a.getOptions().NotificationRetentionDays
+	before := a.virtualDate().AddDate(0, 0, -days)
+
+	if _, err := a.db.DeleteReadNotifications(before); err != nil {
+		log.Println(err)
+	}
+}
+
+func (a *Api) triggerPriceAlerts(coins []m.Coin, date time.Time) error {
+	// Alerts of users with a sandbox are set there, their copies in the
+	// shared database and the alerts of others in their sandbox don't count
+	var (
+		alerts    []m.PriceAlert
+		sandboxed = map[int]bool{}
+	)
+	err := a.forEachSandbox(func(userId int, db *database.DB) error {
+		sandboxed[userId] = true
+
+		triggered, err := db.TriggerPriceAlerts(coins, date)
+		for _, alert := range triggered {
+			if alert.UserId == userId {
+				alerts = append(alerts, alert)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		log.Println(err)
+	}
+
+	triggered, err := a.db.TriggerPriceAlerts(coins, date)
+	if err != nil {
+		return err
+	}
+	for _, alert := range triggered {
+		if !sandboxed[alert.UserId] {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	for _, alert := range alerts {
+		if err = a.Notify(alert.UserId, notificationPriceAlert, alert); err != nil {
+			log.Println(err)
+		}
+	}
+
+	return nil
+}
+
+// Fills the open orders the prices reached and notifies their users
+func (a *Api) fillLimitOrders(coins []m.Coin, date time.Time) error {
+	// Orders of users with a sandbox are placed there, like their alerts
+	var (
+		orders    []m.Order
+		sandboxed = map[int]bool{}
+	)
+	err := a.forEachSandbox(func(userId int, db *database.DB) error {
+		sandboxed[userId] = true
+
+		filled, err := db.FillLimitOrders(coins, date)
+		for _, order := range filled {
+			if order.UserId == userId {
+				orders = append(orders, order)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		log.Println(err)
+	}
+
+	filled, err := a.db.FillLimitOrders(coins, date)
+	if err != nil {
+		return err
+	}
+	for _, order := range filled {
+		if !sandboxed[order.UserId] {
+			orders = append(orders, order)
+		}
+	}
+
+	for _, order := range orders {
+		if err = a.Notify(order.UserId, notificationOrderFilled, order); err != nil {
+			log.Println(err)
+		}
+	}
+
+	return nil
+}
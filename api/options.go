@@ -0,0 +1,455 @@
+package api
+
+import (
+	"govulnapi/api/database"
+	"log"
+	"reflect"
+	"time"
+)
+
+type Options struct {
+	// Storage to use instead of opening DBDriver with DBDsn
+	Repository database.Repository
+	// Source of coin prices instead of the virtual Coingecko server
+	Prices PriceProvider
+	// Generate prices with a random walk seeded with PriceSimulationSeed
+	// instead of getting them from Prices, see SimulatedPrices
+	PriceSimulation     bool
+	PriceSimulationSeed int64
+	// Start the random walk from the prices Prices has on StartDate
+	PriceSimulationAnchored bool
+	// Source of real time pacing virtual days
+	Clock Clock
+	// Database backend, sqlite or postgres
+	DBDriver string
+	// Data source name, defaults to the api.db file for sqlite
+	DBDsn string
+	// Pragmas set on every sqlite connection in addition to the defaults
+	SQLitePragmas map[string]string
+	// Certificate and key files the API is served over TLS with, plain HTTP
+	// is served when they're left empty
+	TLSCertFile string
+	TLSKeyFile  string
+	// Serve TLS with a self-signed certificate generated on startup when no
+	// certificate file is given
+	TLSSelfSigned bool
+	// Address HTTPS is served on while the listen address keeps serving
+	// plain HTTP. Empty serves HTTPS on the listen address alone.
+	TLSListenAddress string
+	// Redirect plain HTTP requests to HTTPS, needs TLSListenAddress
+	TLSRedirectHTTP bool
+	// How long browsers only connect over HTTPS once they got a response
+	// over it, 0 sends no Strict-Transport-Security header
+	HSTSMaxAge time.Duration
+	// Address the gRPC API of the coins is served on, empty disables it
+	GRPCListenAddress string
+	// Negotiate HTTP/2 with clients supporting it, only applies to TLS
+	HTTP2Enabled bool
+	// Origins, e.g. http://localhost:8080, login may redirect to besides
+	// relative paths
+	RedirectOrigins []string
+	// Key used to sign and verify HS256 tokens
+	JwtSecret string
+	// Real time between two virtual days
+	DayDuration time.Duration
+	// Virtual date the lab starts on, and is moved back to by lab resets
+	StartDate time.Time
+	// Enable the deliberately vulnerable variants of features that have a
+	// fixed counterpart
+	VulnerableMode bool
+	// Preset of vulnerabilities taking precedence over VulnerableMode, easy,
+	// medium or hard, see difficultyTable. Empty leaves it to VulnerableMode.
+	Difficulty string
+	// Overrides VulnerableMode and Difficulty for the named vulnerabilities,
+	// see Vulnerabilities
+	Vulnerabilities map[string]bool
+	// Usd a user can deposit or withdraw per virtual day
+	DailyDepositLimit    float64
+	DailyWithdrawalLimit float64
+	// Reject JSON request bodies containing fields unknown to the target model
+	StrictJSONParsing bool
+	// Bytes a gzip compressed request body may decompress to
+	MaxDecompressedBodyBytes int64
+	// Requests a client address may send to GET /coins/{id} per minute, 0
+	// disables the limit
+	CoinDetailRateLimit int
+	// Virtual days read notifications are kept for
+	NotificationRetentionDays int
+	// Virtual days deleted users can be restored for before they're purged
+	DeletedUserRetentionDays int
+	// Virtual days of price history compared when looking for similar coins
+	SimilarCoinsDays int
+	// Directory monthly portfolio reports are written to, one subdirectory
+	// per user
+	ReportsDir string
+	// Directory uploaded avatars are stored in, one subdirectory per user
+	AvatarsDir string
+	// Give every user a copy of the lab data of their own, so whatever they
+	// break only breaks it for them. Needs the default SQLite storage.
+	SandboxMode bool
+	// Directory the sandboxes are stored in, one file per user
+	SandboxDir string
+	// Directory POST /admin/backup writes snapshots to
+	BackupDir string
+	// Delivery attempts before a webhook is marked as failing
+	WebhookMaxAttempts int
+	// Workers fetching coin prices one by one from the virtual Coingecko
+	// server in parallel, a single request gets all prices when <= 1
+	WorkerCount int
+	// How long Shutdown waits for in-flight requests and background jobs
+	// before closing the database
+	ShutdownTimeout time.Duration
+	// Resets the lab to the state seeded with this value once the first
+	// prices are loaded, nil keeps the existing data
+	Seed *int64
+	// Hide flags in lab data that exploiting vulnerabilities reveals, and
+	// score teams submitting them
+	CTFMode bool
+	// Points a CTF team loses for every hint it takes
+	CTFHintPenalty int
+	// Retries of a database call failing because the connection is
+	// unavailable, applies to the default SQLite storage only
+	DBMaxRetries int
+	// Real time between two VACUUMs of the database, 0 disables them
+	VacuumInterval time.Duration
+	// How long the prices of the current virtual date are fetched for before
+	// serving, the ones saved in the database are served as stale after it
+	PriceWarmupTimeout time.Duration
+	// Reports ready while serving stale prices, instead of until a fetch
+	// succeeds
+	AcceptStalePrices bool
+}
+
+type Option func(*Options)
+
+func defaultOptions() Options {
+	return Options{
+		// CWE-547: Use of Hard-coded, Security-relevant Constants
+		JwtSecret:                 "safe-secret",
+		DBDriver:                  database.DriverSQLite,
+		HTTP2Enabled:              true,
+		HSTSMaxAge:                2 * 365 * 24 * time.Hour,
+		RedirectOrigins:           []string{"http://localhost:8080"},
+		DayDuration:               time.Minute,
+		StartDate:                 time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC),
+		VulnerableMode:            true,
+		DailyDepositLimit:         10000,
+		DailyWithdrawalLimit:      10000,
+		StrictJSONParsing:         true,
+		MaxDecompressedBodyBytes:  10 << 20,
+		CoinDetailRateLimit:       60,
+		NotificationRetentionDays: 30,
+		DeletedUserRetentionDays:  30,
+		SimilarCoinsDays:          30,
+		ReportsDir:                "reports",
+		AvatarsDir:                "avatars",
+		SandboxDir:                "sandboxes",
+		BackupDir:                 "backups",
+		WebhookMaxAttempts:        5,
+		DBMaxRetries:              3,
+		VacuumInterval:            7 * 24 * time.Hour,
+		WorkerCount:               1,
+		CTFHintPenalty:            10,
+		ShutdownTimeout:           5 * time.Second,
+		PriceWarmupTimeout:        10 * time.Second,
+	}
+}
+
+func WithRepository(repo database.Repository) Option {
+	return func(o *Options) {
+		o.Repository = repo
+	}
+}
+
+func WithDatabase(driver string, dsn string) Option {
+	return func(o *Options) {
+		o.DBDriver = driver
+		o.DBDsn = dsn
+	}
+}
+
+func WithSQLitePragmas(pragmas map[string]string) Option {
+	return func(o *Options) {
+		o.SQLitePragmas = pragmas
+	}
+}
+
+func WithPriceProvider(prices PriceProvider) Option {
+	return func(o *Options) {
+		o.Prices = prices
+	}
+}
+
+func WithPriceSimulation(seed int64, anchored bool) Option {
+	return func(o *Options) {
+		o.PriceSimulation = true
+		o.PriceSimulationSeed = seed
+		o.PriceSimulationAnchored = anchored
+	}
+}
+
+func WithClock(clock Clock) Option {
+	return func(o *Options) {
+		o.Clock = clock
+	}
+}
+
+func WithTLS(certFile string, keyFile string) Option {
+	return func(o *Options) {
+		o.TLSCertFile = certFile
+		o.TLSKeyFile = keyFile
+	}
+}
+
+func WithSelfSignedTLS(enabled bool) Option {
+	return func(o *Options) {
+		o.TLSSelfSigned = enabled
+	}
+}
+
+func WithTLSListener(address string, redirectHTTP bool) Option {
+	return func(o *Options) {
+		o.TLSListenAddress = address
+		o.TLSRedirectHTTP = redirectHTTP
+	}
+}
+
+func WithHSTSMaxAge(maxAge time.Duration) Option {
+	return func(o *Options) {
+		o.HSTSMaxAge = maxAge
+	}
+}
+
+func WithGRPCListener(address string) Option {
+	return func(o *Options) {
+		o.GRPCListenAddress = address
+	}
+}
+
+func WithHTTP2(enabled bool) Option {
+	return func(o *Options) {
+		o.HTTP2Enabled = enabled
+	}
+}
+
+func WithRedirectOrigins(origins []string) Option {
+	return func(o *Options) {
+		o.RedirectOrigins = origins
+	}
+}
+
+func WithJwtSecret(secret string) Option {
+	return func(o *Options) {
+		o.JwtSecret = secret
+	}
+}
+
+func WithDayDuration(d time.Duration) Option {
+	return func(o *Options) {
+		o.DayDuration = d
+	}
+}
+
+func WithStartDate(date time.Time) Option {
+	return func(o *Options) {
+		o.StartDate = date
+	}
+}
+
+func WithVulnerableMode(vulnerable bool) Option {
+	return func(o *Options) {
+		o.VulnerableMode = vulnerable
+	}
+}
+
+func WithDifficulty(difficulty string) Option {
+	return func(o *Options) {
+		o.Difficulty = difficulty
+	}
+}
+
+func WithVulnerabilities(vulnerabilities map[string]bool) Option {
+	return func(o *Options) {
+		o.Vulnerabilities = vulnerabilities
+	}
+}
+
+func WithDailyCashLimits(deposit float64, withdrawal float64) Option {
+	return func(o *Options) {
+		o.DailyDepositLimit = deposit
+		o.DailyWithdrawalLimit = withdrawal
+	}
+}
+
+func WithStrictJSONParsing(strict bool) Option {
+	return func(o *Options) {
+		o.StrictJSONParsing = strict
+	}
+}
+
+func WithMaxDecompressedBodyBytes(maxBytes int64) Option {
+	return func(o *Options) {
+		o.MaxDecompressedBodyBytes = maxBytes
+	}
+}
+
+func WithCoinDetailRateLimit(requestsPerMinute int) Option {
+	return func(o *Options) {
+		o.CoinDetailRateLimit = requestsPerMinute
+	}
+}
+
+func WithNotificationRetention(days int) Option {
+	return func(o *Options) {
+		o.NotificationRetentionDays = days
+	}
+}
+
+func WithDeletedUserRetention(days int) Option {
+	return func(o *Options) {
+		o.DeletedUserRetentionDays = days
+	}
+}
+
+func WithSimilarCoinsDays(days int) Option {
+	return func(o *Options) {
+		o.SimilarCoinsDays = days
+	}
+}
+
+func WithReportsDir(dir string) Option {
+	return func(o *Options) {
+		o.ReportsDir = dir
+	}
+}
+
+func WithAvatarsDir(dir string) Option {
+	return func(o *Options) {
+		o.AvatarsDir = dir
+	}
+}
+
+func WithSandboxes(enabled bool, dir string) Option {
+	return func(o *Options) {
+		o.SandboxMode = enabled
+		o.SandboxDir = dir
+	}
+}
+
+func WithBackupDir(dir string) Option {
+	return func(o *Options) {
+		o.BackupDir = dir
+	}
+}
+
+func WithWebhookMaxAttempts(attempts int) Option {
+	return func(o *Options) {
+		o.WebhookMaxAttempts = attempts
+	}
+}
+
+func WithDBMaxRetries(retries int) Option {
+	return func(o *Options) {
+		o.DBMaxRetries = retries
+	}
+}
+
+func WithVacuumInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.VacuumInterval = interval
+	}
+}
+
+func WithWorkerCount(workers int) Option {
+	return func(o *Options) {
+		o.WorkerCount = workers
+	}
+}
+
+func WithCTFMode(enabled bool) Option {
+	return func(o *Options) {
+		o.CTFMode = enabled
+	}
+}
+
+func WithCTFHintPenalty(points int) Option {
+	return func(o *Options) {
+		o.CTFHintPenalty = points
+	}
+}
+
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.ShutdownTimeout = timeout
+	}
+}
+
+func WithPriceWarmup(timeout time.Duration, acceptStale bool) Option {
+	return func(o *Options) {
+		o.PriceWarmupTimeout = timeout
+		o.AcceptStalePrices = acceptStale
+	}
+}
+
+func WithSeed(seed int64) Option {
+	return func(o *Options) {
+		o.Seed = &seed
+	}
+}
+
+func (a *Api) getOptions() Options {
+	a.optionsMu.RLock()
+	defer a.optionsMu.RUnlock()
+
+	return a.options
+}
+
+// Applies changes to options that are safe to modify while running, maps
+// need to be replaced instead of modified
+func (a *Api) updateOptions(update func(o *Options)) Options {
+	// Sandboxes read options while they're locked, so they're updated once
+	// options aren't locked anymore
+	defer a.updateSandboxes()
+
+	a.optionsMu.Lock()
+	defer a.optionsMu.Unlock()
+
+	old := a.options
+	update(&a.options)
+	a.db.SetSQLInjection(a.options.vulnerable(VulnSQLInjection))
+	a.db.SetOrderRace(a.options.vulnerable(VulnOrderRaceCondition))
+
+	oldValue, newValue := reflect.ValueOf(old), reflect.ValueOf(a.options)
+	for i := 0; i < oldValue.NumField(); i++ {
+		if !reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			log.Printf(
+				"Option changed: field=%s old=%v new=%v\n",
+				oldValue.Type().Field(i).Name, oldValue.Field(i).Interface(), newValue.Field(i).Interface(),
+			)
+		}
+	}
+
+	return a.options
+}
+
+// Applies options to the running API, e.g. once the config file changed.
+// Only options that are safe to modify while running may be passed, see
+// updateOptions. Vulnerabilities toggled at runtime keep precedence over the
+// configured ones.
+func (a *Api) Reload(opts ...Option) error {
+	settings, err := a.db.GetVulnerabilitySettings()
+	if err != nil {
+		return err
+	}
+
+	a.updateOptions(func(o *Options) {
+		for _, opt := range opts {
+			opt(o)
+		}
+		if len(settings) > 0 {
+			o.Vulnerabilities = mergeVulnerabilities(o.Vulnerabilities, settings)
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"time"
+
+	m "govulnapi/models"
+)
+
+type lot struct {
+	qty   float64
+	price m.Usd
+}
+
+// Open lots per coin, oldest first
+type fifoLots map[string][]lot
+
+func (f fifoLots) buy(coinId string, qty float64, price m.Usd) {
+	f[coinId] = append(f[coinId], lot{qty: qty, price: price})
+}
+
+// Matches sold qty against the oldest lots and returns the realized profit,
+// qty without a matching lot counts as profit in full
+func (f fifoLots) sell(coinId string, qty float64, price m.Usd) m.Usd {
+	var (
+		realized  m.Usd
+		remaining = qty
+		coinLots  = f[coinId]
+	)
+	for remaining > 0 && len(coinLots) > 0 {
+		matched := coinLots[0].qty
+		if remaining < matched {
+			matched = remaining
+		}
+		realized += m.UsdValue(price, matched) - m.UsdValue(coinLots[0].price, matched)
+		remaining -= matched
+		coinLots[0].qty -= matched
+		if coinLots[0].qty <= 0 {
+			coinLots = coinLots[1:]
+		}
+	}
+	f[coinId] = coinLots
+
+	return realized + m.UsdValue(price, remaining)
+}
+
+// Replays orders day by day to compute profit and loss between from and to
+// (both inclusive). Sells are matched against the oldest remaining buys
+// (FIFO) and coins sold without a matching buy, e.g. received through a
+// transaction, have no cost basis.
+func computePnl(orders []m.Order, prices []m.PriceHistory, startingCash m.Usd, from, to time.Time) m.PnlReport {
+	var (
+		report = m.PnlReport{
+			From:        from.Format(time.DateOnly),
+			To:          to.Format(time.DateOnly),
+			EquityCurve: []m.EquityPoint{},
+		}
+		cash       = startingCash
+		lots       = fifoLots{}
+		lastPrices = map[string]m.Usd{}
+	)
+
+	// Cash changes by the value of orders rounded like balances do
+	applyOrder := func(order m.Order) m.Usd {
+		if order.IsBuy {
+			cash -= m.UsdValue(order.Price, order.Qty)
+			lots.buy(order.CoinId, order.Qty, order.Price)
+			return 0
+		}
+
+		cash += m.UsdValue(order.Price, order.Qty)
+		return lots.sell(order.CoinId, order.Qty, order.Price)
+	}
+
+	// Values open lots at the latest known price, or at cost if no price is known
+	valuate := func() (equity m.Usd, unrealized m.Usd) {
+		equity = cash
+		for coinId, coinLots := range lots {
+			for _, l := range coinLots {
+				price, ok := lastPrices[coinId]
+				if !ok {
+					price = l.price
+				}
+				equity += m.UsdValue(price, l.qty)
+				unrealized += m.UsdValue(price, l.qty) - m.UsdValue(l.price, l.qty)
+			}
+		}
+		return equity, unrealized
+	}
+
+	// Advances the replay through the end of the given day
+	orderIdx, priceIdx := 0, 0
+	advance := func(date string) m.Usd {
+		var realized m.Usd
+		for ; orderIdx < len(orders) && orders[orderIdx].VirtualDate <= date; orderIdx++ {
+			realized += applyOrder(orders[orderIdx])
+		}
+		for ; priceIdx < len(prices) && prices[priceIdx].Date <= date; priceIdx++ {
+			lastPrices[prices[priceIdx].CoinId] = prices[priceIdx].Price
+		}
+		return realized
+	}
+
+	// Position before the range, which is empty if the user hadn't traded yet
+	advance(from.AddDate(0, 0, -1).Format(time.DateOnly))
+	_, startUnrealized := valuate()
+
+	var endUnrealized m.Usd
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		report.RealizedPnl += advance(date)
+
+		var equity m.Usd
+		equity, endUnrealized = valuate()
+		report.EquityCurve = append(report.EquityCurve, m.EquityPoint{Date: date, Equity: equity})
+	}
+	report.UnrealizedPnlChange = endUnrealized - startUnrealized
+
+	return report
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	m "govulnapi/models"
+)
+
+func TestFifoLotsSell(t *testing.T) {
+	usd := m.UsdFromFloat
+
+	for _, test := range []struct {
+		name         string
+		buys         []lot
+		sellQty      float64
+		sellPrice    m.Usd
+		wantRealized m.Usd
+		wantLots     []lot
+	}{
+		{
+			name:         "part of a lot",
+			buys:         []lot{{qty: 2, price: usd(100)}},
+			sellQty:      0.5,
+			sellPrice:    usd(150),
+			wantRealized: usd(25), // 0.5 * (150 - 100)
+			wantLots:     []lot{{qty: 1.5, price: usd(100)}},
+		},
+		{
+			name:         "whole lot",
+			buys:         []lot{{qty: 2, price: usd(100)}, {qty: 1, price: usd(300)}},
+			sellQty:      2,
+			sellPrice:    usd(50),
+			wantRealized: usd(-100), // 2 * (50 - 100)
+			wantLots:     []lot{{qty: 1, price: usd(300)}},
+		},
+		{
+			name:      "across lots, oldest first",
+			buys:      []lot{{qty: 1, price: usd(100)}, {qty: 2, price: usd(200)}, {qty: 3, price: usd(400)}},
+			sellQty:   2.5,
+			sellPrice: usd(300),
+			// 1 * (300 - 100) + 1.5 * (300 - 200)
+			wantRealized: usd(350),
+			wantLots:     []lot{{qty: 0.5, price: usd(200)}, {qty: 3, price: usd(400)}},
+		},
+		{
+			name:      "more than the lots",
+			buys:      []lot{{qty: 1, price: usd(100)}},
+			sellQty:   3,
+			sellPrice: usd(300),
+			// 1 * (300 - 100) + 2 * 300 without a cost basis
+			wantRealized: usd(800),
+			wantLots:     []lot{},
+		},
+	} {
+		lots := fifoLots{}
+		for _, buy := range test.buys {
+			lots.buy("bitcoin", buy.qty, buy.price)
+		}
+
+		if realized := lots.sell("bitcoin", test.sellQty, test.sellPrice); realized != test.wantRealized {
+			t.Errorf("%s: realized %v, want %v", test.name, realized, test.wantRealized)
+		}
+		if got := lots["bitcoin"]; !reflect.DeepEqual(got, test.wantLots) {
+			t.Errorf("%s: left lots %+v, want %+v", test.name, got, test.wantLots)
+		}
+	}
+}
+
+func TestComputePnl(t *testing.T) {
+	var (
+		usd   = m.UsdFromFloat
+		day   = func(n int) time.Time { return time.Date(2014, time.January, n, 0, 0, 0, 0, time.UTC) }
+		date  = func(n int) string { return day(n).Format(time.DateOnly) }
+		order = func(n int, isBuy bool, qty float64, price float64) m.Order {
+			return m.Order{CoinId: "bitcoin", IsBuy: isBuy, Qty: qty, Price: usd(price), VirtualDate: date(n), Status: "filled"}
+		}
+	)
+
+	// Buys 1 at 100 and 2 at 200, then sells 2 at 300 matching the lot of
+	// day 2 and half of the lot of day 3, with 1000 of cash to start with
+	orders := []m.Order{order(2, true, 1, 100), order(3, true, 2, 200), order(4, false, 2, 300)}
+	prices := []m.PriceHistory{
+		{CoinId: "bitcoin", Price: usd(100), Date: date(2)},
+		{CoinId: "bitcoin", Price: usd(200), Date: date(3)},
+		{CoinId: "bitcoin", Price: usd(300), Date: date(4)},
+		{CoinId: "bitcoin", Price: usd(250), Date: date(5)},
+	}
+
+	for _, test := range []struct {
+		name     string
+		from, to int
+		want     m.PnlReport
+	}{
+		{
+			// Nothing was held before the range
+			name: "from before the first trade",
+			from: 1, to: 5,
+			want: m.PnlReport{
+				From: date(1), To: date(5),
+				// (300 - 100) + (300 - 200)
+				RealizedPnl: usd(300),
+				// The lot of 1 at 200 is worth 250 at the end
+				UnrealizedPnlChange: usd(50),
+				EquityCurve: []m.EquityPoint{
+					{Date: date(1), Equity: usd(1000)},
+					{Date: date(2), Equity: usd(1000)}, // 900 + 1 * 100
+					{Date: date(3), Equity: usd(1100)}, // 500 + 3 * 200
+					{Date: date(4), Equity: usd(1400)}, // 1100 + 1 * 300
+					{Date: date(5), Equity: usd(1350)}, // 1100 + 1 * 250
+				},
+			},
+		},
+		{
+			// Lots of 1 at 100 and 2 at 200 are worth 200 each before day 4
+			name: "from after buying",
+			from: 4, to: 5,
+			want: m.PnlReport{
+				From: date(4), To: date(5),
+				RealizedPnl:         usd(300),
+				UnrealizedPnlChange: usd(-50), // 50 - 100
+				EquityCurve: []m.EquityPoint{
+					{Date: date(4), Equity: usd(1400)},
+					{Date: date(5), Equity: usd(1350)},
+				},
+			},
+		},
+		{
+			name: "without sells",
+			from: 2, to: 3,
+			want: m.PnlReport{
+				From: date(2), To: date(3),
+				RealizedPnl:         0,
+				UnrealizedPnlChange: usd(100), // 1 * (200 - 100) + 2 * (200 - 200)
+				EquityCurve: []m.EquityPoint{
+					{Date: date(2), Equity: usd(1000)},
+					{Date: date(3), Equity: usd(1100)},
+				},
+			},
+		},
+	} {
+		got := computePnl(orders, prices, usd(1000), day(test.from), day(test.to))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
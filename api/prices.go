@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	m "govulnapi/models"
+)
+
+// Source of coin prices on a virtual date
+type PriceProvider interface {
+	// Fails with ctx's error once it's cancelled
+	Prices(ctx context.Context, date time.Time) ([]m.Coin, error)
+}
+
+// Gets prices from the virtual Coingecko server, either all at once or coin
+// by coin from a pool of workers
+type coingeckoPrices struct {
+	baseUrl string
+	workers int
+	coins   func() ([]m.Coin, error)
+}
+
+func (c coingeckoPrices) Prices(ctx context.Context, date time.Time) ([]m.Coin, error) {
+	if c.workers <= 1 {
+		var coins []m.Coin
+		err := getJSON(ctx, fmt.Sprintf("%s/coins/%v", c.baseUrl, date.UnixMilli()), &coins)
+		return coins, err
+	}
+
+	tracked, err := c.coins()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex // Guards fetched and firstErr
+		fetched  = map[string]m.Coin{}
+		firstErr error
+		ids      = make(chan string)
+	)
+
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for id := range ids {
+				coin, ok, err := c.coinPrice(ctx, id, date)
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				if ok {
+					fetched[id] = coin
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, coin := range tracked {
+		ids <- coin.Id
+	}
+	close(ids)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	// Keep the order of the tracked coins, skipping the ones without a price
+	// on that date
+	coins := []m.Coin{}
+	for _, coin := range tracked {
+		if price, ok := fetched[coin.Id]; ok {
+			coins = append(coins, price)
+		}
+	}
+
+	return coins, nil
+}
+
+// Gets the price of a single coin, ok is false when it has none on that date
+func (c coingeckoPrices) coinPrice(ctx context.Context, id string, date time.Time) (coin m.Coin, ok bool, err error) {
+	coinUrl := fmt.Sprintf("%s/coins/%v/%s", c.baseUrl, date.UnixMilli(), url.PathEscape(id))
+
+	err = getJSON(ctx, coinUrl, &coin)
+	if errors.Is(err, errNotFound) {
+		return coin, false, nil
+	}
+
+	return coin, err == nil, err
+}
+
+var errNotFound = errors.New("Not found!")
+
+// Decodes the JSON response of url into v, retrying until the server can be
+// reached or ctx is cancelled
+func getJSON(ctx context.Context, url string, v interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	var r *http.Response
+	for {
+		r, err = http.DefaultClient.Do(request)
+		if err == nil {
+			break
+		}
+
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	defer r.Body.Close()
+
+	if r.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+// Same prices on every virtual date
+type StaticPrices []m.Coin
+
+func (s StaticPrices) Prices(_ context.Context, date time.Time) ([]m.Coin, error) {
+	return append([]m.Coin{}, s...), nil
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var errRedirectNotAllowed = errors.New("redirect_to needs to be a relative path or an allowed origin!")
+
+// Checks where login may send the user after setting the cookie, any url
+// while the open redirect is enabled
+func (a *Api) checkRedirect(target string) error {
+	// CWE-601: URL Redirection to Untrusted Site ('Open Redirect')
+	// Links to the login with redirect_to=https://evil.example look like they
+	// belong to the lab, but land users on a page of the attacker's choosing
+	if a.vulnerable(VulnLoginOpenRedirect) {
+		return nil
+	}
+
+	// Browsers read \ as / and drop tabs and newlines, so /\evil.example
+	// would leave the site like //evil.example
+	if strings.Contains(target, "\\") || strings.IndexFunc(target, isControl) >= 0 {
+		return errRedirectNotAllowed
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return errRedirectNotAllowed
+	}
+
+	// Relative paths, but not //evil.example which is relative to the scheme
+	// only
+	if u.Scheme == "" && u.Host == "" && strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//") {
+		return nil
+	}
+
+	if u.Scheme == "http" || u.Scheme == "https" {
+		origin := strings.ToLower(u.Scheme + "://" + u.Host)
+		for _, allowed := range a.getOptions().RedirectOrigins {
+			if origin == strings.ToLower(allowed) && u.User == nil {
+				return nil
+			}
+		}
+	}
+
+	return errRedirectNotAllowed
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
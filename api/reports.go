@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+)
+
+// Extensions of files that can be downloaded as reports
+var reportExtensions = []string{".csv"}
+
+var errReportNotFound = errors.New("Report doesn't exist!")
+
+// Directory holding the reports generated for a user
+func (a *Api) reportsDir(userId int) string {
+	return filepath.Join(a.getOptions().ReportsDir, strconv.Itoa(userId))
+}
+
+func monthlyReportName(month time.Time) string {
+	return fmt.Sprintf("portfolio-%s.csv", month.Format("2006-01"))
+}
+
+// Writes the portfolio summary of every user for the virtual month ending
+// with the given day, valued at the day's prices
+func (a *Api) generateMonthlyReports(day time.Time, coins []m.Coin) {
+	portfolios, err := a.db.GetPortfolios()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	// Portfolios of users with a sandbox come from there
+	sandboxed := map[int]m.Portfolio{}
+	err = a.forEachSandbox(func(userId int, db *database.DB) error {
+		sandboxPortfolios, err := db.GetPortfolios()
+		for _, p := range sandboxPortfolios {
+			if p.UserId == userId {
+				sandboxed[userId] = p
+			}
+		}
+		return err
+	})
+	if err != nil {
+		log.Println(err)
+	}
+	for i, p := range portfolios {
+		if sandboxPortfolio, ok := sandboxed[p.UserId]; ok {
+			portfolios[i] = sandboxPortfolio
+		}
+	}
+
+	prices := map[string]m.Usd{}
+	for _, coin := range coins {
+		prices[coin.Id] = coin.Price
+	}
+
+	for _, p := range portfolios {
+		if err = a.writeMonthlyReport(p, day, prices); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// Writes the user's portfolio summary for the current virtual month so far,
+// which is overwritten by the final one at the end of the month
+func (a *Api) generateMonthToDateReport(repo database.Repository, userId int) (string, error) {
+	portfolios, err := repo.GetPortfolios()
+	if err != nil {
+		return "", err
+	}
+
+	a.coinsMu.RLock()
+	day := a.currentDate
+	prices := map[string]m.Usd{}
+	for _, coin := range a.coins {
+		prices[coin.Id] = coin.Price
+	}
+	a.coinsMu.RUnlock()
+
+	for _, p := range portfolios {
+		if p.UserId == userId {
+			return monthlyReportName(day), a.writeMonthlyReport(p, day, prices)
+		}
+	}
+
+	return "", errors.New("Only users have a portfolio!")
+}
+
+func (a *Api) writeMonthlyReport(p m.Portfolio, day time.Time, prices map[string]m.Usd) error {
+	dir := a.reportsDir(p.UserId)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filepath.Join(dir, monthlyReportName(day)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"asset", "qty", "price", "value", "as_of_date"})
+
+	total := p.UsdBalance
+	asOf := day.Format(time.DateOnly)
+	for _, balance := range p.CoinBalances {
+		value := m.UsdValue(prices[balance.CoinId], balance.Qty)
+		total += value
+		writer.Write([]string{
+			balance.CoinId,
+			strconv.FormatFloat(balance.Qty, 'f', -1, 64),
+			prices[balance.CoinId].String(),
+			strconv.FormatFloat(value.Float64(), 'f', 2, 64),
+			asOf,
+		})
+	}
+	writer.Write([]string{"usd", strconv.FormatFloat(p.UsdBalance.Float64(), 'f', 2, 64), "1", strconv.FormatFloat(p.UsdBalance.Float64(), 'f', 2, 64), asOf})
+	writer.Write([]string{"total", "", "", strconv.FormatFloat(total.Float64(), 'f', 2, 64), asOf})
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// Lists the names of the user's reports
+func (a *Api) listReports(userId int) ([]string, error) {
+	names := []string{}
+
+	entries, err := os.ReadDir(a.reportsDir(userId))
+	if errors.Is(err, os.ErrNotExist) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+// Opens one of the user's reports by the file name taken from the url path
+func (a *Api) openReport(userId int, rawName string) (*os.File, error) {
+	// Router params are still escaped, so %2e%2e%2f decodes to ../ here
+	name, err := url.PathUnescape(rawName)
+	if err != nil {
+		return nil, errReportNotFound
+	}
+	dir := a.reportsDir(userId)
+
+	// CWE-22: Improper Limitation of a Pathname to a Restricted Directory ('Path Traversal')
+	// The name is appended to the user's reports directory as it is, so
+	// ../ sequences read any file the API process can access
+	if a.vulnerable(VulnReportPathTraversal) {
+		return os.Open(dir + "/" + name)
+	}
+
+	if !isReportExtension(filepath.Ext(name)) {
+		return nil, errReportNotFound
+	}
+	base, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Clean(filepath.Join(base, name))
+	if !strings.HasPrefix(path, base+string(filepath.Separator)) {
+		return nil, errReportNotFound
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errReportNotFound
+	}
+	return file, err
+}
+
+func isReportExtension(ext string) bool {
+	for _, allowed := range reportExtensions {
+		if strings.EqualFold(ext, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Deletes the reports of all users, e.g. when user ids start over
+func (a *Api) removeReports() {
+	if err := os.RemoveAll(a.getOptions().ReportsDir); err != nil {
+		log.Println(err)
+	}
+}
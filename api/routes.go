@@ -1,52 +1,261 @@
 package api
 
 import (
+	"net/http"
+	"time"
+
 	_ "govulnapi/api/docs"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
-	"github.com/go-chi/jwtauth/v5"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+const (
+	readTimeout  = 5 * time.Second
+	writeTimeout = 10 * time.Second
+	// Streamed exports aren't buffered by Timeout, so they get a deadline
+	// instead
+	exportTimeout = time.Minute
+)
+
 func (s *Api) setupRoutes() {
 	r := s.router
 
+	r.Use(s.countRequests)
+	r.Use(middleware.RequestID)
+	r.Use(s.requestLogger())
+	r.Use(s.recoverer)
+
 	// CWE-942: Permissive Cross-domain Policy with Untrusted Domains
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://*", "https://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
 		AllowedHeaders:   []string{"*"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
-	r.Mount("/", httpSwagger.WrapHandler)
+	var hstsMaxAge time.Duration
+	if s.getOptions().tlsEnabled() {
+		hstsMaxAge = s.getOptions().HSTSMaxAge
+	}
+	r.Use(SecurityHeaders(hstsMaxAge))
+
+	// Requested by every browser opening the API, answered here so they
+	// don't end up as 404s of the Swagger UI
+	r.Get("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	r.Get("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("User-agent: *\nDisallow: /\n"))
+	})
+
+	// Swagger UI needs its scripts and styles, which the strict CSP of the
+	// API responses blocks
+	r.With(ContentSecurityPolicy(docsContentSecurityPolicy)).Mount("/", httpSwagger.WrapHandler)
 
 	r.Route("/api", func(r chi.Router) {
-		r.Get("/coins", s.getCoins)
+		r.Use(DecompressBody(s.getOptions().MaxDecompressedBodyBytes))
+		r.Use(s.rejectWhileReplacing)
+
+		r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, http.StatusNotFound, codeNotFound, "Route not found!")
+		})
+		r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed!")
+		})
+
+		ctf := s.getOptions().CTFMode
 
-		// CWE-598: Use of GET Request Method With Sensitive Query Strings
-		r.Get("/register", s.registerUser)
-		r.Get("/login", s.loginUser)
+		r.Group(func(r chi.Router) {
+			r.Use(MethodTimeouts(readTimeout, writeTimeout))
+
+			r.Get("/readyz", s.getReadiness)
+			r.Get("/version", s.getVersion)
+			r.Get("/debug/vars", s.getDebugVars)
+
+			r.Get("/coins", s.getCoins)
+			r.Get("/coins/top-gainers", s.getTopGainers)
+			r.Get("/coins/top-losers", s.getTopLosers)
+			r.With(RateLimit(func() int { return s.getOptions().CoinDetailRateLimit }, time.Minute)).Get("/coins/{id}", s.getCoinDetail)
+			// Answer from the sandbox of the user when they carry a token
+			r.With(s.verifier, s.sandboxDispatcher).Get("/coins/{id}/orderbook", s.getOrderBook)
+			r.Get("/coins/{id}/similar", s.getSimilarCoins)
+			r.Get("/coins/{id}/moving-average", s.getMovingAverage)
+			r.Get("/coins/{id}/price-change", s.getPriceChange)
+			r.With(s.verifier, s.sandboxDispatcher).Get("/coins/{id}/comments", s.getComments)
+			r.With(s.verifier, s.sandboxDispatcher).Get("/coins/{id}/page", s.getCoinPage)
+			r.Get("/avatars/{user}", s.getAvatar)
+
+			if ctf {
+				r.Get("/ctf/scoreboard", s.getScoreboard)
+			}
+
+			// CWE-598: Use of GET Request Method With Sensitive Query Strings
+			r.Get("/register", s.registerUser)
+			r.Get("/login", s.loginUser)
+		})
 
 		// Token needed
 		r.Group(func(r chi.Router) {
-			r.Use(jwtauth.Verifier(s.jwtAuth))
-			r.Use(jwtauth.Authenticator)
+			// Wraps quiesceWrites, so a write that timed out still holds off
+			// restoring until it's done
+			r.Use(MethodTimeouts(readTimeout, writeTimeout))
+			r.Use(s.verifier)
+			r.Use(s.authenticator)
+			r.Use(s.sandboxDispatcher)
+			r.Use(s.userDispatcher)
+			r.Use(s.quiesceWrites)
+
+			// The web client sends forms
+			r.Group(func(r chi.Router) {
+				r.Use(ContentTypes("application/x-www-form-urlencoded", "multipart/form-data"))
+
+				r.Put("/user/email", s.updateEmail)
+				r.Put("/user/password", s.updatePassword)
+				r.Put("/user/leaderboard", s.updateLeaderboardVisibility)
+			})
+
+			r.With(ContentTypes("application/json", "application/xml", "text/xml")).
+				Post("/transactions/import", s.importTransactions)
+			r.With(ContentTypes("application/json", "application/x-gob")).Post("/strategies", s.addStrategy)
+			r.With(ContentTypes("multipart/form-data")).Post("/me/avatar", s.uploadAvatar)
+			r.With(ContentTypes("multipart/form-data"), s.adminOnly).Post("/coins/bulk-import", s.importCoins)
+
+			r.Group(func(r chi.Router) {
+				r.Use(ContentTypes("application/json"))
+
+				r.Get("/balances/coin", s.getCoinBalances)
+				r.Get("/balances/usd", s.getUsdBalances)
+
+				r.With(s.idempotent).Post("/orders", s.addOrder)
+				r.Get("/orders", s.getOrders)
+				r.Delete("/orders/{id}", s.cancelOrder)
+				r.Get("/portfolio/pnl", s.getPnl)
+				r.Delete("/portfolio/positions/{coin_id}", s.closePosition)
+				r.Get("/portfolio/transactions", s.getTrades)
+				r.Get("/users/{id}/portfolio", s.getUserPortfolio)
+
+				r.Get("/transactions", s.getTransactions)
+				r.Get("/transactions/{id}", s.getTransaction)
+				r.Post("/transactions", s.addTransaction)
+
+				r.Post("/transfer", s.addTransfer)
+				r.Get("/transfers", s.getTransfers)
+				r.Post("/deposit", s.deposit)
+				r.Post("/withdraw", s.withdraw)
+
+				r.Patch("/me", s.updateMe)
+				r.Delete("/me", s.deleteMe)
+				r.Get("/leaderboard", s.getLeaderboard)
+
+				r.Get("/me/price-alerts", s.getPriceAlerts)
+				r.Post("/coins/{id}/price-alert", s.addPriceAlert)
+				r.Get("/me/watchlist", s.getWatchlist)
+				r.Post("/coins/{id}/watchlist", s.watchCoin)
+				r.Delete("/coins/{id}/watchlist", s.unwatchCoin)
+				r.Post("/coins/{id}/news-preview", s.previewNews)
+
+				r.Get("/notifications", s.getNotifications)
+				r.Post("/notifications/{id}/read", s.readNotification)
+
+				r.Get("/reports", s.getReports)
+				r.Post("/reports", s.addReport)
+				r.Get("/reports/{filename}", s.getReport)
+
+				r.Get("/strategies", s.getStrategies)
+
+				r.Post("/coins/{id}/comments", s.addComment)
+				r.Put("/comments/{id}", s.updateComment)
+				r.Delete("/comments/{id}", s.deleteComment)
+				r.Get("/strategies/{id}/run", s.runSavedStrategy)
+
+				r.Get("/webhooks", s.getWebhooks)
+				r.Post("/webhooks", s.addWebhook)
+
+				if ctf {
+					r.Post("/ctf/submit", s.submitFlag)
+					r.Get("/ctf/challenges/{id}/hints/{n}", s.getHint)
+				}
+
+				// Admin role needed
+				r.Group(func(r chi.Router) {
+					r.Use(s.adminOnly)
+
+					r.Post("/coins/{id}/price", s.overrideCoinPrice)
+					r.Get("/coins/export", s.exportCoins)
+					r.Get("/admin/coins/{id}", s.getCoinListing)
+					r.Patch("/admin/coins/{id}", s.updateCoinListing)
+					r.Get("/admin/stats", s.getStats)
+					r.Get("/admin/consistency", s.getConsistency)
+					r.Post("/admin/reload-config", s.reloadConfig)
+					r.Get("/admin/vulnerabilities", s.getVulnerabilities)
+					r.Patch("/admin/vulnerabilities/{id}", s.toggleVulnerability)
+					r.Get("/admin/difficulty", s.getDifficulty)
+					r.Put("/admin/difficulty", s.updateDifficulty)
+					r.Post("/admin/users/{id}/cash", s.adjustUserCash)
+					r.Delete("/admin/users/{id}", s.deleteUser)
+					r.Post("/admin/users/{id}/restore", s.restoreUser)
+					r.Post("/admin/notifications", s.broadcastNotification)
+					r.Post("/admin/diagnostics/ping", s.pingHost)
+					r.Delete("/admin/comments/{id}", s.moderateComment)
+
+					if ctf {
+						r.Get("/admin/flag", s.getAdminFlag)
+						r.Get("/admin/ctf/hints", s.getHintsTaken)
+					}
+				})
+			})
+		})
+
+		// Token needed, without a timeout for streaming and long running
+		// admin tasks
+		r.Group(func(r chi.Router) {
+			r.Use(s.verifier)
+			r.Use(s.authenticator)
+			r.Use(s.sandboxDispatcher)
 			r.Use(s.userDispatcher)
+			r.Use(s.quiesceWrites)
+
+			r.With(Deadline(exportTimeout)).Get("/transactions/export", s.exportTransactions)
 
-			r.Get("/balances/coin", s.getCoinBalances)
-			r.Get("/balances/usd", s.getUsdBalances)
+			r.Group(func(r chi.Router) {
+				r.Use(ContentTypes("application/json"))
+				r.Use(s.adminOnly)
 
-			r.Post("/orders", s.addOrder)
-			r.Get("/orders", s.getOrders)
+				r.Post("/admin/simulate", s.simulateDays)
+				r.Get("/admin/backup", s.backupDatabase)
+				r.Post("/admin/backup", s.createBackup)
+				r.Post("/admin/vacuum", s.vacuumDatabase)
+			})
+		})
+
+		// Token needed, answers with 404 instead of failing in userDispatcher
+		// when the user was deleted
+		r.Group(func(r chi.Router) {
+			r.Use(s.verifier)
+			r.Use(s.authenticator)
+			r.Use(Timeout(readTimeout))
 
-			r.Get("/transactions", s.getTransactions)
-			r.Post("/transactions", s.addTransaction)
+			r.With(s.sandboxDispatcher).Get("/me", s.getMe)
+			// Without sandboxDispatcher, which would keep the sandbox open
+			// while it's removed
+			r.Delete("/me/sandbox", s.resetSandbox)
+		})
+
+		// Restoring and resetting wait for the writes quiesceWrites lets
+		// through, so they can't run behind it
+		r.Group(func(r chi.Router) {
+			r.Use(s.verifier)
+			r.Use(s.authenticator)
+			r.Use(s.adminOnly)
 
-			r.Put("/user/email", s.updateEmail)
-			r.Put("/user/password", s.updatePassword)
+			r.With(ContentTypes("application/octet-stream", "application/vnd.sqlite3")).Post("/admin/restore", s.restoreBackup)
+			r.With(ContentTypes("application/json")).Post("/admin/reset", s.resetLab)
+			r.With(ContentTypes("application/json")).Post("/admin/lab/reset", s.resetLabData)
 		})
 	})
 
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFaviconAndRobots(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+
+	// Answered without a token, like browsers and crawlers ask for them
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/favicon.ico", nil), "")
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("/favicon.ico answered %d %q, want an empty 204", w.Code, w.Body)
+	}
+
+	w = serve(a, httptest.NewRequest(http.MethodGet, "/robots.txt", nil), "")
+	if w.Code != http.StatusOK || w.Body.String() != "User-agent: *\nDisallow: /\n" {
+		t.Errorf("/robots.txt answered %d %q, want every crawler disallowed", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("/robots.txt answered with Content-Type %q, want text/plain", got)
+	}
+}
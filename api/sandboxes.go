@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"govulnapi/api/database"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+// Per-user copies of the lab data, see Options.SandboxMode
+type sandboxes struct {
+	mu  sync.Mutex
+	dbs map[int]*sandbox // By user id
+}
+
+type sandbox struct {
+	db *database.DB
+	// Requests and jobs working on db, it's only closed once they're done
+	users sync.WaitGroup
+}
+
+// File the sandbox of a user is stored in
+func (a *Api) sandboxFile(userId int) string {
+	return filepath.Join(a.getOptions().SandboxDir, strconv.Itoa(userId)+".db")
+}
+
+// Gets the sandbox of a user, creating it from a snapshot of the shared
+// database on first use. The sandbox stays open until release is called.
+func (a *Api) sandbox(userId int) (db *database.DB, release func(), err error) {
+	return a.openSandbox(userId, true)
+}
+
+// Gets the sandbox of a user, or nil when they don't have one yet and create
+// is false
+func (a *Api) openSandbox(userId int, create bool) (db *database.DB, release func(), err error) {
+	a.sandboxes.mu.Lock()
+	defer a.sandboxes.mu.Unlock()
+
+	if box, ok := a.sandboxes.dbs[userId]; ok {
+		box.users.Add(1)
+		return box.db, box.users.Done, nil
+	}
+
+	options := a.getOptions()
+	path := a.sandboxFile(userId)
+	if _, err = os.Stat(path); os.IsNotExist(err) {
+		if !create {
+			return nil, nil, nil
+		}
+		if err = a.createSandbox(path); err != nil {
+			return nil, nil, err
+		}
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	db = database.InitDriver(database.DriverSQLite, path)
+	db.SetMaxRetries(options.DBMaxRetries)
+	if len(options.SQLitePragmas) > 0 {
+		if err = db.ApplyPragmas(options.SQLitePragmas); err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+	}
+	db.SetSQLInjection(options.vulnerable(VulnSQLInjection))
+	db.SetOrderRace(options.vulnerable(VulnOrderRaceCondition))
+
+	if a.sandboxes.dbs == nil {
+		a.sandboxes.dbs = map[int]*sandbox{}
+	}
+	box := &sandbox{db: db}
+	box.users.Add(1)
+	a.sandboxes.dbs[userId] = box
+	log.Printf("Sandbox opened: user_id=%d\n", userId)
+
+	return db, box.users.Done, nil
+}
+
+// Calls fn with the sandbox of every user who has one in sandbox mode, for
+// background jobs that work on the lab data of all users
+func (a *Api) forEachSandbox(fn func(userId int, db *database.DB) error) error {
+	if !a.getOptions().SandboxMode {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(a.getOptions().SandboxDir, "*.db"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		userId, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(file), ".db"))
+		if err != nil {
+			continue
+		}
+
+		// Sandboxes removed meanwhile aren't created again
+		db, release, err := a.openSandbox(userId, false)
+		if err != nil {
+			return err
+		} else if db == nil {
+			continue
+		}
+		err = fn(userId, db)
+		release()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Writes a snapshot of the shared database to path, through a temporary file
+// so a failed snapshot isn't mistaken for a sandbox
+func (a *Api) createSandbox(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err = a.db.Backup(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+// Applies the vulnerabilities toggled at the database level to every open
+// sandbox
+func (a *Api) updateSandboxes() {
+	a.sandboxes.mu.Lock()
+	defer a.sandboxes.mu.Unlock()
+
+	options := a.getOptions()
+
+	for _, box := range a.sandboxes.dbs {
+		box.db.SetSQLInjection(options.vulnerable(VulnSQLInjection))
+		box.db.SetOrderRace(options.vulnerable(VulnOrderRaceCondition))
+	}
+}
+
+// Closes and deletes all sandboxes, e.g. when the shared data they were
+// copied from is replaced. Waits for the requests working on them, while
+// new ones wait for the sandboxes to be gone.
+func (a *Api) removeSandboxes() {
+	a.sandboxes.mu.Lock()
+	defer a.sandboxes.mu.Unlock()
+
+	for userId, box := range a.sandboxes.dbs {
+		box.users.Wait()
+		box.db.Close()
+		delete(a.sandboxes.dbs, userId)
+	}
+	if err := os.RemoveAll(a.getOptions().SandboxDir); err != nil {
+		log.Println(err)
+	}
+}
+
+// Closes and deletes the sandbox of a user once the requests working on it
+// are done, the next request of the user starts over from a snapshot of the
+// shared database
+func (a *Api) removeSandbox(userId int) error {
+	a.sandboxes.mu.Lock()
+	defer a.sandboxes.mu.Unlock()
+
+	if box, ok := a.sandboxes.dbs[userId]; ok {
+		box.users.Wait()
+		box.db.Close()
+		delete(a.sandboxes.dbs, userId)
+	}
+	// Along with the files SQLite keeps next to it in WAL mode
+	path := a.sandboxFile(userId)
+	for _, file := range []string{path, path + "-wal", path + "-shm"} {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Api) closeSandboxes() {
+	a.sandboxes.mu.Lock()
+	defer a.sandboxes.mu.Unlock()
+
+	for _, box := range a.sandboxes.dbs {
+		box.db.Close()
+	}
+}
+
+// Storage of the lab data the request works on, the sandbox of the user in
+// sandbox mode and the shared database otherwise
+func (a *Api) repo(r *http.Request) database.Repository {
+	if repo, ok := r.Context().Value("repo").(database.Repository); ok {
+		return repo
+	}
+	return a.db
+}
+
+// Selects the sandbox of the user whose token the request carries, requests
+// without a valid token and those of admins work on the shared database
+func (s *Api) sandboxDispatcher(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, creds, err := jwtauth.FromContext(r.Context())
+		if !s.getOptions().SandboxMode || err != nil || token == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if role, _ := creds["role"].(string); role != "user" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		userId, ok := creds["user_id"].(float64)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Deleted users don't get a sandbox
+		if _, err = s.db.GetUserById(int(userId)); err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		db, release, err := s.sandbox(int(userId))
+		if err != nil {
+			s.writeInternalError(w, err)
+			return
+		}
+		defer release()
+
+		ctx := context.WithValue(r.Context(), "repo", database.Repository(db))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
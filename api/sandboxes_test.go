@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSandboxedForTesting(t *testing.T) *Api {
+	t.Helper()
+
+	a, _ := NewForTesting(
+		WithSandboxes(true, t.TempDir()),
+		WithVulnerabilities(map[string]bool{VulnSQLInjection: true, VulnProfileMassAssignment: true}),
+	)
+	t.Cleanup(a.Shutdown)
+	return a
+}
+
+func TestSandboxIsolatesSQLInjection(t *testing.T) {
+	a := newSandboxedForTesting(t)
+	alice := login(t, a, "alice@example.com", "password123")
+	bob := login(t, a, "bob@example.com", "password123")
+
+	// The email is pasted into the query unquoted
+	form := url.Values{"email": {`'x' WHERE 0; DROP TABLE "order"; --`}}
+	r := httptest.NewRequest(http.MethodPut, "/api/user/email", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if w := serve(a, r, alice); w.Code != http.StatusOK {
+		t.Fatalf("injecting: %d %s", w.Code, w.Body)
+	}
+
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/api/orders", nil), alice); w.Code != http.StatusInternalServerError {
+		t.Errorf("orders of alice answered %d, want the dropped table to fail", w.Code)
+	}
+	// The sandbox of bob is copied from the shared database only now
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/api/orders", nil), bob); w.Code != http.StatusOK {
+		t.Errorf("orders of bob answered %d %s, want them intact", w.Code, w.Body)
+	}
+}
+
+func TestSandboxKeepsProfileChanges(t *testing.T) {
+	a := newSandboxedForTesting(t)
+	alice := login(t, a, "alice@example.com", "password123")
+
+	r := httptest.NewRequest(http.MethodPatch, "/api/me", strings.NewReader(`{"DisplayName":"alice","UsdBalance":999999,"Role":"admin"}`))
+	r.Header.Set("Content-Type", "application/json")
+	if w := serve(a, r, alice); w.Code != http.StatusOK {
+		t.Fatalf("updating profile: %d %s", w.Code, w.Body)
+	}
+
+	shared, err := a.db.GetUserByEmail("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shared.DisplayName != "" || shared.Role != "user" || shared.UsdBalance != defaultLabReset().StartingBalance {
+		t.Errorf("shared account changed to %+v", shared)
+	}
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/me", nil), alice)
+	if !strings.Contains(w.Body.String(), `"DisplayName":"alice"`) {
+		t.Errorf("profile is %s, want the display name from the sandbox", w.Body)
+	}
+}
+
+func TestRemoveSandboxWaitsForRequests(t *testing.T) {
+	a := newSandboxedForTesting(t)
+	login(t, a, "alice@example.com", "password123")
+	user, err := a.db.GetUserByEmail("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, release, err := a.sandbox(user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed := make(chan error)
+	go func() {
+		removed <- a.removeSandbox(user.Id)
+	}()
+
+	select {
+	case err = <-removed:
+		t.Fatalf("sandbox removed while in use: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, err = db.GetUserById(user.Id); err != nil {
+		t.Errorf("sandbox in use stopped working: %v", err)
+	}
+
+	release()
+	if err = <-removed; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	m "govulnapi/models"
+)
+
+const similarCoinsCount = 5
+
+// Similar coins computed for a single virtual date
+type similarCache struct {
+	mu    sync.Mutex
+	date  time.Time
+	coins map[string][]m.SimilarCoin
+}
+
+// Gets coins whose daily prices correlate the most with the given coin,
+// computed at most once per coin per virtual date
+func (a *Api) similarCoins(coinId string) ([]m.SimilarCoin, error) {
+	date := a.virtualDate()
+
+	a.similar.mu.Lock()
+	defer a.similar.mu.Unlock()
+
+	if !a.similar.date.Equal(date) {
+		a.similar.date = date
+		a.similar.coins = map[string][]m.SimilarCoin{}
+	}
+	if similar, ok := a.similar.coins[coinId]; ok {
+		return similar, nil
+	}
+
+	days := a.getOptions().SimilarCoinsDays
+	from := date.AddDate(0, 0, -days+1).Format(time.DateOnly)
+	prices, err := a.db.GetDailyPrices(from, date.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+
+	history := map[string]map[string]float64{}
+	for _, price := range prices {
+		if history[price.CoinId] == nil {
+			history[price.CoinId] = map[string]float64{}
+		}
+		history[price.CoinId][price.Date] = price.Price.Float64()
+	}
+
+	similar := []m.SimilarCoin{}
+	for id, other := range history {
+		if id == coinId {
+			continue
+		}
+		if r, ok := correlation(history[coinId], other); ok {
+			similar = append(similar, m.SimilarCoin{Id: id, Correlation: r})
+		}
+	}
+
+	sort.Slice(similar, func(i, j int) bool {
+		ri, rj := math.Abs(similar[i].Correlation), math.Abs(similar[j].Correlation)
+		if ri != rj {
+			return ri > rj
+		}
+		return similar[i].Id < similar[j].Id
+	})
+	if len(similar) > similarCoinsCount {
+		similar = similar[:similarCoinsCount]
+	}
+
+	a.similar.coins[coinId] = similar
+	return similar, nil
+}
+
+// Pearson correlation coefficient of prices on dates both coins have, not
+// defined for less than two dates or constant prices
+func correlation(x map[string]float64, y map[string]float64) (float64, bool) {
+	var n, sumX, sumY, sumXX, sumYY, sumXY float64
+
+	for date, px := range x {
+		py, ok := y[date]
+		if !ok {
+			continue
+		}
+		n++
+		sumX += px
+		sumY += py
+		sumXX += px * px
+		sumYY += py * py
+		sumXY += px * py
+	}
+
+	if n < 2 {
+		return 0, false
+	}
+
+	covariance := sumXY - sumX*sumY/n
+	varianceX := sumXX - sumX*sumX/n
+	varianceY := sumYY - sumY*sumY/n
+	if varianceX <= 0 || varianceY <= 0 {
+		return 0, false
+	}
+
+	return covariance / math.Sqrt(varianceX*varianceY), true
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	m "govulnapi/models"
+)
+
+// Prices of each coin by virtual day since 2014-01-01, the last one repeated
+// after that
+type seriesPrices map[string][]float64
+
+func (s seriesPrices) Prices(_ context.Context, date time.Time) ([]m.Coin, error) {
+	day := int(date.Sub(time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+
+	coins := []m.Coin{}
+	for id, prices := range s {
+		coins = append(coins, m.Coin{Id: id, Price: m.UsdFromFloat(prices[min(day, len(prices)-1)])})
+	}
+	return coins, nil
+}
+
+func TestCorrelation(t *testing.T) {
+	series := func(prices ...float64) map[string]float64 {
+		dated := map[string]float64{}
+		for i, price := range prices {
+			dated[time.Date(2014, time.January, i+1, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)] = price
+		}
+		return dated
+	}
+
+	for _, test := range []struct {
+		name   string
+		x, y   map[string]float64
+		want   float64
+		wantOk bool
+	}{
+		{"proportional", series(1, 2, 3, 4), series(10, 20, 30, 40), 1, true},
+		{"opposite", series(1, 2, 3, 4), series(8, 6, 4, 2), -1, true},
+		{"uncorrelated", series(1, 2, 3, 4), series(1, 3, 3, 1), 0, true},
+		{"only shared dates", series(1, 2, 3), series(2, 4), 1, true},
+		{"constant", series(1, 2, 3), series(5, 5, 5), 0, false},
+		{"a single shared date", series(1, 2, 3), series(7), 0, false},
+	} {
+		got, ok := correlation(test.x, test.y)
+		if ok != test.wantOk || math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: got %v, %v, want %v, %v", test.name, got, ok, test.want, test.wantOk)
+		}
+	}
+}
+
+func TestGetSimilarCoins(t *testing.T) {
+	bitcoin := []float64{10, 11, 13, 16, 20, 25, 31, 38, 46}
+	litecoin := make([]float64, len(bitcoin))
+	for i, price := range bitcoin {
+		litecoin[i] = 2*price + 1
+	}
+	a, _ := NewForTesting(WithPriceProvider(seriesPrices{
+		"bitcoin":  bitcoin,
+		"litecoin": litecoin,                             // 1
+		"dogecoin": {90, 88, 88, 84, 80, 76, 69, 62, 54}, // -0.9989
+		"namecoin": {12, 10, 14, 15, 22, 24, 33, 36, 47}, // 0.9922
+		"ripple":   {5, 1, 5, 1, 5, 1, 5, 1, 5},          // 0.0622
+	}))
+	t.Cleanup(a.Shutdown)
+	a.advanceDays(len(bitcoin) - 1)
+
+	w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/bitcoin/similar", nil), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("getting similar coins answered %d %s", w.Code, w.Body)
+	}
+	var similar []m.SimilarCoin
+	if err := json.NewDecoder(w.Body).Decode(&similar); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ranked by the strength of the correlation, whatever its sign
+	want := []m.SimilarCoin{
+		{Id: "litecoin", Correlation: 1},
+		{Id: "dogecoin", Correlation: -0.9988815653204345},
+		{Id: "namecoin", Correlation: 0.9922326211628145},
+		{Id: "ripple", Correlation: 0.062209075224179955},
+	}
+	if len(similar) != len(want) {
+		t.Fatalf("got %+v, want %+v", similar, want)
+	}
+	for i := range want {
+		if similar[i].Id != want[i].Id || math.Abs(similar[i].Correlation-want[i].Correlation) > 1e-9 {
+			t.Errorf("got %+v, want %+v", similar, want)
+			break
+		}
+	}
+
+	if w := serve(a, httptest.NewRequest(http.MethodGet, "/api/coins/nocoin/similar", nil), ""); w.Code != http.StatusNotFound {
+		t.Errorf("getting coins similar to a missing one answered %d, want 404", w.Code)
+	}
+}
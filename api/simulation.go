@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+
+	m "govulnapi/models"
+)
+
+// Daily volatility of the log returns of simulated prices by coin, roughly
+// the one of their historical prices
+var simulationVolatility = map[string]float64{
+	"bitcoin":  0.04,
+	"litecoin": 0.06,
+	"ripple":   0.07,
+	"namecoin": 0.08,
+	"dogecoin": 0.09,
+}
+
+// Volatility of coins missing from simulationVolatility
+const simulationDefaultVolatility = 0.06
+
+// Generates daily prices with a geometric random walk starting on StartDate.
+// Every day of every coin has its own draw, so the same seed and start date
+// always give the same prices, whichever dates are asked for in whichever
+// order.
+type SimulatedPrices struct {
+	Seed      int64
+	StartDate time.Time
+	// Prices on StartDate are taken from it when set, e.g. the virtual
+	// Coingecko server, instead of from TestPrices
+	Anchor PriceProvider
+	// Coins prices are generated for
+	Coins func() ([]m.Coin, error)
+
+	mu     sync.Mutex
+	starts map[string]m.Coin // Prices on StartDate, by coin id
+}
+
+func (s *SimulatedPrices) Prices(ctx context.Context, date time.Time) ([]m.Coin, error) {
+	tracked, err := s.Coins()
+	if err != nil {
+		return nil, err
+	}
+	starts, err := s.startPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	days := int(math.Round(date.Sub(s.StartDate).Hours() / 24))
+	coins := make([]m.Coin, 0, len(tracked))
+	for _, coin := range tracked {
+		start, ok := starts[coin.Id]
+		if !ok {
+			start = m.Coin{Id: coin.Id, Price: m.UsdFromFloat(1)}
+		}
+		coins = append(coins, s.walk(start, days))
+	}
+
+	return coins, nil
+}
+
+// Prices of the coins on StartDate, fetched from Anchor once
+func (s *SimulatedPrices) startPrices(ctx context.Context) (map[string]m.Coin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.starts != nil {
+		return s.starts, nil
+	}
+
+	starts := map[string]m.Coin{}
+	for _, coin := range TestPrices {
+		starts[coin.Id] = coin
+	}
+	if s.Anchor != nil {
+		anchored, err := s.Anchor.Prices(ctx, s.StartDate)
+		if err != nil {
+			return nil, err
+		}
+		for _, coin := range anchored {
+			starts[coin.Id] = coin
+		}
+	}
+
+	s.starts = starts
+	return starts, nil
+}
+
+// Price of the coin the given number of days after StartDate, or before it
+// when negative. Market cap follows the price, volume varies around the
+// starting one.
+func (s *SimulatedPrices) walk(start m.Coin, days int) m.Coin {
+	volatility, ok := simulationVolatility[start.Id]
+	if !ok {
+		volatility = simulationDefaultVolatility
+	}
+
+	// Drift of -volatility²/2 keeps the expected price at the starting one
+	var logReturn float64
+	for day := 1; day <= days; day++ {
+		logReturn += s.shock(start.Id, day)*volatility - volatility*volatility/2
+	}
+	for day := 0; day > days; day-- {
+		logReturn -= s.shock(start.Id, day)*volatility - volatility*volatility/2
+	}
+	ratio := math.Exp(logReturn)
+
+	return m.Coin{
+		Id:        start.Id,
+		Price:     m.UsdFromFloat(start.Price.Float64() * ratio),
+		MarketCap: start.MarketCap * ratio,
+		Volume24h: start.Volume24h * math.Exp(s.shock(start.Id+"/volume", days)*0.3),
+	}
+}
+
+// Standard normal draw of the coin on the day, from two uniform draws of a
+// splitmix64 generator seeded with Seed, the coin and the day
+func (s *SimulatedPrices) shock(coinId string, day int) float64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(coinId))
+	state := uint64(s.Seed) ^ hash.Sum64() ^ uint64(day)*0x9E3779B97F4A7C15
+
+	u1 := float64(splitmix64(&state)>>11+1) / (1 << 53) // In (0, 1] for the log
+	u2 := float64(splitmix64(&state)>>11) / (1 << 53)
+	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
+}
+
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9E3779B97F4A7C15
+	z := *state
+	z = (z ^ z>>30) * 0xBF58476D1CE4E5B9
+	z = (z ^ z>>27) * 0x94D049BB133111EB
+	return z ^ z>>31
+}
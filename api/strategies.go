@@ -0,0 +1,257 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	m "govulnapi/models"
+)
+
+const (
+	strategyFormatJSON = "json"
+	strategyFormatGob  = "gob"
+
+	strategyMaxBytes    = 64 << 10
+	strategyMaxDays     = 365
+	strategyMaxWindow   = 200
+	strategyMaxUsd      = 1e9
+	strategyNameMaxLen  = 64
+	scriptSignalTimeout = 2 * time.Second
+)
+
+var (
+	errStrategyFormat      = errors.New("Strategies saved as gob can't be run anymore, save them as JSON!")
+	errStrategyNoHistory   = errors.New("No price history in the backtested days!")
+	errUnknownSignalType   = errors.New("Signal type needs to be sma_cross!")
+	errStrategyUnknownCoin = errors.New("Coin with requested id doesn't exist!")
+)
+
+// Error of a signal deciding on a day, caused by the strategy rather than
+// the server
+type signalError struct {
+	error
+}
+
+// Decides on every backtested day whether to buy (> 0), sell (< 0) or hold,
+// given the day's price and the prices of the days before
+type signal interface {
+	decide(ctx context.Context, price float64, history []float64) (int, error)
+}
+
+// Strategy as it's backtested, and as it's encoded with gob, which decodes
+// Signal into whichever registered type the blob names
+type strategy struct {
+	Name        string
+	CoinId      string
+	Days        int
+	StartingUsd float64
+	Signal      signal
+}
+
+type smaCrossSignal struct {
+	Window       int
+	BuyBelowPct  float64
+	SellAbovePct float64
+}
+
+func (s smaCrossSignal) decide(_ context.Context, price float64, history []float64) (int, error) {
+	if len(history) < s.Window {
+		return 0, nil
+	}
+
+	average := sma(history[len(history)-s.Window:])
+	switch {
+	case price <= average*(1-s.BuyBelowPct/100):
+		return 1, nil
+	case price >= average*(1+s.SellAbovePct/100):
+		return -1, nil
+	}
+	return 0, nil
+}
+
+// Asks a shell command, which gets the day's price in PRICE and prints buy,
+// sell or hold. Only meant for strategies lab admins write by hand, but as
+// it's registered, gob decodes it from any blob.
+type scriptSignal struct {
+	Command string
+}
+
+func (s scriptSignal) decide(ctx context.Context, price float64, _ []float64) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, scriptSignalTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.Command)
+	cmd.Env = []string{fmt.Sprintf("PRICE=%v", price)}
+	output, _ := cmd.CombinedOutput()
+
+	switch answer := strings.TrimSpace(string(output)); answer {
+	case "buy":
+		return 1, nil
+	case "sell":
+		return -1, nil
+	case "hold":
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("Signal script answered %q, expected buy, sell or hold!", answer)
+	}
+}
+
+func init() {
+	gob.Register(smaCrossSignal{})
+	gob.Register(scriptSignal{})
+}
+
+// Decodes a strategy saved in the format, gob ones only while insecure
+// deserialization is enabled
+func (a *Api) decodeStrategy(format string, data []byte) (strategy, error) {
+	if format == strategyFormatGob {
+		// CWE-502: Deserialization of Untrusted Data
+		// The signal of a gob blob can be any registered type, including
+		// scriptSignal running a shell command of the blob's choosing
+		if !a.vulnerable(VulnStrategyDeserialization) {
+			return strategy{}, errStrategyFormat
+		}
+
+		var decoded strategy
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&decoded); err != nil {
+			return strategy{}, fmt.Errorf("Invalid gob strategy: %v", err)
+		}
+		if decoded.Signal == nil {
+			return strategy{}, errors.New("Signal is required!")
+		}
+		return decoded, a.validateStrategy(decoded)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var definition m.Strategy
+	if err := decoder.Decode(&definition); err != nil {
+		return strategy{}, fmt.Errorf("Invalid JSON strategy: %v", err)
+	}
+	return a.strategyFromDefinition(definition)
+}
+
+// Checks the strategy's fields, and turns its signal into the only type
+// JSON strategies can use
+func (a *Api) strategyFromDefinition(definition m.Strategy) (strategy, error) {
+	if definition.Signal.Type != "sma_cross" {
+		return strategy{}, errUnknownSignalType
+	}
+
+	decoded := strategy{
+		Name:        definition.Name,
+		CoinId:      definition.CoinId,
+		Days:        definition.Days,
+		StartingUsd: definition.StartingUsd,
+		Signal: smaCrossSignal{
+			Window:       definition.Signal.Window,
+			BuyBelowPct:  definition.Signal.BuyBelowPct,
+			SellAbovePct: definition.Signal.SellAbovePct,
+		},
+	}
+	return decoded, a.validateStrategy(decoded)
+}
+
+func (a *Api) validateStrategy(s strategy) error {
+	if s.Name == "" || len(s.Name) > strategyNameMaxLen {
+		return fmt.Errorf("Name needs to be between 1 and %d characters long!", strategyNameMaxLen)
+	}
+	if _, err := a.getCoin(s.CoinId); err != nil {
+		return errStrategyUnknownCoin
+	}
+	if s.Days < 1 || s.Days > strategyMaxDays {
+		return fmt.Errorf("Days needs to be between 1 and %d!", strategyMaxDays)
+	}
+	if !(s.StartingUsd > 0 && s.StartingUsd <= strategyMaxUsd) {
+		return fmt.Errorf("StartingUsd needs to be > 0 and <= %v!", strategyMaxUsd)
+	}
+
+	if sma, ok := s.Signal.(smaCrossSignal); ok {
+		if sma.Window < 2 || sma.Window > strategyMaxWindow {
+			return fmt.Errorf("Window needs to be between 2 and %d!", strategyMaxWindow)
+		}
+		if !(sma.BuyBelowPct >= 0 && sma.BuyBelowPct < 100) || !(sma.SellAbovePct >= 0 && sma.SellAbovePct <= 1000) {
+			return errors.New("BuyBelowPct needs to be >= 0 and < 100, SellAbovePct >= 0 and <= 1000!")
+		}
+	}
+
+	return nil
+}
+
+// Backtests the strategy over its days up to the current virtual date,
+// trading at each day's closing price
+func (a *Api) runStrategy(ctx context.Context, id int, s strategy) (m.StrategyRun, error) {
+	var (
+		to   = a.virtualDate()
+		from = to.AddDate(0, 0, -s.Days+1)
+		// Days before the first one feed the signal's history
+		lookback = from.AddDate(0, 0, -strategyMaxWindow)
+	)
+
+	prices, err := a.db.GetDailyPrices(lookback.Format(time.DateOnly), to.Format(time.DateOnly), s.CoinId)
+	if err != nil {
+		return m.StrategyRun{}, err
+	}
+
+	closes := make([]float64, len(prices))
+	start := len(prices)
+	for i, price := range prices {
+		closes[i] = price.Price.Float64()
+		if start == len(prices) && price.Date >= from.Format(time.DateOnly) {
+			start = i
+		}
+	}
+	if start == len(prices) {
+		return m.StrategyRun{}, errStrategyNoHistory
+	}
+
+	run := m.StrategyRun{
+		StrategyId:  id,
+		Name:        s.Name,
+		CoinId:      s.CoinId,
+		From:        prices[start].Date,
+		To:          prices[len(prices)-1].Date,
+		StartingUsd: s.StartingUsd,
+		Trades:      []m.StrategyTrade{},
+	}
+
+	usd, qty := s.StartingUsd, 0.0
+	for i := start; i < len(prices); i++ {
+		price := closes[i]
+		if price <= 0 {
+			continue
+		}
+
+		decision, err := s.Signal.decide(ctx, price, closes[:i])
+		if err != nil {
+			return m.StrategyRun{}, signalError{err}
+		}
+
+		switch {
+		case decision > 0 && usd > 0:
+			qty, usd = usd/price, 0
+			run.Trades = append(run.Trades, m.StrategyTrade{Date: prices[i].Date, IsBuy: true, Qty: qty, Price: price})
+		case decision < 0 && qty > 0:
+			run.Trades = append(run.Trades, m.StrategyTrade{Date: prices[i].Date, IsBuy: false, Qty: qty, Price: price})
+			usd, qty = qty*price, 0
+		}
+	}
+
+	last := closes[len(closes)-1]
+	run.EndingValue = usd + qty*last
+	run.Pnl = run.EndingValue - s.StartingUsd
+	run.PnlPercent = run.Pnl / s.StartingUsd * 100
+	if first := closes[start]; first > 0 {
+		run.BuyAndHoldPnl = s.StartingUsd/first*last - s.StartingUsd
+	}
+
+	return run, nil
+}
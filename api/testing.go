@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"govulnapi/api/database"
+	m "govulnapi/models"
+)
+
+// Prices used by NewForTesting, matching the coins seeded by migrations
+var TestPrices = StaticPrices{
+	{Id: "bitcoin", Price: m.UsdFromFloat(800), MarketCap: 9.7e9, Volume24h: 2.3e7},
+	{Id: "dogecoin", Price: m.UsdFromFloat(0.0005), MarketCap: 4.4e7, Volume24h: 5.6e5},
+	{Id: "litecoin", Price: m.UsdFromFloat(25), MarketCap: 6e8, Volume24h: 1.1e7},
+	{Id: "namecoin", Price: m.UsdFromFloat(5), MarketCap: 3.9e7, Volume24h: 1.7e5},
+	{Id: "ripple", Price: m.UsdFromFloat(0.03), MarketCap: 2.1e8, Volume24h: 1.2e5},
+}
+
+// Creates an api with its own in-memory database, TestPrices and a fake
+// clock starting at the first virtual day, ready to serve requests through
+// Handler. Advancing the clock by DayDuration moves to the next virtual day
+// in the background. Options override the test setup.
+func NewForTesting(opts ...Option) (*Api, *FakeClock) {
+	clock := NewFakeClock(time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	opts = append([]Option{
+		WithDatabase(database.DriverSQLite, database.MemoryDSN),
+		WithPriceProvider(TestPrices),
+		WithClock(clock),
+	}, opts...)
+
+	a := New("", "", opts...)
+	a.setupRoutes()
+	a.daysMu.Lock()
+	a.refreshCoins()
+	a.daysMu.Unlock()
+	a.goBackground(func() { a.paceDays(clock.After(a.getOptions().DayDuration)) })
+
+	return a, clock
+}
+
+func (a *Api) Handler() http.Handler {
+	return a.router
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// How long generated self-signed certificates are valid for
+const selfSignedValidity = 365 * 24 * time.Hour
+
+func (o Options) tlsEnabled() bool {
+	return o.TLSCertFile != "" || o.TLSSelfSigned
+}
+
+// TLS 1.2 and up with forward secret AEAD cipher suites only, TLS 1.3 suites
+// aren't configurable and are all fine
+func newTLSConfig(options Options) (*tls.Config, error) {
+	var (
+		certificate tls.Certificate
+		err         error
+	)
+	if options.TLSCertFile != "" {
+		certificate, err = tls.LoadX509KeyPair(options.TLSCertFile, options.TLSKeyFile)
+	} else {
+		certificate, err = generateCertificate(options.TLSListenAddress)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+	}, nil
+}
+
+// Self-signed certificate for localhost and the host of the address, if
+// any. Its fingerprint is logged so clients can check they got it.
+func generateCertificate(address string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "govulnapi"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(selfSignedValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if host, _, err := net.SplitHostPort(address); err == nil && host != "" && host != "localhost" {
+		if ip := net.ParseIP(host); ip == nil {
+			template.DNSNames = append(template.DNSNames, host)
+		} else if !ip.IsUnspecified() {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	fingerprint := sha256.Sum256(der)
+	hex := make([]string, len(fingerprint))
+	for i, b := range fingerprint {
+		hex[i] = fmt.Sprintf("%02X", b)
+	}
+	log.Println("Generated self-signed TLS certificate, SHA-256 fingerprint:", strings.Join(hex, ":"))
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// Redirects plain HTTP requests to the same url on the HTTPS port of the
+// address
+func redirectToHTTPS(tlsAddress string, next http.Handler) http.Handler {
+	_, port, _ := net.SplitHostPort(tlsAddress)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if port != "443" {
+			host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
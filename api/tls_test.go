@@ -0,0 +1,65 @@
+package api
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"govulnapi/api/database"
+)
+
+func TestHTTP2Negotiation(t *testing.T) {
+	for _, test := range []struct {
+		enabled             bool
+		wantProto, wantALPN string
+	}{
+		{true, "HTTP/2.0", "h2"},
+		{false, "HTTP/1.1", "http/1.1"},
+	} {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := New(listener.Addr().String(), "",
+			WithDatabase(database.DriverSQLite, database.MemoryDSN),
+			WithPriceProvider(TestPrices),
+			WithClock(NewFakeClock(time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC))),
+			WithSelfSignedTLS(true),
+			WithHTTP2(test.enabled),
+		)
+		served := make(chan error, 1)
+		go func() { served <- a.Serve(listener) }()
+		t.Cleanup(a.Shutdown)
+
+		// Clients offer h2 and HTTP/1.1, the server picks
+		client := &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+				ForceAttemptHTTP2: true,
+			},
+		}
+		var response *http.Response
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			select {
+			case err := <-served:
+				t.Fatalf("server stopped: %v", err)
+			default:
+			}
+			if response, err = client.Get("https://" + listener.Addr().String() + "/api/readyz"); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+
+		if response.Proto != test.wantProto || response.TLS.NegotiatedProtocol != test.wantALPN {
+			t.Errorf("HTTP/2 enabled %v: got %s negotiated as %s, want %s as %s",
+				test.enabled, response.Proto, response.TLS.NegotiatedProtocol, test.wantProto, test.wantALPN)
+		}
+	}
+}
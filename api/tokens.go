@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// CWE-1391: Use of Weak Credentials
+// Found in every password list, tokens are signed with it while
+// VulnJWTWeakSecret is enabled
+const weakJwtSecret = "secret"
+
+var weakJwtAuth = jwtauth.New(string(jwa.HS256), []byte(weakJwtSecret), nil)
+
+// Signs the tokens issued on login
+func (s *Api) tokenAuth() *jwtauth.JWTAuth {
+	if s.vulnerable(VulnJWTWeakSecret) {
+		return weakJwtAuth
+	}
+	return s.jwtAuth
+}
+
+// Verifies the token of a request like jwtauth.Verifier, leaving it and the
+// error in the context for authenticator
+func (s *Api) verifier(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := s.verifyRequest(r)
+		next.ServeHTTP(w, r.WithContext(jwtauth.NewContext(r.Context(), token, err)))
+	})
+}
+
+// Verifies the token sent in the Authorization header, or else in the jwt
+// cookie
+func (s *Api) verifyRequest(r *http.Request) (jwt.Token, error) {
+	tokenString := jwtauth.TokenFromHeader(r)
+	if tokenString == "" {
+		tokenString = jwtauth.TokenFromCookie(r)
+	}
+	if tokenString == "" {
+		return nil, jwtauth.ErrNoTokenFound
+	}
+
+	return s.verifyToken(tokenString)
+}
+
+func (s *Api) verifyToken(tokenString string) (jwt.Token, error) {
+	alg, err := tokenAlgorithm(tokenString)
+	if err != nil {
+		return nil, jwtauth.ErrUnauthorized
+	}
+
+	if strings.EqualFold(alg, string(jwa.NoSignature)) {
+		// CWE-347: Improper Verification of Cryptographic Signature
+		// The header decides whether the token needs a signature at all
+		if !s.vulnerable(VulnJWTAlgNone) {
+			return nil, jwtauth.ErrAlgoInvalid
+		}
+
+		token, err := jwt.ParseInsecure([]byte(tokenString))
+		if err != nil {
+			return nil, jwtauth.ErrUnauthorized
+		}
+		if err = jwt.Validate(token); err != nil {
+			return token, jwtauth.ErrorReason(err)
+		}
+		return token, nil
+	}
+
+	// Only HS256 is accepted, whatever else the header names
+	if alg != string(jwa.HS256) {
+		return nil, jwtauth.ErrAlgoInvalid
+	}
+
+	token, err := jwtauth.VerifyToken(s.jwtAuth, tokenString)
+	if err == jwtauth.ErrUnauthorized && s.vulnerable(VulnJWTWeakSecret) {
+		return jwtauth.VerifyToken(weakJwtAuth, tokenString)
+	}
+	return token, err
+}
+
+// Reads the alg of a compact token's header without verifying it
+func tokenAlgorithm(tokenString string) (string, error) {
+	encoded, _, _ := strings.Cut(tokenString, ".")
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err = json.Unmarshal(decoded, &header); err != nil {
+		return "", err
+	}
+	return header.Alg, nil
+}
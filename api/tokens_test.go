@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+// Forges tokens of the admin, with alg none and signed with weak secrets
+func forgeAdminTokens(t *testing.T, a *Api) map[string]string {
+	t.Helper()
+
+	adminToken := login(t, a, "admin@govulnapi.com", "admin123")
+	decoded, err := a.tokenAuth().Decode(adminToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]interface{}{"user_id": decoded.PrivateClaims()["user_id"], "role": "admin"}
+	encoded, _ := json.Marshal(claims)
+	payload := base64.RawURLEncoding.EncodeToString(encoded)
+	unsigned := func(alg string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"`+alg+`","typ":"JWT"}`)) + "." + payload + "."
+	}
+	signed := func(alg string, secret string) string {
+		_, token, err := jwtauth.New(alg, []byte(secret), nil).Encode(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	return map[string]string{
+		"alg none":          unsigned("none"),
+		"alg None":          unsigned("None"),
+		"alg NONE":          unsigned("NONE"),
+		"weak secret":       signed("HS256", weakJwtSecret),
+		"weak secret HS512": signed("HS512", weakJwtSecret),
+		"other secret":      signed("HS256", "password"),
+	}
+}
+
+func TestForgedTokens(t *testing.T) {
+	for _, test := range []struct {
+		algNone    bool
+		weakSecret bool
+		accepted   []string
+	}{
+		{false, false, nil},
+		{true, false, []string{"alg none", "alg None", "alg NONE"}},
+		{false, true, []string{"weak secret"}},
+		{true, true, []string{"alg none", "alg None", "alg NONE", "weak secret"}},
+	} {
+		a, _ := NewForTesting(WithVulnerabilities(map[string]bool{VulnJWTAlgNone: test.algNone, VulnJWTWeakSecret: test.weakSecret}))
+		t.Cleanup(a.Shutdown)
+
+		accepted := map[string]bool{}
+		for _, name := range test.accepted {
+			accepted[name] = true
+		}
+		for name, token := range forgeAdminTokens(t, a) {
+			w := serve(a, httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil), token)
+			if accepted[name] && w.Code != http.StatusOK {
+				t.Errorf("%s token answered %d %s with alg none %v and weak secret %v, want it accepted", name, w.Code, w.Body, test.algNone, test.weakSecret)
+			}
+			if !accepted[name] && w.Code != http.StatusUnauthorized {
+				t.Errorf("%s token answered %d %s with alg none %v and weak secret %v, want 401", name, w.Code, w.Body, test.algNone, test.weakSecret)
+			}
+		}
+	}
+}
+
+func TestForgedTokensRejectedByDefault(t *testing.T) {
+	a, _ := NewForTesting()
+	t.Cleanup(a.Shutdown)
+
+	for name, token := range forgeAdminTokens(t, a) {
+		if w := serve(a, httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil), token); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s token answered %d, want 401 while both are off by default", name, w.Code)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"log"
+
+	m "govulnapi/models"
+)
+
+// Permanently removes users deleted longer than the retention period ago
+func (a *Api) purgeDeletedUsers() {
+	days := a.getOptions().DeletedUserRetentionDays
+	before := a.virtualDate().AddDate(0, 0, -days)
+
+	purged, err := a.db.PurgeDeletedUsers(before)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if purged > 0 {
+		log.Printf("Purged %d deleted users\n", purged)
+	}
+}
+
+// Reports whether user may see data belonging to any of the owners, which
+// admins may for every user
+func canAccessUser(user m.User, ownerIds ...int) bool {
+	if user.Role == "admin" {
+		return true
+	}
+	for _, ownerId := range ownerIds {
+		if ownerId == user.Id {
+			return true
+		}
+	}
+	return false
+}
+
+// Leaves out the sequential ids of transactions unless they're used to look
+// them up, so only their random ids can be requested
+func (a *Api) transactionsView(transactions []m.Transaction) []m.Transaction {
+	if a.vulnerable(VulnTransactionIDOR) {
+		return transactions
+	}
+
+	view := make([]m.Transaction, len(transactions))
+	for i, transaction := range transactions {
+		transaction.Id = 0
+		view[i] = transaction
+	}
+	return view
+}
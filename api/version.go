@@ -0,0 +1,9 @@
+package api
+
+// Build information, set when building with e.g.
+// -ldflags "-X govulnapi/api.Version=1.2.3 -X govulnapi/api.Commit=abc123 -X govulnapi/api.BuiltAt=2024-01-01T00:00:00Z"
+var (
+	Version = "dev"
+	Commit  = ""
+	BuiltAt = ""
+)
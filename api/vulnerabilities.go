@@ -0,0 +1,337 @@
+package api
+
+import (
+	"log"
+
+	m "govulnapi/models"
+)
+
+// Vulnerabilities that can be toggled individually, the ones left out of
+// Options.Vulnerabilities follow Options.Difficulty when it's set, and
+// VulnerableMode unless they're opt-in otherwise
+const (
+	VulnSQLInjection                = "sql_injection"
+	VulnNegativeTransfers           = "negative_transfers"
+	VulnOrderBookUsers              = "orderbook_users"
+	VulnWebhookSSRF                 = "webhook_ssrf"
+	VulnNewsPreviewSSRF             = "news_preview_ssrf"
+	VulnReportPathTraversal         = "report_path_traversal"
+	VulnTradeImportXXE              = "trade_import_xxe"
+	VulnDiagnosticsCommandInjection = "diagnostics_command_injection"
+	VulnTransactionIDOR             = "transaction_idor"
+	VulnProfileMassAssignment       = "profile_mass_assignment"
+	VulnStrategyDeserialization     = "strategy_deserialization"
+	VulnLoginOpenRedirect           = "login_open_redirect"
+	VulnCommentXSS                  = "comment_xss"
+	VulnOrderRaceCondition          = "order_race_condition"
+	VulnJWTAlgNone                  = "jwt_alg_none"
+	VulnJWTWeakSecret               = "jwt_weak_secret"
+	VulnAvatarUpload                = "avatar_upload"
+	VulnDebugResponses              = "debug_responses"
+	VulnLoginBruteForce             = "login_brute_force"
+)
+
+// Catalog of the vulnerabilities that can be toggled, handlers take the
+// vulnerable code path of the enabled ones. Enabled is filled in when listing
+// them.
+var Vulnerabilities = []m.Vulnerability{
+	{
+		Id:          VulnSQLInjection,
+		Cwe:         89,
+		Description: "User input is formatted into SQL queries instead of being bound as parameters",
+		Routes: []string{
+			"GET /api/register", "GET /api/login", "PUT /api/user/email", "PUT /api/user/password",
+			"POST /api/orders", "POST /api/transactions",
+		},
+		Hints: []string{
+			"Log in with an email containing a single quote and look at the error.",
+			"The password is hashed before it's put into the query, the email isn't.",
+			"A UNION SELECT needs as many columns as the user table has, and the database has tables no endpoint reads.",
+		},
+	},
+	{
+		Id:          VulnNegativeTransfers,
+		Cwe:         839,
+		Description: "Negative transfer amounts are accepted, taking usd from the receiver",
+		Routes:      []string{"POST /api/transfer"},
+		Hints: []string{
+			"Transfers check that you have enough usd, but what about the amount itself?",
+			"Sending a negative amount moves usd in the other direction.",
+		},
+	},
+	{
+		Id:          VulnOrderBookUsers,
+		Cwe:         200,
+		Description: "The order book lists the emails of users with open orders when asked to",
+		Routes:      []string{"GET /api/coins/{id}/orderbook"},
+		Hints: []string{
+			"Read the order book endpoint's parameters in the API documentation.",
+			"Some query parameters aren't meant for regular users.",
+		},
+	},
+	{
+		Id:          VulnWebhookSSRF,
+		Cwe:         918,
+		Description: "Webhooks can point at internal addresses, which the API then sends requests to",
+		Routes:      []string{"POST /api/webhooks"},
+		Hints: []string{
+			"The API sends requests to webhook urls itself, from inside the lab network.",
+			"Other servers run next to the API, e.g. the virtual Coingecko on port 8082.",
+		},
+	},
+	{
+		Id:          VulnNewsPreviewSSRF,
+		Cwe:         918,
+		Description: "News previews fetch any url, including internal addresses and redirects to them, and show the response",
+		Routes:      []string{"POST /api/coins/{id}/news-preview"},
+		Hints: []string{
+			"The preview is fetched by the API server, not by your browser.",
+			"Pages that aren't HTML are shown as they are, try a url only the server can reach.",
+			"If internal addresses are blocked, a public url redirecting to one may not be.",
+		},
+	},
+	{
+		Id:          VulnReportPathTraversal,
+		Cwe:         22,
+		Description: "Report file names are appended to the reports directory as they are, so ../ reads files outside of it",
+		Routes:      []string{"GET /api/reports/{filename}"},
+		Hints: []string{
+			"Report downloads are files read from the server's disk.",
+			"The file name can't contain a slash, unless it's url encoded.",
+			"Try ..%2f..%2f followed by the path of a file every Linux server has.",
+		},
+	},
+	{
+		Id:          VulnTradeImportXXE,
+		Cwe:         611,
+		Description: "XML trade imports expand entities declared in the document, including external ones read from files and urls",
+		Routes:      []string{"POST /api/transactions/import"},
+		Hints: []string{
+			"Trades can be imported as XML too, and XML documents can declare entities.",
+			"Errors of rows that fail to import repeat the value that was wrong.",
+			"An entity declared with SYSTEM \"file:///etc/passwd\" is replaced by the contents of that file.",
+		},
+	},
+	{
+		Id:          VulnDiagnosticsCommandInjection,
+		Cwe:         78,
+		Description: "The admin ping runs a shell command with the host appended to it",
+		Routes:      []string{"POST /api/admin/diagnostics/ping"},
+		Hints: []string{
+			"The ping diagnostics show the output of a command run on the server.",
+			"A shell treats ; and $() in the host as the start of another command.",
+		},
+	},
+	{
+		Id:          VulnTransactionIDOR,
+		Cwe:         639,
+		Description: "Transaction and portfolio details are returned to any logged in user, not only to their owner, and transactions have sequential ids",
+		Routes:      []string{"GET /api/transactions/{id}", "GET /api/users/{id}/portfolio"},
+		Hints: []string{
+			"Look at the ids of your own transactions and of your user.",
+			"Nothing stops you from asking for the next or previous id.",
+		},
+	},
+	{
+		Id:          VulnProfileMassAssignment,
+		Cwe:         915,
+		Description: "Profile updates are decoded over the whole user, so fields like the role and usd balance can be set too",
+		Routes:      []string{"PATCH /api/me"},
+		Hints: []string{
+			"The profile update only documents a few fields, but what is it decoded into?",
+			"Field names of the user show up in other responses, e.g. the portfolio.",
+			"Log in again after changing your role, tokens keep the role they were issued with.",
+		},
+	},
+	{
+		Id:          VulnStrategyDeserialization,
+		Cwe:         502,
+		Description: "Strategies can be saved as gob blobs, whose signal is decoded into any registered type, including one running shell commands",
+		Routes:      []string{"POST /api/strategies", "GET /api/strategies/{id}/run"},
+		Hints: []string{
+			"Strategies aren't only accepted as JSON, try the Content-Type application/x-gob.",
+			"gob decodes an interface field into whichever registered type the blob names, look for gob.Register in the source.",
+			"Errors of a run repeat what the signal answered.",
+		},
+	},
+	{
+		Id:          VulnLoginOpenRedirect,
+		Cwe:         601,
+		Description: "Login redirects to any url given in redirect_to once the cookie is set",
+		Routes:      []string{"GET /api/login"},
+		Hints: []string{
+			"Read the login endpoint's parameters in the API documentation.",
+			"A login link pointing at the lab can send users anywhere afterwards.",
+			"When only relative paths are allowed, remember that //host and /\\host aren't relative to the site.",
+		},
+	},
+	{
+		Id:          VulnCommentXSS,
+		Cwe:         79,
+		Description: "Comments are stored as they are and the coin page puts them into its HTML without escaping",
+		Routes:      []string{"POST /api/coins/{id}/comments", "PUT /api/comments/{id}", "GET /api/coins/{id}/page"},
+		Hints: []string{
+			"Everyone opening a coin's page sees its comments, not only you.",
+			"Comment with some HTML and look at the page source.",
+			"A script tag in a comment runs in the browser of every visitor, including admins.",
+		},
+	},
+	{
+		Id:          VulnOrderRaceCondition,
+		Cwe:         362,
+		Description: "Orders check the balance, and write it back a moment later without a transaction, so concurrent orders spend the same usd or coin",
+		Routes:      []string{"POST /api/orders"},
+		Hints: []string{
+			"Orders take a while, what happens when two of them are placed at the same time?",
+			"Send many buy orders in parallel, each spending most of your usd.",
+			"GET /api/admin/consistency shows whose balances don't match their orders anymore.",
+		},
+	},
+	{
+		Id:          VulnJWTAlgNone,
+		Cwe:         347,
+		Description: "Tokens whose header declares the algorithm none are accepted without a signature",
+		Routes:      []string{"Every route requiring a token"},
+		Hints: []string{
+			"Decode your token, its header names the algorithm it's signed with.",
+			"The none algorithm means the token isn't signed at all.",
+			"Change the claims, set alg to none and drop the signature, but keep the trailing dot.",
+		},
+		OptIn: true,
+	},
+	{
+		Id:          VulnJWTWeakSecret,
+		Cwe:         1391,
+		Description: "Tokens are signed with a dictionary word as HS256 secret, so it can be cracked offline and used to sign any claims",
+		Routes:      []string{"GET /api/login", "Every route requiring a token"},
+		Hints: []string{
+			"A HS256 token can be checked against candidate secrets without asking the API.",
+			"Tools like hashcat (mode 16500) or john try a word list against a token.",
+			"Once the secret is known, sign a token with the claims of another user.",
+		},
+		OptIn: true,
+	},
+	{
+		Id:          VulnAvatarUpload,
+		Cwe:         434,
+		Description: "Avatars are stored under the file name and served with the content type the client sent, so HTML and SVG files run their scripts when opened",
+		Routes:      []string{"POST /api/me/avatar", "GET /api/avatars/{user}"},
+		Hints: []string{
+			"Is an avatar checked to be an image at all?",
+			"The avatar is served with the Content-Type it was uploaded with.",
+			"Upload an SVG or HTML file with a script and send an admin the link to your avatar.",
+		},
+	},
+	{
+		Id:          VulnDebugResponses,
+		Cwe:         209,
+		Description: "Internal errors are answered with their error chain, failing SQL statement and stack trace, and /debug/vars shows the configuration including the JWT secret",
+		Routes:      []string{"Every route failing with 500", "GET /api/debug/vars"},
+		Hints: []string{
+			"What does the API answer when something breaks on its side?",
+			"The Details of an internal error go deeper than its message.",
+			"Go services often serve their runtime state under /debug/vars, and it may hold secrets.",
+		},
+	},
+	{
+		Id:          VulnLoginBruteForce,
+		Cwe:         307,
+		Description: "Logins can be attempted any number of times, so passwords of known emails can be guessed from a word list",
+		Routes:      []string{"GET /api/login"},
+		Hints: []string{
+			"Does the API ever stop answering wrong passwords?",
+			"The order book may tell you which emails exist.",
+			"Students pick passwords like their user name.",
+		},
+	},
+}
+
+func isVulnerability(name string) bool {
+	_, ok := findVulnerability(name)
+	return ok
+}
+
+func findVulnerability(name string) (m.Vulnerability, bool) {
+	for _, vulnerability := range Vulnerabilities {
+		if vulnerability.Id == name {
+			return vulnerability, true
+		}
+	}
+	return m.Vulnerability{}, false
+}
+
+func (o Options) vulnerable(name string) bool {
+	if enabled, ok := o.Vulnerabilities[name]; ok {
+		return enabled
+	}
+	if o.Difficulty != "" {
+		return difficultyEnables(o.Difficulty, name)
+	}
+	if vulnerability, ok := findVulnerability(name); ok && vulnerability.OptIn {
+		return false
+	}
+	return o.VulnerableMode
+}
+
+func (a *Api) vulnerable(name string) bool {
+	return a.getOptions().vulnerable(name)
+}
+
+// Lists the catalog along with which vulnerabilities are enabled
+func (a *Api) vulnerabilityCatalog() []m.Vulnerability {
+	options := a.getOptions()
+
+	catalog := make([]m.Vulnerability, len(Vulnerabilities))
+	for i, vulnerability := range Vulnerabilities {
+		vulnerability.Enabled = options.vulnerable(vulnerability.Id)
+		catalog[i] = vulnerability
+	}
+
+	return catalog
+}
+
+// Enables or disables a vulnerability, keeping the setting in the database
+// so it survives restarts
+func (a *Api) setVulnerability(name string, enabled bool) error {
+	if err := a.db.SetVulnerabilitySetting(name, enabled); err != nil {
+		return err
+	}
+
+	a.updateOptions(func(o *Options) {
+		o.Vulnerabilities = mergeVulnerabilities(o.Vulnerabilities, map[string]bool{name: enabled})
+	})
+
+	return nil
+}
+
+// Applies vulnerabilities toggled at runtime before the database was opened,
+// or replaced by a snapshot, over the configured ones
+func (a *Api) loadVulnerabilitySettings() {
+	settings, err := a.db.GetVulnerabilitySettings()
+	if err != nil {
+		log.Println("Unable to load vulnerability settings:", err)
+		return
+	}
+	if len(settings) == 0 {
+		return
+	}
+
+	a.updateOptions(func(o *Options) {
+		o.Vulnerabilities = mergeVulnerabilities(o.Vulnerabilities, settings)
+	})
+}
+
+// Copies vulnerabilities with changes applied, as options need to be replaced
+// instead of modified
+func mergeVulnerabilities(vulnerabilities map[string]bool, changes map[string]bool) map[string]bool {
+	merged := map[string]bool{}
+	for name, enabled := range vulnerabilities {
+		merged[name] = enabled
+	}
+	for name, enabled := range changes {
+		if isVulnerability(name) {
+			merged[name] = enabled
+		}
+	}
+	return merged
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	m "govulnapi/models"
+)
+
+const (
+	webhookTradeExecuted  = "trade.executed"
+	webhookAlertTriggered = "alert.triggered"
+
+	webhookBackoff   = 2 * time.Second
+	webhookBatchSize = 20
+	webhookTimeout   = 5 * time.Second
+)
+
+// Webhook events sent along with notifications of the given type
+var webhookEvents = map[string]string{
+	notificationOrderFilled: webhookTradeExecuted,
+	notificationPriceAlert:  webhookAlertTriggered,
+}
+
+type webhookPayload struct {
+	Event string
+	Data  json.RawMessage
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Ranges that aren't publicly routable, IPv4 ones also match their
+// IPv4-mapped IPv6 form
+var internalNetworks = parseCIDRs(
+	"0.0.0.0/8",       // This network, 0.0.0.0 reaches localhost
+	"10.0.0.0/8",      // Private
+	"100.64.0.0/10",   // Carrier-grade NAT
+	"127.0.0.0/8",     // Loopback
+	"169.254.0.0/16",  // Link local, e.g. cloud metadata endpoints
+	"172.16.0.0/12",   // Private
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // Documentation
+	"192.168.0.0/16",  // Private
+	"198.18.0.0/15",   // Benchmarking
+	"198.51.100.0/24", // Documentation
+	"203.0.113.0/24",  // Documentation
+	"224.0.0.0/4",     // Multicast
+	"240.0.0.0/4",     // Reserved, including broadcast
+	"::/128",          // Unspecified
+	"::1/128",         // Loopback
+	"64:ff9b::/96",    // NAT64, embedding IPv4 addresses
+	"64:ff9b:1::/48",  // Local NAT64
+	"100::/64",        // Discard
+	"2001:db8::/32",   // Documentation
+	"fc00::/7",        // Unique local
+	"fe80::/10",       // Link local
+	"ff00::/8",        // Multicast
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+func isInternalIP(ip net.IP) bool {
+	for _, network := range internalNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Api) validateWebhookUrl(rawUrl string) error {
+	// CWE-918: Server-Side Request Forgery (SSRF)
+	// Webhooks can target services on the internal network, e.g. the
+	// virtual coingecko or cloud metadata endpoints
+	return a.validateUrl(rawUrl, VulnWebhookSSRF)
+}
+
+// Checks that rawUrl is an absolute http(s) url, and that its host doesn't
+// resolve to an internal address unless the vulnerability is enabled
+func (a *Api) validateUrl(rawUrl string, vulnerability string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("Url needs to be an absolute http(s) url!")
+	}
+
+	if a.vulnerable(vulnerability) {
+		return nil
+	}
+
+	ips, err := net.LookupIP(u.Hostname())
+	if err != nil {
+		return errors.New("Unable to resolve url host!")
+	}
+	for _, ip := range ips {
+		if isInternalIP(ip) {
+			return errors.New("Url can't point to an internal address!")
+		}
+	}
+
+	return nil
+}
+
+func (a *Api) newWebhookClient() *http.Client {
+	client := a.newGuardedClient(VulnWebhookSSRF, webhookTimeout)
+	// Redirects could lead to internal addresses skipping url validation
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return client
+}
+
+// Client refusing to connect to internal addresses unless the vulnerability
+// is enabled, which also covers hosts re-resolving to them after validation
+func (a *Api) newGuardedClient(vulnerability string, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, c syscall.RawConn) error {
+			if a.vulnerable(vulnerability) {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isInternalIP(ip) {
+				return fmt.Errorf("connection to internal address %s blocked", host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network string, address string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, address)
+			},
+		},
+	}
+}
+
+// Queues deliveries of the event to the user webhooks
+func (a *Api) enqueueWebhooks(userId int, event string, data []byte) error {
+	body, err := json.Marshal(webhookPayload{Event: event, Data: data})
+	if err != nil {
+		return err
+	}
+
+	return a.db.EnqueueWebhookDeliveries(userId, event, string(body))
+}
+
+// Delivers due webhooks until shutdown, which lets the batch being delivered
+// finish
+func (a *Api) dispatchWebhooks() {
+	log.Println("Starting webhook dispatcher ...")
+	client := a.newWebhookClient()
+
+	for a.ctx.Err() == nil {
+		deliveries, err := a.db.GetDueWebhookDeliveries(webhookBatchSize)
+		if err != nil {
+			log.Println(err)
+		}
+
+		for _, delivery := range deliveries {
+			a.deliverWebhook(client, delivery)
+		}
+
+		if len(deliveries) < webhookBatchSize {
+			select {
+			case <-a.ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+func (a *Api) deliverWebhook(client *http.Client, delivery m.WebhookDelivery) {
+	err := postWebhook(client, delivery)
+	if err == nil {
+		err = a.db.CompleteWebhookDelivery(delivery)
+	} else {
+		var nextAttempt *time.Time
+		if delivery.Attempts+1 < a.getOptions().WebhookMaxAttempts {
+			next := time.Now().Add(webhookBackoff << delivery.Attempts)
+			nextAttempt = &next
+		}
+		err = a.db.FailWebhookDelivery(delivery, err.Error(), nextAttempt)
+	}
+
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func postWebhook(client *http.Client, delivery m.WebhookDelivery) error {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequest(http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Signature", signWebhook(delivery.Secret, body))
+
+	r, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", r.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsInternalIP(t *testing.T) {
+	for _, test := range []struct {
+		ip       string
+		internal bool
+	}{
+		{"0.0.0.0", true},
+		{"0.1.2.3", true},
+		{"10.0.0.1", true},
+		{"100.64.0.1", true},
+		{"100.127.255.254", true},
+		{"100.128.0.1", false},
+		{"127.0.0.1", true},
+		{"127.255.255.254", true},
+		{"169.254.169.254", true},
+		{"172.16.0.1", true},
+		{"172.32.0.1", false},
+		{"192.0.0.8", true},
+		{"192.168.1.1", true},
+		{"198.18.0.1", true},
+		{"224.0.0.251", true},
+		{"255.255.255.255", true},
+		{"::", true},
+		{"::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:169.254.169.254", true},
+		{"::ffff:0.0.0.0", true},
+		{"64:ff9b::a9fe:a9fe", true},
+		{"fc00::1", true},
+		{"fd12:3456::1", true},
+		{"fe80::1", true},
+		{"ff02::1", true},
+		{"1.1.1.1", false},
+		{"8.8.8.8", false},
+		{"100.63.255.255", false},
+		{"::ffff:8.8.8.8", false},
+		{"2606:4700:4700::1111", false},
+	} {
+		if internal := isInternalIP(net.ParseIP(test.ip)); internal != test.internal {
+			t.Errorf("isInternalIP(%s) = %v, want %v", test.ip, internal, test.internal)
+		}
+	}
+}
+
+func TestValidateWebhookUrl(t *testing.T) {
+	a, _ := NewForTesting(WithVulnerabilities(map[string]bool{VulnWebhookSSRF: false}))
+	t.Cleanup(a.Shutdown)
+
+	for _, rawUrl := range []string{
+		"http://0.0.0.0:8081/api/coins",
+		"http://100.100.100.200/latest/meta-data",
+		"http://[::ffff:127.0.0.1]:8081/",
+		"ftp://8.8.8.8/",
+		"/relative",
+	} {
+		if err := a.validateWebhookUrl(rawUrl); err == nil {
+			t.Errorf("%s was accepted", rawUrl)
+		}
+	}
+	if err := a.validateWebhookUrl("https://8.8.8.8/hook"); err != nil {
+		t.Errorf("public url was rejected: %v", err)
+	}
+}
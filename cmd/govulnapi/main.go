@@ -1,13 +1,20 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"govulnapi/api"
+	"govulnapi/api/database"
 	"govulnapi/coingecko"
+	"govulnapi/config"
 	"govulnapi/web"
 	"io"
 	"log"
 	"os"
 	"os/signal"
+	"syscall"
+
+	"gopkg.in/yaml.v3"
 )
 
 //	@title			  Govulnapi
@@ -27,8 +34,17 @@ import (
 //	@description				        Type "BEARER" followed by a space and the token.
 
 func main() {
+	configPath := flag.String("config", "", "Path to YAML configuration file")
+	migrateOnly := flag.Bool("migrate-only", false, "Apply database migrations and exit")
+	backupPath := flag.String("backup", "", "Write a database snapshot to the given file and exit")
+	restorePath := flag.String("restore", "", "Restore the database from the given snapshot file and exit")
+	printConfig := flag.Bool("print-config", false, "Print the effective configuration with secrets redacted and exit")
+	optionFlags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
 	shutdown := make(chan os.Signal, 1)
-	signal.Notify(shutdown, os.Interrupt)
+	// Docker stops containers with SIGTERM
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	// Log to both stdout and file
 	// CWE-276: Improper Access Control
@@ -39,18 +55,80 @@ func main() {
 	mw := io.MultiWriter(os.Stdout, logFile)
 	log.SetOutput(mw)
 
+	// Load configuration, flags taking precedence over environment variables
+	// and those over the config file. Every invalid setting is reported at
+	// once.
+	loadOptions := func() (*config.Options, error) {
+		opts := config.Default()
+		if *configPath != "" {
+			var err error
+			if opts, err = config.LoadFile(*configPath); err != nil {
+				return nil, err
+			}
+		}
+		err := errors.Join(config.ApplyEnv(opts), optionFlags.Apply(opts))
+		return opts, errors.Join(err, opts.Validate())
+	}
+	opts, err := loadOptions()
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v\n", err)
+	}
+
+	if *printConfig {
+		if err = yaml.NewEncoder(os.Stdout).Encode(opts.Redacted()); err != nil {
+			log.Fatalln(err)
+		}
+		logFile.Close()
+		return
+	}
+
+	if *migrateOnly {
+		database.InitDriver(opts.DBDriver, opts.DBDsn).Close()
+		logFile.Close()
+		return
+	}
+
+	if *backupPath != "" || *restorePath != "" {
+		db := database.InitDriver(opts.DBDriver, opts.DBDsn)
+		if *backupPath != "" {
+			err = db.BackupFile(*backupPath)
+		} else {
+			err = db.RestoreFile(*restorePath)
+		}
+		db.Close()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		logFile.Close()
+		return
+	}
+
 	// Setup servers
-	coingecko := coingecko.New(":8082")
-	api := api.New(":8081", "http://localhost:8082")
+	api := api.New(opts.ListenAddress, opts.CoingeckoBaseUrl, opts.ApiOptions()...)
 	web := web.New(":8080")
 
 	// Run servers
-	go coingecko.Run()
+	// Without a base url, the API reads the data of the virtual Coingecko
+	// server in-process
+	if opts.CoingeckoBaseUrl != "" {
+		go coingecko.New(":8082").Run()
+	}
 	go api.Run()
 	go web.Run()
 
-	// Graceful shutdown for database and logfile
-	<-shutdown
+	// Apply changes of the config file that are safe to make while running
+	if *configPath != "" && opts.ConfigReloadInterval > 0 {
+		stopWatching := config.Watch(*configPath, opts.ConfigReloadInterval, opts, loadOptions, api.Reload)
+		defer stopWatching()
+	}
+
+	// Graceful shutdown for database and logfile, a second signal exits right
+	// away
+	log.Printf("Received %s, shutting down ...\n", <-shutdown)
+	go func() {
+		log.Printf("Received %s again, exiting immediately\n", <-shutdown)
+		os.Exit(1)
+	}()
 	api.Shutdown()
 	logFile.Close()
 }
@@ -3,6 +3,7 @@ package coingecko
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	_ "embed"
 	"encoding/json"
 	"fmt"
@@ -10,6 +11,8 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -26,6 +29,7 @@ type jsonCoin struct {
 type Coingecko struct {
 	router        *mux.Router // CWE-1104: Use of Unmaintained Third Party Components
 	coins         map[string][]m.Coin
+	loadOnce      sync.Once // Loads coins and sets up routes on first use
 	listenAddress string
 }
 
@@ -40,10 +44,29 @@ func New(listenAddress string) *Coingecko {
 }
 
 func (c *Coingecko) Run() {
-	c.loadZipCoinData()
-	c.setupRoutes()
+	handler := c.Handler()
 	log.Println("Starting virtual coingecko ...")
-	log.Fatalln(http.ListenAndServe(c.listenAddress, c.router))
+	log.Fatalln(http.ListenAndServe(c.listenAddress, handler))
+}
+
+// Serves the embedded coin data like Run, e.g. to mount it elsewhere
+func (c *Coingecko) Handler() http.Handler {
+	c.load()
+	return c.router
+}
+
+// Prices of the embedded coin data on the date, so the API can get them
+// in-process without a server. The same date always has the same prices.
+func (c *Coingecko) Prices(_ context.Context, date time.Time) ([]m.Coin, error) {
+	c.load()
+	return append([]m.Coin(nil), c.coins[fmt.Sprintf("%v", date.UnixMilli())]...), nil
+}
+
+func (c *Coingecko) load() {
+	c.loadOnce.Do(func() {
+		c.loadZipCoinData()
+		c.setupRoutes()
+	})
 }
 
 func (c *Coingecko) loadZipCoinData() {
@@ -65,14 +88,26 @@ func (c *Coingecko) loadZipCoinData() {
 		var coinData jsonCoin
 		json.NewDecoder(rc).Decode(&coinData)
 
+		// Market caps and volumes don't exist for every price date
+		marketCaps := map[float64]float64{}
+		for _, v := range coinData.MarketCaps {
+			marketCaps[v[0]] = v[1]
+		}
+		volumes := map[float64]float64{}
+		for _, v := range coinData.TotalVolumes {
+			volumes[v[0]] = v[1]
+		}
+
 		// Parse individual coin fields
 		for _, v := range coinData.Prices {
 			date := fmt.Sprintf("%v", int(v[0]))
 			price := v[1]
 
 			coin := m.Coin{
-				Id:    coinName,
-				Price: price,
+				Id:        coinName,
+				Price:     m.UsdFromFloat(price),
+				MarketCap: marketCaps[v[0]],
+				Volume24h: volumes[v[0]],
 			}
 
 			c.coins[date] = append(c.coins[date], coin)
@@ -83,6 +118,7 @@ func (c *Coingecko) loadZipCoinData() {
 func (c *Coingecko) setupRoutes() {
 	c.router.HandleFunc("/coins", c.getCoins)
 	c.router.HandleFunc("/coins/{date}", c.getCoinsOnDate)
+	c.router.HandleFunc("/coins/{date}/{id}", c.getCoinOnDate)
 }
 
 func (c *Coingecko) getCoinsOnDate(w http.ResponseWriter, r *http.Request) {
@@ -90,6 +126,18 @@ func (c *Coingecko) getCoinsOnDate(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(c.coins[date])
 }
 
+func (c *Coingecko) getCoinOnDate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	for _, coin := range c.coins[vars["date"]] {
+		if coin.Id == vars["id"] {
+			json.NewEncoder(w).Encode(coin)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
 func (c *Coingecko) getCoins(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(c.coins)
 }
@@ -0,0 +1,438 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"govulnapi/api"
+	"govulnapi/api/database"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Options struct {
+	ListenAddress             string            `yaml:"listen_address" env:"GOVULN_LISTEN_ADDRESS,GOVULNAPI_LISTEN"`
+	CoingeckoBaseUrl          string            `yaml:"coingecko_base_url" env:"GOVULN_COINGECKO_BASE_URL,GOVULNAPI_COINGECKO_URL"`
+	TLSCertFile               string            `yaml:"tls_cert_file" env:"GOVULN_TLS_CERT_FILE"`
+	TLSKeyFile                string            `yaml:"tls_key_file" env:"GOVULN_TLS_KEY_FILE"`
+	TLSSelfSigned             bool              `yaml:"tls_self_signed" env:"GOVULN_TLS_SELF_SIGNED"`
+	TLSListenAddress          string            `yaml:"tls_listen_address" env:"GOVULN_TLS_LISTEN_ADDRESS"`
+	TLSRedirectHTTP           bool              `yaml:"tls_redirect_http" env:"GOVULN_TLS_REDIRECT_HTTP"`
+	HSTSMaxAge                time.Duration     `yaml:"hsts_max_age" env:"GOVULN_HSTS_MAX_AGE"`
+	GRPCListenAddress         string            `yaml:"grpc_listen_address" env:"GOVULN_GRPC_LISTEN_ADDRESS"`
+	HTTP2Enabled              bool              `yaml:"http2_enabled" env:"GOVULN_HTTP2_ENABLED"`
+	RedirectOrigins           []string          `yaml:"redirect_origins" env:"GOVULN_REDIRECT_ORIGINS"`
+	JwtSecret                 string            `yaml:"jwt_secret" env:"GOVULN_JWT_SECRET,GOVULNAPI_JWT_SECRET"`
+	PriceSimulation           bool              `yaml:"price_simulation" env:"GOVULN_PRICE_SIMULATION"`
+	PriceSimulationSeed       int64             `yaml:"price_simulation_seed" env:"GOVULN_PRICE_SIMULATION_SEED"`
+	PriceSimulationAnchored   bool              `yaml:"price_simulation_anchored" env:"GOVULN_PRICE_SIMULATION_ANCHORED"`
+	DayDuration               time.Duration     `yaml:"day_duration" env:"GOVULN_DAY_DURATION,GOVULNAPI_DAY_DURATION"`
+	StartDate                 string            `yaml:"start_date" env:"GOVULN_START_DATE"`
+	Seed                      *int64            `yaml:"seed" env:"GOVULN_SEED"`
+	VulnerableMode            bool              `yaml:"vulnerable_mode" env:"GOVULN_VULNERABLE_MODE"`
+	Difficulty                string            `yaml:"difficulty" env:"GOVULN_DIFFICULTY"`
+	Vulnerabilities           map[string]bool   `yaml:"vulnerabilities" env:"GOVULN_VULNERABILITIES"`
+	DailyDepositLimit         float64           `yaml:"daily_deposit_limit" env:"GOVULN_DAILY_DEPOSIT_LIMIT"`
+	DailyWithdrawalLimit      float64           `yaml:"daily_withdrawal_limit" env:"GOVULN_DAILY_WITHDRAWAL_LIMIT"`
+	StrictJSONParsing         bool              `yaml:"strict_json_parsing" env:"GOVULN_STRICT_JSON_PARSING"`
+	MaxDecompressedBodyBytes  int64             `yaml:"max_decompressed_body_bytes" env:"GOVULN_MAX_DECOMPRESSED_BODY_BYTES"`
+	CoinDetailRateLimit       int               `yaml:"coin_detail_rate_limit" env:"GOVULN_COIN_DETAIL_RATE_LIMIT"`
+	NotificationRetentionDays int               `yaml:"notification_retention_days" env:"GOVULN_NOTIFICATION_RETENTION_DAYS"`
+	DeletedUserRetentionDays  int               `yaml:"deleted_user_retention_days" env:"GOVULN_DELETED_USER_RETENTION_DAYS"`
+	SimilarCoinsDays          int               `yaml:"similar_coins_days" env:"GOVULN_SIMILAR_COINS_DAYS"`
+	ReportsDir                string            `yaml:"reports_dir" env:"GOVULN_REPORTS_DIR"`
+	AvatarsDir                string            `yaml:"avatars_dir" env:"GOVULN_AVATARS_DIR"`
+	SandboxMode               bool              `yaml:"sandbox_mode" env:"GOVULN_SANDBOX_MODE"`
+	SandboxDir                string            `yaml:"sandbox_dir" env:"GOVULN_SANDBOX_DIR"`
+	BackupDir                 string            `yaml:"backup_dir" env:"GOVULN_BACKUP_DIR"`
+	WebhookMaxAttempts        int               `yaml:"webhook_max_attempts" env:"GOVULN_WEBHOOK_MAX_ATTEMPTS"`
+	DBDriver                  string            `yaml:"db_driver" env:"GOVULN_DB_DRIVER,GOVULNAPI_DB_DRIVER"`
+	DBDsn                     string            `yaml:"db_dsn" env:"GOVULN_DB_DSN,GOVULNAPI_DB_DSN,GOVULNAPI_DB_PATH"`
+	SQLitePragmas             map[string]string `yaml:"sqlite_pragmas" env:"GOVULN_SQLITE_PRAGMAS"`
+	DBMaxRetries              int               `yaml:"db_max_retries" env:"GOVULN_DB_MAX_RETRIES"`
+	VacuumInterval            time.Duration     `yaml:"vacuum_interval" env:"GOVULN_VACUUM_INTERVAL"`
+	WorkerCount               int               `yaml:"worker_count" env:"GOVULN_WORKER_COUNT"`
+	CTFMode                   bool              `yaml:"ctf_mode" env:"GOVULN_CTF_MODE"`
+	CTFHintPenalty            int               `yaml:"ctf_hint_penalty" env:"GOVULN_CTF_HINT_PENALTY"`
+	ShutdownTimeout           time.Duration     `yaml:"shutdown_timeout" env:"GOVULN_SHUTDOWN_TIMEOUT"`
+	ConfigReloadInterval      time.Duration     `yaml:"config_reload_interval" env:"GOVULN_CONFIG_RELOAD_INTERVAL"`
+	PriceWarmupTimeout        time.Duration     `yaml:"price_warmup_timeout" env:"GOVULN_PRICE_WARMUP_TIMEOUT"`
+	AcceptStalePrices         bool              `yaml:"accept_stale_prices" env:"GOVULN_ACCEPT_STALE_PRICES"`
+}
+
+func Default() *Options {
+	return &Options{
+		ListenAddress:    ":8081",
+		CoingeckoBaseUrl: "http://localhost:8082",
+		HTTP2Enabled:     true,
+		HSTSMaxAge:       2 * 365 * 24 * time.Hour,
+		RedirectOrigins:  []string{"http://localhost:8080"},
+		// CWE-547: Use of Hard-coded, Security-relevant Constants
+		JwtSecret:                 "safe-secret",
+		DayDuration:               time.Minute,
+		StartDate:                 "2014-01-01",
+		VulnerableMode:            true,
+		DailyDepositLimit:         10000,
+		DailyWithdrawalLimit:      10000,
+		StrictJSONParsing:         true,
+		MaxDecompressedBodyBytes:  10 << 20,
+		CoinDetailRateLimit:       60,
+		NotificationRetentionDays: 30,
+		DeletedUserRetentionDays:  30,
+		SimilarCoinsDays:          30,
+		ReportsDir:                "reports",
+		AvatarsDir:                "avatars",
+		SandboxDir:                "sandboxes",
+		BackupDir:                 "backups",
+		WebhookMaxAttempts:        5,
+		DBDriver:                  database.DriverSQLite,
+		DBMaxRetries:              3,
+		VacuumInterval:            7 * 24 * time.Hour,
+		WorkerCount:               1,
+		CTFHintPenalty:            10,
+		ShutdownTimeout:           5 * time.Second,
+		ConfigReloadInterval:      5 * time.Second,
+		PriceWarmupTimeout:        10 * time.Second,
+	}
+}
+
+// Reads YAML configuration file, keys missing from the file keep their
+// default values. Call Validate once all overrides are applied.
+func LoadFile(path string) (*Options, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := Default()
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(opts); err != nil {
+		return nil, fmt.Errorf("Unable to parse config file %s: %w", path, err)
+	}
+
+	return opts, nil
+}
+
+// Overrides fields with values of the environment variables named in their
+// env tags. Tags can list aliases after the name, separated by commas, the
+// first variable that's set is used.
+func ApplyEnv(opts *Options) error {
+	var (
+		errs   []error
+		value  = reflect.ValueOf(opts).Elem()
+		fields = value.Type()
+	)
+
+	for i := 0; i < fields.NumField(); i++ {
+		name, env, ok := lookupEnv(fields.Field(i))
+		if !ok {
+			continue
+		}
+
+		if err := setField(value.Field(i), env); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func lookupEnv(field reflect.StructField) (name string, env string, ok bool) {
+	for _, name = range envNames(field) {
+		if env, ok = os.LookupEnv(name); ok {
+			return name, env, true
+		}
+	}
+	return "", "", false
+}
+
+// Names of the environment variables of the field, aliases last
+func envNames(field reflect.StructField) []string {
+	if tag := field.Tag.Get("env"); tag != "" {
+		return strings.Split(tag, ",")
+	}
+	return nil
+}
+
+func setField(field reflect.Value, env string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(env)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	// Optional values, e.g. the seed
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := setField(elem.Elem(), env); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	// Comma separated name=value pairs, e.g. sql_injection=false
+	if field.Kind() == reflect.Map {
+		values := reflect.MakeMap(field.Type())
+		for _, pair := range strings.Split(env, ",") {
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("%q needs to be in name=value format", pair)
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(elem, value); err != nil {
+				return err
+			}
+			values.SetMapIndex(reflect.ValueOf(strings.TrimSpace(name)), elem)
+		}
+		field.Set(values)
+		return nil
+	}
+
+	// Comma separated values, e.g. http://a.com,http://b.com
+	if field.Kind() == reflect.Slice {
+		values := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, value := range strings.Split(env, ",") {
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(elem, strings.TrimSpace(value)); err != nil {
+				return err
+			}
+			values = reflect.Append(values, elem)
+		}
+		field.Set(values)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(env)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(env, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(env, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
+
+func (o *Options) Validate() error {
+	var errs []error
+
+	if o.ListenAddress == "" {
+		errs = append(errs, errors.New("listen_address is required"))
+	}
+	// Empty reads prices from the embedded data of the virtual Coingecko
+	// server in-process
+	if u, err := url.Parse(o.CoingeckoBaseUrl); o.CoingeckoBaseUrl != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		errs = append(errs, fmt.Errorf("coingecko_base_url: %s needs to be an http(s) url", o.CoingeckoBaseUrl))
+	}
+	if (o.TLSCertFile == "") != (o.TLSKeyFile == "") {
+		errs = append(errs, errors.New("tls_cert_file and tls_key_file need to be set together"))
+	}
+	if o.TLSListenAddress != "" && o.TLSCertFile == "" && !o.TLSSelfSigned {
+		errs = append(errs, errors.New("tls_listen_address needs tls_cert_file or tls_self_signed"))
+	}
+	if o.TLSListenAddress != "" && o.TLSListenAddress == o.ListenAddress {
+		errs = append(errs, errors.New("tls_listen_address needs to differ from listen_address"))
+	}
+	if o.TLSRedirectHTTP && o.TLSListenAddress == "" {
+		errs = append(errs, errors.New("tls_redirect_http needs tls_listen_address"))
+	}
+	if o.GRPCListenAddress != "" && (o.GRPCListenAddress == o.ListenAddress || o.GRPCListenAddress == o.TLSListenAddress) {
+		errs = append(errs, errors.New("grpc_listen_address needs to differ from listen_address and tls_listen_address"))
+	}
+	if o.HSTSMaxAge < 0 {
+		errs = append(errs, errors.New("hsts_max_age needs to be >= 0"))
+	}
+	for _, origin := range o.RedirectOrigins {
+		if u, err := url.Parse(origin); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			errs = append(errs, fmt.Errorf("redirect_origins: %s needs to be an http(s) scheme and host without a path", origin))
+		}
+	}
+	if o.JwtSecret == "" {
+		errs = append(errs, errors.New("jwt_secret is required"))
+	}
+	if o.PriceSimulationAnchored && !o.PriceSimulation {
+		errs = append(errs, errors.New("price_simulation_anchored needs price_simulation"))
+	}
+	if o.DayDuration <= 0 {
+		errs = append(errs, errors.New("day_duration needs to be > 0"))
+	}
+	if _, err := time.Parse(time.DateOnly, o.StartDate); err != nil {
+		errs = append(errs, errors.New("start_date needs to be a date like 2014-01-01"))
+	}
+	if o.Difficulty != "" && !isDifficulty(o.Difficulty) {
+		errs = append(errs, errors.New("difficulty needs to be easy, medium or hard"))
+	}
+	for name := range o.Vulnerabilities {
+		if !isVulnerability(name) {
+			errs = append(errs, fmt.Errorf("vulnerabilities: unknown vulnerability %s", name))
+		}
+	}
+	if o.DailyDepositLimit <= 0 || o.DailyWithdrawalLimit <= 0 {
+		errs = append(errs, errors.New("daily_deposit_limit and daily_withdrawal_limit need to be > 0"))
+	}
+	if o.MaxDecompressedBodyBytes <= 0 {
+		errs = append(errs, errors.New("max_decompressed_body_bytes needs to be > 0"))
+	}
+	if o.CoinDetailRateLimit < 0 {
+		errs = append(errs, errors.New("coin_detail_rate_limit needs to be >= 0"))
+	}
+	if o.NotificationRetentionDays <= 0 {
+		errs = append(errs, errors.New("notification_retention_days needs to be > 0"))
+	}
+	if o.DeletedUserRetentionDays <= 0 {
+		errs = append(errs, errors.New("deleted_user_retention_days needs to be > 0"))
+	}
+	if o.SimilarCoinsDays < 2 {
+		errs = append(errs, errors.New("similar_coins_days needs to be >= 2"))
+	}
+	if o.ReportsDir == "" {
+		errs = append(errs, errors.New("reports_dir is required"))
+	}
+	if o.AvatarsDir == "" {
+		errs = append(errs, errors.New("avatars_dir is required"))
+	}
+	if o.SandboxMode && o.SandboxDir == "" {
+		errs = append(errs, errors.New("sandbox_dir is required in sandbox mode"))
+	}
+	if o.SandboxMode && o.DBDriver != database.DriverSQLite {
+		errs = append(errs, errors.New("sandbox_mode is only supported with sqlite"))
+	}
+	if o.BackupDir == "" {
+		errs = append(errs, errors.New("backup_dir is required"))
+	}
+	if o.WebhookMaxAttempts <= 0 {
+		errs = append(errs, errors.New("webhook_max_attempts needs to be > 0"))
+	}
+	if o.DBDriver != database.DriverSQLite && o.DBDriver != database.DriverPostgres {
+		errs = append(errs, errors.New("db_driver needs to be sqlite or postgres"))
+	}
+	if o.DBDriver == database.DriverPostgres && o.DBDsn == "" {
+		errs = append(errs, errors.New("db_dsn is required for postgres"))
+	}
+	if len(o.SQLitePragmas) > 0 && o.DBDriver != database.DriverSQLite {
+		errs = append(errs, errors.New("sqlite_pragmas are only supported with sqlite"))
+	}
+	if o.DBMaxRetries < 0 {
+		errs = append(errs, errors.New("db_max_retries needs to be >= 0"))
+	}
+	if o.VacuumInterval < 0 {
+		errs = append(errs, errors.New("vacuum_interval needs to be >= 0"))
+	}
+	if o.WorkerCount <= 0 {
+		errs = append(errs, errors.New("worker_count needs to be > 0"))
+	}
+	if o.CTFHintPenalty < 0 {
+		errs = append(errs, errors.New("ctf_hint_penalty needs to be >= 0"))
+	}
+	if o.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("shutdown_timeout needs to be > 0"))
+	}
+	if o.ConfigReloadInterval < 0 {
+		errs = append(errs, errors.New("config_reload_interval needs to be >= 0"))
+	}
+	if o.PriceWarmupTimeout <= 0 {
+		errs = append(errs, errors.New("price_warmup_timeout needs to be > 0"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func isDifficulty(difficulty string) bool {
+	for _, d := range api.Difficulties {
+		if d == difficulty {
+			return true
+		}
+	}
+	return false
+}
+
+func isVulnerability(name string) bool {
+	for _, vulnerability := range api.Vulnerabilities {
+		if vulnerability.Id == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Parsed StartDate, which Validate checked
+func (o *Options) startDate() time.Time {
+	date, _ := time.Parse(time.DateOnly, o.StartDate)
+	return date
+}
+
+func (o *Options) ApiOptions() []api.Option {
+	opts := []api.Option{
+		api.WithDatabase(o.DBDriver, o.DBDsn),
+		api.WithSQLitePragmas(o.SQLitePragmas),
+		api.WithTLS(o.TLSCertFile, o.TLSKeyFile),
+		api.WithSelfSignedTLS(o.TLSSelfSigned),
+		api.WithTLSListener(o.TLSListenAddress, o.TLSRedirectHTTP),
+		api.WithHSTSMaxAge(o.HSTSMaxAge),
+		api.WithGRPCListener(o.GRPCListenAddress),
+		api.WithHTTP2(o.HTTP2Enabled),
+		api.WithRedirectOrigins(o.RedirectOrigins),
+		api.WithJwtSecret(o.JwtSecret),
+		api.WithDayDuration(o.DayDuration),
+		api.WithStartDate(o.startDate()),
+		api.WithVulnerableMode(o.VulnerableMode),
+		api.WithDifficulty(o.Difficulty),
+		api.WithVulnerabilities(o.Vulnerabilities),
+		api.WithDailyCashLimits(o.DailyDepositLimit, o.DailyWithdrawalLimit),
+		api.WithStrictJSONParsing(o.StrictJSONParsing),
+		api.WithMaxDecompressedBodyBytes(o.MaxDecompressedBodyBytes),
+		api.WithCoinDetailRateLimit(o.CoinDetailRateLimit),
+		api.WithNotificationRetention(o.NotificationRetentionDays),
+		api.WithDeletedUserRetention(o.DeletedUserRetentionDays),
+		api.WithSimilarCoinsDays(o.SimilarCoinsDays),
+		api.WithReportsDir(o.ReportsDir),
+		api.WithAvatarsDir(o.AvatarsDir),
+		api.WithSandboxes(o.SandboxMode, o.SandboxDir),
+		api.WithBackupDir(o.BackupDir),
+		api.WithWebhookMaxAttempts(o.WebhookMaxAttempts),
+		api.WithDBMaxRetries(o.DBMaxRetries),
+		api.WithVacuumInterval(o.VacuumInterval),
+		api.WithWorkerCount(o.WorkerCount),
+		api.WithCTFMode(o.CTFMode),
+		api.WithCTFHintPenalty(o.CTFHintPenalty),
+		api.WithShutdownTimeout(o.ShutdownTimeout),
+		api.WithPriceWarmup(o.PriceWarmupTimeout, o.AcceptStalePrices),
+	}
+	if o.Seed != nil {
+		opts = append(opts, api.WithSeed(*o.Seed))
+	}
+	if o.PriceSimulation {
+		opts = append(opts, api.WithPriceSimulation(o.PriceSimulationSeed, o.PriceSimulationAnchored))
+	}
+
+	return opts
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"govulnapi/api/database"
+)
+
+func writeConfig(t *testing.T, yaml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	opts, err := LoadFile(writeConfig(t, `
+listen_address: ":9000"
+day_duration: 30s
+vulnerabilities:
+  sql_injection: false
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.ListenAddress != ":9000" || opts.DayDuration != 30*time.Second || opts.Vulnerabilities["sql_injection"] {
+		t.Errorf("got %+v, want the values of the file", opts)
+	}
+	// Keys missing from the file keep their defaults
+	if opts.JwtSecret != Default().JwtSecret || opts.DBDriver != database.DriverSQLite {
+		t.Errorf("got %+v, want defaults for the other keys", opts)
+	}
+	if err := opts.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadFileRejects(t *testing.T) {
+	for name, yaml := range map[string]string{
+		"unknown key":     "listen_address: \":9000\"\nlisten_adress: \":9001\"\n",
+		"wrong type":      "worker_count: many\n",
+		"bad duration":    "day_duration: a minute\n",
+		"malformed yaml":  "listen_address: [\n",
+		"nested unknown":  "vulnerabilities: {sql_injection: false}\ndb: {dsn: x}\n",
+		"unknown pragmas": "sqlite_pragmas: [wal]\n",
+	} {
+		if _, err := LoadFile(writeConfig(t, yaml)); err == nil {
+			t.Errorf("loaded %s, want an error", name)
+		}
+	}
+
+	_, err := LoadFile(writeConfig(t, "listen_adress: \":9001\"\n"))
+	if err == nil || !strings.Contains(err.Error(), "listen_adress") {
+		t.Errorf("got %v, want the unknown key named", err)
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.yml")); !os.IsNotExist(err) {
+		t.Errorf("got %v for a missing file, want it to not exist", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("defaults are invalid: %v", err)
+	}
+
+	for want, change := range map[string]func(o *Options){
+		"listen_address is required":                 func(o *Options) { o.ListenAddress = "" },
+		"coingecko_base_url":                         func(o *Options) { o.CoingeckoBaseUrl = "ftp://localhost" },
+		"tls_cert_file and tls_key_file":             func(o *Options) { o.TLSCertFile = "cert.pem" },
+		"tls_listen_address needs tls_cert_file":     func(o *Options) { o.TLSListenAddress = ":8443" },
+		"tls_listen_address needs to differ":         func(o *Options) { o.TLSSelfSigned, o.TLSListenAddress = true, o.ListenAddress },
+		"tls_redirect_http needs tls_listen_address": func(o *Options) { o.TLSRedirectHTTP = true },
+		"grpc_listen_address needs to differ":        func(o *Options) { o.GRPCListenAddress = o.ListenAddress },
+		"redirect_origins":                           func(o *Options) { o.RedirectOrigins = []string{"http://localhost:8080/path"} },
+		"jwt_secret is required":                     func(o *Options) { o.JwtSecret = "" },
+		"price_simulation_anchored needs":            func(o *Options) { o.PriceSimulationAnchored = true },
+		"day_duration needs to be > 0":               func(o *Options) { o.DayDuration = 0 },
+		"start_date needs to be a date":              func(o *Options) { o.StartDate = "01/01/2014" },
+		"difficulty needs to be":                     func(o *Options) { o.Difficulty = "insane" },
+		"unknown vulnerability sql_injektion":        func(o *Options) { o.Vulnerabilities = map[string]bool{"sql_injektion": true} },
+		"daily_deposit_limit":                        func(o *Options) { o.DailyWithdrawalLimit = 0 },
+		"similar_coins_days needs to be >= 2":        func(o *Options) { o.SimilarCoinsDays = 1 },
+		"sandbox_mode is only supported with sqlite": func(o *Options) { o.SandboxMode, o.DBDriver, o.DBDsn = true, database.DriverPostgres, "dsn" },
+		"db_driver needs to be sqlite or postgres":   func(o *Options) { o.DBDriver = "mysql" },
+		"db_dsn is required for postgres":            func(o *Options) { o.DBDriver = database.DriverPostgres },
+		"sqlite_pragmas are only supported": func(o *Options) {
+			o.DBDriver, o.DBDsn, o.SQLitePragmas = database.DriverPostgres, "dsn", map[string]string{"cache_size": "1"}
+		},
+		"worker_count needs to be > 0":                func(o *Options) { o.WorkerCount = 0 },
+		"shutdown_timeout needs to be > 0":            func(o *Options) { o.ShutdownTimeout = 0 },
+		"price_warmup_timeout needs to be > 0":        func(o *Options) { o.PriceWarmupTimeout = -time.Second },
+		"max_decompressed_body_bytes needs to be > 0": func(o *Options) { o.MaxDecompressedBodyBytes = 0 },
+	} {
+		opts := Default()
+		change(opts)
+		if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want %q", err, want)
+		}
+	}
+}
+
+func TestValidateReportsEveryError(t *testing.T) {
+	opts := Default()
+	opts.ListenAddress, opts.JwtSecret, opts.WorkerCount = "", "", 0
+
+	err := opts.Validate()
+	if err == nil {
+		t.Fatal("validated invalid options")
+	}
+	for _, want := range []string{"listen_address", "jwt_secret", "worker_count"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want %s reported too", err, want)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("GOVULN_LISTEN_ADDRESS", ":9000")
+	t.Setenv("GOVULN_DAY_DURATION", "30s")
+	t.Setenv("GOVULN_SEED", "42")
+	t.Setenv("GOVULN_VULNERABILITIES", "sql_injection=false, xss=true")
+	t.Setenv("GOVULN_REDIRECT_ORIGINS", "http://a.com, https://b.com")
+	t.Setenv("GOVULN_DAILY_DEPOSIT_LIMIT", "2500.5")
+
+	opts := Default()
+	if err := ApplyEnv(opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.ListenAddress != ":9000" || opts.DayDuration != 30*time.Second || opts.DailyDepositLimit != 2500.5 {
+		t.Errorf("got %+v, want the environment's values", opts)
+	}
+	if opts.Seed == nil || *opts.Seed != 42 {
+		t.Errorf("seed = %v, want 42", opts.Seed)
+	}
+	if len(opts.Vulnerabilities) != 2 || opts.Vulnerabilities["sql_injection"] || !opts.Vulnerabilities["xss"] {
+		t.Errorf("vulnerabilities = %v, want sql_injection off and xss on", opts.Vulnerabilities)
+	}
+	if len(opts.RedirectOrigins) != 2 || opts.RedirectOrigins[1] != "https://b.com" {
+		t.Errorf("redirect origins = %q, want both", opts.RedirectOrigins)
+	}
+	// Unset variables keep the value
+	if opts.JwtSecret != Default().JwtSecret {
+		t.Errorf("jwt secret = %s, want the default", opts.JwtSecret)
+	}
+}
+
+func TestApplyEnvAliases(t *testing.T) {
+	t.Setenv("GOVULNAPI_LISTEN", ":9001")
+	t.Setenv("GOVULNAPI_DB_PATH", "alias.db")
+	opts := Default()
+	if err := ApplyEnv(opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.ListenAddress != ":9001" || opts.DBDsn != "alias.db" {
+		t.Errorf("got %s and %s, want the aliases' values", opts.ListenAddress, opts.DBDsn)
+	}
+
+	// GOVULN_ names go first, then the aliases in the order of the tag
+	t.Setenv("GOVULN_LISTEN_ADDRESS", ":9000")
+	t.Setenv("GOVULNAPI_DB_DSN", "dsn.db")
+	opts = Default()
+	if err := ApplyEnv(opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.ListenAddress != ":9000" || opts.DBDsn != "dsn.db" {
+		t.Errorf("got %s and %s, want the values of the earlier names", opts.ListenAddress, opts.DBDsn)
+	}
+}
+
+func TestApplyEnvBadValues(t *testing.T) {
+	t.Setenv("GOVULN_DAY_DURATION", "a minute")
+	t.Setenv("GOVULNAPI_JWT_SECRET", "secret")
+	t.Setenv("GOVULN_WORKER_COUNT", "many")
+	t.Setenv("GOVULN_CTF_MODE", "maybe")
+	t.Setenv("GOVULN_VULNERABILITIES", "sql_injection")
+
+	opts := Default()
+	err := ApplyEnv(opts)
+	if err == nil {
+		t.Fatal("applied bad values")
+	}
+	// Every bad value is reported, named after its variable
+	for _, name := range []string{"GOVULN_DAY_DURATION", "GOVULN_WORKER_COUNT", "GOVULN_CTF_MODE", "GOVULN_VULNERABILITIES"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("got %v, want %s reported", err, name)
+		}
+	}
+	// Good values are still applied
+	if opts.JwtSecret != "secret" {
+		t.Errorf("jwt secret = %s, want the alias' value", opts.JwtSecret)
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Command line flags overriding options, one per YAML key with dashes instead
+// of underscores, e.g. -listen-address or -day-duration
+type Flags struct {
+	values map[string]string // By flag name, in the format of env values
+}
+
+// Defines a flag for every option on fs
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	var (
+		flags  = &Flags{values: map[string]string{}}
+		fields = reflect.TypeOf(Options{})
+	)
+
+	for i := 0; i < fields.NumField(); i++ {
+		key := yamlKey(fields.Field(i))
+		name := strings.ReplaceAll(key, "_", "-")
+		fs.Var(
+			optionFlag{flags: flags, name: name, isBool: fields.Field(i).Type.Kind() == reflect.Bool},
+			name,
+			fmt.Sprintf("Overrides %s of the config file and the %s environment variable", key, strings.Join(envNames(fields.Field(i)), " or ")),
+		)
+	}
+
+	return flags
+}
+
+// Overrides fields with the values of the flags that were set
+func (f *Flags) Apply(opts *Options) error {
+	var (
+		errs   []error
+		value  = reflect.ValueOf(opts).Elem()
+		fields = value.Type()
+	)
+
+	for i := 0; i < fields.NumField(); i++ {
+		name := strings.ReplaceAll(yamlKey(fields.Field(i)), "_", "-")
+		flagValue, ok := f.values[name]
+		if !ok {
+			continue
+		}
+
+		if err := setField(value.Field(i), flagValue); err != nil {
+			errs = append(errs, fmt.Errorf("-%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func yamlKey(field reflect.StructField) string {
+	key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	return key
+}
+
+// Keeps the value of a flag to be parsed by Apply, so flags are checked
+// along with environment variables instead of one at a time
+type optionFlag struct {
+	flags  *Flags
+	name   string
+	isBool bool
+}
+
+func (f optionFlag) String() string {
+	if f.flags == nil {
+		return ""
+	}
+	return f.flags.values[f.name]
+}
+
+func (f optionFlag) Set(value string) error {
+	f.flags.values[f.name] = value
+	return nil
+}
+
+// Lets boolean options be set without a value, e.g. -ctf-mode
+func (f optionFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+const redacted = "REDACTED"
+
+var dsnPassword = regexp.MustCompile(`(password=)('[^']*'|\S+)`)
+
+// Copy of the options safe to print, with secrets replaced
+func (o Options) Redacted() Options {
+	if o.JwtSecret != "" {
+		o.JwtSecret = redacted
+	}
+	// Postgres takes urls as well as key=value pairs
+	if u, err := url.Parse(o.DBDsn); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			o.DBDsn = u.String()
+		}
+	}
+	o.DBDsn = dsnPassword.ReplaceAllString(o.DBDsn, "${1}"+redacted)
+	return o
+}
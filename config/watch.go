@@ -0,0 +1,109 @@
+package config
+
+import (
+	"govulnapi/api"
+	"log"
+	"os"
+	"reflect"
+	"time"
+)
+
+// Options applied while running when they change in the config file, by YAML
+// key. The others are only read on startup.
+var reloadableOptions = map[string]func(o *Options) api.Option{
+	"day_duration":    func(o *Options) api.Option { return api.WithDayDuration(o.DayDuration) },
+	"vulnerable_mode": func(o *Options) api.Option { return api.WithVulnerableMode(o.VulnerableMode) },
+	"difficulty":      func(o *Options) api.Option { return api.WithDifficulty(o.Difficulty) },
+	"vulnerabilities": func(o *Options) api.Option { return api.WithVulnerabilities(o.Vulnerabilities) },
+	"daily_deposit_limit": func(o *Options) api.Option {
+		return api.WithDailyCashLimits(o.DailyDepositLimit, o.DailyWithdrawalLimit)
+	},
+	"daily_withdrawal_limit": func(o *Options) api.Option {
+		return api.WithDailyCashLimits(o.DailyDepositLimit, o.DailyWithdrawalLimit)
+	},
+	"strict_json_parsing":    func(o *Options) api.Option { return api.WithStrictJSONParsing(o.StrictJSONParsing) },
+	"coin_detail_rate_limit": func(o *Options) api.Option { return api.WithCoinDetailRateLimit(o.CoinDetailRateLimit) },
+}
+
+// Copies the reloadable options that differ in next into o, returning them
+// as API options. Keys that changed but need a restart are returned as well,
+// o keeps their old value.
+func (o *Options) reload(next *Options) (changes []api.Option, restart []string) {
+	var (
+		value     = reflect.ValueOf(o).Elem()
+		nextValue = reflect.ValueOf(next).Elem()
+		fields    = value.Type()
+	)
+
+	for i := 0; i < fields.NumField(); i++ {
+		if reflect.DeepEqual(value.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			continue
+		}
+
+		key := yamlKey(fields.Field(i))
+		option, ok := reloadableOptions[key]
+		if !ok {
+			restart = append(restart, key)
+			continue
+		}
+		value.Field(i).Set(nextValue.Field(i))
+		changes = append(changes, option(o))
+	}
+
+	return changes, restart
+}
+
+// Polls the config file at path every interval, loading the options again
+// with load once its modification time or size changed. Reloadable options
+// that differ from current are passed to apply, changes of the others are
+// logged and ignored until the next restart. Invalid files are logged and
+// ignored as well. Returns a function stopping the polling.
+func Watch(path string, interval time.Duration, current *Options, load func() (*Options, error), apply func(opts ...api.Option) error) (stop func()) {
+	var (
+		done   = make(chan struct{})
+		ticker = time.NewTicker(interval)
+		// Only the watcher reads and changes its copy
+		options = *current
+	)
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		log.Printf("Unable to watch config file %s: %v\n", path, err)
+	}
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			next, err := os.Stat(path)
+			if err != nil || (stat != nil && next.ModTime().Equal(stat.ModTime()) && next.Size() == stat.Size()) {
+				continue
+			}
+			stat = next
+
+			opts, err := load()
+			if err != nil {
+				log.Printf("Ignoring invalid config file %s:\n%v\n", path, err)
+				continue
+			}
+
+			changes, restart := options.reload(opts)
+			for _, key := range restart {
+				log.Printf("Config key %s changed, restart to apply it\n", key)
+			}
+			if len(changes) == 0 {
+				continue
+			}
+			if err = apply(changes...); err != nil {
+				log.Printf("Unable to reload config file %s: %v\n", path, err)
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
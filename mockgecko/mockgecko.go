@@ -0,0 +1,156 @@
+// Package mockgecko serves deterministic prices of a few coins from small
+// embedded fixtures, so tests and offline demos get the same prices on every
+// run without the virtual Coingecko server.
+package mockgecko
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	m "govulnapi/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// One file per coin, in the format of Coingecko's market chart: [unix
+// milliseconds, value] pairs from 2014-01-01 to 2014-04-30
+//
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+var ErrNoPrices = errors.New("No fixture prices on that date!")
+
+type fixture struct {
+	Prices       [][2]float64 `json:"prices"`
+	MarketCaps   [][2]float64 `json:"market_caps"`
+	TotalVolumes [][2]float64 `json:"total_volumes"`
+}
+
+type Mockgecko struct {
+	coins map[int64][]m.Coin // Unix milliseconds to the coins with a price then
+}
+
+func New() *Mockgecko {
+	mockgecko := Mockgecko{coins: map[int64][]m.Coin{}}
+	if err := mockgecko.load(); err != nil {
+		log.Fatalln(err)
+	}
+
+	return &mockgecko
+}
+
+// Prices of the fixtures on the date, fails with ErrNoPrices outside of them
+func (g *Mockgecko) Prices(ctx context.Context, date time.Time) ([]m.Coin, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	coins, ok := g.coins[date.UnixMilli()]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrNoPrices, date.Format(time.DateOnly))
+	}
+
+	return append([]m.Coin(nil), coins...), nil
+}
+
+// Serves the fixtures like the virtual Coingecko server, at /coins/{date} and
+// /coins/{date}/{id} with the date in unix milliseconds
+func (g *Mockgecko) Handler() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/coins/{date}", g.getCoinsOnDate)
+	r.Get("/coins/{date}/{id}", g.getCoinOnDate)
+
+	return r
+}
+
+func (g *Mockgecko) getCoinsOnDate(w http.ResponseWriter, r *http.Request) {
+	coins, ok := g.coinsOnDate(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(coins)
+}
+
+func (g *Mockgecko) getCoinOnDate(w http.ResponseWriter, r *http.Request) {
+	coins, ok := g.coinsOnDate(w, r)
+	if !ok {
+		return
+	}
+
+	for _, coin := range coins {
+		if coin.Id == chi.URLParam(r, "id") {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(coin)
+			return
+		}
+	}
+	http.Error(w, "Coin not found!", http.StatusNotFound)
+}
+
+// Writes the error response itself when ok is false
+func (g *Mockgecko) coinsOnDate(w http.ResponseWriter, r *http.Request) (coins []m.Coin, ok bool) {
+	millis, err := strconv.ParseInt(chi.URLParam(r, "date"), 10, 64)
+	if err != nil {
+		http.Error(w, "Date needs to be in unix milliseconds!", http.StatusBadRequest)
+		return nil, false
+	}
+
+	coins, ok = g.coins[millis]
+	if !ok {
+		http.Error(w, ErrNoPrices.Error(), http.StatusNotFound)
+	}
+
+	return coins, ok
+}
+
+func (g *Mockgecko) load() error {
+	files, err := fixtures.ReadDir("fixtures")
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := fixtures.ReadFile(path.Join("fixtures", file.Name()))
+		if err != nil {
+			return err
+		}
+
+		var coinData fixture
+		if err := json.Unmarshal(data, &coinData); err != nil {
+			return fmt.Errorf("%s: %w", file.Name(), err)
+		}
+
+		marketCaps := map[float64]float64{}
+		for _, v := range coinData.MarketCaps {
+			marketCaps[v[0]] = v[1]
+		}
+		volumes := map[float64]float64{}
+		for _, v := range coinData.TotalVolumes {
+			volumes[v[0]] = v[1]
+		}
+
+		// Files are read in name order, so the coins of a date are too
+		for _, v := range coinData.Prices {
+			millis := int64(v[0])
+			g.coins[millis] = append(g.coins[millis], m.Coin{
+				Id:        strings.TrimSuffix(file.Name(), ".json"),
+				Price:     m.UsdFromFloat(v[1]),
+				MarketCap: marketCaps[v[0]],
+				Volume24h: volumes[v[0]],
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package mockgecko
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	m "govulnapi/models"
+)
+
+func TestPrices(t *testing.T) {
+	g := New()
+
+	coins, err := g.Prices(context.Background(), time.Date(2014, time.April, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]m.Usd{
+		"bitcoin":  m.UsdFromFloat(478.7163),
+		"dogecoin": m.UsdFromFloat(0.000536),
+		"litecoin": m.UsdFromFloat(13.322675),
+	}
+	if len(coins) != len(want) {
+		t.Fatalf("got %d coins, want %d", len(coins), len(want))
+	}
+	for _, coin := range coins {
+		if coin.Price != want[coin.Id] {
+			t.Errorf("%s costs %v, want %v", coin.Id, coin.Price, want[coin.Id])
+		}
+	}
+
+	// Dogecoin has no price on 2014-01-10
+	coins, err = g.Prices(context.Background(), time.Date(2014, time.January, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(coins) != 2 {
+		t.Errorf("got %v, %v, want the 2 other coins", coins, err)
+	}
+
+	if _, err := g.Prices(context.Background(), time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC)); !errors.Is(err, ErrNoPrices) {
+		t.Errorf("got %v outside of the fixtures, want ErrNoPrices", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	handler := New().Handler()
+
+	for path, want := range map[string]int{
+		"/coins/1396310400000":          http.StatusOK,
+		"/coins/1396310400000/bitcoin":  http.StatusOK,
+		"/coins/1396310400000/namecoin": http.StatusNotFound,
+		"/coins/1420070400000":          http.StatusNotFound,
+		"/coins/2014-04-01":             http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Errorf("%s answered %d, want %d", path, w.Code, want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/coins/1396310400000/bitcoin", nil))
+	var coin m.Coin
+	if err := json.NewDecoder(w.Body).Decode(&coin); err != nil {
+		t.Fatal(err)
+	}
+	if coin.Id != "bitcoin" || coin.Price != m.UsdFromFloat(478.7163) {
+		t.Errorf("got %+v, want bitcoin at 478.7163", coin)
+	}
+}
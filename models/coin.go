@@ -1,6 +1,268 @@
 package models
 
 type Coin struct {
-	Id    string `db:"id"`
-	Price float64
+	Id        string `db:"id"`
+	Price     Usd    `swaggertype:"number"`
+	MarketCap float64
+	Volume24h float64
+}
+
+type CoinDetail struct {
+	Coin
+	Name   string `example:"Bitcoin"`
+	Symbol string `example:"BTC"`
+	// Change of the price since the last one of the previous virtual day,
+	// null without one
+	Change24hPercent *float64 `example:"2.5"`
+}
+
+// Coin with its name and symbol
+type ListedCoin struct {
+	Coin
+	Name   string `example:"Bitcoin"`
+	Symbol string `example:"BTC"`
+}
+
+// Coins listed by GET /coins, whose fields may be limited to the requested
+// ones
+type CoinList struct {
+	Data  []ListedCoin `json:"data"`
+	Count int          `json:"count" example:"10"`
+}
+
+// Name and symbol of a listed coin, Version is incremented by every change
+type CoinListing struct {
+	Id      string `db:"id" example:"cardano"`
+	Name    string `db:"name" example:"Cardano"`
+	Symbol  string `db:"symbol" example:"ADA"`
+	Version int    `db:"version" example:"2"`
+}
+
+// Fields left out keep their value
+type CoinPatch struct {
+	Name   *string `example:"Cardano"`
+	Symbol *string `example:"ADA"`
+}
+
+// Coin to list, prices are tracked from the next refresh on
+type NewCoin struct {
+	Id     string `example:"cardano"`
+	Name   string `example:"Cardano"`
+	Symbol string `example:"ADA"`
+}
+
+// Skipped coins were listed already
+type CoinImportResult struct {
+	Inserted int
+	Skipped  int
+	Errors   []CoinImportError
+}
+
+// Coin of an import that was rejected
+type CoinImportError struct {
+	Id     string
+	Reason string
+}
+
+type PriceHistory struct {
+	Id        int     `db:"id"`
+	CoinId    string  `db:"coin_id"`
+	Price     Usd     `db:"price"`
+	MarketCap float64 `db:"market_cap"`
+	Volume24h float64 `db:"volume_24h"`
+	Date      string  `db:"date"`
+	Manual    bool    `db:"manual"`
+}
+
+type SimilarCoin struct {
+	Id          string
+	Correlation float64
+}
+
+// Simple moving average of daily closing prices
+type MovingAverage struct {
+	CoinId   string  `json:"coin_id" example:"bitcoin"`
+	Window   int     `json:"window" example:"7"`
+	Sma      float64 `json:"sma" example:"45321.50"`
+	AsOfDate string  `json:"as_of_date" example:"2014-06-01"`
+}
+
+// Percentage change of the price since 1, 7, 30 and 90 virtual days ago,
+// null without a price on either day
+type PriceChange struct {
+	OneDay     *float64 `json:"1d" example:"1.2"`
+	SevenDays  *float64 `json:"7d" example:"-3.4"`
+	ThirtyDays *float64 `json:"30d"`
+	NinetyDays *float64 `json:"90d"`
+}
+
+type CoinChange struct {
+	Id            string
+	Price         Usd `swaggertype:"number"`
+	PastPrice     Usd `swaggertype:"number"`
+	ChangePercent float64
+}
+
+type OrderBook struct {
+	CoinId      string
+	MarketPrice Usd `swaggertype:"number"`
+	Bids        []OrderBookLevel
+	Asks        []OrderBookLevel
+}
+
+type OrderBookLevel struct {
+	IsBuy bool    `db:"is_buy"`
+	Price Usd     `db:"price" swaggertype:"number"`
+	Count int     `db:"count"`
+	Qty   float64 `db:"qty"`
+	Users string  `db:"users" json:",omitempty"`
+}
+
+type PriceAlert struct {
+	Id           int     `db:"id" swaggerignore:"true"`
+	UserId       int     `db:"user_id" swaggerignore:"true"`
+	CoinId       string  `db:"coin_id" swaggerignore:"true"`
+	ThresholdUsd Usd     `db:"threshold_usd" swaggertype:"number" example:"1000"`
+	Direction    string  `db:"direction" example:"above"`
+	TriggeredAt  *string `db:"triggered_at" swaggerignore:"true"`
+}
+
+type WatchlistEntry struct {
+	CoinId  string `db:"coin_id" example:"bitcoin"`
+	AddedAt string `db:"added_at"`
+	// Latest price of the coin, null when it isn't tracked anymore
+	Price *Usd `swaggertype:"number" example:"825.47"`
+}
+
+type NewsPreviewRequest struct {
+	Url string `example:"https://example.com/bitcoin-news"`
+}
+
+// Title and first paragraph of a news page, or the start of the page when it
+// isn't HTML
+type NewsPreview struct {
+	CoinId    string `example:"bitcoin"`
+	Url       string `example:"https://example.com/bitcoin-news"`
+	Title     string `example:"Bitcoin hits new high"`
+	Paragraph string `example:"Bitcoin rose above $60,000 on Monday."`
+}
+
+type Simulation struct {
+	Days int `example:"30"`
+}
+
+type SimulationResult struct {
+	SimulatedDays    int
+	FinalVirtualDate string
+}
+
+// Virtual time the lab is at, saved after every virtual day so it resumes
+// there after a restart
+type VirtualClock struct {
+	VirtualDate   string `db:"virtual_date"`
+	StartDate     string `db:"start_date"`
+	DayDurationMs int64  `db:"day_duration_ms"`
+	UpdatedAt     string `db:"updated_at"`
+}
+
+// Snapshot written to the backup directory
+type BackupResult struct {
+	BackupPath string `json:"backup_path" example:"/var/backups/api_2024-01-01_120000.db"`
+	SizeBytes  int64  `json:"size_bytes" example:"1234"`
+}
+
+type PingRequest struct {
+	Host string `example:"localhost"`
+	// TCP port probed when not running the vulnerable ping, defaults to 80
+	Port int `example:"8082"`
+}
+
+type PingResult struct {
+	Host       string
+	Port       int    `json:",omitempty"`
+	ResolvedIp string `example:"127.0.0.1"`
+	RttMs      float64
+	Success    bool
+	// Output of the ping command, vulnerable mode only
+	Output string `json:",omitempty"`
+	Error  string `json:",omitempty"`
+}
+
+type Readiness struct {
+	Ready bool
+	// Names of the checks that failed, e.g. no_coins or virtual_clock_stalled
+	FailedChecks []string `example:"virtual_clock_stalled"`
+}
+
+type Version struct {
+	Version string `json:"version" example:"1.2.3"`
+	Commit  string `json:"commit" example:"abc123"`
+	BuiltAt string `json:"built_at" example:"2024-01-01T00:00:00Z"`
+}
+
+type LabReset struct {
+	Seed            int64 `example:"42"`
+	Users           int   `example:"10"`
+	StartingBalance Usd   `swaggertype:"number" example:"10000"`
+	TradesPerUser   int   `example:"3"`
+}
+
+// Part of the lab data to reset, all, users, trades or prices, and how it's
+// seeded again
+type ScopedLabReset struct {
+	Scope string `example:"trades"`
+	LabReset
+}
+
+// Intentional vulnerability of the lab and whether its vulnerable code path
+// is currently taken
+type Vulnerability struct {
+	Id          string   `example:"sql_injection"`
+	Cwe         int      `example:"89"`
+	Description string   `example:"Queries are built by formatting user input into them"`
+	Routes      []string `example:"GET /api/login"`
+	// Ordered, CTF players take them one by one
+	Hints []string `example:"Which queries use the email you log in with?"`
+	// Left disabled by VulnerableMode, only enabled by naming it in
+	// Options.Vulnerabilities
+	OptIn   bool
+	Enabled bool
+}
+
+type VulnerabilityToggle struct {
+	Enabled *bool `example:"false"`
+}
+
+type DifficultyUpdate struct {
+	Difficulty string `example:"hard"`
+}
+
+// Vulnerabilities in effect, by id. Difficulty is empty while they follow
+// vulnerable_mode.
+type Difficulty struct {
+	Difficulty      string `example:"hard"`
+	Vulnerabilities map[string]bool
+	// Toggled one by one, taking precedence over the difficulty
+	Overrides map[string]bool
+}
+
+type Comment struct {
+	Id     int    `db:"id"`
+	CoinId string `db:"coin_id"`
+	UserId int    `db:"user_id"`
+	// Of the author, empty when they didn't set one
+	DisplayName string  `db:"display_name"`
+	Body        string  `db:"body" example:"To the moon!"`
+	VirtualDate string  `db:"virtual_date"`
+	CreatedAt   string  `db:"created_at"`
+	EditedAt    *string `db:"edited_at"`
+}
+
+type CommentPage struct {
+	Comments   []Comment
+	NextCursor string `json:",omitempty"`
+}
+
+type CommentBody struct {
+	Body string `example:"To the moon!"`
 }
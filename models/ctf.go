@@ -0,0 +1,43 @@
+package models
+
+type FlagSubmission struct {
+	Flag string `example:"govulnapi{...}"`
+}
+
+type FlagResult struct {
+	FlagId string `example:"hidden_table"`
+	// False when the flag was submitted before
+	FirstSolve bool
+}
+
+type CTFSolve struct {
+	UserId      int    `db:"user_id" json:"-"`
+	DisplayName string `db:"display_name" json:"-"`
+	FlagId      string `db:"flag_id"`
+	SolvedAt    string `db:"solved_at"`
+}
+
+type ScoreboardEntry struct {
+	Rank int
+	Team string
+	// Points of solved flags minus the penalty of hints taken
+	Score      int
+	HintsTaken int
+	Solves     []CTFSolve
+}
+
+type Hint struct {
+	ChallengeId string `example:"sql_injection"`
+	Number      int    `example:"1"`
+	Text        string
+	// Points the hint costs on the scoreboard
+	Penalty int `example:"10"`
+}
+
+type HintTaken struct {
+	UserId      int    `db:"user_id"`
+	Email       string `db:"email"`
+	ChallengeId string `db:"challenge_id"`
+	Number      int    `db:"number"`
+	TakenAt     string `db:"taken_at"`
+}
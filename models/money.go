@@ -0,0 +1,135 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Amount of usd in millionths of a dollar, so balances and prices add up
+// exactly no matter how many trades they go through. It's a plain number of
+// dollars in JSON and XML, e.g. 10000 or 0.1, and an integer in the database.
+type Usd int64
+
+const usdScale = 1_000_000
+
+// Rounds the amount to the closest millionth of a dollar, halves away from 0
+func UsdFromFloat(amount float64) Usd {
+	return Usd(math.Round(amount * usdScale))
+}
+
+// Value of qty coins at price, rounded like UsdFromFloat
+func UsdValue(price Usd, qty float64) Usd {
+	return Usd(math.Round(float64(price) * qty))
+}
+
+func (u Usd) Float64() float64 {
+	return float64(u) / usdScale
+}
+
+// Dollars with as many decimals as needed, e.g. 10000 or 0.123456
+func (u Usd) String() string {
+	sign, micros := "", int64(u)
+	if micros < 0 {
+		sign, micros = "-", -micros
+	}
+
+	whole, fraction := micros/usdScale, micros%usdScale
+	if fraction == 0 {
+		return fmt.Sprintf("%s%d", sign, whole)
+	}
+	return strings.TrimRight(fmt.Sprintf("%s%d.%06d", sign, whole, fraction), "0")
+}
+
+func (u Usd) MarshalJSON() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *Usd) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	return u.UnmarshalText(data)
+}
+
+// Used for XML, where amounts are written in dollars like in JSON
+func (u Usd) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// Largest amount of dollars accepted, leaving room for adding amounts up
+const maxUsdDollars = math.MaxInt64 / 2 / usdScale
+
+var decimalAmount = regexp.MustCompile(`^(-?)(\d+)(?:\.(\d+))?$`)
+
+// Plain decimals like 0.1 are parsed exactly, others like 1e-3 through a
+// float64. Decimals past the sixth are rounded like UsdFromFloat.
+func (u *Usd) UnmarshalText(data []byte) error {
+	text := strings.TrimSpace(string(data))
+	if match := decimalAmount.FindStringSubmatch(text); match != nil {
+		whole, err := strconv.ParseInt(match[2], 10, 64)
+		if err != nil || whole > maxUsdDollars {
+			return fmt.Errorf("usd amount %s is out of range", data)
+		}
+
+		fraction := (match[3] + "000000")[:6]
+		micros, _ := strconv.ParseInt(fraction, 10, 64)
+		micros += whole * usdScale
+		if len(match[3]) > 6 && match[3][6] >= '5' {
+			micros++
+		}
+		if match[1] == "-" {
+			micros = -micros
+		}
+
+		*u = Usd(micros)
+		return nil
+	}
+
+	amount, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return fmt.Errorf("usd amount needs to be a number, got %s", data)
+	}
+	if math.IsNaN(amount) || math.Abs(amount) > maxUsdDollars {
+		return fmt.Errorf("usd amount %s is out of range", data)
+	}
+
+	*u = UsdFromFloat(amount)
+	return nil
+}
+
+// Scans millionths of a dollar. SQLite returns them as floats from REAL
+// columns, which hold them exactly up to 2^53.
+func (u *Usd) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case int64:
+		*u = Usd(v)
+	case float64:
+		*u = Usd(math.Round(v))
+	case []byte:
+		return u.scanString(string(v))
+	case string:
+		return u.scanString(v)
+	case nil:
+		*u = 0
+	default:
+		return fmt.Errorf("can't scan %T into usd", value)
+	}
+	return nil
+}
+
+func (u *Usd) scanString(value string) error {
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
+	}
+	*u = Usd(math.Round(amount))
+	return nil
+}
+
+func (u Usd) Value() (driver.Value, error) {
+	return int64(u), nil
+}
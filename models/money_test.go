@@ -0,0 +1,111 @@
+package models
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"math"
+	"testing"
+	"testing/quick"
+)
+
+// Amounts up to a trillion dollars, the range balances and prices live in
+func smallUsd(u Usd) Usd {
+	return u % (1_000_000_000_000 * usdScale)
+}
+
+func TestUsdJSONRoundTrip(t *testing.T) {
+	roundTrip := func(u Usd) bool {
+		u = smallUsd(u)
+		data, err := json.Marshal(u)
+		if err != nil {
+			return false
+		}
+		var decoded Usd
+		return json.Unmarshal(data, &decoded) == nil && decoded == u
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUsdXMLRoundTrip(t *testing.T) {
+	type trade struct {
+		Price Usd `xml:"price"`
+	}
+	roundTrip := func(u Usd) bool {
+		data, err := xml.Marshal(trade{Price: smallUsd(u)})
+		if err != nil {
+			return false
+		}
+		var decoded trade
+		return xml.Unmarshal(data, &decoded) == nil && decoded.Price == smallUsd(u)
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUsdFromFloatRounding(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   Usd
+	}{
+		{10000, 10_000_000_000},
+		{0.1, 100_000},
+		{0.0000005, 1},
+		{-0.0000005, -1},
+		{0.0000004, 0},
+		{9905.67397, 9_905_673_970},
+	}
+	for _, test := range tests {
+		if got := UsdFromFloat(test.amount); got != test.want {
+			t.Errorf("UsdFromFloat(%v) = %d, want %d", test.amount, got, test.want)
+		}
+	}
+
+	// Converting back is off by at most half a millionth
+	withinHalf := func(amount float64) bool {
+		amount = math.Mod(amount, 1e9)
+		return math.Abs(UsdFromFloat(amount).Float64()-amount) <= 0.5/usdScale+1e-9
+	}
+	if err := quick.Check(withinHalf, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUsdUnmarshal(t *testing.T) {
+	tests := []struct {
+		data string
+		want Usd
+	}{
+		{`10000`, 10_000_000_000},
+		{`0.1`, 100_000},
+		{`-12.3456785`, -12_345_679},
+		{`1e3`, 1_000_000_000},
+		{`999999999999.999999`, 999_999_999_999_999_999},
+	}
+	for _, test := range tests {
+		var u Usd
+		if err := json.Unmarshal([]byte(test.data), &u); err != nil || u != test.want {
+			t.Errorf("unmarshaling %s gave %d (%v), want %d", test.data, u, err, test.want)
+		}
+	}
+
+	for _, data := range []string{`"10"`, `1e300`, `99999999999999999999`, `true`} {
+		var u Usd
+		if err := json.Unmarshal([]byte(data), &u); err == nil {
+			t.Errorf("unmarshaling %s gave %v, want an error", data, u)
+		}
+	}
+}
+
+func TestUsdValueSymmetric(t *testing.T) {
+	// Buying and selling the same qty at the same price moves the same amount
+	symmetric := func(price Usd, qty float64) bool {
+		price = smallUsd(price)
+		return UsdValue(price, qty) == -UsdValue(price, -qty)
+	}
+	if err := quick.Check(symmetric, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,14 +1,54 @@
 package models
 
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+)
+
 type User struct {
-	Id                 int     `db:"id"`
-	Email              string  `db:"email"`
-	Password           string  `db:"password"`
-	UsdBalance         float64 `db:"usd_balance"`
-	UsdStartingBalance float64 `db:"usd_starting_balance"`
-	CoinBalances       []CoinBalance
-	Transactions       []Transaction
-	Orders             []Order
+	Id                  int    `db:"id"`
+	Email               string `db:"email"`
+	Password            string `db:"password"`
+	UsdBalance          Usd    `db:"usd_balance" swaggertype:"number"`
+	UsdStartingBalance  Usd    `db:"usd_starting_balance" swaggertype:"number"`
+	Role                string `db:"role"`
+	DisplayName         string `db:"display_name"`
+	HideFromLeaderboard bool   `db:"hide_from_leaderboard"`
+	// Virtual date the user was deleted on, nil for active users
+	DeletedAt    *string `db:"deleted_at"`
+	CoinBalances []CoinBalance
+	Transactions []Transaction
+	Orders       []Order
+}
+
+// Account data of a user safe to show them, without the password hash
+type Profile struct {
+	Id          int
+	Email       string `example:"user@example.com"`
+	DisplayName string
+	Role        string `example:"user"`
+}
+
+// Leaving out DisplayName, Email or NewPassword keeps the current one, only
+// changing DisplayName doesn't require CurrentPassword
+type ProfileUpdate struct {
+	DisplayName     string `example:"satoshi"`
+	Email           string `example:"user@example.com"`
+	CurrentPassword string `example:"secret"`
+	NewPassword     string `example:"correct horse battery"`
+}
+
+// Picture uploaded for a profile, served at /avatars/{user}
+type Avatar struct {
+	UserId      int    `db:"user_id"`
+	FileName    string `db:"file_name" example:"avatar.png"`
+	ContentType string `db:"content_type" example:"image/png"`
+	UpdatedAt   string `db:"updated_at"`
+}
+
+type AccountDeletion struct {
+	Password string `example:"secret"`
 }
 
 type CoinBalance struct {
@@ -18,7 +58,8 @@ type CoinBalance struct {
 }
 
 type Transaction struct {
-	Id         int     `db:"id" swaggerignore:"true"`
+	Id         int     `db:"id" json:",omitempty" swaggerignore:"true"`
+	PublicId   string  `db:"public_id" swaggerignore:"true"`
 	SenderId   int     `db:"sender_id" swaggerignore:"true"`
 	ReceiverId int     `db:"receiver_id" swaggerignore:"true"`
 	CoinId     string  `db:"coin_id" example:"bitcoin"`
@@ -29,10 +70,253 @@ type Transaction struct {
 }
 
 type Order struct {
-	UserId int     `db:"user_id" swaggerignore:"true"`
-	CoinId string  `db:"coin_id" example:"bitcoin"`
-	Price  float64 `db:"price" swaggerignore:"true"`
-	IsBuy  bool    `db:"is_buy"`
-	Qty    float64 `db:"qty" example:"1"`
-	Date   string  `db:"date" swaggerignore:"true"`
+	Id          int     `db:"id" swaggerignore:"true"`
+	UserId      int     `db:"user_id" swaggerignore:"true"`
+	CoinId      string  `db:"coin_id" example:"bitcoin"`
+	Price       Usd     `db:"price" swaggerignore:"true"`
+	IsBuy       bool    `db:"is_buy"`
+	Qty         float64 `db:"qty" example:"1"`
+	Date        string  `db:"date" swaggerignore:"true"`
+	VirtualDate string  `db:"virtual_date" swaggerignore:"true"`
+	Status      string  `db:"status" swaggerignore:"true"`
+	// Rests in the order book until the price reaches it when set
+	LimitPrice Usd `db:"-" json:",omitempty" swaggertype:"number" example:"30000"`
+}
+
+type OrderPage struct {
+	Orders     []Order
+	NextCursor string `json:",omitempty"`
+}
+
+type Trade struct {
+	Id          int     `db:"id"`
+	CoinId      string  `db:"coin_id"`
+	CoinName    string  `db:"coin_name"`
+	CoinSymbol  string  `db:"coin_symbol"`
+	IsBuy       bool    `db:"is_buy"`
+	Qty         float64 `db:"qty"`
+	Price       Usd     `db:"price" swaggertype:"number"`
+	Total       Usd     `db:"total" swaggertype:"number"`
+	VirtualDate string  `db:"virtual_date"`
+}
+
+type TradePage struct {
+	Trades  []Trade
+	Page    int
+	PerPage int
+	Total   int
+}
+
+// Past trades backfilled into an account, sent as JSON or as XML like
+// <trades><trade><coinId>bitcoin</coinId>...</trade></trades>
+type TradeImport struct {
+	XMLName xml.Name        `xml:"trades" json:"-" swaggerignore:"true"`
+	Trades  []ImportedTrade `xml:"trade"`
+}
+
+type ImportedTrade struct {
+	CoinId string  `xml:"coinId" example:"bitcoin"`
+	IsBuy  bool    `xml:"isBuy"`
+	Qty    float64 `xml:"qty" example:"0.5"`
+	Price  Usd     `xml:"price" swaggertype:"number" example:"800"`
+	// Virtual date of the trade (YYYY-MM-DD)
+	Date string `xml:"date" example:"2013-12-20"`
+}
+
+type TradeImportResult struct {
+	Imported int
+	Errors   []TradeImportError
+}
+
+// Trade that couldn't be imported, rows are numbered from 1
+type TradeImportError struct {
+	Row   int
+	Error string
+}
+
+type OrderExportRow struct {
+	Id           int     `db:"id"`
+	UserId       int     `db:"user_id"`
+	CoinId       string  `db:"coin_id"`
+	VirtualDate  string  `db:"virtual_date"`
+	IsBuy        bool    `db:"is_buy"`
+	Qty          float64 `db:"qty"`
+	Price        Usd     `db:"price"`
+	Total        Usd     `db:"total"`
+	BalanceAfter Usd     `db:"balance_after"`
+}
+
+type PnlReport struct {
+	From                string
+	To                  string
+	RealizedPnl         Usd `swaggertype:"number"`
+	UnrealizedPnlChange Usd `swaggertype:"number"`
+	EquityCurve         []EquityPoint
+}
+
+type EquityPoint struct {
+	Date   string
+	Equity Usd `swaggertype:"number"`
+}
+
+type ClosedPosition struct {
+	CoinId      string
+	Qty         float64
+	Price       Usd `swaggertype:"number"`
+	RealizedPnl Usd `swaggertype:"number"`
+}
+
+// Signed usd ledger entry, positive amounts credit the user
+type CashTransaction struct {
+	Id                int     `db:"id"`
+	UserId            int     `db:"user_id"`
+	CounterpartyId    *int    `db:"counterparty_id"`
+	CounterpartyEmail *string `db:"counterparty_email"`
+	Type              string  `db:"type"`
+	Amount            Usd     `db:"amount" swaggertype:"number"`
+	VirtualDate       string  `db:"virtual_date"`
+	Date              string  `db:"date"`
+}
+
+type Transfer struct {
+	ToEmail string `example:"user@example.com"`
+	Amount  Usd    `swaggertype:"number" example:"100"`
+}
+
+type CashAmount struct {
+	Amount Usd `swaggertype:"number" example:"100"`
+}
+
+type Portfolio struct {
+	UserId              int    `db:"id"`
+	DisplayName         string `db:"display_name"`
+	HideFromLeaderboard bool   `db:"hide_from_leaderboard"`
+	UsdBalance          Usd    `db:"usd_balance" swaggertype:"number"`
+	CoinBalances        []CoinBalance
+}
+
+type LeaderboardEntry struct {
+	Rank        int
+	UserId      int `json:"-"`
+	DisplayName string
+	Value       Usd `swaggertype:"number"`
+}
+
+type Leaderboard struct {
+	Top []LeaderboardEntry
+	Me  LeaderboardEntry
+}
+
+type Notification struct {
+	Id          int             `db:"id"`
+	UserId      int             `db:"user_id"`
+	Type        string          `db:"type"`
+	Payload     json.RawMessage `db:"payload" swaggertype:"object"`
+	VirtualDate string          `db:"virtual_date"`
+	ReadAt      *string         `db:"read_at"`
+}
+
+type NotificationPage struct {
+	Notifications []Notification
+	UnreadCount   int
+	NextCursor    string `json:",omitempty"`
+}
+
+type Broadcast struct {
+	Message string `example:"Scheduled maintenance tonight"`
+}
+
+type Webhook struct {
+	Id      int      `swaggerignore:"true"`
+	UserId  int      `swaggerignore:"true"`
+	Url     string   `example:"https://example.com/hook"`
+	Events  []string `example:"trade.executed,alert.triggered"`
+	Secret  string   `json:",omitempty" swaggerignore:"true"`
+	Failing bool     `swaggerignore:"true"`
+}
+
+type WebhookDelivery struct {
+	Id        int    `db:"id"`
+	WebhookId int    `db:"webhook_id"`
+	Url       string `db:"url"`
+	Secret    string `db:"secret"`
+	Event     string `db:"event"`
+	Payload   string `db:"payload"`
+	Attempts  int    `db:"attempts"`
+}
+
+// Trading strategy backtested over the Days virtual days up to the current
+// one, starting with StartingUsd and no coins
+type Strategy struct {
+	Name        string  `example:"Buy the dip"`
+	CoinId      string  `example:"bitcoin"`
+	Days        int     `example:"90"`
+	StartingUsd float64 `example:"10000"`
+	Signal      StrategySignal
+}
+
+// Buys with all usd once the price is BuyBelowPct percent below its moving
+// average over the Window days before, and sells all coins once it's
+// SellAbovePct percent above it
+type StrategySignal struct {
+	Type         string  `example:"sma_cross"`
+	Window       int     `example:"20"`
+	BuyBelowPct  float64 `example:"5"`
+	SellAbovePct float64 `example:"5"`
+}
+
+type SavedStrategy struct {
+	Id        int    `db:"id"`
+	UserId    int    `db:"user_id" json:"-"`
+	Name      string `db:"name"`
+	Format    string `db:"format"`
+	Data      []byte `db:"data" json:"-"`
+	CreatedAt string `db:"created_at"`
+}
+
+type StrategyTrade struct {
+	Date  string
+	IsBuy bool
+	Qty   float64
+	Price float64
+}
+
+// Hypothetical outcome of a strategy, compared to buying on the first day
+// and holding
+type StrategyRun struct {
+	StrategyId    int
+	Name          string
+	CoinId        string
+	From          string
+	To            string
+	StartingUsd   float64
+	EndingValue   float64
+	Pnl           float64
+	PnlPercent    float64
+	BuyAndHoldPnl float64
+	Trades        []StrategyTrade
+}
+
+// Balance that differs from the one recomputed from the ledger, Asset being
+// usd or a coin id
+type BalanceDiscrepancy struct {
+	UserId     int     `db:"user_id"`
+	Email      string  `db:"email"`
+	Asset      string  `db:"asset" example:"usd"`
+	Expected   float64 `db:"expected"`
+	Actual     float64 `db:"actual"`
+	Difference float64
+}
+
+type ConsistencyReport struct {
+	UsersChecked  int
+	Discrepancies []BalanceDiscrepancy
+}
+
+// Response replayed to retries of a request with the same Idempotency-Key.
+// Header only holds the headers set by the handler.
+type IdempotentResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
 }
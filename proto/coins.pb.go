@@ -0,0 +1,531 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        (unknown)
+// source: coins.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Coin struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id         string  `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name       string  `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Symbol     string  `protobuf:"bytes,3,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Price      float64 `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
+	MarketCap  float64 `protobuf:"fixed64,5,opt,name=market_cap,json=marketCap,proto3" json:"market_cap,omitempty"`
+	Volume_24H float64 `protobuf:"fixed64,6,opt,name=volume_24h,json=volume24h,proto3" json:"volume_24h,omitempty"`
+}
+
+func (x *Coin) Reset() {
+	*x = Coin{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coins_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Coin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Coin) ProtoMessage() {}
+
+func (x *Coin) ProtoReflect() protoreflect.Message {
+	mi := &file_coins_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Coin.ProtoReflect.Descriptor instead.
+func (*Coin) Descriptor() ([]byte, []int) {
+	return file_coins_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Coin) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Coin) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Coin) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Coin) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Coin) GetMarketCap() float64 {
+	if x != nil {
+		return x.MarketCap
+	}
+	return 0
+}
+
+func (x *Coin) GetVolume_24H() float64 {
+	if x != nil {
+		return x.Volume_24H
+	}
+	return 0
+}
+
+type GetCoinRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Id or symbol, e.g. bitcoin or BTC
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetCoinRequest) Reset() {
+	*x = GetCoinRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coins_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCoinRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCoinRequest) ProtoMessage() {}
+
+func (x *GetCoinRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coins_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCoinRequest.ProtoReflect.Descriptor instead.
+func (*GetCoinRequest) Descriptor() ([]byte, []int) {
+	return file_coins_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetCoinRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListCoinsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListCoinsRequest) Reset() {
+	*x = ListCoinsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coins_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListCoinsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCoinsRequest) ProtoMessage() {}
+
+func (x *ListCoinsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coins_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCoinsRequest.ProtoReflect.Descriptor instead.
+func (*ListCoinsRequest) Descriptor() ([]byte, []int) {
+	return file_coins_proto_rawDescGZIP(), []int{2}
+}
+
+type ListCoinsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Coins []*Coin `protobuf:"bytes,1,rep,name=coins,proto3" json:"coins,omitempty"`
+}
+
+func (x *ListCoinsResponse) Reset() {
+	*x = ListCoinsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coins_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListCoinsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCoinsResponse) ProtoMessage() {}
+
+func (x *ListCoinsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coins_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCoinsResponse.ProtoReflect.Descriptor instead.
+func (*ListCoinsResponse) Descriptor() ([]byte, []int) {
+	return file_coins_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListCoinsResponse) GetCoins() []*Coin {
+	if x != nil {
+		return x.Coins
+	}
+	return nil
+}
+
+type StreamPricesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Ids of the coins to send, every coin when empty
+	Ids []string `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+}
+
+func (x *StreamPricesRequest) Reset() {
+	*x = StreamPricesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coins_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamPricesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamPricesRequest) ProtoMessage() {}
+
+func (x *StreamPricesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coins_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamPricesRequest.ProtoReflect.Descriptor instead.
+func (*StreamPricesRequest) Descriptor() ([]byte, []int) {
+	return file_coins_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StreamPricesRequest) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type PriceUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Virtual date the prices are of, e.g. 2014-01-02
+	VirtualDate string  `protobuf:"bytes,1,opt,name=virtual_date,json=virtualDate,proto3" json:"virtual_date,omitempty"`
+	Coins       []*Coin `protobuf:"bytes,2,rep,name=coins,proto3" json:"coins,omitempty"`
+}
+
+func (x *PriceUpdate) Reset() {
+	*x = PriceUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coins_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PriceUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriceUpdate) ProtoMessage() {}
+
+func (x *PriceUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_coins_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriceUpdate.ProtoReflect.Descriptor instead.
+func (*PriceUpdate) Descriptor() ([]byte, []int) {
+	return file_coins_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PriceUpdate) GetVirtualDate() string {
+	if x != nil {
+		return x.VirtualDate
+	}
+	return ""
+}
+
+func (x *PriceUpdate) GetCoins() []*Coin {
+	if x != nil {
+		return x.Coins
+	}
+	return nil
+}
+
+var File_coins_proto protoreflect.FileDescriptor
+
+var file_coins_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0f, 0x67,
+	0x6f, 0x76, 0x75, 0x6c, 0x6e, 0x61, 0x70, 0x69, 0x2e, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x22, 0x96,
+	0x01, 0x0a, 0x04, 0x43, 0x6f, 0x69, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73,
+	0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x79, 0x6d,
+	0x62, 0x6f, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x61, 0x72,
+	0x6b, 0x65, 0x74, 0x5f, 0x63, 0x61, 0x70, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x6d,
+	0x61, 0x72, 0x6b, 0x65, 0x74, 0x43, 0x61, 0x70, 0x12, 0x1d, 0x0a, 0x0a, 0x76, 0x6f, 0x6c, 0x75,
+	0x6d, 0x65, 0x5f, 0x32, 0x34, 0x68, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x76, 0x6f,
+	0x6c, 0x75, 0x6d, 0x65, 0x32, 0x34, 0x68, 0x22, 0x20, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x43, 0x6f,
+	0x69, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x22, 0x12, 0x0a, 0x10, 0x4c, 0x69, 0x73,
+	0x74, 0x43, 0x6f, 0x69, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x40, 0x0a,
+	0x11, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x69, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x2b, 0x0a, 0x05, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x15, 0x2e, 0x67, 0x6f, 0x76, 0x75, 0x6c, 0x6e, 0x61, 0x70, 0x69, 0x2e, 0x63, 0x6f,
+	0x69, 0x6e, 0x73, 0x2e, 0x43, 0x6f, 0x69, 0x6e, 0x52, 0x05, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x22,
+	0x27, 0x0a, 0x13, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x69, 0x64, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x03, 0x69, 0x64, 0x73, 0x22, 0x5d, 0x0a, 0x0b, 0x50, 0x72, 0x69, 0x63,
+	0x65, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x76, 0x69, 0x72, 0x74, 0x75,
+	0x61, 0x6c, 0x5f, 0x64, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x76,
+	0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x44, 0x61, 0x74, 0x65, 0x12, 0x2b, 0x0a, 0x05, 0x63, 0x6f,
+	0x69, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x67, 0x6f, 0x76, 0x75,
+	0x6c, 0x6e, 0x61, 0x70, 0x69, 0x2e, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x2e, 0x43, 0x6f, 0x69, 0x6e,
+	0x52, 0x05, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x32, 0xf4, 0x01, 0x0a, 0x05, 0x43, 0x6f, 0x69, 0x6e,
+	0x73, 0x12, 0x41, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x69, 0x6e, 0x12, 0x1f, 0x2e, 0x67,
+	0x6f, 0x76, 0x75, 0x6c, 0x6e, 0x61, 0x70, 0x69, 0x2e, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x2e, 0x47,
+	0x65, 0x74, 0x43, 0x6f, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e,
+	0x67, 0x6f, 0x76, 0x75, 0x6c, 0x6e, 0x61, 0x70, 0x69, 0x2e, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x2e,
+	0x43, 0x6f, 0x69, 0x6e, 0x12, 0x52, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x69, 0x6e,
+	0x73, 0x12, 0x21, 0x2e, 0x67, 0x6f, 0x76, 0x75, 0x6c, 0x6e, 0x61, 0x70, 0x69, 0x2e, 0x63, 0x6f,
+	0x69, 0x6e, 0x73, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x69, 0x6e, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x67, 0x6f, 0x76, 0x75, 0x6c, 0x6e, 0x61, 0x70, 0x69,
+	0x2e, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x43, 0x6f, 0x69, 0x6e, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x54, 0x0a, 0x0c, 0x53, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x12, 0x24, 0x2e, 0x67, 0x6f, 0x76, 0x75, 0x6c,
+	0x6e, 0x61, 0x70, 0x69, 0x2e, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c,
+	0x2e, 0x67, 0x6f, 0x76, 0x75, 0x6c, 0x6e, 0x61, 0x70, 0x69, 0x2e, 0x63, 0x6f, 0x69, 0x6e, 0x73,
+	0x2e, 0x50, 0x72, 0x69, 0x63, 0x65, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x30, 0x01, 0x42, 0x11,
+	0x5a, 0x0f, 0x67, 0x6f, 0x76, 0x75, 0x6c, 0x6e, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_coins_proto_rawDescOnce sync.Once
+	file_coins_proto_rawDescData = file_coins_proto_rawDesc
+)
+
+func file_coins_proto_rawDescGZIP() []byte {
+	file_coins_proto_rawDescOnce.Do(func() {
+		file_coins_proto_rawDescData = protoimpl.X.CompressGZIP(file_coins_proto_rawDescData)
+	})
+	return file_coins_proto_rawDescData
+}
+
+var file_coins_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_coins_proto_goTypes = []interface{}{
+	(*Coin)(nil),                // 0: govulnapi.coins.Coin
+	(*GetCoinRequest)(nil),      // 1: govulnapi.coins.GetCoinRequest
+	(*ListCoinsRequest)(nil),    // 2: govulnapi.coins.ListCoinsRequest
+	(*ListCoinsResponse)(nil),   // 3: govulnapi.coins.ListCoinsResponse
+	(*StreamPricesRequest)(nil), // 4: govulnapi.coins.StreamPricesRequest
+	(*PriceUpdate)(nil),         // 5: govulnapi.coins.PriceUpdate
+}
+var file_coins_proto_depIdxs = []int32{
+	0, // 0: govulnapi.coins.ListCoinsResponse.coins:type_name -> govulnapi.coins.Coin
+	0, // 1: govulnapi.coins.PriceUpdate.coins:type_name -> govulnapi.coins.Coin
+	1, // 2: govulnapi.coins.Coins.GetCoin:input_type -> govulnapi.coins.GetCoinRequest
+	2, // 3: govulnapi.coins.Coins.ListCoins:input_type -> govulnapi.coins.ListCoinsRequest
+	4, // 4: govulnapi.coins.Coins.StreamPrices:input_type -> govulnapi.coins.StreamPricesRequest
+	0, // 5: govulnapi.coins.Coins.GetCoin:output_type -> govulnapi.coins.Coin
+	3, // 6: govulnapi.coins.Coins.ListCoins:output_type -> govulnapi.coins.ListCoinsResponse
+	5, // 7: govulnapi.coins.Coins.StreamPrices:output_type -> govulnapi.coins.PriceUpdate
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_coins_proto_init() }
+func file_coins_proto_init() {
+	if File_coins_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_coins_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Coin); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coins_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCoinRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coins_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListCoinsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coins_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListCoinsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coins_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamPricesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coins_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PriceUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_coins_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_coins_proto_goTypes,
+		DependencyIndexes: file_coins_proto_depIdxs,
+		MessageInfos:      file_coins_proto_msgTypes,
+	}.Build()
+	File_coins_proto = out.File
+	file_coins_proto_rawDesc = nil
+	file_coins_proto_goTypes = nil
+	file_coins_proto_depIdxs = nil
+}
@@ -0,0 +1,226 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: coins.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	Coins_GetCoin_FullMethodName      = "/govulnapi.coins.Coins/GetCoin"
+	Coins_ListCoins_FullMethodName    = "/govulnapi.coins.Coins/ListCoins"
+	Coins_StreamPrices_FullMethodName = "/govulnapi.coins.Coins/StreamPrices"
+)
+
+// CoinsClient is the client API for Coins service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Same coins and prices as the HTTP API, for clients that poll prices often
+type CoinsClient interface {
+	// Gets a coin by id or symbol
+	GetCoin(ctx context.Context, in *GetCoinRequest, opts ...grpc.CallOption) (*Coin, error)
+	// Lists every tracked coin
+	ListCoins(ctx context.Context, in *ListCoinsRequest, opts ...grpc.CallOption) (*ListCoinsResponse, error)
+	// Sends the current prices, then the new ones after every refresh until
+	// the client cancels or the API shuts down
+	StreamPrices(ctx context.Context, in *StreamPricesRequest, opts ...grpc.CallOption) (Coins_StreamPricesClient, error)
+}
+
+type coinsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCoinsClient(cc grpc.ClientConnInterface) CoinsClient {
+	return &coinsClient{cc}
+}
+
+func (c *coinsClient) GetCoin(ctx context.Context, in *GetCoinRequest, opts ...grpc.CallOption) (*Coin, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Coin)
+	err := c.cc.Invoke(ctx, Coins_GetCoin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinsClient) ListCoins(ctx context.Context, in *ListCoinsRequest, opts ...grpc.CallOption) (*ListCoinsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCoinsResponse)
+	err := c.cc.Invoke(ctx, Coins_ListCoins_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinsClient) StreamPrices(ctx context.Context, in *StreamPricesRequest, opts ...grpc.CallOption) (Coins_StreamPricesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Coins_ServiceDesc.Streams[0], Coins_StreamPrices_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &coinsStreamPricesClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Coins_StreamPricesClient interface {
+	Recv() (*PriceUpdate, error)
+	grpc.ClientStream
+}
+
+type coinsStreamPricesClient struct {
+	grpc.ClientStream
+}
+
+func (x *coinsStreamPricesClient) Recv() (*PriceUpdate, error) {
+	m := new(PriceUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CoinsServer is the server API for Coins service.
+// All implementations must embed UnimplementedCoinsServer
+// for forward compatibility
+//
+// Same coins and prices as the HTTP API, for clients that poll prices often
+type CoinsServer interface {
+	// Gets a coin by id or symbol
+	GetCoin(context.Context, *GetCoinRequest) (*Coin, error)
+	// Lists every tracked coin
+	ListCoins(context.Context, *ListCoinsRequest) (*ListCoinsResponse, error)
+	// Sends the current prices, then the new ones after every refresh until
+	// the client cancels or the API shuts down
+	StreamPrices(*StreamPricesRequest, Coins_StreamPricesServer) error
+	mustEmbedUnimplementedCoinsServer()
+}
+
+// UnimplementedCoinsServer must be embedded to have forward compatible implementations.
+type UnimplementedCoinsServer struct {
+}
+
+func (UnimplementedCoinsServer) GetCoin(context.Context, *GetCoinRequest) (*Coin, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCoin not implemented")
+}
+func (UnimplementedCoinsServer) ListCoins(context.Context, *ListCoinsRequest) (*ListCoinsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCoins not implemented")
+}
+func (UnimplementedCoinsServer) StreamPrices(*StreamPricesRequest, Coins_StreamPricesServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamPrices not implemented")
+}
+func (UnimplementedCoinsServer) mustEmbedUnimplementedCoinsServer() {}
+
+// UnsafeCoinsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CoinsServer will
+// result in compilation errors.
+type UnsafeCoinsServer interface {
+	mustEmbedUnimplementedCoinsServer()
+}
+
+func RegisterCoinsServer(s grpc.ServiceRegistrar, srv CoinsServer) {
+	s.RegisterService(&Coins_ServiceDesc, srv)
+}
+
+func _Coins_GetCoin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCoinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinsServer).GetCoin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Coins_GetCoin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinsServer).GetCoin(ctx, req.(*GetCoinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coins_ListCoins_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCoinsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinsServer).ListCoins(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Coins_ListCoins_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinsServer).ListCoins(ctx, req.(*ListCoinsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coins_StreamPrices_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamPricesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CoinsServer).StreamPrices(m, &coinsStreamPricesServer{ServerStream: stream})
+}
+
+type Coins_StreamPricesServer interface {
+	Send(*PriceUpdate) error
+	grpc.ServerStream
+}
+
+type coinsStreamPricesServer struct {
+	grpc.ServerStream
+}
+
+func (x *coinsStreamPricesServer) Send(m *PriceUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Coins_ServiceDesc is the grpc.ServiceDesc for Coins service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Coins_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "govulnapi.coins.Coins",
+	HandlerType: (*CoinsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCoin",
+			Handler:    _Coins_GetCoin_Handler,
+		},
+		{
+			MethodName: "ListCoins",
+			Handler:    _Coins_ListCoins_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamPrices",
+			Handler:       _Coins_StreamPrices_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "coins.proto",
+}
@@ -0,0 +1,5 @@
+// Package proto holds the gRPC API of the coins, generated from coins.proto
+// with protoc, protoc-gen-go and protoc-gen-go-grpc
+package proto
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative coins.proto
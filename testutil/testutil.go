@@ -0,0 +1,97 @@
+// Package testutil starts the API on a real HTTP server for tests that need
+// one instead of calling its handler.
+package testutil
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"govulnapi/api"
+	"govulnapi/api/database"
+)
+
+const (
+	clientTimeout = 5 * time.Second
+	// How long NewTestServer waits for the API to become ready
+	readyTimeout = 5 * time.Second
+)
+
+type TestServer struct {
+	Api *api.Api
+	// Advancing it by DayDuration moves to the next virtual day
+	Clock *api.FakeClock
+	// Client with a short timeout, for requests to BaseURL
+	Client  *http.Client
+	baseURL string
+}
+
+// Starts the API on a random port of localhost with its own in-memory
+// database, api.TestPrices and a fake clock, and waits until GET /api/readyz
+// succeeds. The server is shut down when the test finishes. Options override
+// the test setup.
+func NewTestServer(t *testing.T, opts ...api.Option) *TestServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := api.NewFakeClock(time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC))
+	opts = append([]api.Option{
+		api.WithDatabase(database.DriverSQLite, database.MemoryDSN),
+		api.WithPriceProvider(api.TestPrices),
+		api.WithClock(clock),
+	}, opts...)
+
+	server := &TestServer{
+		Api:     api.New(listener.Addr().String(), "", opts...),
+		Clock:   clock,
+		Client:  &http.Client{Timeout: clientTimeout},
+		baseURL: "http://" + listener.Addr().String(),
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Api.Serve(listener)
+	}()
+	t.Cleanup(server.Api.Shutdown)
+
+	if err = server.waitUntilReady(served); err != nil {
+		t.Fatal(err)
+	}
+
+	return server
+}
+
+// Address of the server, e.g. http://127.0.0.1:41234, API routes are below
+// /api
+func (s *TestServer) BaseURL() string {
+	return s.baseURL
+}
+
+func (s *TestServer) waitUntilReady(served <-chan error) error {
+	deadline := time.Now().Add(readyTimeout)
+
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-served:
+			return fmt.Errorf("Server stopped before it was ready: %v", err)
+		default:
+		}
+
+		response, err := s.Client.Get(s.baseURL + "/api/readyz")
+		if err == nil {
+			response.Body.Close()
+			if response.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return fmt.Errorf("Server wasn't ready within %v", readyTimeout)
+}
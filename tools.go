@@ -0,0 +1,8 @@
+//go:build tools
+
+// Pins the versions of tools run by go generate in go.mod
+package tools
+
+import (
+	_ "go.uber.org/mock/mockgen"
+)
@@ -26,8 +26,7 @@ document.addEventListener("alpine:init", () => {
       let r = await fetch(`${API}/register?${parameters}`, {
         credentials: "include",
       });
-      response = await r.text();
-      return response;
+      return this.message(r);
     },
     async login() {
       parameters = new URLSearchParams({
@@ -37,14 +36,14 @@ document.addEventListener("alpine:init", () => {
       let r = await fetch(`${API}/login?${parameters}`, {
         credentials: "include",
       });
-      response = await r.text();
       if (r.status == 200) {
+        response = await r.text();
         // Read user_id from jwt payload
         this.id = JSON.parse(atob(response.split(".")[1])).user_id;
         this.isAuthenticated = true;
         await this.refreshData();
       } else {
-        return response;
+        return this.message(r);
       }
     },
     logout() {
@@ -52,6 +51,14 @@ document.addEventListener("alpine:init", () => {
       this.isAuthenticated = false;
     },
 
+    // Errors are JSON objects, other responses plain text messages
+    async message(r) {
+      if (r.ok) {
+        return r.text();
+      }
+      return (await r.json()).Message;
+    },
+
     async get(endpoint) {
       let r = await fetch(`${API}/${endpoint}`, {
         credentials: "include",
@@ -65,7 +72,7 @@ document.addEventListener("alpine:init", () => {
       this.usdBalances = await this.get("balances/usd");
     },
     async getOrders() {
-      this.orders = await this.get("orders");
+      this.orders = (await this.get("orders")).Orders;
     },
     async getTransactions() {
       this.transactions = await this.get("transactions");
@@ -80,6 +87,7 @@ document.addEventListener("alpine:init", () => {
       let r = await fetch(`${API}/orders`, {
         credentials: "include",
         method: "POST",
+        headers: { "Content-Type": "application/json" },
         body: JSON.stringify({
           coinId: coinId,
           isBuy: isBuy,
@@ -87,12 +95,13 @@ document.addEventListener("alpine:init", () => {
         }),
       });
       await this.refreshData();
-      return r.text();
+      return this.message(r);
     },
     async addTransaction(coinId, address, qty, note) {
       let r = await fetch(`${API}/transactions`, {
         credentials: "include",
         method: "POST",
+        headers: { "Content-Type": "application/json" },
         body: JSON.stringify({
           coinId: coinId,
           address: address,
@@ -101,7 +110,7 @@ document.addEventListener("alpine:init", () => {
         }),
       });
       await this.refreshData();
-      return r.text();
+      return this.message(r);
     },
     async updateEmail(newEmail) {
       let formData = new FormData();
@@ -114,7 +123,7 @@ document.addEventListener("alpine:init", () => {
       if (r.status == 200) {
         this.email = newEmail;
       }
-      return r.text();
+      return this.message(r);
     },
     async updatePassword(newPassword) {
       let formData = new FormData();
@@ -127,7 +136,7 @@ document.addEventListener("alpine:init", () => {
       if (r.status == 200) {
         this.password = newPassword;
       }
-      return r.text();
+      return this.message(r);
     },
   });
 
@@ -141,7 +150,7 @@ document.addEventListener("alpine:init", () => {
     },
     async refresh() {
       let r = await fetch(`${API}/coins`);
-      this.list = await r.json();
+      this.list = (await r.json()).data;
     },
   });
 });